@@ -3,10 +3,13 @@ package json
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"math/big"
 	"os"
 	"testing"
 
 	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/iden3/go-schema-processor/v2/merklize"
 	"github.com/iden3/go-schema-processor/v2/processor"
 	tst "github.com/iden3/go-schema-processor/v2/testing"
@@ -58,6 +61,104 @@ func TestParser_ParseClaimWithDataSlots(t *testing.T) {
 	require.False(t, ok)
 }
 
+// wideHasher is the poseidon hasher with the field larger than the one of
+// claim slots
+type wideHasher struct {
+	merklize.PoseidonHasher
+}
+
+func (wideHasher) Prime() *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), 256)
+}
+
+// nopMerkleTree accepts any entries
+type nopMerkleTree struct{}
+
+func (nopMerkleTree) Add(context.Context, *big.Int, *big.Int) error {
+	return nil
+}
+
+func (nopMerkleTree) GenerateProof(context.Context,
+	*big.Int) (*merkletree.Proof, error) {
+
+	return nil, errors.New("not implemented")
+}
+
+func (nopMerkleTree) Root() *merkletree.Hash {
+	return &merkletree.HashZero
+}
+
+func TestParser_ParseClaimWithNestedDataSlots(t *testing.T) {
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://www.w3.org/2018/credentials/v1":                     "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			"https://example.com/schema-delivery-address-nested.json-ld": "testdata/schema-delivery-address-nested.json-ld",
+		},
+		tst.IgnoreUntouchedURLs())()
+
+	credentialBytes, err := os.ReadFile("testdata/non-merklized-nested.json-ld")
+	require.NoError(t, err)
+
+	parseCredential := func(t testing.TB, subject map[string]any) verifiable.W3CCredential {
+		var credential verifiable.W3CCredential
+		err := json.Unmarshal(credentialBytes, &credential)
+		require.NoError(t, err)
+		for k, v := range subject {
+			credential.CredentialSubject[k] = v
+		}
+		return credential
+	}
+
+	parser := Parser{}
+	opts := processor.CoreClaimOptions{
+		SubjectPosition:       verifiable.CredentialSubjectPositionIndex,
+		MerklizedRootPosition: verifiable.CredentialMerklizedRootPositionNone,
+	}
+
+	credential := parseCredential(t, nil)
+	claim, err := parser.ParseClaim(context.Background(), credential, &opts)
+	require.NoError(t, err)
+
+	index, value := claim.RawSlots()
+	require.NotEmpty(t, index[2])
+	require.NotEmpty(t, index[3])
+	require.Equal(t, big.NewInt(42), value[2].ToInt())
+	require.NotEmpty(t, value[3])
+
+	line1Hash, err := merklize.PoseidonHasher{}.HashBytes(
+		[]byte("Kyiv, Khreshchatyk 1"))
+	require.NoError(t, err)
+	require.Equal(t, line1Hash, index[3].ToInt())
+
+	t.Run("missing nested field", func(t *testing.T) {
+		credential := parseCredential(t, map[string]any{
+			"homeAddress": map[string]any{"line2": "apt. 2"}})
+		_, err := parser.ParseClaim(context.Background(), credential, &opts)
+		require.ErrorIs(t, err, merklize.ErrorEntryNotFound)
+		require.EqualError(t, err, "slotIndexB: field not found in "+
+			"credential credentialSubject.homeAddress.line1: entry not found")
+	})
+
+	t.Run("value overflows slot", func(t *testing.T) {
+		credential := parseCredential(t, map[string]any{
+			"postalProviderInformation": map[string]any{
+				"insured": true,
+				// Q + 1
+				"officeNo": "21888242871839275222246405745257275088548364400416034343698204186575808495618",
+			}})
+		opts := opts
+		opts.MerklizerOpts = []merklize.MerklizeOption{
+			merklize.WithHasher(wideHasher{}),
+			merklize.WithNegativeIntegerEncoding(
+				merklize.NegativeIntegerTwosComplement),
+			merklize.WithMerkleTree(nopMerkleTree{})}
+		_, err := parser.ParseClaim(context.Background(), credential, &opts)
+		require.ErrorIs(t, err, verifiable.ErrSlotOverflow)
+		require.ErrorContains(t, err, "slotValueA: field "+
+			"credentialSubject.postalProviderInformation.officeNo")
+	})
+}
+
 func TestParser_ParseClaimWithMerklizedRoot(t *testing.T) {
 	defer tst.MockHTTPClient(t,
 		map[string]string{
@@ -149,3 +250,27 @@ func Test_GetFieldSlotIndex(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 7, slotIndex)
 }
+
+func Test_GetFieldSlotIndex_Nested(t *testing.T) {
+	contextBytes, err := os.ReadFile(
+		"testdata/schema-delivery-address-nested.json-ld")
+	require.NoError(t, err)
+
+	parser := Parser{}
+	for field, wantIdx := range map[string]int{
+		"price":                              2,
+		"homeAddress.line1":                  3,
+		"postalProviderInformation.officeNo": 6,
+		"postalProviderInformation.insured":  7,
+	} {
+		slotIndex, err := parser.GetFieldSlotIndex(field,
+			"DeliverAddressMultiTestForked", contextBytes)
+		require.NoError(t, err)
+		require.Equal(t, wantIdx, slotIndex, field)
+	}
+
+	_, err = parser.GetFieldSlotIndex("homeAddress.line2",
+		"DeliverAddressMultiTestForked", contextBytes)
+	require.EqualError(t, err,
+		"field `homeAddress.line2` not specified in serialization info")
+}
@@ -0,0 +1,292 @@
+package json
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+)
+
+type schemaBuilderOptions struct {
+	documentLoader ld.DocumentLoader
+	contextURL     string
+	allRequired    bool
+	required       []string
+	title          string
+	description    string
+}
+
+// SchemaBuilderOption is an option for SchemaFromContext
+type SchemaBuilderOption func(*schemaBuilderOptions)
+
+// WithSchemaDocumentLoader sets the document loader to resolve remote
+// contexts imported by the context document
+func WithSchemaDocumentLoader(loader ld.DocumentLoader) SchemaBuilderOption {
+	return func(opts *schemaBuilderOptions) {
+		opts.documentLoader = loader
+	}
+}
+
+// WithSchemaContextURL sets the URL of the context document to put into
+// $metadata.uris.jsonLdContext of the generated schema
+func WithSchemaContextURL(u string) SchemaBuilderOption {
+	return func(opts *schemaBuilderOptions) {
+		opts.contextURL = u
+	}
+}
+
+// WithAllFieldsRequired marks all credentialSubject fields as required
+func WithAllFieldsRequired() SchemaBuilderOption {
+	return func(opts *schemaBuilderOptions) {
+		opts.allRequired = true
+	}
+}
+
+// WithRequiredFields marks given credentialSubject fields as required
+func WithRequiredFields(fields ...string) SchemaBuilderOption {
+	return func(opts *schemaBuilderOptions) {
+		opts.required = append(opts.required, fields...)
+	}
+}
+
+// WithSchemaTitle sets title and description of the generated schema
+func WithSchemaTitle(title, description string) SchemaBuilderOption {
+	return func(opts *schemaBuilderOptions) {
+		opts.title = title
+		opts.description = description
+	}
+}
+
+// SchemaFromContext generates JSON Schema (JsonSchema2023) for the
+// credential of typeName type defined in the JSON-LD context document.
+// Fields of credentialSubject are taken from the type-scoped context of the
+// type, their JSON types are derived from XSD datatypes. Nested objects are
+// supported if they are defined with their own scoped context.
+func SchemaFromContext(ctxBytes []byte, typeName string,
+	opts ...SchemaBuilderOption) ([]byte, error) {
+
+	o := schemaBuilderOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var ctxObj map[string]any
+	err := json.Unmarshal(ctxBytes, &ctxObj)
+	if err != nil {
+		return nil, err
+	}
+
+	rootCtx, ok := ctxObj[contextFullKey]
+	if !ok {
+		return nil, errors.New("document has no @context")
+	}
+
+	ldOpts := merklize.Options{DocumentLoader: o.documentLoader}.
+		JSONLDOptions()
+	ldCtx, err := ld.NewContext(nil, ldOpts).Parse(rootCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	typeDef := ldCtx.GetTermDefinition(typeName)
+	typeCtx, isType := typeDef["@context"]
+	if !isType {
+		return nil, errors.Errorf("looks like %v is not a type", typeName)
+	}
+
+	subjectSchema, err := objectSchemaFromContext(ldCtx, typeCtx)
+	if err != nil {
+		return nil, err
+	}
+	subjectSchema.Properties["id"] = jsonSchema{
+		Type:   "string",
+		Format: "uri",
+	}
+
+	if o.allRequired {
+		for f := range subjectSchema.Properties {
+			if f != "id" {
+				subjectSchema.Required = append(subjectSchema.Required, f)
+			}
+		}
+	}
+	for _, f := range o.required {
+		if _, ok := subjectSchema.Properties[f]; !ok {
+			return nil, errors.Errorf(
+				"required field %v is not defined in the context", f)
+		}
+		subjectSchema.Required = append(subjectSchema.Required, f)
+	}
+	subjectSchema.Required = uniqueSorted(subjectSchema.Required)
+
+	schema := credentialSchemaEnvelope(subjectSchema)
+	schema.Schema = "http://json-schema.org/draft-07/schema#"
+	schema.Title = o.title
+	schema.Description = o.description
+	schema.Metadata = &schemaMetadata{Type: typeName}
+	if o.contextURL != "" {
+		schema.Metadata.URIs = map[string]string{
+			"jsonLdContext": o.contextURL,
+		}
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+type schemaMetadata struct {
+	URIs map[string]string `json:"uris,omitempty"`
+	Type string            `json:"type"`
+}
+
+type jsonSchema struct {
+	Schema      string                `json:"$schema,omitempty"`
+	Metadata    *schemaMetadata       `json:"$metadata,omitempty"`
+	Title       string                `json:"title,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Type        any                   `json:"type,omitempty"`
+	Format      string                `json:"format,omitempty"`
+	Minimum     *int                  `json:"minimum,omitempty"`
+	Maximum     *int                  `json:"maximum,omitempty"`
+	Required    []string              `json:"required,omitempty"`
+	Properties  map[string]jsonSchema `json:"properties,omitempty"`
+	Items       *jsonSchema           `json:"items,omitempty"`
+}
+
+// credentialSchemaEnvelope returns the schema of W3C credential with the
+// given credentialSubject schema
+func credentialSchemaEnvelope(subjectSchema jsonSchema) jsonSchema {
+	strOrStrArr := jsonSchema{
+		Type:  []string{"string", "array"},
+		Items: &jsonSchema{Type: "string"},
+	}
+	return jsonSchema{
+		Type: "object",
+		Required: []string{"@context", "id", "type", "issuanceDate",
+			"credentialSubject", "credentialSchema", "credentialStatus",
+			"issuer"},
+		Properties: map[string]jsonSchema{
+			"@context":          {Type: []string{"string", "array", "object"}},
+			"id":                {Type: "string"},
+			"type":              strOrStrArr,
+			"issuer":            {Type: []string{"string", "object"}},
+			"issuanceDate":      {Type: "string", Format: "date-time"},
+			"expirationDate":    {Type: "string", Format: "date-time"},
+			"credentialSubject": subjectSchema,
+			"credentialSchema": {
+				Type:     "object",
+				Required: []string{"id", "type"},
+				Properties: map[string]jsonSchema{
+					"id":   {Type: "string", Format: "uri"},
+					"type": {Type: "string"},
+				},
+			},
+			"credentialStatus": {Type: "object"},
+		},
+	}
+}
+
+func objectSchemaFromContext(parentCtx *ld.Context,
+	localCtx any) (jsonSchema, error) {
+
+	activeCtx, err := parentCtx.Parse(localCtx)
+	if err != nil {
+		return jsonSchema{}, err
+	}
+
+	schema := jsonSchema{
+		Type:       "object",
+		Properties: make(map[string]jsonSchema),
+	}
+	for _, term := range localContextTerms(localCtx) {
+		termDef := activeCtx.GetTermDefinition(term)
+		if termDef == nil {
+			continue
+		}
+
+		if nestedCtx, ok := termDef["@context"]; ok {
+			var nested jsonSchema
+			nested, err = objectSchemaFromContext(activeCtx, nestedCtx)
+			if err != nil {
+				return jsonSchema{}, err
+			}
+			schema.Properties[term] = nested
+			continue
+		}
+
+		tp, _ := termDef["@type"].(string)
+		schema.Properties[term] = schemaForDatatype(tp)
+	}
+	return schema, nil
+}
+
+// localContextTerms returns sorted names of the terms defined with
+// expanded term definitions in the local context. Keywords, keyword aliases
+// and prefixes (terms defined with plain strings) are skipped.
+func localContextTerms(localCtx any) []string {
+	var terms []string
+	for _, c := range ld.Arrayify(localCtx) {
+		cm, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		for k, v := range cm {
+			if strings.HasPrefix(k, "@") {
+				continue
+			}
+			if _, isDef := v.(map[string]any); !isDef {
+				continue
+			}
+			terms = append(terms, k)
+		}
+	}
+	return uniqueSorted(terms)
+}
+
+func schemaForDatatype(tp string) jsonSchema {
+	intPtr := func(i int) *int { return &i }
+
+	switch tp {
+	case ld.XSDString:
+		return jsonSchema{Type: "string"}
+	case ld.XSDNS + "anyURI", "@id":
+		return jsonSchema{Type: "string", Format: "uri"}
+	case ld.XSDBoolean:
+		return jsonSchema{Type: "boolean"}
+	case ld.XSDInteger:
+		return jsonSchema{Type: "integer"}
+	case ld.XSDNS + "positiveInteger":
+		return jsonSchema{Type: "integer", Minimum: intPtr(1)}
+	case ld.XSDNS + "nonNegativeInteger":
+		return jsonSchema{Type: "integer", Minimum: intPtr(0)}
+	case ld.XSDNS + "negativeInteger":
+		return jsonSchema{Type: "integer", Maximum: intPtr(-1)}
+	case ld.XSDNS + "nonPositiveInteger":
+		return jsonSchema{Type: "integer", Maximum: intPtr(0)}
+	case ld.XSDDouble, ld.XSDNS + "float", ld.XSDNS + "decimal":
+		return jsonSchema{Type: "number"}
+	case ld.XSDNS + "dateTime":
+		return jsonSchema{Type: "string", Format: "date-time"}
+	case ld.XSDNS + "date":
+		return jsonSchema{Type: "string", Format: "date"}
+	default:
+		// unknown or missing datatype, do not restrict the value
+		return jsonSchema{}
+	}
+}
+
+func uniqueSorted(in []string) []string {
+	if len(in) == 0 {
+		return nil
+	}
+	sort.Strings(in)
+	out := in[:1]
+	for _, s := range in[1:] {
+		if s != out[len(out)-1] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package json
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaFromContext(t *testing.T) {
+	ctxBytes, err := os.ReadFile("../merklize/testdata/kyc_schema.json-ld")
+	require.NoError(t, err)
+
+	schemaBytes, err := SchemaFromContext(ctxBytes, "KYCAgeCredential",
+		WithAllFieldsRequired(),
+		WithSchemaContextURL("https://example.com/kyc.json-ld"))
+	require.NoError(t, err)
+
+	var schema map[string]any
+	err = json.Unmarshal(schemaBytes, &schema)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]any{
+		"type": "KYCAgeCredential",
+		"uris": map[string]any{
+			"jsonLdContext": "https://example.com/kyc.json-ld",
+		},
+	}, schema["$metadata"])
+
+	subjectSchema := schema["properties"].(map[string]any)["credentialSubject"]
+	require.Equal(t, map[string]any{
+		"type":     "object",
+		"required": []any{"birthday", "documentType"},
+		"properties": map[string]any{
+			"id":           map[string]any{"type": "string", "format": "uri"},
+			"birthday":     map[string]any{"type": "integer"},
+			"documentType": map[string]any{"type": "integer"},
+		},
+	}, subjectSchema)
+
+	credential := `{
+  "@context": ["https://www.w3.org/2018/credentials/v1",
+    "https://example.com/kyc.json-ld"],
+  "id": "urn:uuid:3a8d1822-a00e-11ee-8f57-a27b3ddbdc29",
+  "type": ["VerifiableCredential", "KYCAgeCredential"],
+  "issuanceDate": "2023-12-21T16:35:46.737547+02:00",
+  "issuer": "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf",
+  "credentialSchema": {
+    "id": "https://example.com/kyc.json",
+    "type": "JsonSchema2023"
+  },
+  "credentialStatus": {},
+  "credentialSubject": {
+    "id": "did:polygonid:polygon:mumbai:2qH2mPVRN7ZDCnEofjeh8Qd2Uo3YsEhTVhKhjB8xs4",
+    "birthday": 19960424,
+    "documentType": 2
+  }
+}`
+	err = Validator{}.ValidateData([]byte(credential), schemaBytes)
+	require.NoError(t, err)
+
+	var credObj map[string]any
+	err = json.Unmarshal([]byte(credential), &credObj)
+	require.NoError(t, err)
+	credObj["credentialSubject"].(map[string]any)["birthday"] = "19960424"
+	badCredential, err := json.Marshal(credObj)
+	require.NoError(t, err)
+	err = Validator{}.ValidateData(badCredential, schemaBytes)
+	require.Error(t, err)
+
+	_, err = SchemaFromContext(ctxBytes, "KYCAgeCredential",
+		WithRequiredFields("unknownField"))
+	require.EqualError(t, err,
+		"required field unknownField is not defined in the context")
+}
@@ -0,0 +1,64 @@
+package loaders
+
+import (
+	"context"
+	"io"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// ContextDocumentLoader is the document loader that supports cancellation
+// of the loading with the context. The loader returned by NewDocumentLoader
+// implements it.
+type ContextDocumentLoader interface {
+	ld.DocumentLoader
+	LoadDocumentWithContext(ctx context.Context,
+		u string) (*ld.RemoteDocument, error)
+}
+
+// IPFSContextClient is IPFSClient that supports cancellation. If the client
+// passed to NewDocumentLoader implements it, CatWithContext is used instead
+// of Cat.
+type IPFSContextClient interface {
+	IPFSClient
+	CatWithContext(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// LoadDocument loads the document using loader.LoadDocumentWithContext if the
+// loader implements ContextDocumentLoader. Otherwise, the context is checked
+// before calling loader.LoadDocument.
+func LoadDocument(ctx context.Context, loader ld.DocumentLoader,
+	u string) (*ld.RemoteDocument, error) {
+
+	if ctxLoader, ok := loader.(ContextDocumentLoader); ok {
+		return ctxLoader.LoadDocumentWithContext(ctx, u)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+	}
+	return loader.LoadDocument(u)
+}
+
+// BindContext returns the document loader that loads documents with the
+// context using LoadDocument. It allows to cancel JSON-LD processing of the
+// json-gold library, which does not pass the context to document loaders.
+func BindContext(ctx context.Context,
+	loader ld.DocumentLoader) ld.DocumentLoader {
+
+	return &boundLoader{ctx: ctx, loader: loader}
+}
+
+type boundLoader struct {
+	ctx    context.Context
+	loader ld.DocumentLoader
+}
+
+func (l *boundLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	return LoadDocument(l.ctx, l.loader, u)
+}
+
+func (l *boundLoader) LoadDocumentWithContext(ctx context.Context,
+	u string) (*ld.RemoteDocument, error) {
+
+	return LoadDocument(ctx, l.loader, u)
+}
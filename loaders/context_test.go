@@ -0,0 +1,136 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxIPFSClient struct {
+	ctx context.Context
+}
+
+func (c *ctxIPFSClient) Cat(_ string) (io.ReadCloser, error) {
+	return nil, errors.New("Cat should not be called")
+}
+
+func (c *ctxIPFSClient) CatWithContext(ctx context.Context,
+	_ string) (io.ReadCloser, error) {
+
+	c.ctx = ctx
+	return io.NopCloser(strings.NewReader(`{}`)), nil
+}
+
+type ctxKey struct{}
+
+func TestDocumentLoader_LoadDocumentWithContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/ld+json")
+			_, _ = w.Write([]byte(`{"@context":{}}`))
+		}))
+	defer srv.Close()
+
+	loader := NewDocumentLoader(nil, "")
+	ctxLoader, ok := loader.(ContextDocumentLoader)
+	require.True(t, ok)
+
+	_, err := ctxLoader.LoadDocumentWithContext(context.Background(), srv.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ctxLoader.LoadDocumentWithContext(ctx, srv.URL)
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = BindContext(ctx, loader).LoadDocument(srv.URL)
+	require.ErrorIs(t, err, context.Canceled)
+
+	t.Run("ipfs client with context", func(t *testing.T) {
+		cli := &ctxIPFSClient{}
+		ctx := context.WithValue(context.Background(), ctxKey{}, 1)
+		_, err := LoadDocument(ctx, NewDocumentLoader(cli, ""),
+			"ipfs://QmdP4MZkESEabRVB322r2xWm7TCi7LueMNWMJawYmSy7hp")
+		require.NoError(t, err)
+		require.Equal(t, 1, cli.ctx.Value(ctxKey{}))
+	})
+
+	t.Run("loader without context", func(t *testing.T) {
+		var calls int
+		plain := &warmupTestLoader{onStart: func(string) { calls++ }}
+		_, err := LoadDocument(ctx, plain, "https://example.com/a")
+		var ldErr *ld.JsonLdError
+		require.ErrorAs(t, err, &ldErr)
+		require.ErrorIs(t, err, context.Canceled)
+		require.Equal(t, 0, calls)
+
+		_, err = BindContext(context.Background(), plain).
+			LoadDocument("https://example.com/a")
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+	})
+}
+
+type blockingIPFSClient struct {
+	release chan struct{}
+}
+
+func (c *blockingIPFSClient) Cat(_ string) (io.ReadCloser, error) {
+	<-c.release
+	return io.NopCloser(strings.NewReader(`{}`)), nil
+}
+
+func TestDocumentLoader_Timeouts(t *testing.T) {
+	slowHandler := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			okIPFSHandler(w, r)
+		case <-r.Context().Done():
+		}
+	}
+	slowSrv := httptest.NewServer(http.HandlerFunc(slowHandler))
+	defer slowSrv.Close()
+	gw := newTestIPFSGateway(t, slowHandler)
+	ctx := context.Background()
+
+	// the HTTP timeout does not apply to IPFS gateways
+	loader := NewDocumentLoader(nil, gw.URL, WithCacheEngine(nil),
+		WithHTTPTimeout(20*time.Millisecond), WithIPFSTimeout(time.Minute))
+	_, err := LoadDocument(ctx, loader, slowSrv.URL)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = LoadDocument(ctx, loader, "ipfs://cid1")
+	require.NoError(t, err)
+
+	loader = NewDocumentLoader(nil, gw.URL, WithCacheEngine(nil),
+		WithHTTPTimeout(time.Minute), WithIPFSTimeout(20*time.Millisecond))
+	_, err = LoadDocument(ctx, loader, "ipfs://cid1")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = LoadDocument(ctx, loader, slowSrv.URL)
+	require.NoError(t, err)
+
+	t.Run("request deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err := LoadDocument(ctx, loader, slowSrv.URL)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("ipfs client without context", func(t *testing.T) {
+		cli := &blockingIPFSClient{release: make(chan struct{})}
+		defer close(cli.release)
+		loader := NewDocumentLoader(cli, "",
+			WithIPFSTimeout(20*time.Millisecond))
+		start := time.Now()
+		_, err := LoadDocument(ctx, loader, "ipfs://cid1")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Less(t, time.Since(start), time.Second)
+	})
+}
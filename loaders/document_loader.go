@@ -1,6 +1,7 @@
 package loaders
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -34,12 +35,36 @@ type IPFSClient interface {
 	Cat(url string) (io.ReadCloser, error)
 }
 
+// SchemeFetcher fetches raw JSON-LD documents for URLs of a custom scheme,
+// like ar:// or s3://
+type SchemeFetcher interface {
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// SchemeFetcherFunc is an adapter to use ordinary functions as SchemeFetcher
+type SchemeFetcherFunc func(ctx context.Context, url string) (io.ReadCloser,
+	error)
+
+// Fetch calls f(ctx, url)
+func (f SchemeFetcherFunc) Fetch(ctx context.Context,
+	url string) (io.ReadCloser, error) {
+
+	return f(ctx, url)
+}
+
 type documentLoader struct {
-	ipfsCli     IPFSClient // @formatter:off : Goland bug
-	ipfsGW      string
-	cacheEngine CacheEngine
-	noCache     bool
-	httpClient  *http.Client
+	ipfsCli        IPFSClient // @formatter:off : Goland bug
+	ipfsGWs        []string
+	ipfsGWStrategy IPFSGatewayStrategy
+	ipfsGWTimeout  time.Duration
+	ipfsGWBackoff  *time.Duration
+	ipfsGWHealth   ipfsGatewayHealth
+	ipfsTimeout    time.Duration
+	httpTimeout    time.Duration
+	cacheEngine    CacheEngine
+	noCache        bool
+	httpClient     *http.Client
+	fetchers       map[string]SchemeFetcher
 }
 
 type DocumentLoaderOption func(*documentLoader)
@@ -61,13 +86,50 @@ func WithHTTPClient(httpClient *http.Client) DocumentLoaderOption {
 	}
 }
 
+// WithHTTPTimeout sets the timeout of loading a document from an http or
+// https URL. Zero means no timeout other than the one of the context. It does
+// not apply to requests to IPFS gateways.
+func WithHTTPTimeout(timeout time.Duration) DocumentLoaderOption {
+	return func(loader *documentLoader) {
+		loader.httpTimeout = timeout
+	}
+}
+
+// WithIPFSTimeout sets the timeout of loading a document from an ipfs URL,
+// including the failover between IPFS gateways. IPFS is usually much slower
+// than HTTP, so it may be configured separately. Zero means no timeout other
+// than the one of the context. IPFS clients not implementing
+// IPFSContextClient are not canceled on timeout, their results are
+// discarded.
+func WithIPFSTimeout(timeout time.Duration) DocumentLoaderOption {
+	return func(loader *documentLoader) {
+		loader.ipfsTimeout = timeout
+	}
+}
+
+// WithSchemeFetcher registers the fetcher for URLs of the scheme (without
+// "://", case-insensitive). Fetchers take precedence over the built-in
+// support of http, https and ipfs schemes. Documents loaded by fetchers are
+// not cached.
+func WithSchemeFetcher(scheme string, fetcher SchemeFetcher) DocumentLoaderOption {
+	return func(loader *documentLoader) {
+		if loader.fetchers == nil {
+			loader.fetchers = make(map[string]SchemeFetcher)
+		}
+		loader.fetchers[strings.ToLower(scheme)] = fetcher
+	}
+}
+
 // NewDocumentLoader creates a new document loader with a cache for http.
-// ipfs cache is not implemented yet.
+// ipfs cache is not implemented yet. By default, the cache is limited to
+// DefaultCacheMaxEntries documents and DefaultCacheMaxBytes bytes.
 func NewDocumentLoader(ipfsCli IPFSClient, ipfsGW string,
 	opts ...DocumentLoaderOption) ld.DocumentLoader {
 	loader := &documentLoader{
 		ipfsCli: ipfsCli,
-		ipfsGW:  ipfsGW,
+	}
+	if ipfsGW != "" {
+		loader.ipfsGWs = []string{ipfsGW}
 	}
 
 	for _, opt := range opts {
@@ -75,21 +137,40 @@ func NewDocumentLoader(ipfsCli IPFSClient, ipfsGW string,
 	}
 
 	if loader.cacheEngine == nil && !loader.noCache {
-		// Should not be errors if we call NewMemoryCacheEngine without options
-		loader.cacheEngine, _ = NewMemoryCacheEngine()
+		// Should not be errors if we call NewMemoryCacheEngine with valid
+		// limits
+		loader.cacheEngine, _ = NewMemoryCacheEngine(
+			WithCacheMaxEntries(DefaultCacheMaxEntries),
+			WithCacheMaxBytes(DefaultCacheMaxBytes))
 	}
 
 	return loader
 }
 
 func (d *documentLoader) LoadDocument(
+	u string) (*ld.RemoteDocument, error) {
+
+	return d.LoadDocumentWithContext(context.Background(), u)
+}
+
+// LoadDocumentWithContext loads the document passing the context to HTTP
+// requests, IPFS clients implementing IPFSContextClient and scheme fetchers.
+func (d *documentLoader) LoadDocumentWithContext(ctx context.Context,
 	u string) (doc *ld.RemoteDocument, err error) {
 
 	const ipfsPrefix = "ipfs://"
 
+	ctx = withExpectedDigest(ctx, u)
+
+	if fetcher, ok := d.schemeFetcher(u); ok {
+		return loadDocumentWithFetcher(ctx, fetcher, u)
+	}
+
 	switch {
 	case strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://"):
-		return d.loadDocumentFromHTTP(u)
+		ctx, cancel := contextWithTimeout(ctx, d.httpTimeout)
+		defer cancel()
+		return d.loadDocumentFromHTTP(ctx, u)
 
 	case strings.HasPrefix(u, ipfsPrefix):
 		// supported URLs:
@@ -101,11 +182,14 @@ func (d *documentLoader) LoadDocument(
 		// strip ipfs:// prefix
 		u = u[len(ipfsPrefix):]
 
+		ctx, cancel := contextWithTimeout(ctx, d.ipfsTimeout)
+		defer cancel()
+
 		switch {
 		case d.ipfsCli != nil:
-			doc.Document, err = d.loadDocumentFromIPFSNode(u)
-		case d.ipfsGW != "":
-			doc.Document, err = d.loadDocumentFromIPFSGW(u)
+			doc.Document, err = d.loadDocumentFromIPFSNode(ctx, u)
+		case len(d.ipfsGWs) != 0:
+			doc.Document, err = d.loadDocumentFromIPFSGW(ctx, u)
 		default:
 			err = ld.NewJsonLdError(ld.LoadingDocumentFailed,
 				errors.New("ipfs is not configured"))
@@ -118,11 +202,66 @@ func (d *documentLoader) LoadDocument(
 
 	default:
 		err = errors.New("unsupported URL schema")
+		if scheme, ok := urlScheme(u); ok {
+			err = fmt.Errorf("%w: %v", err, scheme)
+		}
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
+	}
+}
+
+// contextWithTimeout returns the context with the timeout if it is positive.
+// The deadline of the parent context is kept if it is earlier.
+func contextWithTimeout(ctx context.Context,
+	timeout time.Duration) (context.Context, context.CancelFunc) {
+
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+func urlScheme(u string) (string, bool) {
+	i := strings.Index(u, ":")
+	if i <= 0 {
+		return "", false
+	}
+	return strings.ToLower(u[:i]), true
+}
+
+func (d *documentLoader) schemeFetcher(u string) (SchemeFetcher, bool) {
+	if len(d.fetchers) == 0 {
+		return nil, false
+	}
+	scheme, ok := urlScheme(u)
+	if !ok {
+		return nil, false
+	}
+	fetcher, ok := d.fetchers[scheme]
+	return fetcher, ok
+}
+
+func loadDocumentWithFetcher(ctx context.Context, fetcher SchemeFetcher,
+	u string) (doc *ld.RemoteDocument, err error) {
+
+	r, err := fetcher.Fetch(ctx, u)
+	if err != nil {
 		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
 	}
+	defer func() {
+		err2 := r.Close()
+		if err == nil && err2 != nil {
+			err = ld.NewJsonLdError(ld.LoadingDocumentFailed, err2)
+		}
+	}()
+
+	document, err := documentFromReader(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return &ld.RemoteDocument{DocumentURL: u, Document: document}, nil
 }
 
-func (d *documentLoader) loadDocumentFromIPFSNode(
+func (d *documentLoader) loadDocumentFromIPFSNode(ctx context.Context,
 	ipfsURL string) (document any, err error) {
 
 	if d.ipfsCli == nil {
@@ -130,7 +269,11 @@ func (d *documentLoader) loadDocumentFromIPFSNode(
 	}
 
 	var r io.ReadCloser
-	r, err = d.ipfsCli.Cat(ipfsURL)
+	if ctxCli, ok := d.ipfsCli.(IPFSContextClient); ok {
+		r, err = ctxCli.CatWithContext(ctx, ipfsURL)
+	} else {
+		r, err = catWithContext(ctx, d.ipfsCli, ipfsURL)
+	}
 	if err != nil {
 		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
 	}
@@ -141,22 +284,44 @@ func (d *documentLoader) loadDocumentFromIPFSNode(
 		}
 	}()
 
-	return ld.DocumentFromReader(r)
+	return documentFromReader(ctx, r)
 }
 
-func (d *documentLoader) loadDocumentFromIPFSGW(
-	ipfsURL string) (any, error) {
+// catWithContext calls Cat of the client that does not support cancellation
+// and returns when the context is done without waiting for Cat. The reader
+// returned by Cat after that is closed.
+func catWithContext(ctx context.Context, cli IPFSClient,
+	ipfsURL string) (io.ReadCloser, error) {
 
-	ipfsURL = strings.TrimRight(d.ipfsGW, "/") + "/ipfs/" +
-		strings.TrimLeft(ipfsURL, "/")
-	doc, err := d.loadDocumentFromHTTP(ipfsURL)
-	if err != nil {
-		return nil, err
+	if ctx.Done() == nil {
+		return cli.Cat(ipfsURL)
+	}
+
+	type result struct {
+		r   io.ReadCloser
+		err error
+	}
+	results := make(chan result, 1)
+	go func() {
+		r, err := cli.Cat(ipfsURL)
+		results <- result{r, err}
+	}()
+
+	select {
+	case res := <-results:
+		return res.r, res.err
+	case <-ctx.Done():
+		go func() {
+			res := <-results
+			if res.err == nil {
+				_ = res.r.Close()
+			}
+		}()
+		return nil, ctx.Err()
 	}
-	return doc.Document, nil
 }
 
-func (d *documentLoader) loadDocumentFromHTTP(
+func (d *documentLoader) loadDocumentFromHTTP(ctx context.Context,
 	u string) (*ld.RemoteDocument, error) {
 
 	var doc *ld.RemoteDocument
@@ -168,7 +333,8 @@ func (d *documentLoader) loadDocumentFromHTTP(
 	shouldCache := false
 	var expireTime time.Time
 
-	if d.cacheEngine != nil {
+	// documents with expected digests are verified on every load
+	if d.cacheEngine != nil && expectedDigest(ctx) == "" {
 		doc, expireTime, err = d.cacheEngine.Get(u)
 		switch {
 		case errors.Is(err, ErrCacheMiss):
@@ -188,7 +354,7 @@ func (d *documentLoader) loadDocumentFromHTTP(
 		return doc, nil
 	}
 
-	req, err := http.NewRequest("GET", u, http.NoBody)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, http.NoBody)
 	if err != nil {
 		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
 	}
@@ -239,7 +405,7 @@ func (d *documentLoader) loadDocumentFromHTTP(
 			!rApplicationJSON.MatchString(contentType) {
 
 			finalURL := ld.Resolve(u, alternateLink[0]["target"])
-			doc, err = d.LoadDocument(finalURL)
+			doc, err = d.LoadDocumentWithContext(ctx, finalURL)
 			if err != nil {
 				return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
 			}
@@ -256,7 +422,7 @@ func (d *documentLoader) loadDocumentFromHTTP(
 	}
 
 	if doc.Document == nil {
-		doc.Document, err = ld.DocumentFromReader(res.Body)
+		doc.Document, err = documentFromReader(ctx, res.Body)
 		if err != nil {
 			return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed, err)
 		}
@@ -0,0 +1,54 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDocumentLoader_SchemeFetcher(t *testing.T) {
+	var fetched []string
+	arFetcher := SchemeFetcherFunc(func(_ context.Context, u string) (io.ReadCloser, error) {
+		fetched = append(fetched, u)
+		return io.NopCloser(strings.NewReader(`{"@context":{"a":"b"}}`)), nil
+	})
+	fetchErr := errors.New("bucket not found")
+	s3Fetcher := SchemeFetcherFunc(func(_ context.Context, u string) (io.ReadCloser, error) {
+		return nil, fetchErr
+	})
+
+	loader := NewDocumentLoader(nil, "",
+		WithSchemeFetcher("AR", arFetcher),
+		WithSchemeFetcher("s3", s3Fetcher))
+
+	t.Run("registered scheme", func(t *testing.T) {
+		doc, err := loader.LoadDocument("ar://tx-id")
+		require.NoError(t, err)
+		require.Equal(t, "ar://tx-id", doc.DocumentURL)
+		require.Equal(t,
+			map[string]any{"@context": map[string]any{"a": "b"}},
+			doc.Document)
+		require.Equal(t, []string{"ar://tx-id"}, fetched)
+	})
+
+	t.Run("fetcher error", func(t *testing.T) {
+		_, err := loader.LoadDocument("s3://bucket/schema.jsonld")
+		require.ErrorIs(t, err, fetchErr)
+		var ldErr *ld.JsonLdError
+		require.ErrorAs(t, err, &ldErr)
+		require.Equal(t, ld.LoadingDocumentFailed, ldErr.Code)
+	})
+
+	t.Run("unsupported scheme", func(t *testing.T) {
+		_, err := loader.LoadDocument("ens://example.eth")
+		var ldErr *ld.JsonLdError
+		require.ErrorAs(t, err, &ldErr)
+		require.Equal(t, ld.LoadingDocumentFailed, ldErr.Code)
+		require.Contains(t, err.Error(), "ens")
+	})
+}
@@ -0,0 +1,279 @@
+package loaders
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrInsecureURL is returned by the client created with NewSecureHTTPClient
+// and WithHTTPSOnly option for requests to non-https URLs, redirects
+// included
+var ErrInsecureURL = errors.New("only https URLs are allowed")
+
+// ErrCertificatePinning is returned by the client created with
+// NewSecureHTTPClient when the certificate chain of the host does not match
+// its pins
+var ErrCertificatePinning = errors.New("certificate pinning failed")
+
+type httpSecurity struct {
+	httpsOnly bool
+	// pinned CAs and SPKI SHA-256 hashes keyed by lowercase host name
+	caPins   map[string]*x509.CertPool
+	spkiPins map[string][][]byte
+	err      error
+}
+
+// HTTPSecurityOption is an option for NewSecureHTTPClient
+type HTTPSecurityOption func(*httpSecurity)
+
+// WithHTTPSOnly refuses requests to plain http URLs
+func WithHTTPSOnly() HTTPSecurityOption {
+	return func(s *httpSecurity) {
+		s.httpsOnly = true
+	}
+}
+
+// WithPinnedCA requires the certificate chain of the host (name without
+// port) to be issued by one of the CA certificates instead of the system
+// roots. It may be used for hosts with certificates of a private CA.
+func WithPinnedCA(host string, caCerts ...*x509.Certificate) HTTPSecurityOption {
+	return func(s *httpSecurity) {
+		if len(caCerts) == 0 {
+			s.err = fmt.Errorf("no CA certificates to pin for %v", host)
+			return
+		}
+		if s.caPins == nil {
+			s.caPins = make(map[string]*x509.CertPool)
+		}
+		host = strings.ToLower(host)
+		pool, ok := s.caPins[host]
+		if !ok {
+			pool = x509.NewCertPool()
+			s.caPins[host] = pool
+		}
+		for _, c := range caCerts {
+			pool.AddCert(c)
+		}
+	}
+}
+
+// WithPinnedSPKI requires the verified certificate chain of the host (name
+// without port) to contain a certificate with the public key matching one
+// of the pins. A pin is the base64 encoded SHA-256 hash of the
+// SubjectPublicKeyInfo of the certificate, the same as pin-sha256 of HPKP.
+func WithPinnedSPKI(host string, pins ...string) HTTPSecurityOption {
+	return func(s *httpSecurity) {
+		if len(pins) == 0 {
+			s.err = fmt.Errorf("no SPKI pins for %v", host)
+			return
+		}
+		if s.spkiPins == nil {
+			s.spkiPins = make(map[string][][]byte)
+		}
+		host = strings.ToLower(host)
+		for _, p := range pins {
+			h, err := base64.StdEncoding.DecodeString(p)
+			if err != nil || len(h) != sha256.Size {
+				s.err = fmt.Errorf("invalid SPKI pin for %v: %v", host, p)
+				return
+			}
+			s.spkiPins[host] = append(s.spkiPins[host], h)
+		}
+	}
+}
+
+// NewSecureHTTPClient returns the copy of httpClient (http.DefaultClient if
+// nil) enforcing the security options. The client is meant to be configured
+// once and shared by all network access of the verification: pass it to
+// WithHTTPClient for the document loader, to the HTTPClient field of the
+// status resolvers and to DID resolvers. Pinning requires the transport of
+// httpClient to be *http.Transport (or nil for the default one), its
+// DialTLSContext is replaced.
+func NewSecureHTTPClient(httpClient *http.Client,
+	opts ...HTTPSecurityOption) (*http.Client, error) {
+
+	var s httpSecurity
+	for _, o := range opts {
+		o(&s)
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	client := *httpClient
+
+	rt := client.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if len(s.caPins) != 0 || len(s.spkiPins) != 0 {
+		tr, ok := rt.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf(
+				"certificate pinning requires *http.Transport, got %T", rt)
+		}
+		tr = tr.Clone()
+		s.configureTransport(tr)
+		rt = tr
+	}
+	if s.httpsOnly {
+		rt = httpsOnlyTransport{rt: rt}
+	}
+	client.Transport = rt
+	return &client, nil
+}
+
+// configureTransport adds the check of the pins to TLS connections of the
+// transport. Certificates of hosts with pinned CAs can't be verified against
+// the system roots by crypto/tls, so the verification of all hosts is done
+// by VerifyConnection in that case. The name of the host is not known to
+// VerifyConnection for IP addresses (they are not sent with SNI), so TLS
+// connections are dialed by the transport with the host name bound to the
+// check.
+func (s *httpSecurity) configureTransport(tr *http.Transport) {
+	cfg := tr.TLSClientConfig
+	if cfg == nil {
+		cfg = &tls.Config{}
+	} else {
+		cfg = cfg.Clone()
+	}
+	tr.TLSClientConfig = cfg
+
+	roots := cfg.RootCAs
+	verifyConnection := cfg.VerifyConnection
+	customVerify := len(s.caPins) != 0
+	if customVerify {
+		cfg.InsecureSkipVerify = true
+	}
+	// used for connections through proxies
+	cfg.VerifyConnection = s.verifyConnection("", roots, customVerify,
+		verifyConnection)
+
+	dial := tr.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	tr.DialTLSContext = func(ctx context.Context, network,
+		addr string) (net.Conn, error) {
+
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		connCfg := tr.TLSClientConfig.Clone()
+		if connCfg.ServerName == "" {
+			connCfg.ServerName = host
+		}
+		connCfg.VerifyConnection = s.verifyConnection(connCfg.ServerName,
+			roots, customVerify, verifyConnection)
+		tlsConn := tls.Client(conn, connCfg)
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		return tlsConn, nil
+	}
+}
+
+// verifyConnection returns the check of the connection to the host. If host
+// is empty, the server name of the connection is used.
+func (s *httpSecurity) verifyConnection(host string, roots *x509.CertPool,
+	customVerify bool,
+	next func(tls.ConnectionState) error) func(tls.ConnectionState) error {
+
+	return func(cs tls.ConnectionState) error {
+		if next != nil {
+			if err := next(cs); err != nil {
+				return err
+			}
+		}
+
+		host := host
+		if host == "" {
+			host = cs.ServerName
+		}
+		if host == "" && customVerify {
+			return fmt.Errorf("%w: unknown host name", ErrCertificatePinning)
+		}
+		host = strings.ToLower(host)
+
+		chains := cs.VerifiedChains
+		if customVerify {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("no peer certificates")
+			}
+			vo := x509.VerifyOptions{
+				DNSName:       host,
+				Roots:         roots,
+				Intermediates: x509.NewCertPool(),
+			}
+			if pool, ok := s.caPins[host]; ok {
+				vo.Roots = pool
+			}
+			for _, c := range cs.PeerCertificates[1:] {
+				vo.Intermediates.AddCert(c)
+			}
+			var err error
+			chains, err = cs.PeerCertificates[0].Verify(vo)
+			if err != nil {
+				return fmt.Errorf("%w: %v: %v", ErrCertificatePinning, host,
+					err)
+			}
+		}
+
+		pins, ok := s.spkiPins[host]
+		if !ok || chainsMatchSPKI(chains, pins) {
+			return nil
+		}
+		return fmt.Errorf("%w: %v: no certificate matches SPKI pins",
+			ErrCertificatePinning, host)
+	}
+}
+
+func chainsMatchSPKI(chains [][]*x509.Certificate, pins [][]byte) bool {
+	for _, chain := range chains {
+		for _, c := range chain {
+			h := sha256.Sum256(c.RawSubjectPublicKeyInfo)
+			for _, p := range pins {
+				if bytes.Equal(h[:], p) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+type httpsOnlyTransport struct {
+	rt http.RoundTripper
+}
+
+func (t httpsOnlyTransport) RoundTrip(req *http.Request) (*http.Response,
+	error) {
+
+	if !strings.EqualFold(req.URL.Scheme, "https") {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInsecureURL, req.URL.Redacted())
+	}
+	return t.rt.RoundTrip(req)
+}
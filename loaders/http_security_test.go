@@ -0,0 +1,148 @@
+package loaders
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func selfSignedCert(t testing.TB) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey,
+		key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestNewSecureHTTPClient(t *testing.T) {
+	const doc = `{"@context":{"name":"https://example.com/vocab#name"}}`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ld+json")
+		_, _ = w.Write([]byte(doc))
+	})
+	tlsSrv := httptest.NewTLSServer(handler)
+	defer tlsSrv.Close()
+	otherSrv := httptest.NewTLSServer(handler)
+	defer otherSrv.Close()
+	plainSrv := httptest.NewServer(handler)
+	defer plainSrv.Close()
+
+	get := func(t testing.TB, client *http.Client, u string) error {
+		resp, err := client.Get(u)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return resp.Body.Close()
+	}
+
+	t.Run("https only", func(t *testing.T) {
+		client, err := NewSecureHTTPClient(tlsSrv.Client(), WithHTTPSOnly())
+		require.NoError(t, err)
+		require.NoError(t, get(t, client, tlsSrv.URL))
+		err = get(t, client, plainSrv.URL)
+		require.ErrorIs(t, err, ErrInsecureURL)
+		err = get(t, client, tlsSrv.URL+"/redirect?to="+plainSrv.URL)
+		require.ErrorIs(t, err, ErrInsecureURL)
+
+		loader := NewDocumentLoader(nil, "", WithHTTPClient(client))
+		_, err = loader.LoadDocument(tlsSrv.URL + "/ctx.jsonld")
+		require.NoError(t, err)
+		_, err = loader.LoadDocument(plainSrv.URL + "/ctx.jsonld")
+		require.ErrorContains(t, err, ErrInsecureURL.Error())
+	})
+
+	t.Run("pinned CA", func(t *testing.T) {
+		// the test server certificate is not trusted by the system roots
+		require.Error(t, get(t, &http.Client{}, tlsSrv.URL))
+
+		client, err := NewSecureHTTPClient(nil,
+			WithPinnedCA("127.0.0.1", tlsSrv.Certificate()))
+		require.NoError(t, err)
+		require.NoError(t, get(t, client, tlsSrv.URL))
+
+		client, err = NewSecureHTTPClient(nil,
+			WithPinnedCA("127.0.0.1", selfSignedCert(t)))
+		require.NoError(t, err)
+		err = get(t, client, tlsSrv.URL)
+		require.ErrorIs(t, err, ErrCertificatePinning)
+
+		_, err = NewSecureHTTPClient(nil, WithPinnedCA("127.0.0.1"))
+		require.EqualError(t, err, "no CA certificates to pin for 127.0.0.1")
+	})
+
+	t.Run("pinned SPKI", func(t *testing.T) {
+		spki := sha256.Sum256(tlsSrv.Certificate().RawSubjectPublicKeyInfo)
+		pin := base64.StdEncoding.EncodeToString(spki[:])
+		client, err := NewSecureHTTPClient(tlsSrv.Client(),
+			WithPinnedSPKI("127.0.0.1", pin))
+		require.NoError(t, err)
+		require.NoError(t, get(t, client, tlsSrv.URL))
+
+		wrongPin := base64.StdEncoding.EncodeToString(make([]byte, 32))
+		client, err = NewSecureHTTPClient(tlsSrv.Client(),
+			WithPinnedSPKI("127.0.0.1", wrongPin))
+		require.NoError(t, err)
+		err = get(t, client, tlsSrv.URL)
+		require.ErrorIs(t, err, ErrCertificatePinning)
+
+		// pins of other hosts are not checked
+		client, err = NewSecureHTTPClient(tlsSrv.Client(),
+			WithPinnedSPKI("example.com", wrongPin))
+		require.NoError(t, err)
+		require.NoError(t, get(t, client, otherSrv.URL))
+
+		_, err = NewSecureHTTPClient(nil,
+			WithPinnedSPKI("127.0.0.1", "abc"))
+		require.EqualError(t, err, "invalid SPKI pin for 127.0.0.1: abc")
+	})
+
+	t.Run("custom transport", func(t *testing.T) {
+		base := &http.Client{Transport: roundTripperFunc(
+			func(r *http.Request) (*http.Response, error) {
+				return http.DefaultTransport.RoundTrip(r)
+			})}
+		_, err := NewSecureHTTPClient(base, WithPinnedCA("127.0.0.1",
+			tlsSrv.Certificate()))
+		require.EqualError(t, err, "certificate pinning requires "+
+			"*http.Transport, got loaders.roundTripperFunc")
+
+		client, err := NewSecureHTTPClient(base, WithHTTPSOnly())
+		require.NoError(t, err)
+		require.ErrorIs(t, get(t, client, plainSrv.URL), ErrInsecureURL)
+	})
+}
@@ -0,0 +1,165 @@
+package loaders
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// ErrIntegrityMismatch is returned by the document loader when the fetched
+// document does not match the digest set with WithIntegrity
+var ErrIntegrityMismatch = errors.New(
+	"document does not match its integrity digest")
+
+var sriHashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+// DigestSRI returns the Subresource Integrity digest of the document bytes
+// with sha384 algorithm, e.g. "sha384-<base64 digest>"
+func DigestSRI(data []byte) string {
+	h := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(h[:])
+}
+
+// VerifyDigestSRI checks the document bytes against the Subresource
+// Integrity metadata: space separated digests with sha256, sha384 or sha512
+// algorithm. The data matches if any of the digests with supported
+// algorithms matches.
+func VerifyDigestSRI(data []byte, sri string) error {
+	v, err := newSRIVerifier(sri)
+	if err != nil {
+		return err
+	}
+	_, _ = v.Write(data)
+	return v.verify()
+}
+
+type sriDigest struct {
+	hash   hash.Hash
+	digest []byte
+}
+
+// sriVerifier hashes written bytes with all algorithms of the integrity
+// metadata
+type sriVerifier struct {
+	sri     string
+	digests []sriDigest
+}
+
+func newSRIVerifier(sri string) (*sriVerifier, error) {
+	v := &sriVerifier{sri: sri}
+	for _, token := range strings.Fields(sri) {
+		alg, digestB64, ok := strings.Cut(token, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid integrity digest: %v", token)
+		}
+		newHash, ok := sriHashes[alg]
+		if !ok {
+			continue
+		}
+		// options are separated with '?'
+		digestB64, _, _ = strings.Cut(digestB64, "?")
+		digest, err := base64.StdEncoding.DecodeString(digestB64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integrity digest: %v", token)
+		}
+		v.digests = append(v.digests, sriDigest{newHash(), digest})
+	}
+	if len(v.digests) == 0 {
+		return nil, fmt.Errorf("no supported integrity digests: %v", sri)
+	}
+	return v, nil
+}
+
+func (v *sriVerifier) Write(p []byte) (int, error) {
+	for _, d := range v.digests {
+		d.hash.Write(p)
+	}
+	return len(p), nil
+}
+
+func (v *sriVerifier) verify() error {
+	for _, d := range v.digests {
+		if subtle.ConstantTimeCompare(d.hash.Sum(nil), d.digest) == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrIntegrityMismatch, v.sri)
+}
+
+type integrityCtxKey struct{}
+
+type expectedDigestCtxKey struct{}
+
+// WithIntegrity returns the context with the integrity metadata of
+// documents: document URL mapped to Subresource Integrity digests (see
+// VerifyDigestSRI). The loader returned by NewDocumentLoader verifies the
+// bytes of these documents fetched with the context and fails with
+// ErrIntegrityMismatch if they do not match. Such documents are always
+// fetched, cached versions are not used. Use BindContext to pass the context
+// through JSON-LD processing.
+func WithIntegrity(ctx context.Context,
+	digests map[string]string) context.Context {
+
+	merged := make(map[string]string, len(digests))
+	for u, d := range integrityFromContext(ctx) {
+		merged[u] = d
+	}
+	for u, d := range digests {
+		merged[u] = d
+	}
+	return context.WithValue(ctx, integrityCtxKey{}, merged)
+}
+
+func integrityFromContext(ctx context.Context) map[string]string {
+	digests, _ := ctx.Value(integrityCtxKey{}).(map[string]string)
+	return digests
+}
+
+// withExpectedDigest sets the digest of the document being loaded. It is
+// set for every document, so the digest of a document does not leak to
+// documents loaded while loading it.
+func withExpectedDigest(ctx context.Context, u string) context.Context {
+	digest := integrityFromContext(ctx)[u]
+	if digest == "" && ctx.Value(expectedDigestCtxKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, expectedDigestCtxKey{}, digest)
+}
+
+func expectedDigest(ctx context.Context) string {
+	digest, _ := ctx.Value(expectedDigestCtxKey{}).(string)
+	return digest
+}
+
+// documentFromReader parses the document verifying the integrity of its
+// bytes if the digest is expected
+func documentFromReader(ctx context.Context, r io.Reader) (any, error) {
+	digest := expectedDigest(ctx)
+	if digest == "" {
+		return ld.DocumentFromReader(r)
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	err = VerifyDigestSRI(data, digest)
+	if err != nil {
+		return nil, err
+	}
+	return ld.DocumentFromReader(bytes.NewReader(data))
+}
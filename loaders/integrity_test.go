@@ -0,0 +1,72 @@
+package loaders
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyDigestSRI(t *testing.T) {
+	data := []byte(`{"@context":{"a":"b"}}`)
+	digest := DigestSRI(data)
+	require.Regexp(t, `^sha384-[A-Za-z0-9+/]+=*$`, digest)
+	require.NoError(t, VerifyDigestSRI(data, digest))
+
+	// any of supported digests matches, unknown algorithms are ignored
+	require.NoError(t, VerifyDigestSRI(data,
+		"md5-AAAA sha256-AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA= "+digest))
+
+	err := VerifyDigestSRI([]byte(`{}`), digest)
+	require.ErrorIs(t, err, ErrIntegrityMismatch)
+
+	err = VerifyDigestSRI(data, "md5-AAAA")
+	require.EqualError(t, err, "no supported integrity digests: md5-AAAA")
+}
+
+func TestDocumentLoader_WithIntegrity(t *testing.T) {
+	var content atomic.Value
+	content.Store(`{"@context":{"a":"b"}}`)
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			w.Header().Set("Content-Type", "application/ld+json")
+			w.Header().Set("Cache-Control", "max-age=3600")
+			_, _ = w.Write([]byte(content.Load().(string)))
+		}))
+	defer srv.Close()
+	u := srv.URL + "/context.jsonld"
+
+	loader := NewDocumentLoader(nil, "")
+
+	// cache the document
+	_, err := loader.LoadDocument(u)
+	require.NoError(t, err)
+	_, err = loader.LoadDocument(u)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	// the document with the digest is fetched and verified
+	content.Store(`{"@context":{"a":"c"}}`)
+	ctx := WithIntegrity(context.Background(),
+		map[string]string{u: DigestSRI([]byte(`{"@context":{"a":"c"}}`))})
+	doc, err := LoadDocument(ctx, loader, u)
+	require.NoError(t, err)
+	require.Equal(t,
+		map[string]any{"@context": map[string]any{"a": "c"}}, doc.Document)
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	ctx = WithIntegrity(context.Background(),
+		map[string]string{u: DigestSRI([]byte(`{"@context":{"a":"b"}}`))})
+	_, err = LoadDocument(ctx, loader, u)
+	require.ErrorIs(t, err, ErrIntegrityMismatch)
+
+	// digests apply to listed documents only
+	_, err = LoadDocument(ctx, BindContext(ctx, loader),
+		srv.URL+"/other.jsonld")
+	require.NoError(t, err)
+}
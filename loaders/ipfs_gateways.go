@@ -0,0 +1,212 @@
+package loaders
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// IPFSGatewayStrategy defines how documents are loaded when several IPFS
+// gateways are configured
+type IPFSGatewayStrategy uint8
+
+const (
+	// IPFSGatewayFailover requests gateways one by one until one of them
+	// succeeds. This is the default strategy.
+	IPFSGatewayFailover IPFSGatewayStrategy = iota
+	// IPFSGatewayRace requests gateways concurrently and returns the first
+	// successful response. Requests to other gateways are canceled.
+	IPFSGatewayRace
+)
+
+// defaultIPFSGatewayBackoff is the time a failed gateway is considered
+// unhealthy
+const defaultIPFSGatewayBackoff = 30 * time.Second
+
+// WithIPFSGateways adds IPFS gateways used to load ipfs:// URLs if the IPFS
+// client is not set. The gateway passed to NewDocumentLoader, if any, goes
+// first.
+func WithIPFSGateways(gateways ...string) DocumentLoaderOption {
+	return func(loader *documentLoader) {
+		loader.ipfsGWs = append(loader.ipfsGWs, gateways...)
+	}
+}
+
+// WithIPFSGatewayStrategy sets the strategy of loading documents from
+// multiple IPFS gateways
+func WithIPFSGatewayStrategy(
+	strategy IPFSGatewayStrategy) DocumentLoaderOption {
+
+	return func(loader *documentLoader) {
+		loader.ipfsGWStrategy = strategy
+	}
+}
+
+// WithIPFSGatewayTimeout sets the timeout of a request to a single IPFS
+// gateway, so a slow gateway does not prevent failover to the next one.
+// Zero means no timeout other than the one of the context.
+func WithIPFSGatewayTimeout(timeout time.Duration) DocumentLoaderOption {
+	return func(loader *documentLoader) {
+		loader.ipfsGWTimeout = timeout
+	}
+}
+
+// WithIPFSGatewayHealthCheck sets the time an IPFS gateway is considered
+// unhealthy after a failed request, 30 seconds by default. Unhealthy
+// gateways are requested after healthy ones, or are not requested at all
+// by IPFSGatewayRace if there are healthy gateways. Zero disables health
+// checks.
+func WithIPFSGatewayHealthCheck(backoff time.Duration) DocumentLoaderOption {
+	return func(loader *documentLoader) {
+		loader.ipfsGWBackoff = &backoff
+	}
+}
+
+// ipfsGatewayHealth tracks failed IPFS gateways
+type ipfsGatewayHealth struct {
+	m              sync.Mutex
+	unhealthyUntil map[string]time.Time
+}
+
+// order returns healthy gateways followed by unhealthy ones, keeping the
+// configured order within both groups
+func (h *ipfsGatewayHealth) order(gateways []string,
+	now time.Time) (healthy, unhealthy []string) {
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	for _, gw := range gateways {
+		if now.Before(h.unhealthyUntil[gw]) {
+			unhealthy = append(unhealthy, gw)
+		} else {
+			healthy = append(healthy, gw)
+		}
+	}
+	return healthy, unhealthy
+}
+
+func (h *ipfsGatewayHealth) report(gw string, err error,
+	backoff time.Duration) {
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if err == nil {
+		delete(h.unhealthyUntil, gw)
+		return
+	}
+	if h.unhealthyUntil == nil {
+		h.unhealthyUntil = make(map[string]time.Time)
+	}
+	h.unhealthyUntil[gw] = time.Now().Add(backoff)
+}
+
+func (d *documentLoader) ipfsGatewayBackoff() time.Duration {
+	if d.ipfsGWBackoff == nil {
+		return defaultIPFSGatewayBackoff
+	}
+	return *d.ipfsGWBackoff
+}
+
+func (d *documentLoader) loadDocumentFromIPFSGW(ctx context.Context,
+	ipfsURL string) (any, error) {
+
+	backoff := d.ipfsGatewayBackoff()
+	healthy, unhealthy := d.ipfsGWs, []string(nil)
+	if backoff > 0 {
+		healthy, unhealthy = d.ipfsGWHealth.order(d.ipfsGWs, time.Now())
+	}
+
+	if d.ipfsGWStrategy == IPFSGatewayRace {
+		gateways := healthy
+		if len(gateways) == 0 {
+			gateways = unhealthy
+		}
+		return d.raceIPFSGateways(ctx, gateways, ipfsURL)
+	}
+
+	gateways := append(healthy[:len(healthy):len(healthy)], unhealthy...)
+	var lastErr error
+	for _, gw := range gateways {
+		var doc any
+		doc, lastErr = d.loadDocumentFromGateway(ctx, gw, ipfsURL)
+		if lastErr == nil {
+			return doc, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, ipfsGatewaysError(len(gateways), lastErr)
+}
+
+func (d *documentLoader) raceIPFSGateways(ctx context.Context,
+	gateways []string, ipfsURL string) (any, error) {
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		doc any
+		err error
+	}
+	results := make(chan result, len(gateways))
+	for _, gw := range gateways {
+		go func(gw string) {
+			doc, err := d.loadDocumentFromGateway(ctx, gw, ipfsURL)
+			results <- result{doc, err}
+		}(gw)
+	}
+
+	var lastErr error
+	for range gateways {
+		r := <-results
+		if r.err == nil {
+			return r.doc, nil
+		}
+		lastErr = r.err
+	}
+	return nil, ipfsGatewaysError(len(gateways), lastErr)
+}
+
+// loadDocumentFromGateway loads the document from the gateway with the
+// per-gateway timeout and reports the result to the health tracker. Requests
+// canceled by the caller are not reported.
+func (d *documentLoader) loadDocumentFromGateway(ctx context.Context,
+	gw, ipfsURL string) (any, error) {
+
+	gwCtx := ctx
+	if d.ipfsGWTimeout > 0 {
+		var cancel context.CancelFunc
+		gwCtx, cancel = context.WithTimeout(ctx, d.ipfsGWTimeout)
+		defer cancel()
+	}
+
+	u := strings.TrimRight(gw, "/") + "/ipfs/" + strings.TrimLeft(ipfsURL, "/")
+	doc, err := d.loadDocumentFromHTTP(gwCtx, u)
+	if ctx.Err() == nil {
+		if backoff := d.ipfsGatewayBackoff(); backoff > 0 {
+			d.ipfsGWHealth.report(gw, err, backoff)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return doc.Document, nil
+}
+
+// ipfsGatewaysError returns the error of the single gateway as is, so the
+// single gateway configuration reports the same errors as before
+func ipfsGatewaysError(n int, lastErr error) error {
+	if n == 1 {
+		return lastErr
+	}
+	return ld.NewJsonLdError(ld.LoadingDocumentFailed,
+		fmt.Errorf("all %d IPFS gateways failed, last error: %w", n,
+			lastErr))
+}
@@ -0,0 +1,133 @@
+package loaders
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testIPFSGateway struct {
+	*httptest.Server
+	m        sync.Mutex
+	requests []string
+}
+
+func newTestIPFSGateway(t *testing.T,
+	handler func(w http.ResponseWriter, r *http.Request)) *testIPFSGateway {
+
+	gw := &testIPFSGateway{}
+	gw.Server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gw.m.Lock()
+			gw.requests = append(gw.requests, r.URL.Path)
+			gw.m.Unlock()
+			handler(w, r)
+		}))
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+func (gw *testIPFSGateway) Requests() []string {
+	gw.m.Lock()
+	defer gw.m.Unlock()
+	return append([]string(nil), gw.requests...)
+}
+
+func okIPFSHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/ld+json")
+	_, _ = w.Write([]byte(`{"@context":{"a":"b"}}`))
+}
+
+func failingIPFSHandler(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusBadGateway)
+}
+
+func blockingIPFSHandler(_ http.ResponseWriter, r *http.Request) {
+	<-r.Context().Done()
+}
+
+func TestDocumentLoader_IPFSGatewaysFailover(t *testing.T) {
+	gw1 := newTestIPFSGateway(t, failingIPFSHandler)
+	gw2 := newTestIPFSGateway(t, okIPFSHandler)
+
+	loader := NewDocumentLoader(nil, gw1.URL, WithCacheEngine(nil),
+		WithIPFSGateways(gw2.URL))
+
+	doc, err := loader.LoadDocument("ipfs://cid1")
+	require.NoError(t, err)
+	require.Equal(t, "ipfs://cid1", doc.DocumentURL)
+	require.Equal(t,
+		map[string]any{"@context": map[string]any{"a": "b"}}, doc.Document)
+	require.Equal(t, []string{"/ipfs/cid1"}, gw1.Requests())
+	require.Equal(t, []string{"/ipfs/cid1"}, gw2.Requests())
+
+	// the failed gateway is requested after the healthy one
+	_, err = loader.LoadDocument("ipfs://cid2")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/ipfs/cid1"}, gw1.Requests())
+	require.Equal(t, []string{"/ipfs/cid1", "/ipfs/cid2"}, gw2.Requests())
+
+	t.Run("health check disabled", func(t *testing.T) {
+		gw1 := newTestIPFSGateway(t, failingIPFSHandler)
+		loader := NewDocumentLoader(nil, gw1.URL, WithCacheEngine(nil),
+			WithIPFSGateways(gw2.URL), WithIPFSGatewayHealthCheck(0))
+		for _, cid := range []string{"cid1", "cid2"} {
+			_, err := loader.LoadDocument("ipfs://" + cid)
+			require.NoError(t, err)
+		}
+		require.Equal(t, []string{"/ipfs/cid1", "/ipfs/cid2"},
+			gw1.Requests())
+	})
+
+	t.Run("all gateways failed", func(t *testing.T) {
+		gw2 := newTestIPFSGateway(t, failingIPFSHandler)
+		loader := NewDocumentLoader(nil, gw1.URL, WithCacheEngine(nil),
+			WithIPFSGateways(gw2.URL))
+		_, err := loader.LoadDocument("ipfs://cid1")
+		require.ErrorContains(t, err, "all 2 IPFS gateways failed")
+		require.ErrorContains(t, err, "Bad response status code: 502")
+	})
+}
+
+func TestDocumentLoader_IPFSGatewayTimeout(t *testing.T) {
+	gw1 := newTestIPFSGateway(t, blockingIPFSHandler)
+	gw2 := newTestIPFSGateway(t, okIPFSHandler)
+
+	loader := NewDocumentLoader(nil, "", WithCacheEngine(nil),
+		WithIPFSGateways(gw1.URL, gw2.URL),
+		WithIPFSGatewayTimeout(50*time.Millisecond))
+
+	start := time.Now()
+	_, err := loader.LoadDocument("ipfs://cid1")
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Equal(t, []string{"/ipfs/cid1"}, gw1.Requests())
+	require.Equal(t, []string{"/ipfs/cid1"}, gw2.Requests())
+}
+
+func TestDocumentLoader_IPFSGatewaysRace(t *testing.T) {
+	gw1 := newTestIPFSGateway(t, blockingIPFSHandler)
+	gw2 := newTestIPFSGateway(t, okIPFSHandler)
+
+	loader := NewDocumentLoader(nil, "", WithCacheEngine(nil),
+		WithIPFSGateways(gw1.URL, gw2.URL),
+		WithIPFSGatewayStrategy(IPFSGatewayRace))
+
+	start := time.Now()
+	doc, err := loader.LoadDocument("ipfs://cid1")
+	require.NoError(t, err)
+	require.Less(t, time.Since(start), 5*time.Second)
+	require.Equal(t,
+		map[string]any{"@context": map[string]any{"a": "b"}}, doc.Document)
+	require.Equal(t, []string{"/ipfs/cid1"}, gw2.Requests())
+
+	// the canceled request does not make the gateway unhealthy
+	healthy, unhealthy := loader.(*documentLoader).ipfsGWHealth.order(
+		[]string{gw1.URL, gw2.URL}, time.Now())
+	require.Equal(t, []string{gw1.URL, gw2.URL}, healthy)
+	require.Empty(t, unhealthy)
+}
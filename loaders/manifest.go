@@ -0,0 +1,195 @@
+package loaders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+var (
+	// ErrNotInManifest is returned by the manifest loader when the document
+	// URL is not listed in the manifest
+	ErrNotInManifest = errors.New("document is not in the manifest")
+	// ErrManifestMismatch is returned by the manifest loader when the
+	// fetched document does not match the hash or size from the manifest
+	ErrManifestMismatch = errors.New("document does not match the manifest")
+)
+
+// ManifestEntry describes the expected content of a JSON-LD document. The
+// hash and size are calculated over the JSON encoding of the parsed document
+// (encoding/json with sorted object keys), so they do not depend on
+// whitespace and key order of the original response.
+type ManifestEntry struct {
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+}
+
+// ContextManifest maps document URL to its ManifestEntry
+type ContextManifest map[string]ManifestEntry
+
+// URLs returns sorted list of URLs in the manifest
+func (m ContextManifest) URLs() []string {
+	urls := make([]string, 0, len(m))
+	for u := range m {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// BuildContextManifest walks the closure of remote contexts referenced by
+// the document (a credential, a schema or a context itself, decoded with
+// json.Unmarshal) including nested scoped contexts and @import. Every
+// context is loaded using the loader and recorded in the manifest.
+func BuildContextManifest(ctx context.Context, loader ld.DocumentLoader,
+	doc any) (ContextManifest, error) {
+
+	manifest := make(ContextManifest)
+	queue := findContextURLs(doc, nil)
+	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		u := queue[0]
+		queue = queue[1:]
+		if _, ok := manifest[u]; ok {
+			continue
+		}
+
+		rd, err := LoadDocument(ctx, loader, u)
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := newManifestEntry(rd.Document)
+		if err != nil {
+			return nil, err
+		}
+		manifest[u] = entry
+
+		base, err := url.Parse(u)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, findContextURLs(rd.Document, base)...)
+	}
+
+	return manifest, nil
+}
+
+type manifestLoader struct {
+	loader   ld.DocumentLoader
+	manifest ContextManifest
+}
+
+// NewManifestLoader returns the document loader that loads documents using
+// the wrapped loader and validates them against the manifest. Documents not
+// listed in the manifest are rejected with ErrNotInManifest, documents with
+// different content are rejected with ErrManifestMismatch.
+func NewManifestLoader(loader ld.DocumentLoader,
+	manifest ContextManifest) ld.DocumentLoader {
+
+	return &manifestLoader{loader: loader, manifest: manifest}
+}
+
+func (l *manifestLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	return l.LoadDocumentWithContext(context.Background(), u)
+}
+
+func (l *manifestLoader) LoadDocumentWithContext(ctx context.Context,
+	u string) (*ld.RemoteDocument, error) {
+
+	expected, ok := l.manifest[u]
+	if !ok {
+		return nil, fmt.Errorf("%w: %v", ErrNotInManifest, u)
+	}
+
+	rd, err := LoadDocument(ctx, l.loader, u)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := newManifestEntry(rd.Document)
+	if err != nil {
+		return nil, err
+	}
+	if entry != expected {
+		return nil, fmt.Errorf("%w: %v: expected sha256 %v (%v bytes), "+
+			"got %v (%v bytes)", ErrManifestMismatch, u, expected.SHA256,
+			expected.Size, entry.SHA256, entry.Size)
+	}
+
+	return rd, nil
+}
+
+func newManifestEntry(doc any) (ManifestEntry, error) {
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		return ManifestEntry{}, err
+	}
+	return manifestEntryOf(docBytes), nil
+}
+
+// manifestEntryOf returns the ManifestEntry of the JSON encoded document
+func manifestEntryOf(docBytes []byte) ManifestEntry {
+	h := sha256.Sum256(docBytes)
+	return ManifestEntry{
+		SHA256: hex.EncodeToString(h[:]),
+		Size:   len(docBytes),
+	}
+}
+
+// findContextURLs returns URLs of remote contexts found in @context and
+// @import values at any level of the document. Relative URLs are resolved
+// against base if it is not nil.
+func findContextURLs(doc any, base *url.URL) []string {
+	var urls []string
+	addURL := func(u string) {
+		if base != nil {
+			ref, err := url.Parse(u)
+			if err == nil {
+				u = base.ResolveReference(ref).String()
+			}
+		}
+		urls = append(urls, u)
+	}
+
+	var walk func(v any, inContext bool)
+	walk = func(v any, inContext bool) {
+		switch vt := v.(type) {
+		case string:
+			if inContext {
+				addURL(vt)
+			}
+		case []any:
+			for _, v2 := range vt {
+				walk(v2, inContext)
+			}
+		case map[string]any:
+			keys := make([]string, 0, len(vt))
+			for k := range vt {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				switch k {
+				case "@context", "@import":
+					walk(vt[k], true)
+				default:
+					walk(vt[k], false)
+				}
+			}
+		}
+	}
+	walk(doc, false)
+
+	return urls
+}
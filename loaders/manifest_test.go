@@ -0,0 +1,138 @@
+package loaders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+type mapLoader struct {
+	docs  map[string]string
+	calls map[string]int
+}
+
+func newMapLoader(docs map[string]string) *mapLoader {
+	return &mapLoader{docs: docs, calls: make(map[string]int)}
+}
+
+func (l *mapLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	l.calls[u]++
+	docStr, ok := l.docs[u]
+	if !ok {
+		return nil, fmt.Errorf("document not found: %v", u)
+	}
+	var doc any
+	err := json.Unmarshal([]byte(docStr), &doc)
+	if err != nil {
+		return nil, err
+	}
+	return &ld.RemoteDocument{DocumentURL: u, Document: doc}, nil
+}
+
+const (
+	manifestCtxA      = "https://example.com/contexts/a.jsonld"
+	manifestCtxScoped = "https://example.com/contexts/scoped.jsonld"
+	manifestCtxImport = "https://example.com/contexts/import.jsonld"
+	manifestCtxNested = "https://example.com/contexts/nested.jsonld"
+)
+
+func manifestTestDocs() map[string]string {
+	return map[string]string{
+		manifestCtxA: `{
+  "@context": {
+    "@version": 1.1,
+    "ex": "https://example.com/vocab#",
+    "Person": {
+      "@id": "ex:Person",
+      "@context": "` + manifestCtxScoped + `"
+    },
+    "address": {"@id": "ex:address", "@type": "@id"}
+  }
+}`,
+		manifestCtxScoped: `{
+  "@context": {
+    "@version": 1.1,
+    "@import": "import.jsonld",
+    "name": "https://example.com/vocab#name"
+  }
+}`,
+		manifestCtxImport: `{
+  "@context": [
+    {"@context": "` + manifestCtxNested + `"},
+    {"age": "https://example.com/vocab#age"}
+  ]
+}`,
+		manifestCtxNested: `{
+  "@context": {"city": "https://example.com/vocab#city"}
+}`,
+	}
+}
+
+func TestBuildContextManifest(t *testing.T) {
+	loader := newMapLoader(manifestTestDocs())
+	doc := map[string]any{
+		"@context": []any{manifestCtxA,
+			map[string]any{"other": "https://example.com/other#"}},
+		"type": "Person",
+		// the same context referenced twice should be loaded once
+		"address": map[string]any{"@context": manifestCtxA},
+		// @context in a value is not a context reference
+		"note": "https://example.com/not-a-context.jsonld",
+	}
+
+	manifest, err := BuildContextManifest(context.Background(), loader, doc)
+	require.NoError(t, err)
+	require.Equal(t, []string{manifestCtxA, manifestCtxImport,
+		manifestCtxNested, manifestCtxScoped}, manifest.URLs())
+	for _, u := range manifest.URLs() {
+		require.Equal(t, 1, loader.calls[u], u)
+	}
+
+	entry, err := newManifestEntry(map[string]any{"@context": map[string]any{
+		"city": "https://example.com/vocab#city"}})
+	require.NoError(t, err)
+	require.Equal(t, entry, manifest[manifestCtxNested])
+	require.Len(t, entry.SHA256, 64)
+
+	// the manifest must round-trip through JSON
+	manifestBytes, err := json.Marshal(manifest)
+	require.NoError(t, err)
+	var manifest2 ContextManifest
+	err = json.Unmarshal(manifestBytes, &manifest2)
+	require.NoError(t, err)
+	require.Equal(t, manifest, manifest2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = BuildContextManifest(ctx, loader, doc)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestManifestLoader(t *testing.T) {
+	docs := manifestTestDocs()
+	manifest, err := BuildContextManifest(context.Background(),
+		newMapLoader(docs), map[string]any{"@context": manifestCtxA})
+	require.NoError(t, err)
+
+	loader := NewManifestLoader(newMapLoader(docs), manifest)
+	rd, err := loader.LoadDocument(manifestCtxScoped)
+	require.NoError(t, err)
+	require.Equal(t, manifestCtxScoped, rd.DocumentURL)
+
+	_, err = loader.LoadDocument("https://example.com/contexts/unknown.jsonld")
+	require.ErrorIs(t, err, ErrNotInManifest)
+
+	// whitespace and key order do not change the manifest entry
+	docs[manifestCtxNested] = `{"@context":{"city":"https://example.com/vocab#city"}}`
+	_, err = loader.LoadDocument(manifestCtxNested)
+	require.NoError(t, err)
+
+	// tampered content is rejected
+	docs[manifestCtxNested] = `{"@context":{"city":"https://evil.example/vocab#city"}}`
+	_, err = loader.LoadDocument(manifestCtxNested)
+	require.ErrorIs(t, err, ErrManifestMismatch)
+}
@@ -1,22 +1,44 @@
 package loaders
 
 import (
+	"container/list"
 	"encoding/json"
+	"errors"
 	"sync"
 	"time"
 
 	"github.com/piprate/json-gold/ld"
 )
 
+const (
+	// DefaultCacheMaxEntries is the maximal number of documents in the cache
+	// of the loader created by NewDocumentLoader without WithCacheEngine
+	DefaultCacheMaxEntries = 1000
+	// DefaultCacheMaxBytes is the maximal size of documents in the cache of
+	// the loader created by NewDocumentLoader without WithCacheEngine
+	DefaultCacheMaxBytes = 64 << 20
+)
+
 type cachedRemoteDocument struct {
+	key            string
 	remoteDocument *ld.RemoteDocument
 	expireTime     time.Time
+	storeTime      time.Time
+	size           int64
 }
 
+// memoryCacheEngine is the LRU cache of documents. Embedded documents are
+// not evicted and do not count towards limits.
 type memoryCacheEngine struct {
-	m         sync.RWMutex
-	cache     map[string]*cachedRemoteDocument
-	embedDocs map[string]*ld.RemoteDocument
+	m          sync.Mutex
+	cache      map[string]*list.Element
+	lru        *list.List // of *cachedRemoteDocument, most recent first
+	size       int64
+	maxEntries int
+	maxBytes   int64
+	ttl        time.Duration
+	now        func() time.Time
+	embedDocs  map[string]*ld.RemoteDocument
 }
 
 func (m *memoryCacheEngine) Get(
@@ -29,14 +51,20 @@ func (m *memoryCacheEngine) Get(
 		}
 	}
 
-	m.m.RLock()
-	defer m.m.RUnlock()
+	m.m.Lock()
+	defer m.m.Unlock()
 
-	cd, ok := m.cache[key]
-	if ok {
-		return cd.remoteDocument, cd.expireTime, nil
+	e, ok := m.cache[key]
+	if !ok {
+		return nil, time.Time{}, ErrCacheMiss
+	}
+	cd := e.Value.(*cachedRemoteDocument)
+	if m.ttl > 0 && m.now().Sub(cd.storeTime) >= m.ttl {
+		m.remove(e)
+		return nil, time.Time{}, ErrCacheMiss
 	}
-	return nil, time.Time{}, ErrCacheMiss
+	m.lru.MoveToFront(e)
+	return cd.remoteDocument, cd.expireTime, nil
 }
 
 func (m *memoryCacheEngine) Set(key string, doc *ld.RemoteDocument,
@@ -51,17 +79,51 @@ func (m *memoryCacheEngine) Set(key string, doc *ld.RemoteDocument,
 		}
 	}
 
+	var size int64
+	if m.maxBytes > 0 {
+		docBytes, err := json.Marshal(doc.Document)
+		if err != nil {
+			return err
+		}
+		size = int64(len(key) + len(doc.DocumentURL) + len(doc.ContextURL) +
+			len(docBytes))
+	}
+
 	m.m.Lock()
 	defer m.m.Unlock()
 
-	m.cache[key] = &cachedRemoteDocument{
+	if e, ok := m.cache[key]; ok {
+		m.remove(e)
+	}
+	// the document does not fit into the cache at all
+	if m.maxBytes > 0 && size > m.maxBytes {
+		return nil
+	}
+
+	m.cache[key] = m.lru.PushFront(&cachedRemoteDocument{
+		key:            key,
 		remoteDocument: doc,
 		expireTime:     expireTime,
+		storeTime:      m.now(),
+		size:           size,
+	})
+	m.size += size
+
+	for (m.maxEntries > 0 && m.lru.Len() > m.maxEntries) ||
+		(m.maxBytes > 0 && m.size > m.maxBytes) {
+
+		m.remove(m.lru.Back())
 	}
 
 	return nil
 }
 
+func (m *memoryCacheEngine) remove(e *list.Element) {
+	cd := m.lru.Remove(e).(*cachedRemoteDocument)
+	delete(m.cache, cd.key)
+	m.size -= cd.size
+}
+
 type MemoryCacheEngineOption func(*memoryCacheEngine) error
 
 func WithEmbeddedDocumentBytes(u string, doc []byte) MemoryCacheEngineOption {
@@ -81,11 +143,53 @@ func WithEmbeddedDocumentBytes(u string, doc []byte) MemoryCacheEngineOption {
 	}
 }
 
+// WithCacheMaxEntries limits the number of cached documents. Least recently
+// used documents are evicted first. Zero means no limit.
+func WithCacheMaxEntries(n int) MemoryCacheEngineOption {
+	return func(engine *memoryCacheEngine) error {
+		if n < 0 {
+			return errors.New("max entries must not be negative")
+		}
+		engine.maxEntries = n
+		return nil
+	}
+}
+
+// WithCacheMaxBytes limits the total size of cached documents. The size of
+// a document is the length of its JSON encoding, key and URLs. Least
+// recently used documents are evicted first, documents larger than the limit
+// are not cached. Zero means no limit.
+func WithCacheMaxBytes(n int64) MemoryCacheEngineOption {
+	return func(engine *memoryCacheEngine) error {
+		if n < 0 {
+			return errors.New("max bytes must not be negative")
+		}
+		engine.maxBytes = n
+		return nil
+	}
+}
+
+// WithCacheTTL sets the time documents are kept in the cache regardless of
+// their expiration time. Zero means documents are kept until evicted.
+func WithCacheTTL(ttl time.Duration) MemoryCacheEngineOption {
+	return func(engine *memoryCacheEngine) error {
+		if ttl < 0 {
+			return errors.New("TTL must not be negative")
+		}
+		engine.ttl = ttl
+		return nil
+	}
+}
+
+// NewMemoryCacheEngine creates the in-memory LRU cache of documents safe for
+// concurrent use. Without limit options the cache is unbounded.
 func NewMemoryCacheEngine(
 	opts ...MemoryCacheEngineOption) (CacheEngine, error) {
 
 	e := &memoryCacheEngine{
-		cache: make(map[string]*cachedRemoteDocument),
+		cache: make(map[string]*list.Element),
+		lru:   list.New(),
+		now:   time.Now,
 	}
 
 	for _, opt := range opts {
@@ -0,0 +1,125 @@
+package loaders
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+func testRemoteDocument(u string) *ld.RemoteDocument {
+	return &ld.RemoteDocument{DocumentURL: u,
+		Document: map[string]any{"@context": map[string]any{"a": "b"}}}
+}
+
+func requireCached(t *testing.T, e CacheEngine, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		doc, _, err := e.Get(k)
+		require.NoError(t, err, k)
+		require.Equal(t, k, doc.DocumentURL)
+	}
+}
+
+func requireNotCached(t *testing.T, e CacheEngine, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		_, _, err := e.Get(k)
+		require.ErrorIs(t, err, ErrCacheMiss, k)
+	}
+}
+
+func TestMemoryCacheEngine_MaxEntries(t *testing.T) {
+	e, err := NewMemoryCacheEngine(WithCacheMaxEntries(2))
+	require.NoError(t, err)
+	expireTime := time.Now().Add(time.Hour)
+
+	require.NoError(t, e.Set("a", testRemoteDocument("a"), expireTime))
+	require.NoError(t, e.Set("b", testRemoteDocument("b"), expireTime))
+	// a becomes the most recently used one
+	requireCached(t, e, "a")
+	require.NoError(t, e.Set("c", testRemoteDocument("c"), expireTime))
+
+	requireNotCached(t, e, "b")
+	requireCached(t, e, "a", "c")
+
+	// replacing the document does not evict others
+	require.NoError(t, e.Set("c", testRemoteDocument("c"), expireTime))
+	requireCached(t, e, "a", "c")
+}
+
+func TestMemoryCacheEngine_MaxBytes(t *testing.T) {
+	// every document takes 24 bytes: key, URL and 22 bytes of JSON
+	e, err := NewMemoryCacheEngine(WithCacheMaxBytes(60))
+	require.NoError(t, err)
+	expireTime := time.Now().Add(time.Hour)
+
+	require.NoError(t, e.Set("a", testRemoteDocument("a"), expireTime))
+	require.NoError(t, e.Set("b", testRemoteDocument("b"), expireTime))
+	requireCached(t, e, "a", "b")
+	require.Equal(t, int64(48), e.(*memoryCacheEngine).size)
+
+	require.NoError(t, e.Set("c", testRemoteDocument("c"), expireTime))
+	requireNotCached(t, e, "a")
+	requireCached(t, e, "b", "c")
+
+	// too large documents are not cached
+	large := &ld.RemoteDocument{DocumentURL: "d",
+		Document: map[string]any{"@context": "0123456789012345678901234567890123456789012345678901234567890"}}
+	require.NoError(t, e.Set("d", large, expireTime))
+	requireNotCached(t, e, "d")
+	requireCached(t, e, "b", "c")
+}
+
+func TestMemoryCacheEngine_TTL(t *testing.T) {
+	e, err := NewMemoryCacheEngine(WithCacheTTL(time.Minute))
+	require.NoError(t, err)
+	now := time.Now()
+	e.(*memoryCacheEngine).now = func() time.Time { return now }
+
+	require.NoError(t, e.Set("a", testRemoteDocument("a"),
+		now.Add(time.Hour)))
+	requireCached(t, e, "a")
+
+	now = now.Add(time.Minute)
+	requireNotCached(t, e, "a")
+	require.Zero(t, e.(*memoryCacheEngine).lru.Len())
+}
+
+func TestMemoryCacheEngine_InvalidOptions(t *testing.T) {
+	_, err := NewMemoryCacheEngine(WithCacheMaxEntries(-1))
+	require.EqualError(t, err, "max entries must not be negative")
+	_, err = NewMemoryCacheEngine(WithCacheMaxBytes(-1))
+	require.EqualError(t, err, "max bytes must not be negative")
+	_, err = NewMemoryCacheEngine(WithCacheTTL(-time.Second))
+	require.EqualError(t, err, "TTL must not be negative")
+}
+
+func TestMemoryCacheEngine_Concurrent(t *testing.T) {
+	e, err := NewMemoryCacheEngine(WithCacheMaxEntries(10),
+		WithCacheMaxBytes(200))
+	require.NoError(t, err)
+	expireTime := time.Now().Add(time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				k := fmt.Sprintf("%d-%d", i, j%20)
+				_ = e.Set(k, testRemoteDocument(k), expireTime)
+				_, _, _ = e.Get(k)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	engine := e.(*memoryCacheEngine)
+	require.LessOrEqual(t, engine.lru.Len(), 10)
+	require.LessOrEqual(t, engine.size, int64(200))
+	require.Len(t, engine.cache, engine.lru.Len())
+}
@@ -0,0 +1,114 @@
+package loaders
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// RecordedDocument is the document loaded by RecordingDocumentLoader
+type RecordedDocument struct {
+	// URL is the requested URL of the document
+	URL string `json:"url"`
+	// DocumentURL is the final URL of the document reported by the wrapped
+	// loader, e.g. after redirects
+	DocumentURL string `json:"documentUrl,omitempty"`
+	// Document is the JSON encoding of the parsed document, the same the
+	// ManifestEntry is calculated over
+	Document json.RawMessage `json:"document"`
+	// FetchedAt is the time the document was loaded
+	FetchedAt time.Time `json:"fetchedAt"`
+}
+
+// RecordingDocumentLoader is the document loader that loads documents using
+// the wrapped loader and records every loaded document. It allows to see
+// which documents (and which versions of contexts) were used for
+// merklization or verification, e.g. for audit trails or to build offline
+// verification bundles. Failed loads are not recorded. It is safe for
+// concurrent use.
+type RecordingDocumentLoader struct {
+	loader ld.DocumentLoader
+
+	m    sync.Mutex
+	docs []RecordedDocument
+}
+
+// NewRecordingDocumentLoader returns the document loader recording all
+// documents loaded with the loader
+func NewRecordingDocumentLoader(
+	loader ld.DocumentLoader) *RecordingDocumentLoader {
+
+	return &RecordingDocumentLoader{loader: loader}
+}
+
+func (l *RecordingDocumentLoader) LoadDocument(
+	u string) (*ld.RemoteDocument, error) {
+
+	return l.LoadDocumentWithContext(context.Background(), u)
+}
+
+func (l *RecordingDocumentLoader) LoadDocumentWithContext(ctx context.Context,
+	u string) (*ld.RemoteDocument, error) {
+
+	rd, err := LoadDocument(ctx, l.loader, u)
+	if err != nil {
+		return nil, err
+	}
+
+	docBytes, err := json.Marshal(rd.Document)
+	if err != nil {
+		return nil, err
+	}
+
+	l.m.Lock()
+	l.docs = append(l.docs, RecordedDocument{
+		URL:         u,
+		DocumentURL: rd.DocumentURL,
+		Document:    docBytes,
+		FetchedAt:   time.Now().UTC(),
+	})
+	l.m.Unlock()
+	return rd, nil
+}
+
+// Documents returns all recorded documents in the order of loading. The
+// document loaded several times is returned for every load.
+func (l *RecordingDocumentLoader) Documents() []RecordedDocument {
+	l.m.Lock()
+	defer l.m.Unlock()
+	docs := make([]RecordedDocument, len(l.docs))
+	copy(docs, l.docs)
+	return docs
+}
+
+// Contexts returns the last loaded document for every requested URL
+func (l *RecordingDocumentLoader) Contexts() map[string]json.RawMessage {
+	l.m.Lock()
+	defer l.m.Unlock()
+	contexts := make(map[string]json.RawMessage, len(l.docs))
+	for _, d := range l.docs {
+		contexts[d.URL] = d.Document
+	}
+	return contexts
+}
+
+// Manifest returns the ContextManifest of the last loaded document for
+// every requested URL
+func (l *RecordingDocumentLoader) Manifest() ContextManifest {
+	contexts := l.Contexts()
+	manifest := make(ContextManifest, len(contexts))
+	for u, docBytes := range contexts {
+		manifest[u] = manifestEntryOf(docBytes)
+	}
+	return manifest
+}
+
+// Reset removes all recorded documents
+func (l *RecordingDocumentLoader) Reset() {
+	l.m.Lock()
+	l.docs = nil
+	l.m.Unlock()
+}
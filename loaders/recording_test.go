@@ -0,0 +1,62 @@
+package loaders
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingDocumentLoader(t *testing.T) {
+	docs := manifestTestDocs()
+	loader := NewRecordingDocumentLoader(newMapLoader(docs))
+
+	start := time.Now().UTC()
+	manifest, err := BuildContextManifest(context.Background(), loader,
+		map[string]any{"@context": manifestCtxA})
+	require.NoError(t, err)
+
+	// documents of the manifest are recorded in the order of loading
+	recorded := loader.Documents()
+	var urls []string
+	for _, d := range recorded {
+		urls = append(urls, d.URL)
+		require.Equal(t, d.URL, d.DocumentURL)
+		require.False(t, d.FetchedAt.Before(start))
+	}
+	require.Equal(t, []string{manifestCtxA, manifestCtxScoped,
+		manifestCtxImport, manifestCtxNested}, urls)
+	require.Equal(t, manifest, loader.Manifest())
+
+	var nested any
+	require.NoError(t, json.Unmarshal(recorded[3].Document, &nested))
+	require.Equal(t, map[string]any{"@context": map[string]any{
+		"city": "https://example.com/vocab#city"}}, nested)
+
+	// repeated loads are recorded every time, Contexts keeps the last one
+	docs[manifestCtxNested] = `{"@context":{"city":"https://example.com/v2#city"}}`
+	_, err = loader.LoadDocument(manifestCtxNested)
+	require.NoError(t, err)
+	require.Len(t, loader.Documents(), 5)
+	require.JSONEq(t, docs[manifestCtxNested],
+		string(loader.Contexts()[manifestCtxNested]))
+	require.NotEqual(t, manifest[manifestCtxNested],
+		loader.Manifest()[manifestCtxNested])
+
+	// failed loads are not recorded
+	_, err = loader.LoadDocument("https://example.com/contexts/unknown.jsonld")
+	require.Error(t, err)
+	require.Len(t, loader.Documents(), 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = loader.LoadDocumentWithContext(ctx, manifestCtxA)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, loader.Documents(), 5)
+
+	loader.Reset()
+	require.Empty(t, loader.Documents())
+	require.Empty(t, loader.Manifest())
+}
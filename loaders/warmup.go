@@ -0,0 +1,107 @@
+package loaders
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+const defaultWarmupConcurrency = 4
+
+type warmupOptions struct {
+	concurrency int
+	interval    time.Duration
+}
+
+// WarmupOption is an option for Warmup
+type WarmupOption func(*warmupOptions)
+
+// WithWarmupConcurrency sets the maximum number of documents fetched
+// concurrently during warmup. Values less than 1 are ignored.
+func WithWarmupConcurrency(n int) WarmupOption {
+	return func(opts *warmupOptions) {
+		if n > 0 {
+			opts.concurrency = n
+		}
+	}
+}
+
+// WithWarmupInterval sets the minimal interval between starting two
+// consecutive fetches. Zero value disables rate limiting.
+func WithWarmupInterval(interval time.Duration) WarmupOption {
+	return func(opts *warmupOptions) {
+		opts.interval = interval
+	}
+}
+
+// Warmup loads the list of documents using the document loader, so they are
+// stored in the loader's cache before the first real usage. It returns a map
+// of URLs that failed to load to their errors. If all documents were loaded
+// successfully, the map is empty. If the context is canceled, URLs that were
+// not fetched yet are reported with the context error.
+func Warmup(ctx context.Context, loader ld.DocumentLoader, urls []string,
+	opts ...WarmupOption) map[string]error {
+
+	o := warmupOptions{concurrency: defaultWarmupConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var (
+		errsM sync.Mutex
+		errs  = make(map[string]error)
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, o.concurrency)
+	)
+	setErr := func(u string, err error) {
+		errsM.Lock()
+		errs[u] = err
+		errsM.Unlock()
+	}
+
+	var ticker *time.Ticker
+	if o.interval > 0 {
+		ticker = time.NewTicker(o.interval)
+		defer ticker.Stop()
+	}
+
+	for i, u := range urls {
+		if ticker != nil && i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-ticker.C:
+			}
+		}
+
+		// select picks a random ready case, so check the context first to
+		// not start new fetches after cancellation
+		if err := ctx.Err(); err != nil {
+			setErr(u, err)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			setErr(u, ctx.Err())
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(u string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			_, err := LoadDocument(ctx, loader, u)
+			if err != nil {
+				setErr(u, err)
+			}
+		}(u)
+	}
+
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,134 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+	"github.com/stretchr/testify/require"
+)
+
+type warmupTestLoader struct {
+	m       sync.Mutex
+	active  int
+	maxSeen int
+	starts  []time.Time
+	loaded  map[string]int
+	delay   time.Duration
+	fail    map[string]error
+	onStart func(u string)
+}
+
+func (l *warmupTestLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	l.m.Lock()
+	l.active++
+	if l.active > l.maxSeen {
+		l.maxSeen = l.active
+	}
+	l.starts = append(l.starts, time.Now())
+	if l.loaded == nil {
+		l.loaded = make(map[string]int)
+	}
+	l.loaded[u]++
+	onStart := l.onStart
+	l.m.Unlock()
+
+	if onStart != nil {
+		onStart(u)
+	}
+	time.Sleep(l.delay)
+
+	l.m.Lock()
+	l.active--
+	l.m.Unlock()
+
+	if err, ok := l.fail[u]; ok {
+		return nil, err
+	}
+	return &ld.RemoteDocument{DocumentURL: u}, nil
+}
+
+func warmupTestURLs(n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/%v.jsonld", i)
+	}
+	return urls
+}
+
+func TestWarmup_Concurrency(t *testing.T) {
+	urls := warmupTestURLs(12)
+	loader := &warmupTestLoader{delay: 20 * time.Millisecond}
+	errs := Warmup(context.Background(), loader, urls,
+		WithWarmupConcurrency(3))
+	require.Empty(t, errs)
+	require.Len(t, loader.loaded, len(urls))
+	require.LessOrEqual(t, loader.maxSeen, 3)
+	require.Greater(t, loader.maxSeen, 1)
+
+	// default concurrency is used for invalid values
+	loader = &warmupTestLoader{delay: 20 * time.Millisecond}
+	errs = Warmup(context.Background(), loader, urls,
+		WithWarmupConcurrency(0))
+	require.Empty(t, errs)
+	require.LessOrEqual(t, loader.maxSeen, defaultWarmupConcurrency)
+}
+
+func TestWarmup_Interval(t *testing.T) {
+	urls := warmupTestURLs(4)
+	interval := 30 * time.Millisecond
+	loader := &warmupTestLoader{}
+	errs := Warmup(context.Background(), loader, urls,
+		WithWarmupConcurrency(len(urls)), WithWarmupInterval(interval))
+	require.Empty(t, errs)
+	require.Len(t, loader.starts, len(urls))
+	elapsed := loader.starts[len(urls)-1].Sub(loader.starts[0])
+	require.GreaterOrEqual(t, elapsed, time.Duration(len(urls)-1)*interval-
+		5*time.Millisecond)
+}
+
+func TestWarmup_Errors(t *testing.T) {
+	urls := warmupTestURLs(5)
+	errNotFound := errors.New("not found")
+	loader := &warmupTestLoader{fail: map[string]error{
+		urls[1]: errNotFound,
+		urls[3]: errNotFound,
+	}}
+	errs := Warmup(context.Background(), loader, urls)
+	require.Equal(t, map[string]error{
+		urls[1]: errNotFound,
+		urls[3]: errNotFound,
+	}, errs)
+	require.Len(t, loader.loaded, len(urls))
+}
+
+func TestWarmup_Cancel(t *testing.T) {
+	urls := warmupTestURLs(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// cancel the context when the third document starts loading; with
+	// concurrency 1 no other document may start after that
+	loader := &warmupTestLoader{onStart: func(u string) {
+		if u == urls[2] {
+			cancel()
+		}
+	}}
+	errs := Warmup(ctx, loader, urls, WithWarmupConcurrency(1))
+
+	require.Len(t, loader.loaded, 3)
+	require.Len(t, errs, len(urls)-3)
+	for _, u := range urls[3:] {
+		require.ErrorIs(t, errs[u], context.Canceled, u)
+	}
+
+	// nothing is loaded with the canceled context
+	loader = &warmupTestLoader{}
+	errs = Warmup(ctx, loader, urls, WithWarmupInterval(time.Millisecond))
+	require.Empty(t, loader.loaded)
+	require.Len(t, errs, len(urls))
+}
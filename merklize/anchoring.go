@@ -0,0 +1,113 @@
+package merklize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+)
+
+// DefaultAnchorMTLevels is the number of levels of the root of roots merkle
+// tree created by BuildAnchorBatch
+const DefaultAnchorMTLevels = 40
+
+// ErrorAnchorBatchEmpty is returned when BuildAnchorBatch is called without
+// credential roots
+var ErrorAnchorBatchEmpty = errors.New("no credential roots to anchor")
+
+// AnchorProof proves that the credential root is included into the anchored
+// root of roots.
+type AnchorProof struct {
+	// Index is the position of the credential root in the batch. It is the
+	// key of the credential root in the root of roots merkle tree.
+	Index          int               `json:"index"`
+	CredentialRoot *merkletree.Hash  `json:"credentialRoot"`
+	Proof          *merkletree.Proof `json:"proof"`
+}
+
+// AnchorBatch is the payload to anchor on-chain: the root of the merkle tree
+// with credential roots as values and inclusion proofs for every credential.
+type AnchorBatch struct {
+	Root   *merkletree.Hash `json:"root"`
+	Proofs []AnchorProof    `json:"proofs"`
+}
+
+// BuildAnchorBatch builds the root of roots merkle tree over the credential
+// roots (as returned by Merklizer.Root) and generates inclusion proofs. The
+// credential root at position i is stored under key i. The merkle tree has
+// DefaultAnchorMTLevels levels if mtLevels is zero.
+func BuildAnchorBatch(ctx context.Context, credentialRoots []*merkletree.Hash,
+	mtLevels int) (*AnchorBatch, error) {
+
+	if len(credentialRoots) == 0 {
+		return nil, ErrorAnchorBatchEmpty
+	}
+	if mtLevels == 0 {
+		mtLevels = DefaultAnchorMTLevels
+	}
+
+	mt, err := merkletree.NewMerkleTree(ctx, memory.NewMemoryStorage(),
+		mtLevels)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range credentialRoots {
+		if r == nil {
+			return nil, fmt.Errorf("credential root #%v is nil", i)
+		}
+		err = mt.Add(ctx, big.NewInt(int64(i)), r.BigInt())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	batch := &AnchorBatch{
+		Root:   mt.Root(),
+		Proofs: make([]AnchorProof, len(credentialRoots)),
+	}
+	for i, r := range credentialRoots {
+		var proof *merkletree.Proof
+		proof, _, err = mt.GenerateProof(ctx, big.NewInt(int64(i)), nil)
+		if err != nil {
+			return nil, err
+		}
+		batch.Proofs[i] = AnchorProof{
+			Index:          i,
+			CredentialRoot: r,
+			Proof:          proof,
+		}
+	}
+
+	return batch, nil
+}
+
+// VerifyAnchorProof checks that the credential root from the proof is
+// included into the root of roots provided by the chain. The credential
+// root itself should be compared with the root of the merklized credential
+// by the caller, see VerifyCredentialAnchor.
+func VerifyAnchorProof(chainRoot *merkletree.Hash, p AnchorProof) bool {
+	if chainRoot == nil || p.CredentialRoot == nil || p.Proof == nil ||
+		!p.Proof.Existence {
+
+		return false
+	}
+	return merkletree.VerifyProof(chainRoot, p.Proof,
+		big.NewInt(int64(p.Index)), p.CredentialRoot.BigInt())
+}
+
+// VerifyCredentialAnchor checks that the root of the merklized credential
+// is anchored with the root of roots provided by the chain.
+func VerifyCredentialAnchor(mz *Merklizer, chainRoot *merkletree.Hash,
+	p AnchorProof) bool {
+
+	if p.CredentialRoot == nil ||
+		p.CredentialRoot.BigInt().Cmp(mz.Root().BigInt()) != 0 {
+
+		return false
+	}
+	return VerifyAnchorProof(chainRoot, p)
+}
@@ -0,0 +1,62 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ArraySlice is the part of the Path selecting elements of the array from
+// Start to End (exclusive) for RawValue, like the Go slice expression.
+// Negative indexes count from the end of the array, End of 0 selects the
+// elements up to the end. RawValue returns the slice of the values
+// resolved by the rest of the path for every selected element. Paths with
+// ArraySlice have no merkle tree entries.
+type ArraySlice struct {
+	Start int
+	End   int
+}
+
+// AllElements selects all elements of the array
+var AllElements = ArraySlice{}
+
+func (s ArraySlice) String() string {
+	switch {
+	case s.Start == 0 && s.End == 0:
+		return "[*]"
+	case s.End == 0:
+		return fmt.Sprintf("[%v:]", s.Start)
+	default:
+		return fmt.Sprintf("[%v:%v]", s.Start, s.End)
+	}
+}
+
+// bounds returns the indexes of the slice in the array of length n
+func (s ArraySlice) bounds(n int) (int, int, error) {
+	start, end := s.Start, s.End
+	if start < 0 {
+		start += n
+	}
+	if end <= 0 {
+		end += n
+	}
+	if start < 0 || end > n || start > end {
+		return 0, 0, errors.New("slice is out of range")
+	}
+	return start, end, nil
+}
+
+// rvExtractArraySlice returns the selected elements of the array and the
+// index of the first one. Values other than arrays are considered arrays of
+// one element, as the JSON-LD compaction replaces such arrays with their
+// element.
+func rvExtractArraySlice(obj any, s ArraySlice) ([]any, int, error) {
+	objArr, isArray := obj.([]any)
+	if !isArray {
+		objArr = []any{obj}
+	}
+	start, end, err := s.bounds(len(objArr))
+	if err != nil {
+		return nil, 0, err
+	}
+	return objArr[start:end], start, nil
+}
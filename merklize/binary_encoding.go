@@ -14,7 +14,9 @@ import (
 	"github.com/iden3/go-merkletree-sql/v2/db/memory"
 )
 
-const rdfEntryEncodingVersion = 1
+// rdfEntryEncodingVersion 2 adds the hasher ID after the version, version 3
+// adds the lexical form and the language after the datatype
+const rdfEntryEncodingVersion = 3
 
 type entryType uint8
 
@@ -56,6 +58,11 @@ func (e *RDFEntry) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	err = enc.Encode(HasherIDOf(e.getHasher()))
+	if err != nil {
+		return nil, err
+	}
+
 	err = enc.Encode(e.key.parts)
 	if err != nil {
 		return nil, err
@@ -86,10 +93,30 @@ func (e *RDFEntry) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
+	err = enc.Encode(e.lexical)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enc.Encode(e.language)
+	if err != nil {
+		return nil, err
+	}
+
 	return buf.Bytes(), nil
 }
 
+// UnmarshalBinary decodes the entry. If the hasher of the entry is not set,
+// the hasher registered with the ID recorded in the data is used. Otherwise,
+// the ID of the entry hasher should match the recorded one, or
+// ErrorHasherMismatch is returned.
 func (e *RDFEntry) UnmarshalBinary(in []byte) error {
+	return e.unmarshalBinary(in, false)
+}
+
+// unmarshalBinary decodes the entry. If hasherOverride is true, the hasher
+// of the entry is used regardless of the hasher ID recorded in the data.
+func (e *RDFEntry) unmarshalBinary(in []byte, hasherOverride bool) error {
 	dec := gob.NewDecoder(bytes.NewReader(in))
 
 	var encVersion int
@@ -99,14 +126,25 @@ func (e *RDFEntry) UnmarshalBinary(in []byte) error {
 		return err
 	}
 
-	if encVersion != rdfEntryEncodingVersion {
-		return fmt.Errorf("wrong encoding version: %v", encVersion)
+	err = negotiateEncodingVersion(EncodingFormatRDFEntry, encVersion)
+	if err != nil {
+		return err
 	}
 
-	e.key.hasher = e.getHasher()
-	if e.hasher == nil {
-		e.hasher = e.key.hasher
+	var hasherID string
+	if encVersion > 1 {
+		err = dec.Decode(&hasherID)
+		if err != nil {
+			return err
+		}
+	}
+	h, err := resolveSerializedHasher(e.hasher, hasherID, hasherOverride)
+	if err != nil {
+		return err
 	}
+	e.hasher = h
+	e.key.hasher = h
+
 	err = dec.Decode(&e.key.parts)
 	if err != nil {
 		return err
@@ -140,15 +178,35 @@ func (e *RDFEntry) UnmarshalBinary(in []byte) error {
 		return err
 	}
 
+	e.lexical, e.language = "", ""
+	if encVersion > 2 {
+		err = dec.Decode(&e.lexical)
+		if err != nil {
+			return err
+		}
+		err = dec.Decode(&e.language)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-const mzEncodingVersion = 1
-
+// mzEncodingVersion 2 adds the hasher ID after the version and encodes
+// entries as byte slices
+const mzEncodingVersion = 2
+
+// MerklizerFromBytes decodes the Merklizer encoded with MarshalBinary. The
+// hasher is chosen by the hasher ID recorded in the data (see
+// RegisterHasher). If the hasher is set with WithHasher, its ID should match
+// the recorded one, or ErrorHasherMismatch is returned. Use
+// WithHasherOverride to decode with another hasher anyway. The negative
+// integer encoding is recorded with the hasher ID, so the Merklizer created
+// with WithNegativeIntegerEncoding is decoded with the same encoding.
 func MerklizerFromBytes(in []byte, opts ...MerklizeOption) (*Merklizer, error) {
 	mz := &Merklizer{
 		safeMode: true,
-		hasher:   defaultHasher,
 	}
 	for _, o := range opts {
 		o(mz)
@@ -167,7 +225,16 @@ func (mz *Merklizer) MarshalBinary() ([]byte, error) {
 		return nil, err
 	}
 
-	err = enc.Encode(mz.srcDoc)
+	err = enc.Encode(HasherIDOf(mz.Hasher()))
+	if err != nil {
+		return nil, err
+	}
+
+	srcDoc, err := mz.sourceDocument()
+	if err != nil {
+		return nil, err
+	}
+	err = enc.Encode(srcDoc)
 	if err != nil {
 		return nil, err
 	}
@@ -198,8 +265,12 @@ func (mz *Merklizer) MarshalBinary() ([]byte, error) {
 			return nil, err
 		}
 
-		e := e // actually not needed, but lint complains
-		err = enc.Encode(&e)
+		var entryBytes []byte
+		entryBytes, err = e.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		err = enc.Encode(entryBytes)
 		if err != nil {
 			return nil, err
 		}
@@ -222,8 +293,26 @@ func (mz *Merklizer) UnmarshalBinary(in []byte) error {
 		return err
 	}
 
-	if mzEncodingVersion != encodingVersion {
-		return fmt.Errorf("wrong encoding version: %v", encodingVersion)
+	err = negotiateEncodingVersion(EncodingFormatMerklizer, encodingVersion)
+	if err != nil {
+		return err
+	}
+
+	var hasherID string
+	if encodingVersion > 1 {
+		err = enc.Decode(&hasherID)
+		if err != nil {
+			return err
+		}
+	}
+	if mz.intEncoding != nil {
+		mz.hasher = HasherWithNegativeIntegerEncoding(mz.hasher,
+			*mz.intEncoding)
+	}
+	mz.hasher, err = resolveSerializedHasher(mz.hasher, hasherID,
+		mz.hasherOverride)
+	if err != nil {
+		return err
 	}
 
 	err = enc.Decode(&mz.srcDoc)
@@ -249,10 +338,6 @@ func (mz *Merklizer) UnmarshalBinary(in []byte) error {
 
 	addToMT := false
 
-	if mz.hasher == nil {
-		mz.hasher = defaultHasher
-	}
-
 	// if merkletree is not set with options, initialize new in-memory MT.
 	if mz.mt == nil {
 		var mt *merkletree.MerkleTree
@@ -295,7 +380,17 @@ func (mz *Merklizer) UnmarshalBinary(in []byte) error {
 			return err
 		}
 
-		err = enc.Decode(&entries[i])
+		if encodingVersion == 1 {
+			err = enc.Decode(&entries[i])
+		} else {
+			var entryBytes []byte
+			err = enc.Decode(&entryBytes)
+			if err == nil {
+				// entries are decoded with the Merklizer hasher checked
+				// above
+				err = entries[i].unmarshalBinary(entryBytes, true)
+			}
+		}
 		if err != nil {
 			return err
 		}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
 	"github.com/stretchr/testify/require"
 )
 
@@ -182,6 +183,177 @@ func TestRDFEntry_BinaryMashaler_CustomHasher(t *testing.T) {
 	require.Zero(t, val.Cmp(val2))
 }
 
+// namedMD5Hasher is md5Hasher values of which differ by name
+type namedMD5Hasher struct {
+	*md5Hasher
+	name string
+}
+
+func TestRDFEntry_BinaryMashaler_HasherID(t *testing.T) {
+	md5H := namedMD5Hasher{&md5Hasher{}, "entry"}
+	RegisterHasher("md5-test-entry", md5H)
+	require.Equal(t, "md5-test-entry", HasherIDOf(md5H))
+	require.Equal(t, HasherIDPoseidonBN254, HasherIDOf(PoseidonHasher{}))
+	require.Equal(t, "", HasherIDOf(namedMD5Hasher{&md5Hasher{}, "other"}))
+
+	opts := Options{Hasher: md5H}
+	path, err := opts.NewPath("x", "y", 1, "z")
+	require.NoError(t, err)
+	ent, err := opts.NewRDFEntry(path, "abc")
+	require.NoError(t, err)
+	entBytes, err := ent.MarshalBinary()
+	require.NoError(t, err)
+
+	var ent2 RDFEntry
+	err = ent2.UnmarshalBinary(entBytes)
+	require.NoError(t, err)
+	require.Equal(t, ent, ent2)
+
+	ent3, err := NewRDFEntry(path, "")
+	require.NoError(t, err)
+	err = ent3.UnmarshalBinary(entBytes)
+	require.ErrorIs(t, err, ErrorHasherMismatch)
+	require.EqualError(t, err, `hasher mismatch: data is hashed with `+
+		`"md5-test-entry", configured hasher is "poseidon-bn254"`)
+}
+
+func TestRDFEntry_BinaryMashaler_EncodingVersion(t *testing.T) {
+	path, err := NewPath("x", "y", 1, "z")
+	require.NoError(t, err)
+	ent, err := NewRDFEntry(path, "abc")
+	require.NoError(t, err)
+
+	encodeEntry := func(version int, withHasherID bool) []byte {
+		var buf bytes.Buffer
+		enc := gob.NewEncoder(&buf)
+		require.NoError(t, enc.Encode(version))
+		if withHasherID {
+			require.NoError(t, enc.Encode(HasherIDPoseidonBN254))
+		}
+		require.NoError(t, enc.Encode(path.parts))
+		require.NoError(t, doEncode(enc, entryTypeString, "abc"))
+		require.NoError(t, enc.Encode(ent.datatype))
+		return buf.Bytes()
+	}
+
+	// version 1 is read and written with the current version
+	var ent2 RDFEntry
+	err = ent2.UnmarshalBinary(encodeEntry(1, false))
+	require.NoError(t, err)
+	require.Equal(t, ent, ent2)
+	entBytes, err := ent2.MarshalBinary()
+	require.NoError(t, err)
+	version, err := EncodingVersion(entBytes)
+	require.NoError(t, err)
+	require.Equal(t, rdfEntryEncodingVersion, version)
+
+	c, ok := EncodingCompatibilityOf(EncodingFormatRDFEntry)
+	require.True(t, ok)
+	require.Equal(t, EncodingCompatibility{Current: rdfEntryEncodingVersion,
+		MinReadable: 1}, c)
+
+	err = ent2.UnmarshalBinary(encodeEntry(rdfEntryEncodingVersion+1, true))
+	require.ErrorIs(t, err, ErrorUnsupportedEncodingVersion)
+	var versionErr *EncodingVersionError
+	require.ErrorAs(t, err, &versionErr)
+	require.Equal(t, EncodingFormatRDFEntry, versionErr.Format)
+	require.Equal(t, rdfEntryEncodingVersion+1, versionErr.Version)
+	require.EqualError(t, err, "RDFEntry encoding version 4 is not "+
+		"supported (supported versions are 1 to 3): the data is encoded by "+
+		"a newer version of the library")
+
+	err = ent2.UnmarshalBinary(encodeEntry(0, false))
+	require.ErrorIs(t, err, ErrorUnsupportedEncodingVersion)
+}
+
+func TestMerklizer_BinaryMashaler_HasherID(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps,
+		tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+	mzBytes, err := mz.MarshalBinary()
+	require.NoError(t, err)
+
+	mz2, err := MerklizerFromBytes(mzBytes)
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), mz2.Root())
+	require.Equal(t, PoseidonHasher{}, mz2.Hasher())
+
+	_, err = MerklizerFromBytes(mzBytes, WithHasher(&md5Hasher{}))
+	require.ErrorIs(t, err, ErrorHasherMismatch)
+
+	mz3, err := MerklizerFromBytes(mzBytes, WithHasherOverride(&md5Hasher{}))
+	require.NoError(t, err)
+	require.NotEqual(t, mz.Root(), mz3.Root())
+
+	t.Run("registered hasher", func(t *testing.T) {
+		md5H := namedMD5Hasher{&md5Hasher{}, "merklizer"}
+		RegisterHasher("md5-test-merklizer", md5H)
+
+		mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument),
+			WithHasher(md5H))
+		require.NoError(t, err)
+		mzBytes, err := mz.MarshalBinary()
+		require.NoError(t, err)
+
+		mz2, err := MerklizerFromBytes(mzBytes)
+		require.NoError(t, err)
+		require.Equal(t, md5H, mz2.Hasher())
+		require.Len(t, mz2.entries, len(mz.entries))
+
+		_, err = MerklizerFromBytes(mzBytes, WithHasher(PoseidonHasher{}))
+		require.ErrorIs(t, err, ErrorHasherMismatch)
+	})
+}
+
+func TestMerklizer_BinaryMashaler_NegativeIntegerEncoding(t *testing.T) {
+	ctx := context.Background()
+	doc := strings.Replace(untrustedTestDoc, `"age": 42`, `"age": -42`, 1)
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithDocumentLoader(noRemoteDocumentLoader{}),
+		WithNegativeIntegerEncoding(NegativeIntegerTwosComplement))
+	require.NoError(t, err)
+	require.Equal(t, "poseidon-bn254+twos-complement",
+		HasherIDOf(mz.Hasher()))
+	mzBytes, err := mz.MarshalBinary()
+	require.NoError(t, err)
+
+	mz2, err := MerklizerFromBytes(mzBytes)
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), mz2.Root())
+	require.Equal(t, NegativeIntegerTwosComplement,
+		negativeIntegerEncodingOf(mz2.Hasher()))
+
+	mz3, err := MerklizerFromBytes(mzBytes,
+		WithNegativeIntegerEncoding(NegativeIntegerTwosComplement))
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), mz3.Root())
+
+	_, err = MerklizerFromBytes(mzBytes,
+		WithNegativeIntegerEncoding(NegativeIntegerSignMagnitude))
+	require.ErrorIs(t, err, ErrorHasherMismatch)
+
+	_, err = MerklizerFromBytes(mzBytes, WithHasher(PoseidonHasher{}))
+	require.ErrorIs(t, err, ErrorHasherMismatch)
+
+	// the default encoding is recorded with the plain hasher ID
+	mz4, err := MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithDocumentLoader(noRemoteDocumentLoader{}))
+	require.NoError(t, err)
+	mzBytes, err = mz4.MarshalBinary()
+	require.NoError(t, err)
+	_, err = MerklizerFromBytes(mzBytes,
+		WithNegativeIntegerEncoding(NegativeIntegerTwosComplement))
+	require.ErrorIs(t, err, ErrorHasherMismatch)
+	mz5, err := MerklizerFromBytes(mzBytes)
+	require.NoError(t, err)
+	require.Equal(t, mz4.Root(), mz5.Root())
+	require.NotEqual(t, mz.Root(), mz5.Root())
+}
+
 func testMarshalCompactObjCustomFunction(t testing.TB, obj map[string]any) {
 	var buf bytes.Buffer
 	enc := gob.NewEncoder(&buf)
@@ -0,0 +1,52 @@
+package merklize
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// CanonicalLiteral returns the canonical form of the RDF literal that is
+// hashed into the merkle tree. It allows external systems, e.g. smart
+// contracts or other SDKs, to compute value hashes compatible with this
+// package:
+//   - xsd:boolean is "true" or "false", the hash of 1 or 0 is the value;
+//   - integer types are decimal integers, the number itself is the value
+//     (negative numbers are encoded with the negative integer encoding of
+//     the hasher);
+//   - xsd:dateTime is RFC 3339 time in UTC with nanoseconds, the number of
+//     nanoseconds since the Unix epoch modulo the field prime is the value;
+//   - xsd:double is the lexical form of DefaultDoubleCanonicalizer;
+//   - literals of other datatypes are returned as is.
+//
+// Literals of other datatypes and doubles are hashed as bytes of the
+// string with Hasher.HashBytes.
+func CanonicalLiteral(datatype, lexicalForm string) (string, error) {
+	return Options{}.CanonicalLiteral(datatype, lexicalForm)
+}
+
+// CanonicalLiteral returns the canonical form of the RDF literal with the
+// hasher and the double canonicalizer of the options. See CanonicalLiteral.
+func (o Options) CanonicalLiteral(datatype,
+	lexicalForm string) (string, error) {
+
+	v, err := convertStringToXSDValue(datatype, lexicalForm,
+		o.getHasher().Prime(), o.DoubleCanonicalizer)
+	if err != nil {
+		return "", err
+	}
+
+	switch tv := v.(type) {
+	case bool:
+		return strconv.FormatBool(tv), nil
+	case *big.Int:
+		return tv.String(), nil
+	case time.Time:
+		return tv.UTC().Format(time.RFC3339Nano), nil
+	case string:
+		return tv, nil
+	default:
+		return "", fmt.Errorf("unexpected value type: %T", v)
+	}
+}
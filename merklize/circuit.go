@@ -0,0 +1,193 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+)
+
+var (
+	// ErrorPathTooDeep is returned when the path has more parts than allowed
+	ErrorPathTooDeep = errors.New("path is too deep")
+	// ErrorKeyOutOfField is returned when the merkle tree key of the path is
+	// not less than the hasher's prime
+	ErrorKeyOutOfField = errors.New("path key is out of the field")
+	// ErrorValueOutOfField is returned when the merkle tree entry of the
+	// value is not less than the hasher's prime
+	ErrorValueOutOfField = errors.New("value is out of the field")
+	// ErrorProofTooDeep is returned when the merkle tree proof has more
+	// siblings than the circuit supports
+	ErrorProofTooDeep = errors.New("proof depth exceeds circuit levels")
+)
+
+// CircuitConstraints describes the limits of the circuit that would consume
+// the path, value and proof.
+type CircuitConstraints struct {
+	// MTLevels is the number of levels of the merkle tree in the circuit.
+	// If zero, the proof depth is not checked.
+	MTLevels int
+	// MaxPathDepth is the maximum number of path parts. If zero, the path
+	// depth is not checked.
+	MaxPathDepth int
+}
+
+// MtEntryWithDepthCheck is the same as MtEntry but returns ErrorPathTooDeep
+// if the path has more than maxDepth parts and ErrorKeyOutOfField if the
+// calculated key does not fit into the field of the hasher.
+func (p *Path) MtEntryWithDepthCheck(maxDepth int) (*big.Int, error) {
+	if maxDepth > 0 && len(p.parts) > maxDepth {
+		return nil, fmt.Errorf("%w: %v parts, max %v", ErrorPathTooDeep,
+			len(p.parts), maxDepth)
+	}
+
+	key, err := p.MtEntry()
+	if err != nil {
+		return nil, err
+	}
+
+	if !inField(key, p.getHasher()) {
+		return nil, ErrorKeyOutOfField
+	}
+	return key, nil
+}
+
+// ValidateCircuitInputs checks that the path, value and proof may be used
+// as inputs of the circuit with given constraints. Value and proof are
+// optional and are not checked if nil.
+func ValidateCircuitInputs(path Path, value Value, proof *merkletree.Proof,
+	constraints CircuitConstraints) error {
+
+	_, err := path.MtEntryWithDepthCheck(constraints.MaxPathDepth)
+	if err != nil {
+		return err
+	}
+
+	if value != nil {
+		var valueEntry *big.Int
+		valueEntry, err = value.MtEntry()
+		if err != nil {
+			return err
+		}
+		if !inField(valueEntry, path.getHasher()) {
+			return ErrorValueOutOfField
+		}
+	}
+
+	if proof != nil && constraints.MTLevels > 0 {
+		depth := len(proof.AllSiblings())
+		if depth > constraints.MTLevels {
+			return fmt.Errorf("%w: %v siblings, %v levels",
+				ErrorProofTooDeep, depth, constraints.MTLevels)
+		}
+	}
+
+	return nil
+}
+
+func (p *Path) getHasher() Hasher {
+	if p.hasher == nil {
+		return defaultHasher
+	}
+	return p.hasher
+}
+
+func inField(v *big.Int, h Hasher) bool {
+	return v.Sign() >= 0 && v.Cmp(h.Prime()) < 0
+}
+
+// CircomNodeAux is the auxiliary node of the merkle tree proof as inputs of
+// circom circuits. For existence proofs and non-existence proofs ending in
+// the empty node, Key and Value are "0". NoAux is "1" if the non-existence
+// proof has no auxiliary node and "0" otherwise.
+type CircomNodeAux struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	NoAux string `json:"noAux"`
+}
+
+// ToCircomSiblings returns the siblings of the proof as decimal strings
+// padded with "0" to levels items, the number of levels of the merkle tree
+// in the circuit. ErrorProofTooDeep is returned if the proof has more
+// siblings.
+func ToCircomSiblings(proof *merkletree.Proof, levels int) ([]string,
+	error) {
+
+	if proof == nil {
+		return nil, errors.New("proof is nil")
+	}
+	if levels <= 0 {
+		return nil, fmt.Errorf("invalid number of levels: %v", levels)
+	}
+
+	siblings := proof.AllSiblings()
+	if len(siblings) > levels {
+		return nil, fmt.Errorf("%w: %v siblings, %v levels",
+			ErrorProofTooDeep, len(siblings), levels)
+	}
+
+	out := make([]string, levels)
+	for i := range out {
+		out[i] = "0"
+		if i < len(siblings) {
+			out[i] = siblings[i].BigInt().String()
+		}
+	}
+	return out, nil
+}
+
+// ToCircomNodeAux returns the auxiliary node of the proof as circuit inputs
+func ToCircomNodeAux(proof *merkletree.Proof) CircomNodeAux {
+	switch {
+	case proof.Existence:
+		return CircomNodeAux{Key: "0", Value: "0", NoAux: "0"}
+	case proof.NodeAux != nil:
+		return CircomNodeAux{
+			Key:   proof.NodeAux.Key.BigInt().String(),
+			Value: proof.NodeAux.Value.BigInt().String(),
+			NoAux: "0",
+		}
+	default:
+		return CircomNodeAux{Key: "0", Value: "0", NoAux: "1"}
+	}
+}
+
+// FromCircomSiblings restores the merkle tree proof from the circuit inputs
+// returned by ToCircomSiblings and ToCircomNodeAux. Trailing zero siblings
+// are the padding and are dropped. The node aux is ignored for existence
+// proofs.
+func FromCircomSiblings(existence bool, siblings []string,
+	nodeAux CircomNodeAux) (*merkletree.Proof, error) {
+
+	hashes := make([]*merkletree.Hash, len(siblings))
+	for i, s := range siblings {
+		h, err := merkletree.NewHashFromString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sibling %v: %w", i, err)
+		}
+		hashes[i] = h
+	}
+	for len(hashes) > 0 &&
+		hashes[len(hashes)-1].Equals(&merkletree.HashZero) {
+
+		hashes = hashes[:len(hashes)-1]
+	}
+
+	var aux *merkletree.NodeAux
+	if !existence && nodeAux.NoAux == "0" {
+		key, err := merkletree.NewHashFromString(nodeAux.Key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node aux key: %w", err)
+		}
+		value, err := merkletree.NewHashFromString(nodeAux.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node aux value: %w", err)
+		}
+		aux = &merkletree.NodeAux{Key: key, Value: value}
+	} else if !existence && nodeAux.NoAux != "1" {
+		return nil, fmt.Errorf("invalid noAux: %q", nodeAux.NoAux)
+	}
+
+	return merkletree.NewProofFromData(existence, hashes, aux)
+}
@@ -0,0 +1,145 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrorDuplicateEntryPath is returned when several RDF entries have the same
+// path. It happens when the same predicate appears in several named graphs
+// with the same path, e.g. two credentials of a presentation embedded as
+// @graph values. Such entries can't be put into the merkle tree, because the
+// tree has only one leaf per path.
+var ErrorDuplicateEntryPath = errors.New("multiple entries with the same path")
+
+// EntryPathCollision lists values of RDF entries that share the same path
+type EntryPathCollision struct {
+	Path   Path
+	Values []any
+}
+
+func (c EntryPathCollision) String() string {
+	parts := make([]string, len(c.Path.parts))
+	for i, p := range c.Path.parts {
+		parts[i] = fmt.Sprintf("%v", p)
+	}
+	return fmt.Sprintf("[%v]: %v values", strings.Join(parts, " / "),
+		len(c.Values))
+}
+
+// FindEntryPathCollisions returns all paths that are shared by more than one
+// entry, ordered by the merkle tree key of the path. Values are in the order
+// of entries.
+func FindEntryPathCollisions(
+	entries []RDFEntry) ([]EntryPathCollision, error) {
+
+	type group struct {
+		key    string
+		path   Path
+		values []any
+	}
+	groups := make(map[string]*group)
+	for _, e := range entries {
+		key, err := e.KeyMtEntry()
+		if err != nil {
+			return nil, err
+		}
+		g, ok := groups[key.String()]
+		if !ok {
+			g = &group{key: key.String(), path: e.key}
+			groups[key.String()] = g
+		}
+		g.values = append(g.values, e.value)
+	}
+
+	var dups []*group
+	for _, g := range groups {
+		if len(g.values) > 1 {
+			dups = append(dups, g)
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i].key < dups[j].key })
+
+	var collisions []EntryPathCollision
+	for _, g := range dups {
+		collisions = append(collisions,
+			EntryPathCollision{Path: g.path, Values: g.values})
+	}
+	return collisions, nil
+}
+
+// DuplicateEntryError is returned by MerklizeJSONLD when several entries
+// have the same path. It wraps ErrorDuplicateEntryPath.
+type DuplicateEntryError struct {
+	Collisions []EntryPathCollision
+}
+
+func (e *DuplicateEntryError) Error() string {
+	descs := make([]string, len(e.Collisions))
+	for i, c := range e.Collisions {
+		descs[i] = c.String()
+	}
+	return fmt.Sprintf("%v: %v", ErrorDuplicateEntryPath,
+		strings.Join(descs, "; "))
+}
+
+// Unwrap returns ErrorDuplicateEntryPath
+func (e *DuplicateEntryError) Unwrap() error {
+	return ErrorDuplicateEntryPath
+}
+
+// Paths returns the paths shared by several entries
+func (e *DuplicateEntryError) Paths() []Path {
+	paths := make([]Path, len(e.Collisions))
+	for i, c := range e.Collisions {
+		paths[i] = c.Path
+	}
+	return paths
+}
+
+// duplicateEntryPathError returns DuplicateEntryError for the collisions of
+// entries. If skipIdentical is true, collisions of identical values are not
+// reported.
+func duplicateEntryPathError(entries []RDFEntry, skipIdentical bool) error {
+	collisions, err := FindEntryPathCollisions(entries)
+	if err != nil {
+		return err
+	}
+	if skipIdentical {
+		var differing []EntryPathCollision
+		for _, c := range collisions {
+			if !identicalValues(c.Values) {
+				differing = append(differing, c)
+			}
+		}
+		collisions = differing
+	}
+	return &DuplicateEntryError{Collisions: collisions}
+}
+
+func identicalValues(values []any) bool {
+	for _, v := range values[1:] {
+		if !equalValues(values[0], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalValues compares values of RDF entries
+func equalValues(a, b any) bool {
+	switch at := a.(type) {
+	case time.Time:
+		bt, ok := b.(time.Time)
+		return ok && at.Equal(bt)
+	case *big.Int:
+		bt, ok := b.(*big.Int)
+		return ok && at.Cmp(bt) == 0
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,167 @@
+package merklize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/piprate/json-gold/ld"
+)
+
+// ErrorNoCompactionContext is returned by TermCompactedDocument and
+// RawValueByDocPath if the compaction context is not set with
+// WithCompactionContext or WithDocumentCompactionContext
+var ErrorNoCompactionContext = errors.New("compaction context is not set")
+
+var signedNumRE = regexp.MustCompile(`^-?\d+$`)
+
+// WithCompactionContext sets the JSON-LD context (a context URL, an array of
+// them or a context object) to compact the source document with, so its
+// values can be found by human-friendly terms with TermCompactedDocument and
+// RawValueByDocPath. The compacted document used by RawValue is not
+// affected.
+func WithCompactionContext(ldContext any) MerklizeOption {
+	return func(m *Merklizer) {
+		m.compactionCtx = ldContext
+		m.compactWithDocCtx = false
+		m.compactionCtxSet = true
+	}
+}
+
+// WithDocumentCompactionContext is like WithCompactionContext, but the
+// source document is compacted with its own @context
+func WithDocumentCompactionContext() MerklizeOption {
+	return func(m *Merklizer) {
+		m.compactionCtx = nil
+		m.compactWithDocCtx = true
+		m.compactionCtxSet = true
+	}
+}
+
+// CompactedDocument returns the source document compacted without context,
+// so all terms are expanded to IRIs. RawValue looks up values in this
+// document by parts of the Path. The document must not be modified.
+func (mz *Merklizer) CompactedDocument() map[string]any {
+	return mz.compacted
+}
+
+// TermCompactedDocument returns the source document compacted with the
+// context set with WithCompactionContext or WithDocumentCompactionContext.
+// The document is compacted on the first call. Remote contexts are loaded
+// with the document loader of the Merklizer. The document must not be
+// modified.
+func (mz *Merklizer) TermCompactedDocument(
+	ctx context.Context) (_ map[string]any, err error) {
+
+	if !mz.compactionCtxSet {
+		return nil, ErrorNoCompactionContext
+	}
+
+	mz.termCompactedM.Lock()
+	defer mz.termCompactedM.Unlock()
+	if mz.termCompacted != nil {
+		return mz.termCompacted, nil
+	}
+
+	srcDoc, err := mz.sourceDocument()
+	if err != nil {
+		return nil, err
+	}
+	if len(srcDoc) == 0 {
+		return nil, ErrorNoSourceDocument
+	}
+	var obj any
+	err = json.Unmarshal(srcDoc, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	ldContext := mz.compactionCtx
+	if mz.compactWithDocCtx {
+		if objMap, ok := obj.(map[string]any); ok {
+			ldContext = objMap["@context"]
+		}
+	}
+
+	defer recoverPanic(&err)
+	options := newJSONLDOptions(mz.safeMode,
+		loaders.BindContext(ctx, mz.getDocumentLoader()))
+	compacted, err := ld.NewJsonLdProcessor().Compact(obj, ldContext,
+		options)
+	if err != nil {
+		return nil, err
+	}
+	mz.termCompacted = compacted
+	return mz.termCompacted, nil
+}
+
+// RawValueByDocPath is like RawValue, but looks up the value in the document
+// returned by TermCompactedDocument by the path in the document notation,
+// e.g. "credentialSubject.birthday" or "credentialSubject.0.birthday" for
+// arrays. Negative indexes count from the end of the array, e.g.
+// "credentialSubject.-1.birthday" is the birthday of the last subject, and
+// "*" selects all elements: the slice of values is returned for
+// "credentialSubject.*.birthday".
+func (mz *Merklizer) RawValueByDocPath(ctx context.Context,
+	docPath string) (any, error) {
+
+	obj, err := mz.TermCompactedDocument(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rawValueByDocPath(obj, strings.Split(docPath, "."), nil)
+}
+
+func rawValueByDocPath(value any, terms []string,
+	traversedTerms []string) (any, error) {
+
+	var err error
+	for i, term := range terms {
+		traversedTerms = append(traversedTerms, term)
+		switch {
+		case term == "*":
+			var elems []any
+			var start int
+			elems, start, err = rvExtractArraySlice(value, AllElements)
+			if err != nil {
+				break
+			}
+			// errors of elements name their indexes instead of "*"
+			n := len(traversedTerms) - 1
+			values := make([]any, len(elems))
+			for j, elem := range elems {
+				values[j], err = rawValueByDocPath(elem, terms[i+1:],
+					append(traversedTerms[:n:n], strconv.Itoa(start+j)))
+				if err != nil {
+					return nil, err
+				}
+			}
+			return values, nil
+		case signedNumRE.MatchString(term):
+			var idx int
+			idx, err = strconv.Atoi(term)
+			if err == nil {
+				value, err = rvExtractArrayIdx(value, idx)
+			}
+		default:
+			value, err = rvExtractObjField(value, term)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%v at '%v'", err,
+				strings.Join(traversedTerms, "."))
+		}
+	}
+
+	if jsObj, isJSONObj := value.(map[string]any); isJSONObj {
+		if val, hasValue := jsObj["@value"]; hasValue {
+			return val, nil
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,192 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// ComparisonOperator is the operator of the comparison of merklized values
+// checked by circuits
+type ComparisonOperator uint8
+
+const (
+	// ComparisonLT is "less than" operator
+	ComparisonLT ComparisonOperator = iota + 1
+	// ComparisonGT is "greater than" operator
+	ComparisonGT
+)
+
+func (o ComparisonOperator) String() string {
+	switch o {
+	case ComparisonLT:
+		return "lt"
+	case ComparisonGT:
+		return "gt"
+	default:
+		return fmt.Sprintf("ComparisonOperator(%d)", uint8(o))
+	}
+}
+
+var (
+	// ErrorNotComparable is returned when the merklized value is neither an
+	// integer nor a dateTime
+	ErrorNotComparable = errors.New("value is not comparable")
+	// ErrorComparisonMismatch is returned when the comparison of values is
+	// not consistent with their field elements
+	ErrorComparisonMismatch = errors.New(
+		"comparison is not consistent with field elements")
+)
+
+// ComparableValue is the integer or dateTime value with the data used by
+// comparison circuits
+type ComparableValue struct {
+	// Int is the signed integer value. For dateTime values, it is the number
+	// of nanoseconds since Unix epoch.
+	Int *big.Int
+	// FieldElement is the merkle tree value entry of the value. Circuits
+	// compare field elements as unsigned integers.
+	FieldElement *big.Int
+	// Negative is true if Int is negative and FieldElement is the encoding of
+	// the negative value
+	Negative bool
+	// Encoding maps Int to FieldElement. dateTime values are always mapped
+	// with NegativeIntegerFieldComplement.
+	Encoding NegativeIntegerEncoding
+
+	prime *big.Int
+}
+
+// NewComparableInt returns the comparable value of the integer mapped to the
+// field with the negative integer encoding of the hasher
+func NewComparableInt(h Hasher, v *big.Int) (*ComparableValue, error) {
+	if h == nil {
+		h = defaultHasher
+	}
+	return newComparableValue(h.Prime(), negativeIntegerEncodingOf(h), v)
+}
+
+// NewComparableTime returns the comparable value of the dateTime
+func NewComparableTime(h Hasher, t time.Time) (*ComparableValue, error) {
+	if h == nil {
+		h = defaultHasher
+	}
+	nanos := new(big.Int).Mul(big.NewInt(t.Unix()), bigTen9)
+	nanos.Add(nanos, big.NewInt(int64(t.Nanosecond())))
+	return newComparableValue(h.Prime(), NegativeIntegerFieldComplement,
+		nanos)
+}
+
+func newComparableValue(prime *big.Int, enc NegativeIntegerEncoding,
+	v *big.Int) (*ComparableValue, error) {
+
+	fieldElement, err := enc.Encode(prime, v)
+	if err != nil {
+		return nil, err
+	}
+	return &ComparableValue{
+		Int:          new(big.Int).Set(v),
+		FieldElement: fieldElement,
+		Negative:     v.Sign() < 0,
+		Encoding:     enc,
+		prime:        new(big.Int).Set(prime),
+	}, nil
+}
+
+// ComparableValue returns the comparable value of the integer or dateTime
+// entry by the path. The field element is the value entry of the merkle
+// tree. Returns ErrorNotComparable for values of other types.
+func (mz *Merklizer) ComparableValue(path Path) (*ComparableValue, error) {
+	e, err := mz.Entry(path)
+	if err != nil {
+		return nil, err
+	}
+	h := e.getHasher()
+
+	var cv *ComparableValue
+	switch v := e.value.(type) {
+	case int64:
+		cv, err = NewComparableInt(h, big.NewInt(v))
+	case int:
+		cv, err = NewComparableInt(h, big.NewInt(int64(v)))
+	case *big.Int:
+		cv, err = NewComparableInt(h, v)
+	case time.Time:
+		cv, err = NewComparableTime(h, v)
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrorNotComparable, e.value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	valueEntry, err := e.ValueMtEntry()
+	if err != nil {
+		return nil, err
+	}
+	if valueEntry.Cmp(cv.FieldElement) != 0 {
+		return nil, fmt.Errorf("%w: value %v is not mapped to %v",
+			ErrorComparisonMismatch, cv.Int, valueEntry)
+	}
+	return cv, nil
+}
+
+// CheckComparison checks that the claimed result of comparison "a op b" of
+// signed values is correct, that the values match their field elements and
+// that comparison of the field elements as unsigned integers, as done by
+// circuits, gives the same result. The latter fails when a negative value
+// is compared with a non-negative one, as negative values are mapped to the
+// upper half of the field, or when the encoding does not preserve the order
+// of negative values, e.g. NegativeIntegerSignMagnitude.
+func CheckComparison(a, b *ComparableValue, op ComparisonOperator,
+	claimed bool) error {
+
+	if a.prime == nil || b.prime == nil || a.prime.Cmp(b.prime) != 0 {
+		return errors.New("values are mapped to different fields")
+	}
+	for _, cv := range []*ComparableValue{a, b} {
+		fieldElement, err := cv.Encoding.Encode(cv.prime, cv.Int)
+		if err != nil {
+			return err
+		}
+		if fieldElement.Cmp(cv.FieldElement) != 0 ||
+			cv.Negative != (cv.Int.Sign() < 0) {
+
+			return fmt.Errorf("%w: value %v is not mapped to %v",
+				ErrorComparisonMismatch, cv.Int, cv.FieldElement)
+		}
+	}
+
+	result, err := compareResult(a.Int.Cmp(b.Int), op)
+	if err != nil {
+		return err
+	}
+	if result != claimed {
+		return fmt.Errorf("claimed comparison %v %v %v = %v is false", a.Int,
+			op, b.Int, claimed)
+	}
+
+	circuitResult, err := compareResult(a.FieldElement.Cmp(b.FieldElement),
+		op)
+	if err != nil {
+		return err
+	}
+	if circuitResult != result {
+		return fmt.Errorf("%w: %v %v %v is %v, but comparison of field "+
+			"elements is %v", ErrorComparisonMismatch, a.Int, op, b.Int,
+			result, circuitResult)
+	}
+	return nil
+}
+
+func compareResult(cmp int, op ComparisonOperator) (bool, error) {
+	switch op {
+	case ComparisonLT:
+		return cmp < 0, nil
+	case ComparisonGT:
+		return cmp > 0, nil
+	default:
+		return false, fmt.Errorf("unsupported comparison operator: %v", op)
+	}
+}
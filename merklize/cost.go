@@ -0,0 +1,83 @@
+package merklize
+
+import (
+	"context"
+	"errors"
+	"math/bits"
+
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/piprate/json-gold/ld"
+)
+
+// MerklizationCost is the estimated cost of merklization of the document
+type MerklizationCost struct {
+	// DocumentSize is the size of the document in bytes
+	DocumentSize int
+	// Quads is the number of RDF quads of the document
+	Quads int
+	// Entries is the number of quads that become merkle tree entries. It
+	// may be larger than the number of entries of the merklized document if
+	// the document has duplicate entries merged with WithDedupEntries.
+	Entries int
+	// TreeInsertions is the projected number of merkle tree insertions
+	TreeInsertions int
+	// HashOperations is the projected number of hash computations: hashing
+	// of entry keys and values and updating of the tree on insertions
+	HashOperations int
+}
+
+// EstimateMerklizationCost converts the document to RDF quads without
+// canonicalization and projects the cost of its merklization. It allows to
+// enforce performance budgets before merklizing documents. Remote contexts
+// are loaded with the document loader set with options.
+func EstimateMerklizationCost(ctx context.Context, docBytes []byte,
+	opts ...MerklizeOption) (cost MerklizationCost, err error) {
+
+	defer recoverPanic(&err)
+
+	mz := &Merklizer{safeMode: true}
+	for _, o := range opts {
+		o(mz)
+	}
+
+	obj, err := mz.decodeDocument(docBytes)
+	if err != nil {
+		return cost, err
+	}
+	doc, _, err := mz.normalizeNumbers(obj)
+	if err != nil {
+		return cost, err
+	}
+
+	options := newJSONLDOptions(mz.safeMode,
+		loaders.BindContext(ctx, mz.getDocumentLoader()))
+	rdf, err := ld.NewJsonLdProcessor().ToRDF(doc, options)
+	if err != nil {
+		return cost, err
+	}
+	dataset, ok := rdf.(*ld.RDFDataset)
+	if !ok {
+		return cost, errors.New("[assertion] expected *ld.RDFDataset type")
+	}
+
+	cost.DocumentSize = len(docBytes)
+	for _, quads := range dataset.Graphs {
+		for _, q := range quads {
+			cost.Quads++
+			if _, isBlank := q.Object.(*ld.BlankNode); !isBlank {
+				cost.Entries++
+			}
+		}
+	}
+
+	cost.TreeInsertions = cost.Entries
+	// every entry hashes its key and value, the insertion rehashes the path
+	// from the leaf to the root which depth is about log2 of the number of
+	// leaves, but not larger than 40 levels of the tree
+	depth := bits.Len(uint(cost.Entries)) + 1
+	if depth > 40 {
+		depth = 40
+	}
+	cost.HashOperations = cost.Entries * (2 + depth)
+	return cost, nil
+}
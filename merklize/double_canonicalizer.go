@@ -0,0 +1,79 @@
+package merklize
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// DoubleCanonicalizer formats xsd:double values to the lexical forms that
+// are hashed into the merkle tree. Issuers and verifiers must use the same
+// canonicalizer, otherwise values of the same double are hashed
+// differently.
+//
+// Note that JSON-LD processing converts JSON numbers typed as xsd:double
+// to the lexical forms of DefaultDoubleCanonicalizer before the
+// canonicalizer is applied, so for such numbers only the precision of
+// DefaultDoubleCanonicalizer (16 significant digits) is available.
+type DoubleCanonicalizer interface {
+	CanonicalDouble(v float64) string
+}
+
+// DoubleCanonicalizerFunc is a function implementing DoubleCanonicalizer
+type DoubleCanonicalizerFunc func(v float64) string
+
+// CanonicalDouble calls f(v)
+func (f DoubleCanonicalizerFunc) CanonicalDouble(v float64) string {
+	return f(v)
+}
+
+// DefaultDoubleCanonicalizer formats doubles as JSON-LD processing does: the
+// mantissa is rounded to 16 significant digits and trailing zeros are
+// removed, e.g. "1.5E0" or "1.0E-1". Special values are formatted as
+// "NaN", "+Inf" and "-Inf".
+var DefaultDoubleCanonicalizer DoubleCanonicalizer = DoubleCanonicalizerFunc(
+	ld.GetCanonicalDouble)
+
+// W3CDoubleCanonicalizer formats doubles in the canonical representation
+// of XML Schema 1.1: the shortest mantissa that is parsed back to the same
+// double, e.g. "3.0000000000000004E-1" or "5.0E-324". Zeros are "0.0E0" and
+// "-0.0E0", special values are "NaN", "INF" and "-INF".
+var W3CDoubleCanonicalizer DoubleCanonicalizer = DoubleCanonicalizerFunc(
+	w3cCanonicalDouble)
+
+func w3cCanonicalDouble(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "INF"
+	case math.IsInf(v, -1):
+		return "-INF"
+	case v == 0 && math.Signbit(v):
+		return "-0.0E0"
+	case v == 0:
+		return "0.0E0"
+	}
+
+	// e.g. "1.5E+00" or "5E-324"
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(v, 'E', -1, 64), "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	e, err := strconv.Atoi(exp)
+	if err != nil {
+		// should not happen, the exponent is formatted by strconv
+		return ld.GetCanonicalDouble(v)
+	}
+	return mantissa + "E" + strconv.Itoa(e)
+}
+
+// WithDoubleCanonicalizer sets the formatting of xsd:double values. By
+// default, DefaultDoubleCanonicalizer is used.
+func WithDoubleCanonicalizer(dc DoubleCanonicalizer) MerklizeOption {
+	return func(m *Merklizer) {
+		m.doubleCanon = dc
+	}
+}
@@ -0,0 +1,213 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// DroppedTerm describes a property of the source document that did not
+// expand into an absolute IRI or keyword.
+type DroppedTerm struct {
+	// Term is the property name as it appears in the source document
+	Term string
+	// Path is the dot separated path to the property in the source document.
+	// Array indexes are represented as numbers, e.g.
+	// credentialSubject.0.birthday
+	Path string
+	// Contexts is a list of remote contexts that were in scope for the
+	// property. Inline contexts are not listed.
+	Contexts []string
+}
+
+// DroppedTermsError is returned by MerklizeJSONLD in safe mode when the
+// document contains properties that are not defined in its contexts.
+type DroppedTermsError struct {
+	Terms []DroppedTerm
+	err   error
+}
+
+func (e *DroppedTermsError) Error() string {
+	paths := make([]string, len(e.Terms))
+	for i := range e.Terms {
+		paths[i] = e.Terms[i].Path
+	}
+	return fmt.Sprintf("%v; dropped properties: %v", e.err,
+		strings.Join(paths, ", "))
+}
+
+// Unwrap returns the original JSON-LD processing error
+func (e *DroppedTermsError) Unwrap() error {
+	return e.err
+}
+
+// explainDroppedTerms converts the "invalid property" JSON-LD error into
+// DroppedTermsError listing all properties that would be dropped by the
+// expansion. The search is a best effort: if no dropped properties were
+// found, the original error is returned.
+func explainDroppedTerms(err error, doc any,
+	options *ld.JsonLdOptions) error {
+
+	var ldErr *ld.JsonLdError
+	if !errors.As(err, &ldErr) || ldErr.Code != ld.InvalidProperty {
+		return err
+	}
+
+	var terms []DroppedTerm
+	findErr := findDroppedTerms(ld.NewContext(nil, options), "", doc, nil,
+		nil, &terms)
+	if findErr != nil || len(terms) == 0 {
+		return err
+	}
+
+	return &DroppedTermsError{Terms: terms, err: err}
+}
+
+func findDroppedTerms(activeCtx *ld.Context, activeProperty string,
+	element any, path []string, contexts []string,
+	out *[]DroppedTerm) error {
+
+	switch el := element.(type) {
+	case []any:
+		for i := range el {
+			err := findDroppedTerms(activeCtx, activeProperty, el[i],
+				append(path, strconv.Itoa(i)), contexts, out)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]any:
+		// handled below
+	default:
+		return nil
+	}
+
+	elem := element.(map[string]any)
+	if _, isValue := elem["@value"]; isValue {
+		return nil
+	}
+
+	propertyScopedCtx := activeCtx.GetTermDefinition(activeProperty)["@context"]
+	activeCtx = activeCtx.RevertToPreviousContext()
+
+	var err error
+	if propertyScopedCtx != nil {
+		activeCtx, err = activeCtx.Parse(propertyScopedCtx)
+		if err != nil {
+			return err
+		}
+	}
+
+	if elemCtx, hasCtx := elem["@context"]; hasCtx {
+		activeCtx, err = activeCtx.Parse(elemCtx)
+		if err != nil {
+			return err
+		}
+		contexts = appendRemoteContexts(contexts, elemCtx)
+	}
+
+	keys := ld.GetOrderedKeys(elem)
+
+	typeScopedCtx := activeCtx
+	for _, key := range keys {
+		var expandedProperty string
+		expandedProperty, err = activeCtx.ExpandIri(key, false, true, nil,
+			nil)
+		if err != nil {
+			return err
+		}
+		if expandedProperty != "@type" {
+			continue
+		}
+
+		var types []string
+		switch v := elem[key].(type) {
+		case string:
+			types = append(types, v)
+		case []any:
+			for _, t := range v {
+				if tStr, ok := t.(string); ok {
+					types = append(types, tStr)
+				}
+			}
+			sort.Strings(types)
+		}
+
+		for _, tt := range types {
+			td := typeScopedCtx.GetTermDefinition(tt)
+			if tCtx, hasCtx := td["@context"]; hasCtx {
+				activeCtx, err = activeCtx.Parse(tCtx)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, key := range keys {
+		if key == "@context" {
+			continue
+		}
+
+		keyPath := append(path, key)
+
+		var expandedProperty string
+		expandedProperty, err = activeCtx.ExpandIri(key, false, true, nil,
+			nil)
+		if err != nil {
+			return err
+		}
+
+		if expandedProperty == "" ||
+			(!strings.Contains(expandedProperty, ":") &&
+				!ld.IsKeyword(expandedProperty)) {
+
+			*out = append(*out, DroppedTerm{
+				Term:     key,
+				Path:     strings.Join(keyPath, "."),
+				Contexts: append([]string(nil), contexts...),
+			})
+			continue
+		}
+
+		if ld.IsKeyword(expandedProperty) {
+			switch expandedProperty {
+			case "@graph", "@included", "@list", "@set":
+				err = findDroppedTerms(activeCtx, activeProperty, elem[key],
+					keyPath, contexts, out)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if activeCtx.GetTypeMapping(key) == "@json" {
+			continue
+		}
+
+		err = findDroppedTerms(activeCtx, key, elem[key], keyPath, contexts,
+			out)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func appendRemoteContexts(contexts []string, ctx any) []string {
+	// copy to not modify the slice of the parent element
+	contexts = append([]string(nil), contexts...)
+	for _, c := range ld.Arrayify(ctx) {
+		if cStr, ok := c.(string); ok {
+			contexts = append(contexts, cStr)
+		}
+	}
+	return contexts
+}
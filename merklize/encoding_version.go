@@ -0,0 +1,104 @@
+package merklize
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+// ErrorUnsupportedEncodingVersion is returned when the data is encoded with
+// a version of the binary format that this library can't read
+var ErrorUnsupportedEncodingVersion = errors.New(
+	"unsupported encoding version")
+
+// EncodingFormat is the binary serialization format
+type EncodingFormat string
+
+const (
+	// EncodingFormatRDFEntry is the format of RDFEntry.MarshalBinary
+	EncodingFormatRDFEntry EncodingFormat = "RDFEntry"
+	// EncodingFormatMerklizer is the format of Merklizer.MarshalBinary
+	EncodingFormatMerklizer EncodingFormat = "Merklizer"
+)
+
+// EncodingCompatibility describes the versions of the binary format the
+// library supports. Data is always written with the Current version and may
+// be read if encoded with any version from MinReadable to Current.
+type EncodingCompatibility struct {
+	Current     int
+	MinReadable int
+}
+
+// Readable returns true if the data encoded with the version can be read
+func (c EncodingCompatibility) Readable(version int) bool {
+	return version >= c.MinReadable && version <= c.Current
+}
+
+// encodingCompatibility is the compatibility table of the binary formats.
+// When the format changes, bump the current version and keep the previous
+// one readable, so persisted data survives library upgrades.
+var encodingCompatibility = map[EncodingFormat]EncodingCompatibility{
+	EncodingFormatRDFEntry: {
+		Current:     rdfEntryEncodingVersion,
+		MinReadable: 1,
+	},
+	EncodingFormatMerklizer: {
+		Current:     mzEncodingVersion,
+		MinReadable: 1,
+	},
+}
+
+// EncodingCompatibilityOf returns the supported versions of the format
+func EncodingCompatibilityOf(
+	format EncodingFormat) (EncodingCompatibility, bool) {
+
+	c, ok := encodingCompatibility[format]
+	return c, ok
+}
+
+// EncodingVersionError is returned when the encoding version of the data is
+// not supported. It wraps ErrorUnsupportedEncodingVersion.
+type EncodingVersionError struct {
+	Format        EncodingFormat
+	Version       int
+	Compatibility EncodingCompatibility
+}
+
+func (e *EncodingVersionError) Error() string {
+	hint := "the data is encoded by a newer version of the library"
+	if e.Version < e.Compatibility.MinReadable {
+		hint = "the data is encoded by an outdated version of the library"
+	}
+	return fmt.Sprintf("%v encoding version %v is not supported "+
+		"(supported versions are %v to %v): %v", e.Format, e.Version,
+		e.Compatibility.MinReadable, e.Compatibility.Current, hint)
+}
+
+func (e *EncodingVersionError) Unwrap() error {
+	return ErrorUnsupportedEncodingVersion
+}
+
+// negotiateEncodingVersion checks that the data encoded with the version
+// can be read
+func negotiateEncodingVersion(format EncodingFormat, version int) error {
+	c := encodingCompatibility[format]
+	if !c.Readable(version) {
+		return &EncodingVersionError{Format: format, Version: version,
+			Compatibility: c}
+	}
+	return nil
+}
+
+// EncodingVersion returns the version of the data encoded with
+// RDFEntry.MarshalBinary or Merklizer.MarshalBinary. It may be used to
+// decide whether persisted data should be re-encoded with the current
+// version.
+func EncodingVersion(in []byte) (int, error) {
+	var version int
+	err := gob.NewDecoder(bytes.NewReader(in)).Decode(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode encoding version: %w", err)
+	}
+	return version, nil
+}
@@ -0,0 +1,83 @@
+package merklize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+)
+
+// Entries returns the merklized entries ordered by their key merkle tree
+// entries in ascending order. The order does not depend on the document
+// layout or map iteration, so it is stable across runs and encodings.
+func (mz *Merklizer) Entries() []RDFEntry {
+	type keyedEntry struct {
+		key   *big.Int
+		entry RDFEntry
+	}
+
+	keyed := make([]keyedEntry, 0, len(mz.entries))
+	for k, e := range mz.entries {
+		key, ok := new(big.Int).SetString(k, 10)
+		if !ok {
+			// should not happen, keys are set from KeyMtEntry
+			key = new(big.Int)
+		}
+		keyed = append(keyed, keyedEntry{key: key, entry: e})
+	}
+	sort.Slice(keyed, func(i, j int) bool {
+		return keyed[i].key.Cmp(keyed[j].key) < 0
+	})
+
+	entries := make([]RDFEntry, len(keyed))
+	for i := range keyed {
+		entries[i] = keyed[i].entry
+	}
+	return entries
+}
+
+// RecomputeRoot builds the in-memory merkle tree of the given depth from
+// entries and returns its root. It does not need the original document, so
+// it may be used to validate the integrity of stored entries (e.g. decoded
+// from JSON or binary encoding) against the known root. Key and value merkle
+// tree entries are calculated with the hasher; if hasher is nil, the default
+// one is used. Merklizer uses depth 40.
+func RecomputeRoot(ctx context.Context, entries []RDFEntry, hasher Hasher,
+	depth int) (*merkletree.Hash, error) {
+
+	if depth <= 0 {
+		return nil, fmt.Errorf("invalid merkle tree depth: %v", depth)
+	}
+	if hasher == nil {
+		hasher = defaultHasher
+	}
+
+	mt, err := merkletree.NewMerkleTree(ctx, memory.NewMemoryStorage(), depth)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range entries {
+		e.hasher = hasher
+		e.key.hasher = hasher
+
+		key, val, err := e.KeyValueMtEntries()
+		if err != nil {
+			return nil, fmt.Errorf("entry %v: %w", i, err)
+		}
+
+		err = mt.Add(ctx, key, val)
+		if errors.Is(err, merkletree.ErrEntryIndexAlreadyExists) {
+			return nil, fmt.Errorf("entry %v: %w", i,
+				ErrorDuplicateEntryPath)
+		} else if err != nil {
+			return nil, fmt.Errorf("entry %v: %w", i, err)
+		}
+	}
+
+	return mt.Root(), nil
+}
@@ -0,0 +1,57 @@
+package merklize
+
+// WithExcludedPaths drops entries of the paths and their subtrees from the
+// merkle tree, e.g. to get roots independent of mutable operational metadata
+// like credentialStatus or refreshService. The entry is dropped if its path
+// starts with any of the excluded paths: all parts of the excluded path,
+// including array indexes, should match the first parts of the entry path.
+//
+// Entries are excluded after JSON-LD processing, so the resulting tree does
+// not depend on how the excluded properties are written in the document.
+// The source document keeps excluded properties: RawValue returns their
+// values, but Proof returns proofs of non-existence and Entry returns
+// ErrorEntryNotFound. The limit of entries set with WithUntrustedLimits
+// counts excluded entries too. Both the issuer and the verifier should
+// exclude the same paths to get the same root.
+func WithExcludedPaths(paths ...Path) MerklizeOption {
+	return func(m *Merklizer) {
+		m.excludedPaths = append(m.excludedPaths, paths...)
+	}
+}
+
+// excludeEntries returns entries which paths do not start with any of the
+// excluded paths
+func excludeEntries(entries []RDFEntry, excludedPaths []Path) []RDFEntry {
+	if len(excludedPaths) == 0 {
+		return entries
+	}
+
+	filtered := make([]RDFEntry, 0, len(entries))
+	for _, e := range entries {
+		if !pathExcluded(e.key, excludedPaths) {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+func pathExcluded(p Path, excludedPaths []Path) bool {
+	for _, excluded := range excludedPaths {
+		if pathHasPrefix(p, excluded) {
+			return true
+		}
+	}
+	return false
+}
+
+func pathHasPrefix(p, prefix Path) bool {
+	if len(prefix.parts) == 0 || len(prefix.parts) > len(p.parts) {
+		return false
+	}
+	for i := range prefix.parts {
+		if p.parts[i] != prefix.parts[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,73 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+)
+
+// TypedValue is the raw value of the field with its XSD datatype, e.g. the
+// value from the JSON document and the datatype from the JSON-LD context.
+// It is hashed the same way as the value is hashed on merklization.
+type TypedValue struct {
+	Datatype string
+	Value    any
+}
+
+// VerifyFieldProof checks that the proof is the proof of existence of the
+// field with the path and the value in the merkle tree with the root.
+//
+// The value may be:
+//   - Value, e.g. returned by Merklizer.Proof;
+//   - TypedValue with the raw value and its datatype;
+//   - int64, int, bool, string, time.Time or *big.Int, hashed as is, as
+//     RDFEntry values are hashed.
+//
+// If hasher is nil, the default hasher is used. The path and the value are
+// hashed with the hasher regardless of the hashers they are created with.
+func VerifyFieldProof(root *merkletree.Hash, proof *merkletree.Proof,
+	path Path, value any, hasher Hasher) (bool, error) {
+
+	if root == nil || proof == nil {
+		return false, errors.New("root and proof are required")
+	}
+	if !proof.Existence {
+		return false, nil
+	}
+	if hasher == nil {
+		hasher = defaultHasher
+	}
+
+	path.hasher = hasher
+	keyMtEntry, err := path.MtEntry()
+	if err != nil {
+		return false, fmt.Errorf("failed to hash path: %w", err)
+	}
+
+	valueMtEntry, err := fieldValueMtEntry(hasher, value)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash value: %w", err)
+	}
+
+	return merkletree.VerifyProof(root, proof, keyMtEntry, valueMtEntry), nil
+}
+
+func fieldValueMtEntry(h Hasher, v any) (*big.Int, error) {
+	switch tv := v.(type) {
+	case TypedValue:
+		return valueToHash(h, nil, tv.Datatype, tv.Value)
+	case *TypedValue:
+		return valueToHash(h, nil, tv.Datatype, tv.Value)
+	case *value:
+		return mkValueMtEntry(h, tv.value)
+	case Value:
+		return tv.MtEntry()
+	case int64, int, bool, string, time.Time, *big.Int:
+		return mkValueMtEntry(h, v)
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrIncorrectType, v)
+	}
+}
@@ -0,0 +1,76 @@
+package merklize
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// Fixture is a canonical description of the merklization result, used to
+// compare other implementations against this one. Entries are ordered as
+// returned by Merklizer.Entries, numbers are encoded as decimal strings.
+type Fixture struct {
+	Root      string         `json:"root"`
+	Entries   []FixtureEntry `json:"entries"`
+	Compacted map[string]any `json:"compacted"`
+}
+
+// FixtureEntry describes the single merklized entry
+type FixtureEntry struct {
+	Path      []any  `json:"path"`
+	PathHash  string `json:"pathHash"`
+	ValueHash string `json:"valueHash"`
+	Datatype  string `json:"datatype,omitempty"`
+}
+
+// Fixture returns the fixture of the merklized document
+func (mz *Merklizer) Fixture() (*Fixture, error) {
+	entries := mz.Entries()
+	f := &Fixture{
+		Root:      mz.Root().BigInt().String(),
+		Entries:   make([]FixtureEntry, len(entries)),
+		Compacted: mz.compacted,
+	}
+
+	for i, e := range entries {
+		key, value, err := e.KeyValueMtEntries()
+		if err != nil {
+			return nil, err
+		}
+		path := e.key.parts
+		if path == nil {
+			path = []any{}
+		}
+		f.Entries[i] = FixtureEntry{
+			Path:      path,
+			PathHash:  key.String(),
+			ValueHash: value.String(),
+			Datatype:  e.datatype,
+		}
+	}
+
+	return f, nil
+}
+
+// GenerateFixture merklizes the document and returns its fixture encoded
+// as indented JSON. The output is stable for the same document and options,
+// so it may be stored as a golden file in tests of dependent projects.
+func GenerateFixture(ctx context.Context, in io.Reader,
+	opts ...MerklizeOption) ([]byte, error) {
+
+	mz, err := MerklizeJSONLD(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := mz.Fixture()
+	if err != nil {
+		return nil, err
+	}
+
+	fixtureBytes, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(fixtureBytes, '\n'), nil
+}
@@ -0,0 +1,214 @@
+package merklize
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// ErrorHasherNonConformant is returned by ConformanceTest when the hasher
+// lacks a property required by the merklizer
+var ErrorHasherNonConformant = errors.New("hasher is not conformant")
+
+// HasherVector is the known result of the hasher. Either Inputs for Hash or
+// Msg for HashBytes is set.
+type HasherVector struct {
+	Inputs []*big.Int
+	Msg    []byte
+	Want   *big.Int
+}
+
+// PoseidonVectors are known results of PoseidonHasher. Hashers wrapping
+// Poseidon (e.g. caching or instrumented ones) should pass them to be
+// compatible with roots verified by circuits.
+var PoseidonVectors = []HasherVector{
+	{
+		Inputs: []*big.Int{big.NewInt(1)},
+		Want: mustBigInt(
+			"18586133768512220936620570745912940619677854269274689475585506675881198879027"),
+	},
+	{
+		Inputs: []*big.Int{big.NewInt(1), big.NewInt(2)},
+		Want: mustBigInt(
+			"7853200120776062878684798364095072458815029376092732009249414926327459813530"),
+	},
+	{
+		Inputs: []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)},
+		Want: mustBigInt(
+			"6542985608222806190361240322586112750744169038454362455181422643027100751666"),
+	},
+	{
+		Msg: []byte("abc"),
+		Want: mustBigInt(
+			"455780574318648527863663256724909024656761775419289715658012790702198762987"),
+	},
+	{
+		Msg: []byte("https://www.w3.org/2018/credentials#credentialSubject"),
+		Want: mustBigInt(
+			"18532097674919014048008069202084032997989380657376613392499963846034459854090"),
+	},
+}
+
+// ConformanceTest checks the properties of the hasher required to build
+// merkle trees of documents: the prime is positive and is not shared with
+// the caller, both Hash and HashBytes are deterministic, don't modify their
+// inputs, return values in the field and distinguish the order of inputs.
+// The results of the hasher are checked against vectors, PoseidonVectors
+// are used for PoseidonHasher if none are given. Custom hashers should pass
+// the test before producing roots.
+func ConformanceTest(h Hasher, vectors ...HasherVector) error {
+	if h == nil {
+		return fmt.Errorf("%w: hasher is nil", ErrorHasherNonConformant)
+	}
+	if len(vectors) == 0 {
+		switch h.(type) {
+		case PoseidonHasher, *PoseidonHasher:
+			vectors = PoseidonVectors
+		}
+	}
+
+	checks := []func(Hasher) error{
+		checkHasherPrime,
+		checkHasherHash,
+		checkHasherHashBytes,
+	}
+	for _, check := range checks {
+		if err := check(h); err != nil {
+			return fmt.Errorf("%w: %v", ErrorHasherNonConformant, err)
+		}
+	}
+
+	for i, v := range vectors {
+		var got *big.Int
+		var err error
+		if v.Msg != nil {
+			got, err = h.HashBytes(v.Msg)
+		} else {
+			got, err = h.Hash(v.Inputs)
+		}
+		if err != nil {
+			return fmt.Errorf("%w: vector %v: %v", ErrorHasherNonConformant,
+				i, err)
+		}
+		if got == nil || got.Cmp(v.Want) != 0 {
+			return fmt.Errorf("%w: vector %v: got %v, want %v",
+				ErrorHasherNonConformant, i, got, v.Want)
+		}
+	}
+	return nil
+}
+
+func checkHasherPrime(h Hasher) error {
+	prime := h.Prime()
+	if prime == nil || prime.Cmp(big.NewInt(1)) <= 0 {
+		return fmt.Errorf("prime %v is not greater than 1", prime)
+	}
+	want := new(big.Int).Set(prime)
+	prime.Add(prime, big.NewInt(1))
+	if h.Prime().Cmp(want) != 0 {
+		return errors.New("prime is changed by the caller")
+	}
+	return nil
+}
+
+func checkHasherHash(h Hasher) error {
+	prime := h.Prime()
+	inputs := [][]*big.Int{
+		{big.NewInt(1)},
+		{big.NewInt(1), big.NewInt(2)},
+		{big.NewInt(2), big.NewInt(1)},
+		{big.NewInt(1), big.NewInt(2), big.NewInt(3)},
+		{new(big.Int).Sub(prime, big.NewInt(1)), big.NewInt(0)},
+	}
+
+	seen := make(map[string]int, len(inputs))
+	for i, in := range inputs {
+		inCopy := make([]*big.Int, len(in))
+		for j := range in {
+			inCopy[j] = new(big.Int).Set(in[j])
+		}
+
+		res, err := hashInField(prime, func() (*big.Int, error) {
+			return h.Hash(in)
+		})
+		if err != nil {
+			return fmt.Errorf("Hash of %v: %w", inCopy, err)
+		}
+		for j := range in {
+			if in[j].Cmp(inCopy[j]) != 0 {
+				return fmt.Errorf("Hash modifies inputs %v", inCopy)
+			}
+		}
+		if prev, ok := seen[res.String()]; ok {
+			return fmt.Errorf("Hash of %v and %v are equal", inputs[prev],
+				inCopy)
+		}
+		seen[res.String()] = i
+	}
+	return nil
+}
+
+func checkHasherHashBytes(h Hasher) error {
+	prime := h.Prime()
+	msgs := [][]byte{
+		[]byte("a"),
+		[]byte("ab"),
+		[]byte("ba"),
+		bytes.Repeat([]byte{0xff}, 100),
+	}
+
+	seen := make(map[string]int, len(msgs))
+	for i, msg := range msgs {
+		msgCopy := append([]byte(nil), msg...)
+		res, err := hashInField(prime, func() (*big.Int, error) {
+			return h.HashBytes(msg)
+		})
+		if err != nil {
+			return fmt.Errorf("HashBytes of %x: %w", msgCopy, err)
+		}
+		if !bytes.Equal(msg, msgCopy) {
+			return fmt.Errorf("HashBytes modifies the message %x", msgCopy)
+		}
+		if prev, ok := seen[res.String()]; ok {
+			return fmt.Errorf("HashBytes of %x and %x are equal", msgs[prev],
+				msgCopy)
+		}
+		seen[res.String()] = i
+	}
+	return nil
+}
+
+// hashInField calls hash twice and checks that results are equal and are
+// in the field
+func hashInField(prime *big.Int, hash func() (*big.Int, error)) (*big.Int,
+	error) {
+
+	res, err := hash()
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, errors.New("result is nil")
+	}
+	if res.Sign() < 0 || res.Cmp(prime) >= 0 {
+		return nil, fmt.Errorf("result %v is out of the field", res)
+	}
+	res2, err := hash()
+	if err != nil {
+		return nil, err
+	}
+	if res2 == nil || res.Cmp(res2) != 0 {
+		return nil, fmt.Errorf("result is not deterministic: %v and %v",
+			res, res2)
+	}
+	return res, nil
+}
+
+func mustBigInt(s string) *big.Int {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid big int: " + s)
+	}
+	return i
+}
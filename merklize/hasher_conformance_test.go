@@ -0,0 +1,102 @@
+package merklize
+
+import (
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// counterHasher returns different results on every call
+type counterHasher struct {
+	md5Hasher
+	n int64
+}
+
+func (h *counterHasher) Hash(inpBI []*big.Int) (*big.Int, error) {
+	return big.NewInt(atomic.AddInt64(&h.n, 1)), nil
+}
+
+// sharedPrimeHasher returns its prime instead of a copy
+type sharedPrimeHasher struct {
+	PoseidonHasher
+	prime *big.Int
+}
+
+func (h sharedPrimeHasher) Prime() *big.Int {
+	return h.prime
+}
+
+// smallPrimeHasher has the field smaller than results of Poseidon
+type smallPrimeHasher struct {
+	PoseidonHasher
+}
+
+func (h smallPrimeHasher) Prime() *big.Int {
+	return big.NewInt(7)
+}
+
+// unorderedHasher ignores the order of inputs
+type unorderedHasher struct {
+	PoseidonHasher
+}
+
+func (h unorderedHasher) Hash(inpBI []*big.Int) (*big.Int, error) {
+	sum := new(big.Int)
+	for _, i := range inpBI {
+		sum.Add(sum, i)
+	}
+	return sum.Mod(sum, h.Prime()), nil
+}
+
+func TestConformanceTest(t *testing.T) {
+	require.NoError(t, ConformanceTest(PoseidonHasher{}))
+	require.NoError(t, ConformanceTest(&PoseidonHasher{}, PoseidonVectors...))
+	require.NoError(t, ConformanceTest(&md5Hasher{}))
+
+	testCases := []struct {
+		name    string
+		hasher  Hasher
+		vectors []HasherVector
+		wantErr string
+	}{
+		{
+			name:    "nil hasher",
+			wantErr: "hasher is not conformant: hasher is nil",
+		},
+		{
+			name:    "not deterministic",
+			hasher:  &counterHasher{},
+			wantErr: "hasher is not conformant: Hash of [1]: result is not deterministic: 1 and 2",
+		},
+		{
+			name:    "shared prime",
+			hasher:  sharedPrimeHasher{prime: big.NewInt(1 << 40)},
+			wantErr: "hasher is not conformant: prime is changed by the caller",
+		},
+		{
+			name:    "out of field",
+			hasher:  smallPrimeHasher{},
+			wantErr: "hasher is not conformant: Hash of [1]: result 18586133768512220936620570745912940619677854269274689475585506675881198879027 is out of the field",
+		},
+		{
+			name:    "order of inputs",
+			hasher:  unorderedHasher{},
+			wantErr: "hasher is not conformant: Hash of [1 2] and [2 1] are equal",
+		},
+		{
+			name:    "vectors of other hasher",
+			hasher:  &md5Hasher{},
+			vectors: PoseidonVectors,
+			wantErr: "hasher is not conformant: vector 0: got 113842407384990359002707962975597223745, want 18586133768512220936620570745912940619677854269274689475585506675881198879027",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ConformanceTest(tc.hasher, tc.vectors...)
+			require.ErrorIs(t, err, ErrorHasherNonConformant)
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
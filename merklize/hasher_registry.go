@@ -0,0 +1,120 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// HasherIDPoseidonBN254 is the ID of PoseidonHasher
+const HasherIDPoseidonBN254 = "poseidon-bn254"
+
+// ErrorHasherMismatch is returned when the serialized Merklizer or RDFEntry
+// was created with a hasher other than the configured one
+var ErrorHasherMismatch = errors.New("hasher mismatch")
+
+// IdentifiedHasher is a Hasher with the ID recorded in serialized Merklizer
+// and RDFEntry
+type IdentifiedHasher interface {
+	Hasher
+	HasherID() string
+}
+
+var (
+	hasherRegistryM sync.RWMutex
+	hasherRegistry  = map[string]Hasher{
+		HasherIDPoseidonBN254: PoseidonHasher{},
+	}
+)
+
+// HasherID returns the ID of the PoseidonHasher
+func (p PoseidonHasher) HasherID() string {
+	return HasherIDPoseidonBN254
+}
+
+// RegisterHasher registers the hasher with the id, so Merklizer and RDFEntry
+// serialized with this hasher are deserialized with it. Replaces the hasher
+// registered with the same id before.
+func RegisterHasher(id string, h Hasher) {
+	if id == "" {
+		panic("hasher id is empty")
+	}
+	if h == nil {
+		panic("hasher is nil")
+	}
+	hasherRegistryM.Lock()
+	defer hasherRegistryM.Unlock()
+	hasherRegistry[id] = h
+}
+
+// HasherByID returns the hasher registered with the id
+func HasherByID(id string) (Hasher, error) {
+	hasherRegistryM.RLock()
+	defer hasherRegistryM.RUnlock()
+	h, ok := hasherRegistry[id]
+	if !ok {
+		return nil, fmt.Errorf("hasher %q is not registered", id)
+	}
+	return h, nil
+}
+
+// HasherIDOf returns the ID of the hasher: the HasherID of IdentifiedHasher
+// or the id the hasher is registered with. Returns an empty string for
+// unknown hashers.
+func HasherIDOf(h Hasher) string {
+	if h == nil {
+		return ""
+	}
+	if ih, ok := h.(IdentifiedHasher); ok {
+		return ih.HasherID()
+	}
+	if !reflect.TypeOf(h).Comparable() {
+		return ""
+	}
+
+	hasherRegistryM.RLock()
+	defer hasherRegistryM.RUnlock()
+	for id, rh := range hasherRegistry {
+		if rh == h {
+			return id
+		}
+	}
+	return ""
+}
+
+// resolveSerializedHasher returns the hasher to deserialize the data
+// encoded with hasherID. If h is not nil, it is checked to match hasherID
+// unless override is true. Without h, the hasher registered with hasherID is
+// used, or the default one for data without hasher ID. The ID of the hasher
+// with the negative integer encoding (see HasherWithNegativeIntegerEncoding)
+// is resolved to the registered hasher wrapped with the encoding.
+func resolveSerializedHasher(h Hasher, hasherID string,
+	override bool) (Hasher, error) {
+
+	switch {
+	case h != nil && (override || hasherID == ""):
+		return h, nil
+	case h != nil:
+		if id := HasherIDOf(h); id != hasherID {
+			return nil, fmt.Errorf("%w: data is hashed with %q, configured "+
+				"hasher is %q", ErrorHasherMismatch, hasherID, id)
+		}
+		return h, nil
+	case hasherID == "":
+		return defaultHasher, nil
+	default:
+		if h, err := HasherByID(hasherID); err == nil {
+			return h, nil
+		}
+		baseID, encoding := splitHasherIDEncoding(hasherID)
+		if encoding == NegativeIntegerFieldComplement {
+			return HasherByID(hasherID)
+		}
+		h, err := HasherByID(baseID)
+		if err != nil {
+			return nil, err
+		}
+		return HasherWithNegativeIntegerEncoding(h, encoding), nil
+	}
+}
@@ -0,0 +1,218 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// NegativeIntegerEncoding defines how signed integers are mapped to field
+// elements. Non-negative integers are mapped as is in all encodings.
+type NegativeIntegerEncoding uint8
+
+const (
+	// NegativeIntegerFieldComplement maps negative integer v to prime + v.
+	// Integers in range [-(prime-1)/2, prime/2] are supported. This is the
+	// default encoding.
+	NegativeIntegerFieldComplement NegativeIntegerEncoding = iota
+	// NegativeIntegerTwosComplement maps negative integer v to 2^n + v where
+	// n is the bit length of the prime minus one (253 for the Poseidon
+	// hasher). Integers in range [-2^(n-1), 2^(n-1)-1] are supported.
+	NegativeIntegerTwosComplement
+	// NegativeIntegerSignMagnitude maps integer v to |v| with the sign bit
+	// 2^(n-1) set for negative values, where n is the bit length of the prime
+	// minus one. Integers in range [-(2^(n-1)-1), 2^(n-1)-1] are supported.
+	NegativeIntegerSignMagnitude
+)
+
+// ErrorIntegerOutOfRange is returned when the integer can't be represented
+// with the selected NegativeIntegerEncoding
+var ErrorIntegerOutOfRange = errors.New("integer is out of range")
+
+func (e NegativeIntegerEncoding) String() string {
+	switch e {
+	case NegativeIntegerFieldComplement:
+		return "field-complement"
+	case NegativeIntegerTwosComplement:
+		return "twos-complement"
+	case NegativeIntegerSignMagnitude:
+		return "sign-magnitude"
+	default:
+		return fmt.Sprintf("NegativeIntegerEncoding(%d)", uint8(e))
+	}
+}
+
+// Encode maps the signed integer to the field element of the prime field.
+func (e NegativeIntegerEncoding) Encode(prime, v *big.Int) (*big.Int,
+	error) {
+
+	switch e {
+	case NegativeIntegerFieldComplement:
+		minVal, maxVal := minMaxFromPrime(prime)
+		if v.Cmp(maxVal) > 0 || v.Cmp(minVal) < 0 {
+			return nil, fmt.Errorf("%w: %v", ErrorIntegerOutOfRange, v)
+		}
+		if v.Sign() < 0 {
+			return new(big.Int).Add(prime, v), nil
+		}
+		return new(big.Int).Set(v), nil
+
+	case NegativeIntegerTwosComplement:
+		n := uint(prime.BitLen() - 1)
+		half := new(big.Int).Lsh(big.NewInt(1), n-1)
+		if v.Cmp(half) >= 0 || v.Cmp(new(big.Int).Neg(half)) < 0 {
+			return nil, fmt.Errorf("%w: %v", ErrorIntegerOutOfRange, v)
+		}
+		if v.Sign() < 0 {
+			return new(big.Int).Add(new(big.Int).Lsh(half, 1), v), nil
+		}
+		return new(big.Int).Set(v), nil
+
+	case NegativeIntegerSignMagnitude:
+		signBit := new(big.Int).Lsh(big.NewInt(1), uint(prime.BitLen()-2))
+		abs := new(big.Int).Abs(v)
+		if abs.Cmp(signBit) >= 0 {
+			return nil, fmt.Errorf("%w: %v", ErrorIntegerOutOfRange, v)
+		}
+		if v.Sign() < 0 {
+			return abs.Or(abs, signBit), nil
+		}
+		return abs, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported negative integer encoding: %v", e)
+	}
+}
+
+// Decode maps the field element back to the signed integer. It is the
+// inverse of Encode.
+func (e NegativeIntegerEncoding) Decode(prime, x *big.Int) (*big.Int,
+	error) {
+
+	if x.Sign() < 0 || x.Cmp(prime) >= 0 {
+		return nil, fmt.Errorf("%w: %v", ErrorValueOutOfField, x)
+	}
+
+	switch e {
+	case NegativeIntegerFieldComplement:
+		_, maxVal := minMaxFromPrime(prime)
+		if x.Cmp(maxVal) > 0 {
+			return new(big.Int).Sub(x, prime), nil
+		}
+		return new(big.Int).Set(x), nil
+
+	case NegativeIntegerTwosComplement:
+		n := uint(prime.BitLen() - 1)
+		limit := new(big.Int).Lsh(big.NewInt(1), n)
+		if x.Cmp(limit) >= 0 {
+			return nil, fmt.Errorf("%w: %v", ErrorIntegerOutOfRange, x)
+		}
+		if x.Bit(int(n-1)) == 1 {
+			return new(big.Int).Sub(x, limit), nil
+		}
+		return new(big.Int).Set(x), nil
+
+	case NegativeIntegerSignMagnitude:
+		signBitIdx := prime.BitLen() - 2
+		if x.BitLen() > signBitIdx+1 {
+			return nil, fmt.Errorf("%w: %v", ErrorIntegerOutOfRange, x)
+		}
+		if x.Bit(signBitIdx) == 1 {
+			abs := new(big.Int).SetBit(new(big.Int).Set(x), signBitIdx, 0)
+			return abs.Neg(abs), nil
+		}
+		return new(big.Int).Set(x), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported negative integer encoding: %v", e)
+	}
+}
+
+// DecodeIntegerValue returns the signed integer from the merkle tree value
+// entry using the negative integer encoding of the hasher.
+func DecodeIntegerValue(h Hasher, valueEntry *big.Int) (*big.Int, error) {
+	if h == nil {
+		h = defaultHasher
+	}
+	return negativeIntegerEncodingOf(h).Decode(h.Prime(), valueEntry)
+}
+
+// NegativeIntegerEncoder may be implemented by Hasher to change the mapping
+// of negative integers. Hashers that do not implement it use
+// NegativeIntegerFieldComplement.
+type NegativeIntegerEncoder interface {
+	NegativeIntegerEncoding() NegativeIntegerEncoding
+}
+
+type integerEncodingHasher struct {
+	Hasher
+	encoding NegativeIntegerEncoding
+}
+
+func (h integerEncodingHasher) NegativeIntegerEncoding() NegativeIntegerEncoding {
+	return h.encoding
+}
+
+// HasherID returns the ID of the wrapped hasher with the encoding suffix,
+// e.g. poseidon-bn254+twos-complement, so the encoding is recorded in
+// serialized Merklizer and RDFEntry. Returns an empty string if the wrapped
+// hasher is unknown.
+func (h integerEncodingHasher) HasherID() string {
+	id := HasherIDOf(h.Hasher)
+	if id == "" {
+		return ""
+	}
+	return id + hasherIDEncodingSep + h.encoding.String()
+}
+
+const hasherIDEncodingSep = "+"
+
+// splitHasherIDEncoding splits the hasher ID recorded by
+// integerEncodingHasher into the ID of the wrapped hasher and the negative
+// integer encoding. IDs without the encoding suffix are returned as is with
+// NegativeIntegerFieldComplement.
+func splitHasherIDEncoding(id string) (string, NegativeIntegerEncoding) {
+	for _, e := range []NegativeIntegerEncoding{NegativeIntegerTwosComplement,
+		NegativeIntegerSignMagnitude} {
+
+		if strings.HasSuffix(id, hasherIDEncodingSep+e.String()) {
+			return strings.TrimSuffix(id, hasherIDEncodingSep+e.String()), e
+		}
+	}
+	return id, NegativeIntegerFieldComplement
+}
+
+// HasherWithNegativeIntegerEncoding returns the hasher that hashes values
+// with h and maps negative integers with the given encoding
+func HasherWithNegativeIntegerEncoding(h Hasher,
+	encoding NegativeIntegerEncoding) Hasher {
+
+	if h == nil {
+		h = defaultHasher
+	}
+	if ih, ok := h.(integerEncodingHasher); ok {
+		h = ih.Hasher
+	}
+	if encoding == NegativeIntegerFieldComplement {
+		return h
+	}
+	return integerEncodingHasher{Hasher: h, encoding: encoding}
+}
+
+// WithNegativeIntegerEncoding sets the mapping of negative integers. It is
+// applied to the hasher set with WithHasher option or to the default one.
+func WithNegativeIntegerEncoding(
+	encoding NegativeIntegerEncoding) MerklizeOption {
+
+	return func(m *Merklizer) {
+		m.intEncoding = &encoding
+	}
+}
+
+func negativeIntegerEncodingOf(h Hasher) NegativeIntegerEncoding {
+	if e, ok := h.(NegativeIntegerEncoder); ok {
+		return e.NegativeIntegerEncoding()
+	}
+	return NegativeIntegerFieldComplement
+}
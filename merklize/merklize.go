@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/iden3/go-iden3-crypto/constants"
@@ -36,6 +37,9 @@ var (
 	ErrorUnsupportedType = errors.New("unsupported type")
 	// ErrorEntryNotFound is returned when entry not found in merklized document
 	ErrorEntryNotFound = errors.New("entry not found")
+	// ErrorNoSourceDocument is returned when the source document was not
+	// stored in Merklizer (see WithoutSourceDocument option)
+	ErrorNoSourceDocument = errors.New("source document is not available")
 )
 
 // SetHasher changes default hasher
@@ -56,13 +60,31 @@ func SetDocumentLoader(docLoader ld.DocumentLoader) {
 type Options struct {
 	Hasher         Hasher
 	DocumentLoader ld.DocumentLoader
+	// NegativeIntegerEncoding changes the mapping of negative integers to
+	// field elements. By default, the encoding of the Hasher is used
+	// (NegativeIntegerFieldComplement for hashers that do not implement
+	// NegativeIntegerEncoder).
+	NegativeIntegerEncoding NegativeIntegerEncoding
+	// StrictDatatypes makes EntriesFromRDF fail on literals that do not
+	// conform to their XSD datatypes
+	StrictDatatypes bool
+	// DoubleCanonicalizer formats xsd:double values before hashing. If nil,
+	// DefaultDoubleCanonicalizer is used.
+	DoubleCanonicalizer DoubleCanonicalizer
+	// TimeNormalization is the normalization of xsd:dateTime values of
+	// entries
+	TimeNormalization TimeNormalization
 }
 
 func (o Options) getHasher() Hasher {
-	if o.Hasher != nil {
-		return o.Hasher
+	h := o.Hasher
+	if h == nil {
+		h = defaultHasher
 	}
-	return defaultHasher
+	if o.NegativeIntegerEncoding != NegativeIntegerFieldComplement {
+		h = HasherWithNegativeIntegerEncoding(h, o.NegativeIntegerEncoding)
+	}
+	return h
 }
 
 func (o Options) getDocumentLoader() ld.DocumentLoader {
@@ -123,8 +145,14 @@ func (o Options) NewRDFEntry(key Path, value interface{}) (RDFEntry, error) {
 	switch v := value.(type) {
 	case int:
 		e.value = int64(v)
-	case int64, string, bool, time.Time:
+	case int64, string, bool:
 		e.value = value
+	case time.Time:
+		tm, err := o.TimeNormalization.normalizeValue(v)
+		if err != nil {
+			return e, err
+		}
+		e.value = tm
 	case *big.Int:
 		e.value = new(big.Int).Set(v)
 	default:
@@ -135,10 +163,12 @@ func (o Options) NewRDFEntry(key Path, value interface{}) (RDFEntry, error) {
 }
 
 func (o Options) NewPathFromDocument(docBytes []byte,
-	path string) (Path, error) {
+	path string) (_ Path, err error) {
+
+	defer recoverPanic(&err)
 
 	var docObj map[string]interface{}
-	err := json.Unmarshal(docBytes, &docObj)
+	err = json.Unmarshal(docBytes, &docObj)
 	if err != nil {
 		return Path{}, err
 	}
@@ -279,10 +309,12 @@ func TypeFromContext(ctxBytes []byte, path string) (string, error) {
 }
 
 func (p *Path) pathFromContext(ctxBytes []byte, path string,
-	jsonLdOptions *ld.JsonLdOptions) error {
+	jsonLdOptions *ld.JsonLdOptions) (err error) {
+
+	defer recoverPanic(&err)
 
 	var ctxObj map[string]interface{}
-	err := json.Unmarshal(ctxBytes, &ctxObj)
+	err = json.Unmarshal(ctxBytes, &ctxObj)
 	if err != nil {
 		return err
 	}
@@ -468,7 +500,9 @@ func (p *Path) MtEntry() (*big.Int, error) {
 		h = defaultHasher
 	}
 
-	intKeyParts := make([]*big.Int, len(p.parts))
+	intKeyPartsP := getBigIntSlice(len(p.parts))
+	defer putBigIntSlice(intKeyPartsP)
+	intKeyParts := *intKeyPartsP
 	for i := range p.parts {
 		switch v := p.parts[i].(type) {
 		case string:
@@ -477,19 +511,21 @@ func (p *Path) MtEntry() (*big.Int, error) {
 				return nil, err
 			}
 		case int:
-			intKeyParts[i] = big.NewInt(int64(v))
+			x := getBigInt()
+			defer putBigInt(x)
+			intKeyParts[i] = x.SetInt64(int64(v))
 		default:
 			return nil, fmt.Errorf("unexpected type %T", v)
 		}
 	}
 
-	return h.Hash(intKeyParts)
+	return hashScratch(h, intKeyParts)
 }
 
 func (p *Path) Append(parts ...interface{}) error {
 	for i := range parts {
 		switch parts[i].(type) {
-		case string, int:
+		case string, int, ArraySlice:
 		default:
 			return fmt.Errorf("incorrect part type: %T", parts)
 		}
@@ -503,7 +539,7 @@ func (p *Path) Append(parts ...interface{}) error {
 func (p *Path) Prepend(parts ...interface{}) error {
 	for i := range parts {
 		switch parts[i].(type) {
-		case string, int:
+		case string, int, ArraySlice:
 		default:
 			return fmt.Errorf("incorrect part type: %T", parts)
 		}
@@ -518,6 +554,10 @@ type Value interface {
 
 	IsTime() bool
 	AsTime() (time.Time, error)
+	// AsCanonicalTime returns the instant of the time value in UTC. The
+	// number of nanoseconds since the Unix epoch of the instant is hashed
+	// into the merkle tree.
+	AsCanonicalTime() (time.Time, error)
 
 	IsString() bool
 	AsString() (string, error)
@@ -530,6 +570,11 @@ type Value interface {
 
 	IsBool() bool
 	AsBool() (bool, error)
+
+	// Format returns the value formatted for display in the locale, e.g.
+	// "en-US". unitHints tell how to interpret integers (UnitHintDate,
+	// UnitHintUnixTime) or name the unit of numbers, e.g. "kg".
+	Format(locale string, unitHints ...UnitHint) (string, error)
 }
 
 var ErrIncorrectType = errors.New("incorrect type")
@@ -538,6 +583,10 @@ type value struct {
 	// valid types are: int64, string, bool, time.Time, *big.Int
 	value  any
 	hasher Hasher
+	// datatype and lexical form of the literal, if the value is of the
+	// merklized entry
+	datatype string
+	lexical  string
 }
 
 // NewValue creates new Value
@@ -570,6 +619,16 @@ func (v *value) AsTime() (time.Time, error) {
 	return tm, nil
 }
 
+// AsCanonicalTime returns the time value in UTC or error if value is not
+// Time.
+func (v *value) AsCanonicalTime() (time.Time, error) {
+	tm, err := v.AsTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tm.UTC(), nil
+}
+
 // IsString returns true is value is of type string
 func (v *value) IsString() bool {
 	_, ok := v.value.(string)
@@ -911,7 +970,7 @@ func getQuad(ds *ld.RDFDataset, idx datasetIdx) (*ld.Quad, error) {
 func (r *relationship) path(dsIdx datasetIdx, ds *ld.RDFDataset,
 	idx *int) (Path, error) {
 
-	var k = Path{hasher: r.hasher}
+	var k = Path{hasher: r.hasher, parts: make([]interface{}, 0, 8)}
 
 	if idx != nil {
 		err := k.Append(*idx)
@@ -1010,7 +1069,16 @@ func getIriValue(n ld.Node) (*ld.IRI, error) {
 var dateRE = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
 
 // EntriesFromRDF creates entries from RDF dataset suitable to add to
-// merkle tree
+// merkle tree.
+//
+// Values of the same property (JSON arrays without @list container, either
+// plain or @set) are RDF sets, so their index in the entry path is the
+// position of the value in the canonically ordered quads of the normalized
+// dataset, not in the source array. Reordering of such arrays does not
+// change the root, but the index of the value in the path is not the index
+// in the source document: other implementations must use the same
+// normalization (URDNA2015) to produce the same paths. @list arrays keep the
+// source order and are merklized as rdf:first / rdf:rest chains.
 func EntriesFromRDF(ds *ld.RDFDataset) ([]RDFEntry, error) {
 	return EntriesFromRDFWithHasher(ds, defaultHasher)
 }
@@ -1020,6 +1088,23 @@ func EntriesFromRDF(ds *ld.RDFDataset) ([]RDFEntry, error) {
 func EntriesFromRDFWithHasher(ds *ld.RDFDataset,
 	hasher Hasher) ([]RDFEntry, error) {
 
+	return entriesFromRDF(ds, hasher, nil, false, false,
+		TimeNormalizationPreserve)
+}
+
+// EntriesFromRDF creates entries from RDF dataset with the hasher of
+// options. If StrictDatatypes is set, all literals are checked to conform to
+// their XSD datatypes and InvalidLiteralsError is returned for invalid ones.
+func (o Options) EntriesFromRDF(ds *ld.RDFDataset) ([]RDFEntry, error) {
+	return entriesFromRDF(ds, o.getHasher(), o.DoubleCanonicalizer, false,
+		o.StrictDatatypes, o.TimeNormalization)
+}
+
+func entriesFromRDF(ds *ld.RDFDataset, hasher Hasher,
+	doubleCanon DoubleCanonicalizer,
+	includeNodeIDs, strictDatatypes bool,
+	timeNorm TimeNormalization) ([]RDFEntry, error) {
+
 	// check graph naming assertions for dataset
 	if err := assertDatasetConsistency(ds); err != nil {
 		return nil, err
@@ -1040,6 +1125,7 @@ func EntriesFromRDFWithHasher(ds *ld.RDFDataset,
 	}
 
 	entries := make([]RDFEntry, 0, len(quads))
+	var invalidLiterals []InvalidLiteral
 	graphProcessor := func(graphName string, quads []*ld.Quad) error {
 		counts, err := countEntries(quads)
 		if err != nil {
@@ -1049,25 +1135,70 @@ func EntriesFromRDFWithHasher(ds *ld.RDFDataset,
 
 		for quadIdx, q := range quads {
 			quadGraphIdx := datasetIdx{graphName, quadIdx}
+			quadErr := func(err error, path Path) error {
+				return &QuadError{Graph: graphName, Index: quadIdx,
+					Quad: q, Path: path, Err: err}
+			}
 			qKey, err := mkQArrKey(q)
 			if err != nil {
-				return err
+				return quadErr(err, Path{})
 			}
+			entryPath := func() (Path, error) {
+				var idx *int
+				switch counts[qKey] {
+				case 0:
+					return Path{}, errors.New(
+						"[assertion] key not found in counts")
+				case 1:
+					// leave idx nil: only one element, do not consider it
+					// as an array
+				default:
+					idx = new(int)
+					*idx = seenCount[qKey]
+					seenCount[qKey]++
+				}
+				return rs.path(quadGraphIdx, ds, idx)
+			}
+			// knownPathErr adds the path of the entry to the error if the
+			// path can be built
+			knownPathErr := func(err error) error {
+				path, _ := entryPath()
+				return quadErr(err, path)
+			}
+
 			var e RDFEntry
+			var literalErr error
+			if negativeIntegerEncodingOf(hasher) !=
+				NegativeIntegerFieldComplement {
+				// keep the hasher to map negative integer values with its
+				// encoding
+				e.hasher = hasher
+			}
 			switch qo := q.Object.(type) {
 			case *ld.Literal:
 				if qo == nil {
-					return errors.New("object Literal is nil")
+					return quadErr(errors.New("object Literal is nil"),
+						Path{})
 				}
-				e.value, err = convertStringToXSDValue(qo.Datatype, qo.Value,
-					hasher.Prime())
-				if err != nil {
-					return err
+				if strictDatatypes {
+					literalErr = validateXSDLexicalForm(qo.Datatype, qo.Value)
+				}
+				if literalErr == nil {
+					e.value, literalErr = convertStringToXSDValue(
+						qo.Datatype, qo.Value, hasher.Prime(), doubleCanon)
+				}
+				if literalErr == nil {
+					e.value, literalErr = timeNorm.normalizeValue(e.value)
+				}
+				if literalErr != nil && !strictDatatypes {
+					return knownPathErr(literalErr)
 				}
 				e.datatype = qo.Datatype
+				e.lexical = qo.Value
+				e.language = qo.Language
 			case *ld.IRI:
 				if qo == nil {
-					return errors.New("object IRI is nil")
+					return quadErr(errors.New("object IRI is nil"), Path{})
 				}
 				e.value = qo.GetValue()
 			case *ld.BlankNode:
@@ -1078,25 +1209,27 @@ func EntriesFromRDFWithHasher(ds *ld.RDFDataset,
 					// no value to put itself.
 					continue
 				}
-				return errors.New("BlankNode is not supported yet")
+				return knownPathErr(
+					errors.New("BlankNode is not supported yet"))
 			default:
-				return errors.New("unexpected Quad's Object type")
+				return quadErr(errors.New("unexpected Quad's Object type"),
+					Path{})
 			}
 
-			var idx *int
-			switch counts[qKey] {
-			case 0:
-				return errors.New("[assertion] key not found in counts")
-			case 1:
-				// leave idx nil: only one element, do not consider it as an array
-			default:
-				idx = new(int)
-				*idx = seenCount[qKey]
-				seenCount[qKey]++
-			}
-			e.key, err = rs.path(quadGraphIdx, ds, idx)
+			e.key, err = entryPath()
 			if err != nil {
-				return err
+				return quadErr(err, Path{})
+			}
+
+			if literalErr != nil {
+				qo := q.Object.(*ld.Literal)
+				invalidLiterals = append(invalidLiterals, InvalidLiteral{
+					Path:     e.key,
+					Value:    qo.Value,
+					Datatype: qo.Datatype,
+					Err:      literalErr,
+				})
+				continue
 			}
 
 			entries = append(entries, e)
@@ -1106,25 +1239,108 @@ func EntriesFromRDFWithHasher(ds *ld.RDFDataset,
 	if err := iterGraphsOrdered(ds, graphProcessor); err != nil {
 		return nil, err
 	}
+	if len(invalidLiterals) != 0 {
+		return nil, &InvalidLiteralsError{Literals: invalidLiterals}
+	}
+
+	if includeNodeIDs {
+		idEntries, err := nodeIDEntries(ds, rs, hasher)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, idEntries...)
+	}
+
 	return entries, nil
 }
 
+// nodeIDEntries returns entries with @id IRIs of all nodes that have
+// properties. The key of the entry is the path of the node followed by
+// "@id", e.g. [https://www.w3.org/2018/credentials#credentialSubject @id] for
+// credentialSubject or [@id] for the top-level node. This is the same path
+// ResolveDocPath returns for "credentialSubject.id" and "id" when "id" is an
+// alias of "@id" (as in https://www.w3.org/2018/credentials/v1 context).
+// Blank nodes are skipped.
+func nodeIDEntries(ds *ld.RDFDataset, rs *relationship,
+	hasher Hasher) ([]RDFEntry, error) {
+
+	var entries []RDFEntry
+	seenNodes := make(map[string]bool)
+	seenPaths := make(map[string]string)
+	processGraph := func(graphName string, quads []*ld.Quad) error {
+		for quadIdx, q := range quads {
+			subject, ok := q.Subject.(*ld.IRI)
+			if !ok || subject == nil {
+				continue
+			}
+			nodeKey := graphName + "\x00" + subject.Value
+			if seenNodes[nodeKey] {
+				continue
+			}
+			seenNodes[nodeKey] = true
+
+			propPath, err := rs.path(datasetIdx{graphName, quadIdx}, ds, nil)
+			if err != nil {
+				return err
+			}
+			// replace the property predicate with @id
+			parts := make([]interface{}, 0, len(propPath.parts))
+			parts = append(parts, propPath.parts[:len(propPath.parts)-1]...)
+			parts = append(parts, "@id")
+			e := RDFEntry{
+				key:    Path{parts: parts, hasher: hasher},
+				value:  subject.Value,
+				hasher: hasher,
+			}
+
+			keyMtEntry, err := e.KeyMtEntry()
+			if err != nil {
+				return err
+			}
+			if prevID, ok := seenPaths[keyMtEntry.String()]; ok &&
+				prevID != subject.Value {
+
+				return fmt.Errorf(
+					"multiple nodes with the same path: %v and %v",
+					prevID, subject.Value)
+			}
+			seenPaths[keyMtEntry.String()] = subject.Value
+
+			entries = append(entries, e)
+		}
+		return nil
+	}
+	err := iterGraphsOrdered(ds, processGraph)
+	return entries, err
+}
+
 // HashValue hashes value according to datatype.
 func HashValue(datatype string, value any) (*big.Int, error) {
-	return valueToHash(defaultHasher, datatype, value)
+	return valueToHash(defaultHasher, nil, datatype, value)
 }
 
 // HashValueWithHasher hashes value according to datatype with a provided Hasher.
 func HashValueWithHasher(h Hasher, datatype string, value any) (*big.Int, error) {
-	return valueToHash(h, datatype, value)
+	return valueToHash(h, nil, datatype, value)
+}
+
+// HashValue hashes value according to datatype with the hasher and the
+// double canonicalizer of the options
+func (o Options) HashValue(datatype string, value any) (*big.Int, error) {
+	return valueToHash(o.getHasher(), o.DoubleCanonicalizer, datatype, value)
 }
 
-func valueToHash(h Hasher, datatype string, value any) (*big.Int, error) {
-	v, err := convertAnyToString(value, datatype)
+func valueToHash(h Hasher, dc DoubleCanonicalizer, datatype string,
+	value any) (*big.Int, error) {
+
+	if dc == nil {
+		dc = DefaultDoubleCanonicalizer
+	}
+	v, err := convertAnyToString(value, datatype, dc)
 	if err != nil {
 		return nil, err
 	}
-	xsdValue, err := convertStringToXSDValue(datatype, v, h.Prime())
+	xsdValue, err := convertStringToXSDValue(datatype, v, h.Prime(), dc)
 	if err != nil {
 		return nil, err
 	}
@@ -1132,7 +1348,9 @@ func valueToHash(h Hasher, datatype string, value any) (*big.Int, error) {
 }
 
 // only supported xsd types.
-func convertAnyToString(value any, datatype string) (str string, err error) {
+func convertAnyToString(value any, datatype string,
+	dc DoubleCanonicalizer) (str string, err error) {
+
 	if datatype == ld.XSDDouble {
 		switch v := value.(type) {
 		case string:
@@ -1140,36 +1358,36 @@ func convertAnyToString(value any, datatype string) (str string, err error) {
 			if err != nil {
 				return "", err
 			}
-			return ld.GetCanonicalDouble(f), nil
+			return dc.CanonicalDouble(f), nil
 		case int:
-			return intToXSDDoubleStr(v)
+			return intToXSDDoubleStr(dc, v)
 		case int8:
-			return intToXSDDoubleStr(v)
+			return intToXSDDoubleStr(dc, v)
 		case int16:
-			return intToXSDDoubleStr(v)
+			return intToXSDDoubleStr(dc, v)
 		case int32:
-			return intToXSDDoubleStr(v)
+			return intToXSDDoubleStr(dc, v)
 		case int64:
-			return intToXSDDoubleStr(v)
+			return intToXSDDoubleStr(dc, v)
 		case uint:
-			return uintToXSDDoubleStr(v)
+			return uintToXSDDoubleStr(dc, v)
 		case uint8:
-			return uintToXSDDoubleStr(v)
+			return uintToXSDDoubleStr(dc, v)
 		case uint16:
-			return uintToXSDDoubleStr(v)
+			return uintToXSDDoubleStr(dc, v)
 		case uint32:
-			return uintToXSDDoubleStr(v)
+			return uintToXSDDoubleStr(dc, v)
 		case uint64:
-			return uintToXSDDoubleStr(v)
+			return uintToXSDDoubleStr(dc, v)
 		}
 	}
 
 	switch v := value.(type) {
 	case float64:
 		// https://www.w3.org/TR/2014/REC-json-ld-api-20140116/#data-round-tripping
-		str = ld.GetCanonicalDouble(v)
+		str = dc.CanonicalDouble(v)
 	case float32:
-		str = ld.GetCanonicalDouble(float64(v))
+		str = dc.CanonicalDouble(float64(v))
 	case string:
 		str = fmt.Sprintf("%v", v)
 	case int64, int32, int16, int8, int, bool:
@@ -1197,8 +1415,10 @@ type allUInts interface {
 // So hashes for 18446744073709551615 and 18446744073709551614 would be
 // the same, which is not correct. That is why we use big.Rat here to check
 // that float can represent integer value without loss of precision.
-func intToXSDDoubleStr[T allInts](v T) (string, error) {
-	out := ld.GetCanonicalDouble(float64(v))
+func intToXSDDoubleStr[T allInts](dc DoubleCanonicalizer,
+	v T) (string, error) {
+
+	out := dc.CanonicalDouble(float64(v))
 
 	r := new(big.Rat)
 	_, ok := r.SetString(out)
@@ -1220,8 +1440,10 @@ func intToXSDDoubleStr[T allInts](v T) (string, error) {
 
 // see comment for intToXSDDoubleStr for explanations why this function
 // uses big.Rat
-func uintToXSDDoubleStr[T allUInts](v T) (string, error) {
-	out := ld.GetCanonicalDouble(float64(v))
+func uintToXSDDoubleStr[T allUInts](dc DoubleCanonicalizer,
+	v T) (string, error) {
+
+	out := dc.CanonicalDouble(float64(v))
 
 	r := new(big.Rat)
 	_, ok := r.SetString(out)
@@ -1278,7 +1500,10 @@ func minMaxByXSDType(xsdType string,
 }
 
 func convertStringToXSDValue(datatype string, value string,
-	maxFieldValue *big.Int) (resultValue interface{}, err error) {
+	maxFieldValue *big.Int,
+	dc DoubleCanonicalizer) (resultValue interface{}, err error) {
+
+	defer recoverPanic(&err)
 
 	switch datatype {
 	case ld.XSDBoolean:
@@ -1297,6 +1522,12 @@ func convertStringToXSDValue(datatype string, value string,
 		ld.XSDNS + "negativeInteger",
 		ld.XSDNS + "nonPositiveInteger":
 
+		if len(value) > maxIntegerLength {
+			err = fmt.Errorf("integer value is too long: %v characters",
+				len(value))
+			break
+		}
+
 		var i *big.Int
 		i, err = intFromStr(value)
 		if err != nil {
@@ -1337,7 +1568,10 @@ func convertStringToXSDValue(datatype string, value string,
 		if err != nil {
 			return "", err
 		}
-		resultValue = ld.GetCanonicalDouble(f)
+		if dc == nil {
+			dc = DefaultDoubleCanonicalizer
+		}
+		resultValue = dc.CanonicalDouble(f)
 
 	default:
 		resultValue = value
@@ -1455,15 +1689,38 @@ func MerkleTreeSQLAdapter(mt *merkletree.MerkleTree) MerkleTree {
 
 // Merklizer is a struct to work with json-ld doc merklization
 type Merklizer struct {
-	srcDoc         []byte
-	compacted      map[string]interface{}
-	mt             MerkleTree
-	entries        map[string]RDFEntry
-	hasher         Hasher
-	safeMode       bool
-	ipfsCli        loaders.IPFSClient // @formatter:off : Goland bug
-	ipfsGW         string
-	documentLoader ld.DocumentLoader
+	srcDoc   []byte
+	noSrcDoc bool
+	// srcObj is the decoded source document passed to MerklizeJSONLDObject.
+	// It is encoded to srcDoc on first use.
+	srcObj            any
+	srcDocM           sync.Mutex
+	compacted         map[string]interface{}
+	mt                MerkleTree
+	mtStorage         merkletree.Storage
+	entries           map[string]RDFEntry
+	hasher            Hasher
+	safeMode          bool
+	ipfsCli           loaders.IPFSClient // @formatter:off : Goland bug
+	ipfsGW            string
+	documentLoader    ld.DocumentLoader
+	limits            *UntrustedLimits
+	nodeIDs           bool
+	dedupEntries      bool
+	intEncoding       *NegativeIntegerEncoding
+	hasherOverride    bool
+	strictDatatypes   bool
+	numberPrecision   NumberPrecision
+	doubleCanon       DoubleCanonicalizer
+	excludedPaths     []Path
+	timeNormalization TimeNormalization
+
+	// compaction context of TermCompactedDocument
+	compactionCtx     any
+	compactionCtxSet  bool
+	compactWithDocCtx bool
+	termCompactedM    sync.Mutex
+	termCompacted     map[string]any
 }
 
 // MerklizeOption is options for merklizer
@@ -1476,6 +1733,15 @@ func WithHasher(h Hasher) MerklizeOption {
 	}
 }
 
+// WithHasherOverride sets the hasher used by MerklizerFromBytes regardless
+// of the hasher ID recorded in the serialized data
+func WithHasherOverride(h Hasher) MerklizeOption {
+	return func(m *Merklizer) {
+		m.hasher = h
+		m.hasherOverride = true
+	}
+}
+
 // WithMerkleTree sets MerkleTree option
 func WithMerkleTree(mt MerkleTree) MerklizeOption {
 	return func(m *Merklizer) {
@@ -1483,6 +1749,15 @@ func WithMerkleTree(mt MerkleTree) MerklizeOption {
 	}
 }
 
+// WithMerkleTreeStorage sets the storage for the merkle tree created by the
+// Merklizer. By default, in-memory storage is used. The option is ignored if
+// the merkle tree is set with WithMerkleTree option.
+func WithMerkleTreeStorage(storage merkletree.Storage) MerklizeOption {
+	return func(m *Merklizer) {
+		m.mtStorage = storage
+	}
+}
+
 // WithSafeMode enables the Safe mode when extending a JSON-LD document.
 // The default setting for this mode is "true". If the function encounters
 // an unknown field with an incorrect IRI predicate, it will return an error.
@@ -1494,6 +1769,41 @@ func WithSafeMode(safeMode bool) MerklizeOption {
 	}
 }
 
+// WithStrictDatatypes enables checking that lexical forms of all literals
+// conform to their XSD datatypes (e.g. "abc" or "1.0" typed as xsd:integer,
+// or "yes" typed as xsd:boolean). By default, only values converted to
+// merkle tree values are checked, and merklization fails on the first one.
+// In strict mode, InvalidLiteralsError reports all invalid literals.
+func WithStrictDatatypes(strict bool) MerklizeOption {
+	return func(m *Merklizer) {
+		m.strictDatatypes = strict
+	}
+}
+
+// WithNodeIDs enables adding @id IRIs of all nodes with properties as
+// entries. By default, the @id of a nested node is present only as the value
+// of the parent's property (path of the property, e.g. credentialSubject),
+// and the @id of the top-level node is not merklized at all. With this option
+// every node with the @id gets an entry with the key of the node's path
+// followed by "@id" (e.g. credentialSubject.@id or just @id for the
+// top-level node). Blank nodes are not affected.
+func WithNodeIDs(include bool) MerklizeOption {
+	return func(m *Merklizer) {
+		m.nodeIDs = include
+	}
+}
+
+// WithDedupEntries enables silent merging of entries with the same path and
+// the same value, e.g. the same property of several top-level nodes with
+// equal values. Only one of such entries is put into the merkle tree. Entries
+// with the same path and different values still fail the merklization with
+// DuplicateEntryError.
+func WithDedupEntries(dedup bool) MerklizeOption {
+	return func(m *Merklizer) {
+		m.dedupEntries = dedup
+	}
+}
+
 // WithIPFSClient sets IPFS client option required to resolve ipfs:// contexts.
 // It works only if documentLoader is not set using WithDocumentLoader option.
 // Otherwise, it will be ignored.
@@ -1524,11 +1834,109 @@ func WithDocumentLoader(documentLoader ld.DocumentLoader) MerklizeOption {
 	}
 }
 
+// WithoutSourceDocument disables storing of the source document in the
+// Merklizer. It saves memory for big documents, but ResolveDocPath would
+// return ErrorNoSourceDocument and the source document would not be included
+// into the binary encoding of the Merklizer.
+func WithoutSourceDocument() MerklizeOption {
+	return func(m *Merklizer) {
+		m.noSrcDoc = true
+	}
+}
+
 // MerklizeJSONLD takes a JSON-LD document, parses it and returns a
 // Merklizer
 func MerklizeJSONLD(ctx context.Context, in io.Reader,
 	opts ...MerklizeOption) (*Merklizer, error) {
 
+	mz, err := newMerklizer(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	srcDoc, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := mz.decodeDocument(srcDoc)
+	if err != nil {
+		return nil, err
+	}
+	doc, _, err := mz.normalizeNumbers(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mz.noSrcDoc {
+		mz.srcDoc = srcDoc
+	}
+
+	err = mz.merklizeObj(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return mz, nil
+}
+
+// MerklizeJSONLDObject is the same as MerklizeJSONLD but takes an already
+// decoded JSON-LD document (as returned by json.Unmarshal into interface{} or
+// map[string]interface{}) to avoid decoding the document one more time.
+// The document is kept by the Merklizer and encoded to JSON only when it is
+// needed by ResolveDocPath or MarshalBinary, so it should not be modified
+// after the call. Use WithoutSourceDocument to not keep the document.
+func MerklizeJSONLDObject(ctx context.Context, doc any,
+	opts ...MerklizeOption) (*Merklizer, error) {
+
+	mz, err := newMerklizer(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mz.noSrcDoc {
+		mz.srcObj = doc
+	}
+
+	if mz.numberPrecision != NumberPrecisionLossy {
+		doc, _, err = mz.normalizeNumbers(doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = mz.merklizeObj(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return mz, nil
+}
+
+// MerklizerFromEntries builds the Merklizer from entries computed before,
+// e.g. by MerklizeJSONLD and persisted with RDFEntry.MarshalBinary, without
+// JSON-LD processing of the source document. The merkle tree and the entry
+// index are rebuilt, so proofs can be generated with Proof, but the source
+// document and the compacted document are not available: RawValue and
+// ResolveDocPath return errors. Entries must be hashed with the hasher set
+// with WithHasher option or with the default one.
+func MerklizerFromEntries(ctx context.Context, entries []RDFEntry,
+	opts ...MerklizeOption) (*Merklizer, error) {
+
+	mz, err := newMerklizer(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	mz.noSrcDoc = true
+
+	err = mz.addEntries(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	return mz, nil
+}
+
+func newMerklizer(ctx context.Context,
+	opts ...MerklizeOption) (*Merklizer, error) {
+
 	mz := &Merklizer{safeMode: true}
 	for _, o := range opts {
 		o(mz)
@@ -1536,7 +1944,11 @@ func MerklizeJSONLD(ctx context.Context, in io.Reader,
 
 	// if merkletree is not set with options, initialize new in-memory MT.
 	if mz.mt == nil {
-		mt, err := merkletree.NewMerkleTree(ctx, memory.NewMemoryStorage(), 40)
+		storage := mz.mtStorage
+		if storage == nil {
+			storage = memory.NewMemoryStorage()
+		}
+		mt, err := merkletree.NewMerkleTree(ctx, storage, 40)
 		if err != nil {
 			return nil, err
 		}
@@ -1547,53 +1959,93 @@ func MerklizeJSONLD(ctx context.Context, in io.Reader,
 	if mz.hasher == nil {
 		mz.hasher = defaultHasher
 	}
-
-	var err error
-	mz.srcDoc, err = io.ReadAll(in)
-	if err != nil {
-		return nil, err
+	if mz.intEncoding != nil {
+		mz.hasher = HasherWithNegativeIntegerEncoding(mz.hasher,
+			*mz.intEncoding)
 	}
 
-	var obj map[string]interface{}
-	err = json.Unmarshal(mz.srcDoc, &obj)
-	if err != nil {
-		return nil, err
-	}
+	return mz, nil
+}
 
+func (mz *Merklizer) merklizeObj(ctx context.Context, obj any) error {
 	proc := ld.NewJsonLdProcessor()
-	options := newJSONLDOptions(mz.safeMode, mz.getDocumentLoader())
+	options := newJSONLDOptions(mz.safeMode,
+		loaders.BindContext(ctx, mz.getDocumentLoader()))
 	normDoc, err := proc.Normalize(obj, options)
 	if err != nil {
-		return nil, err
+		if mz.safeMode {
+			return explainDroppedTerms(err, obj, options)
+		}
+		return err
 	}
 
 	dataset, ok := normDoc.(*ld.RDFDataset)
 	if !ok {
-		return nil, errors.New("[assertion] expected *ld.RDFDataset type")
+		return errors.New("[assertion] expected *ld.RDFDataset type")
 	}
 
-	entries, err := EntriesFromRDFWithHasher(dataset, mz.hasher)
+	// check the number of entries before their calculation: every quad with
+	// a literal or an IRI object becomes an entry, quads with blank node
+	// objects (nested objects without @id) do not
+	var entriesNum int
+	for _, quads := range dataset.Graphs {
+		for _, q := range quads {
+			if _, isBlank := q.Object.(*ld.BlankNode); !isBlank {
+				entriesNum++
+			}
+		}
+	}
+	err = mz.checkEntriesLimit(entriesNum)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	entries, err := entriesFromRDF(dataset, mz.hasher, mz.doubleCanon,
+		mz.nodeIDs, mz.strictDatatypes, mz.timeNormalization)
+	if err != nil {
+		return err
+	}
+
+	err = mz.addEntries(ctx, excludeEntries(entries, mz.excludedPaths))
+	if err != nil {
+		return err
+	}
+
+	// in safe mode, the unknown property may be reported by compaction only,
+	// e.g. when the document does not define any context
+	mz.compacted, err = proc.Compact(obj, nil, options)
+	if err != nil && mz.safeMode {
+		return explainDroppedTerms(err, obj, options)
+	}
+	return err
+}
+
+// addEntries indexes entries by their keys and adds them to the merkle tree
+func (mz *Merklizer) addEntries(ctx context.Context, entries []RDFEntry) error {
+	err := mz.checkEntriesLimit(len(entries))
+	if err != nil {
+		return err
 	}
 
 	mz.entries = make(map[string]RDFEntry, len(entries))
+	uniqEntries := make([]RDFEntry, 0, len(entries))
 	for _, e := range entries {
 		var key *big.Int
 		key, err = e.KeyMtEntry()
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if prev, ok := mz.entries[key.String()]; ok {
+			if mz.dedupEntries && equalValues(prev.value, e.value) {
+				continue
+			}
+			return duplicateEntryPathError(entries, mz.dedupEntries)
 		}
 		mz.entries[key.String()] = e
+		uniqEntries = append(uniqEntries, e)
 	}
 
-	err = AddEntriesToMerkleTree(ctx, mz.mt, entries)
-	if err != nil {
-		return nil, err
-	}
-
-	mz.compacted, err = proc.Compact(obj, nil, options)
-	return mz, err
+	return AddEntriesToMerkleTree(ctx, mz.mt, uniqEntries)
 }
 
 func (mz *Merklizer) Entry(path Path) (RDFEntry, error) {
@@ -1642,22 +2094,32 @@ func rvExtractObjField(obj any, field string) (any, error) {
 	return obj, nil
 }
 
+// rvExtractArrayIdx returns the element of the array. Negative indexes count
+// from the end of the array, -1 is the last element.
 func rvExtractArrayIdx(obj any, idx int) (any, error) {
 	objArr, isArray := obj.([]any)
 	if !isArray {
 		return nil, errors.New("expected array")
 	}
+	if idx < 0 {
+		idx += len(objArr)
+	}
 	if idx < 0 || idx >= len(objArr) {
 		return nil, errors.New("index is out of range")
 	}
 	return objArr[idx], nil
 }
 
+// RawValue returns the value from the source document by the path. Negative
+// indexes of the path count from the end of the array, e.g. -1 is the last
+// element. For ArraySlice parts of the path (e.g. AllElements), the slice of
+// values of the selected elements is returned.
 func (mz *Merklizer) RawValue(path Path) (any, error) {
-	parts := path.Parts()
-	var obj any = mz.compacted
+	return rawValue(mz.compacted, path.Parts(), nil)
+}
+
+func rawValue(obj any, parts []any, traversedParts []string) (any, error) {
 	var err error
-	var traversedParts []string
 	currentPath := func() string { return strings.Join(traversedParts, " / ") }
 
 	for len(parts) > 0 {
@@ -1668,6 +2130,26 @@ func (mz *Merklizer) RawValue(path Path) (any, error) {
 		case int:
 			traversedParts = append(traversedParts, fmt.Sprintf("[%v]", field))
 			obj, err = rvExtractArrayIdx(obj, field)
+		case ArraySlice:
+			traversedParts = append(traversedParts, field.String())
+			var elems []any
+			var start int
+			elems, start, err = rvExtractArraySlice(obj, field)
+			if err != nil {
+				break
+			}
+			// errors of elements name their indexes instead of the slice
+			n := len(traversedParts) - 1
+			values := make([]any, len(elems))
+			for i, elem := range elems {
+				elemParts := append(traversedParts[:n:n],
+					fmt.Sprintf("[%v]", start+i))
+				values[i], err = rawValue(elem, parts[1:], elemParts)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return values, nil
 		default:
 			err = errors.New("unexpected type of path")
 		}
@@ -1697,6 +2179,14 @@ func (mz *Merklizer) JSONLDType(path Path) (string, error) {
 }
 
 func (mz *Merklizer) ResolveDocPath(path string) (Path, error) {
+	srcDoc, err := mz.sourceDocument()
+	if err != nil {
+		return Path{}, err
+	}
+	if len(srcDoc) == 0 {
+		return Path{}, ErrorNoSourceDocument
+	}
+
 	opts := Options{
 		Hasher:         mz.hasher,
 		DocumentLoader: mz.getDocumentLoader(),
@@ -1705,17 +2195,37 @@ func (mz *Merklizer) ResolveDocPath(path string) (Path, error) {
 		opts.Hasher = defaultHasher
 	}
 
-	realPath, err := opts.NewPathFromDocument(mz.srcDoc, path)
+	realPath, err := opts.NewPathFromDocument(srcDoc, path)
 	if err != nil {
 		return Path{}, err
 	}
 	return realPath, nil
 }
 
+// sourceDocument returns the JSON encoding of the source document. The
+// document passed to MerklizeJSONLDObject is encoded on the first call.
+func (mz *Merklizer) sourceDocument() ([]byte, error) {
+	mz.srcDocM.Lock()
+	defer mz.srcDocM.Unlock()
+
+	if mz.srcObj != nil {
+		srcDoc, err := json.Marshal(mz.srcObj)
+		if err != nil {
+			return nil, err
+		}
+		mz.srcDoc = srcDoc
+		mz.srcObj = nil
+	}
+	return mz.srcDoc, nil
+}
+
 func (mz *Merklizer) Options() Options {
 	return Options{
-		Hasher:         mz.hasher,
-		DocumentLoader: mz.getDocumentLoader(),
+		Hasher:              mz.hasher,
+		DocumentLoader:      mz.getDocumentLoader(),
+		StrictDatatypes:     mz.strictDatatypes,
+		DoubleCanonicalizer: mz.doubleCanon,
+		TimeNormalization:   mz.timeNormalization,
 	}
 }
 
@@ -1741,7 +2251,7 @@ func (mz *Merklizer) Proof(ctx context.Context,
 			return nil, nil, errors.New(
 				"[assertion] no Entry found while existence is true")
 		}
-		value, err = NewValue(mz.hasher, entry.value)
+		value, err = newEntryValue(mz.hasher, entry)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -1750,6 +2260,39 @@ func (mz *Merklizer) Proof(ctx context.Context,
 	return proof, value, err
 }
 
+// ProofByDocPath is the same as Proof but takes the path in the document
+// notation, e.g. "credentialSubject.birthday", and resolves it with
+// ResolveDocPath first. If the path can't be resolved, the error names the
+// term that failed to resolve.
+func (mz *Merklizer) ProofByDocPath(ctx context.Context,
+	docPath string) (*merkletree.Proof, Value, error) {
+
+	path, err := mz.ResolveDocPath(docPath)
+	if err != nil {
+		if errors.Is(err, ErrorNoSourceDocument) {
+			return nil, nil, err
+		}
+		return nil, nil, mz.docPathError(docPath, err)
+	}
+
+	return mz.Proof(ctx, path)
+}
+
+// docPathError finds the first term of docPath that can't be resolved and
+// returns the error with the term
+func (mz *Merklizer) docPathError(docPath string, err error) error {
+	terms := strings.Split(docPath, ".")
+	for i := 1; i < len(terms); i++ {
+		_, err2 := mz.ResolveDocPath(strings.Join(terms[:i], "."))
+		if err2 != nil {
+			return fmt.Errorf("failed to resolve term %q of path %q: %w",
+				terms[i-1], docPath, err2)
+		}
+	}
+	return fmt.Errorf("failed to resolve term %q of path %q: %w",
+		terms[len(terms)-1], docPath, err)
+}
+
 func (mz *Merklizer) MkValue(val any) (Value, error) {
 	return NewValue(mz.hasher, val)
 }
@@ -1790,11 +2333,15 @@ func mkValueMtEntry(h Hasher, v interface{}) (*big.Int, error) {
 }
 
 func mkValueInt[I int64 | int32 | int](h Hasher, val I) (*big.Int, error) {
-	if val >= 0 {
-		return big.NewInt(int64(val)), nil
-	} else {
-		return new(big.Int).Add(h.Prime(), big.NewInt(int64(val))), nil
+	enc := negativeIntegerEncodingOf(h)
+	if enc != NegativeIntegerFieldComplement {
+		return enc.Encode(h.Prime(), big.NewInt(int64(val)))
 	}
+	x := big.NewInt(int64(val))
+	if val < 0 {
+		x.Add(x, h.Prime())
+	}
+	return x, nil
 }
 
 func mkValueUInt[I uint64 | uint32 | uint](val I) (*big.Int, error) {
@@ -1802,11 +2349,17 @@ func mkValueUInt[I uint64 | uint32 | uint](val I) (*big.Int, error) {
 }
 
 func mkValueBool(h Hasher, val bool) (*big.Int, error) {
+	inpP := getBigIntSlice(1)
+	defer putBigIntSlice(inpP)
+	x := getBigInt()
+	defer putBigInt(x)
 	if val {
-		return h.Hash([]*big.Int{big.NewInt(1)})
+		x.SetInt64(1)
 	} else {
-		return h.Hash([]*big.Int{big.NewInt(0)})
+		x.SetInt64(0)
 	}
+	(*inpP)[0] = x
+	return hashScratch(h, *inpP)
 }
 
 func mkValueString(h Hasher, val string) (*big.Int, error) {
@@ -1814,15 +2367,19 @@ func mkValueString(h Hasher, val string) (*big.Int, error) {
 }
 
 func mkValueTime(h Hasher, val time.Time) (*big.Int, error) {
-	var x = new(big.Int).Mul(
-		big.NewInt(val.Unix()),
-		big.NewInt(1_000_000_000))
-	x.Add(x, big.NewInt(int64(val.Nanosecond())))
+	nanos := getBigInt()
+	defer putBigInt(nanos)
+	var x = new(big.Int).Mul(nanos.SetInt64(val.Unix()), bigTen9)
+	x.Add(x, nanos.SetInt64(int64(val.Nanosecond())))
 	x.Mod(x, h.Prime())
 	return x, nil
 }
 
 func mkValueBigInt(h Hasher, val *big.Int) (*big.Int, error) {
+	enc := negativeIntegerEncodingOf(h)
+	if enc != NegativeIntegerFieldComplement {
+		return enc.Encode(h.Prime(), val)
+	}
 	if val.Cmp(h.Prime()) >= 0 {
 		return nil, fmt.Errorf("value is too big: %v", val.String())
 	}
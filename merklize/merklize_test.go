@@ -297,6 +297,7 @@ func TestEntriesFromRDF_multigraph(t *testing.T) {
 			value: big.NewInt(123),
 
 			datatype: "http://www.w3.org/2001/XMLSchema#integer",
+			lexical:  "123",
 		},
 		{
 			key: mkPath("https://www.w3.org/2018/credentials#verifiableCredential",
@@ -311,6 +312,7 @@ func TestEntriesFromRDF_multigraph(t *testing.T) {
 			value: big.NewInt(19960424),
 
 			datatype: "http://www.w3.org/2001/XMLSchema#integer",
+			lexical:  "19960424",
 		},
 	}
 
@@ -332,6 +334,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"http://schema.org/birthDate"),
 			value:    time.Date(1958, 7, 17, 0, 0, 0, 0, time.UTC),
 			datatype: "http://www.w3.org/2001/XMLSchema#dateTime",
+			lexical:  "1958-07-17",
 		},
 		{
 			key: mkPath(
@@ -339,6 +342,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"http://schema.org/familyName"),
 			value:    "SMITH",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "SMITH",
 		},
 		{
 			key: mkPath(
@@ -346,6 +350,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"http://schema.org/gender"),
 			value:    "Male",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "Male",
 		},
 		{
 			key: mkPath(
@@ -353,6 +358,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"http://schema.org/givenName"),
 			value:    "JOHN",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "JOHN",
 		},
 		{
 			key: mkPath(
@@ -381,6 +387,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#birthCountry"),
 			value:    "Bahamas",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "Bahamas",
 		},
 		{
 			key: mkPath(
@@ -388,6 +395,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#commuterClassification"),
 			value:    "C1",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "C1",
 		},
 		{
 			key: mkPath(
@@ -395,6 +403,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#lprCategory"),
 			value:    "C09",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "C09",
 		},
 		{
 			key: mkPath(
@@ -402,6 +411,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#lprNumber"),
 			value:    "999-999-999",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "999-999-999",
 		},
 		{
 			key: mkPath(
@@ -409,6 +419,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#residentSince"),
 			value:    time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
 			datatype: "http://www.w3.org/2001/XMLSchema#dateTime",
+			lexical:  "2015-01-01",
 		},
 		{
 			key: mkPath(
@@ -416,6 +427,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"http://schema.org/birthDate"),
 			value:    time.Date(1958, 7, 18, 0, 0, 0, 0, time.UTC),
 			datatype: "http://www.w3.org/2001/XMLSchema#dateTime",
+			lexical:  "1958-07-18",
 		},
 		{
 			key: mkPath(
@@ -423,6 +435,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"http://schema.org/familyName"),
 			value:    "SMITH",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "SMITH",
 		},
 		{
 			key: mkPath(
@@ -430,6 +443,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"http://schema.org/gender"),
 			value:    "Male",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "Male",
 		},
 		{
 			key: mkPath(
@@ -437,6 +451,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"http://schema.org/givenName"),
 			value:    "JOHN",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "JOHN",
 		},
 		{
 			key: mkPath(
@@ -465,6 +480,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#birthCountry"),
 			value:    "Bahamas",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "Bahamas",
 		},
 		{
 			key: mkPath(
@@ -472,6 +488,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#commuterClassification"),
 			value:    "C1",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "C1",
 		},
 		{
 			key: mkPath(
@@ -479,6 +496,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#lprCategory"),
 			value:    "C09",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "C09",
 		},
 		{
 			key: mkPath(
@@ -486,6 +504,7 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#lprNumber"),
 			value:    "999-999-999",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "999-999-999",
 		},
 		{
 			key: mkPath(
@@ -493,11 +512,13 @@ func TestEntriesFromRDF(t *testing.T) {
 				"https://w3id.org/citizenship#residentSince"),
 			value:    time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC),
 			datatype: "http://www.w3.org/2001/XMLSchema#dateTime",
+			lexical:  "2015-01-01",
 		},
 		{
 			key:      mkPath("http://schema.org/description"),
 			value:    "Government of Example Permanent Resident Card.",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "Government of Example Permanent Resident Card.",
 		},
 		{
 			key: mkPath("http://schema.org/identifier"),
@@ -505,11 +526,13 @@ func TestEntriesFromRDF(t *testing.T) {
 			value: big.NewInt(83627465),
 
 			datatype: "http://www.w3.org/2001/XMLSchema#integer",
+			lexical:  "83627465",
 		},
 		{
 			key:      mkPath("http://schema.org/name"),
 			value:    "Permanent Resident Card",
 			datatype: "http://www.w3.org/2001/XMLSchema#string",
+			lexical:  "Permanent Resident Card",
 		},
 		{
 			key: mkPath("http://www.w3.org/1999/02/22-rdf-syntax-ns#type",
@@ -540,12 +563,14 @@ func TestEntriesFromRDF(t *testing.T) {
 			//value: "2029-12-03T12:19:52Z",
 			value:    time.Date(2029, 12, 3, 12, 19, 52, 0, time.UTC),
 			datatype: "http://www.w3.org/2001/XMLSchema#dateTime",
+			lexical:  "2029-12-03T12:19:52Z",
 		},
 		{
 			key: mkPath("https://www.w3.org/2018/credentials#issuanceDate"),
 			//value: "2019-12-03T12:19:52Z",
 			value:    time.Date(2019, 12, 3, 12, 19, 52, 0, time.UTC),
 			datatype: "http://www.w3.org/2001/XMLSchema#dateTime",
+			lexical:  "2019-12-03T12:19:52Z",
 		},
 		{
 			key:      mkPath("https://www.w3.org/2018/credentials#issuer"),
@@ -729,6 +754,276 @@ func TestMerklizer_Proof(t *testing.T) {
 		mzRoot.Hex())
 }
 
+func TestVerifyFieldProof(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+
+	path, err := NewPath(
+		"https://www.w3.org/2018/credentials#credentialSubject", 1,
+		"http://schema.org/birthDate")
+	require.NoError(t, err)
+	p, value, err := mz.Proof(ctx, path)
+	require.NoError(t, err)
+
+	ok, err := VerifyFieldProof(mz.Root(), p, path, value, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the raw value is hashed according to its datatype
+	ok, err = VerifyFieldProof(mz.Root(), p, path,
+		TypedValue{Datatype: ld.XSDNS + "dateTime", Value: "1958-07-18"}, nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = VerifyFieldProof(mz.Root(), p, path,
+		time.Date(1958, 7, 18, 0, 0, 0, 0, time.UTC), nil)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// the raw value hashed as a string does not match
+	ok, err = VerifyFieldProof(mz.Root(), p, path, "1958-07-18", nil)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ok, err = VerifyFieldProof(mz.Root(), p, path,
+		TypedValue{Datatype: ld.XSDNS + "dateTime", Value: "1958-07-19"}, nil)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// the proof is pinned to the root
+	otherRoot, err := merkletree.NewHashFromBigInt(big.NewInt(1))
+	require.NoError(t, err)
+	ok, err = VerifyFieldProof(otherRoot, p, path, value, nil)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, err = VerifyFieldProof(mz.Root(), p, path, 1.5, nil)
+	require.ErrorIs(t, err, ErrIncorrectType)
+}
+
+func TestMerklizeJSONLDObject(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	var doc map[string]any
+	err := json.Unmarshal([]byte(testDocument), &doc)
+	require.NoError(t, err)
+
+	mz, err := MerklizeJSONLDObject(ctx, doc)
+	require.NoError(t, err)
+	require.Equal(t,
+		"d001de1d1b74d3b24b394566511da50df18532264c473845ea51e915a588b02a",
+		mz.Root().Hex())
+
+	path, err := mz.ResolveDocPath("credentialSubject.1.birthCountry")
+	require.NoError(t, err)
+	_, value, err := mz.Proof(ctx, path)
+	require.NoError(t, err)
+	valueStr, err := value.AsString()
+	require.NoError(t, err)
+	require.Equal(t, "Bahamas", valueStr)
+
+	mz, err = MerklizeJSONLDObject(ctx, doc, WithoutSourceDocument())
+	require.NoError(t, err)
+	require.Equal(t,
+		"d001de1d1b74d3b24b394566511da50df18532264c473845ea51e915a588b02a",
+		mz.Root().Hex())
+	_, err = mz.ResolveDocPath("credentialSubject.1.birthCountry")
+	require.ErrorIs(t, err, ErrorNoSourceDocument)
+}
+
+func TestMerklizeJSONLDObject_BinaryEncoding(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	var doc map[string]any
+	err := json.Unmarshal([]byte(testDocument), &doc)
+	require.NoError(t, err)
+
+	t.Run("source document is encoded lazily", func(t *testing.T) {
+		mz, err := MerklizeJSONLDObject(ctx, doc)
+		require.NoError(t, err)
+		require.Nil(t, mz.srcDoc)
+
+		mzBytes, err := mz.MarshalBinary()
+		require.NoError(t, err)
+		require.NotNil(t, mz.srcDoc)
+
+		mz2, err := MerklizerFromBytes(mzBytes)
+		require.NoError(t, err)
+		require.Equal(t, mz.Root().Hex(), mz2.Root().Hex())
+
+		path, err := mz.ResolveDocPath("credentialSubject.1.birthCountry")
+		require.NoError(t, err)
+		path2, err := mz2.ResolveDocPath("credentialSubject.1.birthCountry")
+		require.NoError(t, err)
+		require.Equal(t, path, path2)
+	})
+
+	t.Run("without source document", func(t *testing.T) {
+		mz, err := MerklizeJSONLDObject(ctx, doc, WithoutSourceDocument())
+		require.NoError(t, err)
+
+		mzBytes, err := mz.MarshalBinary()
+		require.NoError(t, err)
+
+		mz2, err := MerklizerFromBytes(mzBytes)
+		require.NoError(t, err)
+		require.Equal(t, mz.Root().Hex(), mz2.Root().Hex())
+
+		path, err := NewPath(
+			"https://www.w3.org/2018/credentials#credentialSubject", 1,
+			"http://schema.org/birthDate")
+		require.NoError(t, err)
+		value, err := mz2.RawValue(path)
+		require.NoError(t, err)
+		require.Equal(t, "1958-07-18", value)
+
+		_, err = mz2.ResolveDocPath("credentialSubject.1.birthCountry")
+		require.ErrorIs(t, err, ErrorNoSourceDocument)
+
+		mzBytes2, err := mz2.MarshalBinary()
+		require.NoError(t, err)
+		mz3, err := MerklizerFromBytes(mzBytes2)
+		require.NoError(t, err)
+		require.Equal(t, mz.Root().Hex(), mz3.Root().Hex())
+		_, err = mz3.ResolveDocPath("credentialSubject.1.birthCountry")
+		require.ErrorIs(t, err, ErrorNoSourceDocument)
+	})
+}
+
+func TestMerklizeJSONLD_WithMerkleTreeStorage(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	storage := memory.NewMemoryStorage()
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument),
+		WithMerkleTreeStorage(storage))
+	require.NoError(t, err)
+	require.Equal(t,
+		"d001de1d1b74d3b24b394566511da50df18532264c473845ea51e915a588b02a",
+		mz.Root().Hex())
+
+	storageRoot, err := storage.GetRoot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, mz.Root().Hex(), storageRoot.Hex())
+}
+
+// smallFieldHasher is a hasher with tiny field to test out of field errors.
+// If constHash is true, Hash always returns 1.
+type smallFieldHasher struct {
+	testHasher
+	constHash bool
+}
+
+func (h smallFieldHasher) Hash(inpBI []*big.Int) (*big.Int, error) {
+	if h.constHash {
+		return big.NewInt(1), nil
+	}
+	return h.testHasher.Hash(inpBI)
+}
+
+func (h smallFieldHasher) Prime() *big.Int {
+	return big.NewInt(7)
+}
+
+func TestValidateCircuitInputs(t *testing.T) {
+	path, err := NewPath("a", 1, "b")
+	require.NoError(t, err)
+	value, err := NewValue(defaultHasher, "value")
+	require.NoError(t, err)
+
+	var proof merkletree.Proof
+	err = json.Unmarshal([]byte(`{"existence":false,"siblings":["1","2","3"]}`),
+		&proof)
+	require.NoError(t, err)
+
+	err = ValidateCircuitInputs(path, value, &proof,
+		CircuitConstraints{MTLevels: 3, MaxPathDepth: 3})
+	require.NoError(t, err)
+
+	_, err = path.MtEntryWithDepthCheck(2)
+	require.ErrorIs(t, err, ErrorPathTooDeep)
+
+	err = ValidateCircuitInputs(path, value, &proof,
+		CircuitConstraints{MTLevels: 2})
+	require.ErrorIs(t, err, ErrorProofTooDeep)
+
+	smallPath, err := Options{Hasher: smallFieldHasher{}}.NewPath("a")
+	require.NoError(t, err)
+	_, err = smallPath.MtEntryWithDepthCheck(0)
+	require.ErrorIs(t, err, ErrorKeyOutOfField)
+
+	constHasher := smallFieldHasher{constHash: true}
+	constPath, err := Options{Hasher: constHasher}.NewPath("a")
+	require.NoError(t, err)
+	bigValue, err := NewValue(constHasher, int64(100))
+	require.NoError(t, err)
+	err = ValidateCircuitInputs(constPath, bigValue, nil, CircuitConstraints{})
+	require.ErrorIs(t, err, ErrorValueOutOfField)
+}
+
+func TestCircomSiblings(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps,
+		tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+
+	existingPath, err := mz.ResolveDocPath("credentialSubject.1.birthDate")
+	require.NoError(t, err)
+	missingPath, err := NewPath("http://example.com/missing")
+	require.NoError(t, err)
+
+	for _, path := range []Path{existingPath, missingPath} {
+		proof, value, err := mz.Proof(ctx, path)
+		require.NoError(t, err)
+
+		siblings, err := ToCircomSiblings(proof, 40)
+		require.NoError(t, err)
+		require.Len(t, siblings, 40)
+		require.Equal(t, "0", siblings[39])
+		nodeAux := ToCircomNodeAux(proof)
+
+		proof2, err := FromCircomSiblings(proof.Existence, siblings, nodeAux)
+		require.NoError(t, err)
+		require.Equal(t, proof, proof2)
+
+		if proof.Existence {
+			require.Equal(t, CircomNodeAux{Key: "0", Value: "0", NoAux: "0"},
+				nodeAux)
+			key, err := path.MtEntry()
+			require.NoError(t, err)
+			valueEntry, err := value.MtEntry()
+			require.NoError(t, err)
+			require.True(t, merkletree.VerifyProof(mz.Root(), proof2, key,
+				valueEntry))
+		} else {
+			require.Equal(t, proof.NodeAux == nil, nodeAux.NoAux == "1")
+		}
+
+		_, err = ToCircomSiblings(proof, len(proof.AllSiblings())-1)
+		require.ErrorIs(t, err, ErrorProofTooDeep)
+	}
+
+	emptyProof, err := merkletree.NewProofFromData(false, nil, nil)
+	require.NoError(t, err)
+	nodeAux := ToCircomNodeAux(emptyProof)
+	require.Equal(t, CircomNodeAux{Key: "0", Value: "0", NoAux: "1"}, nodeAux)
+	siblings, err := ToCircomSiblings(emptyProof, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0", "0", "0"}, siblings)
+	proof, err := FromCircomSiblings(false, siblings, nodeAux)
+	require.NoError(t, err)
+	require.Equal(t, emptyProof, proof)
+
+	_, err = FromCircomSiblings(false, []string{"x"}, nodeAux)
+	require.ErrorContains(t, err, "invalid sibling 0")
+	_, err = FromCircomSiblings(false, siblings, CircomNodeAux{NoAux: "2"})
+	require.EqualError(t, err, `invalid noAux: "2"`)
+}
+
 //nolint:deadcode,unused // use for debugging
 func logDataset(in *ld.RDFDataset) {
 	fmt.Printf("Log dataset of %v keys\n", len(in.Graphs))
@@ -821,6 +1116,53 @@ func TestFieldPathFromContext(t *testing.T) {
 	require.Equal(t, want, result)
 }
 
+func TestPathBuilder(t *testing.T) {
+	ctxBytes, err := os.ReadFile("testdata/kyc_schema.json-ld")
+	require.NoError(t, err)
+
+	path, err := NewPathBuilder(ctxBytes).Type("KYCAgeCredential").
+		Field("birthday").Build()
+	require.NoError(t, err)
+	want, err := NewFieldPathFromContext(ctxBytes, "KYCAgeCredential",
+		"birthday")
+	require.NoError(t, err)
+	require.Equal(t, want, path)
+
+	// the field is not defined without the type-scoped context
+	_, err = NewPathBuilder(ctxBytes).Field("birthday").Build()
+	require.ErrorIs(t, err, ErrorTermNotDefined)
+	require.EqualError(t, err, `step 1 Field("birthday"): `+
+		`term is not defined in the context`)
+
+	// the first failed step is reported
+	_, err = NewPathBuilder(ctxBytes).Type("KYCAgeCredential").
+		Field("birthdate").Index(-1).Build()
+	require.EqualError(t, err, `step 2 Field("birthdate"): `+
+		`term is not defined in the context`)
+	_, err = NewPathBuilder(ctxBytes).Type("KYCAgeCredential").
+		Field("birthday").Index(-1).Build()
+	require.EqualError(t, err, `step 3 Index(-1): index is negative`)
+
+	_, err = NewPathBuilder(ctxBytes).Type("KYCAgeCredential").Build()
+	require.EqualError(t, err, "path is empty")
+
+	_, err = NewPathBuilder([]byte(`{`)).Field("birthday").Build()
+	require.ErrorContains(t, err, "invalid context")
+
+	ctxBytes, err = os.ReadFile("testdata/custom_schema.json")
+	require.NoError(t, err)
+	path, err = NewPathBuilder(ctxBytes).Field("VerifiableCredential").
+		Field("credentialSchema").Index(1).
+		Field("JsonSchemaValidator2018").Build()
+	require.NoError(t, err)
+	want, err = NewPath(
+		"https://www.w3.org/2018/credentials#VerifiableCredential",
+		"https://www.w3.org/2018/credentials#credentialSchema", 1,
+		"https://www.w3.org/2018/credentials#JsonSchemaValidator2018")
+	require.NoError(t, err)
+	require.Equal(t, want, path)
+}
+
 func TestPathFromDocument(t *testing.T) {
 	t.Run("path with index array", func(t *testing.T) {
 		in := "credentialSubject.1.birthDate"
@@ -1226,6 +1568,120 @@ func TestMerklizer_RawValue(t *testing.T) {
 	require.Equal(t, float64(19960425), val)
 }
 
+func TestMerklizer_TermCompactedDocument(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps,
+		tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument),
+		WithDocumentCompactionContext())
+	require.NoError(t, err)
+
+	val, err := mz.RawValueByDocPath(ctx, "credentialSubject.1.birthDate")
+	require.NoError(t, err)
+	require.Equal(t, "1958-07-18", val)
+
+	path, err := mz.ResolveDocPath("credentialSubject.1.birthDate")
+	require.NoError(t, err)
+	rawVal, err := mz.RawValue(path)
+	require.NoError(t, err)
+	require.Equal(t, val, rawVal)
+
+	require.Contains(t, mz.CompactedDocument(),
+		"https://www.w3.org/2018/credentials#credentialSubject")
+	doc, err := mz.TermCompactedDocument(ctx)
+	require.NoError(t, err)
+	require.Contains(t, doc, "credentialSubject")
+	require.Contains(t, doc, "@context")
+
+	_, err = mz.RawValueByDocPath(ctx, "credentialSubject.2.birthDate")
+	require.EqualError(t, err,
+		"index is out of range at 'credentialSubject.2'")
+
+	// caller-provided context
+	mz, err = MerklizeJSONLD(ctx, strings.NewReader(testDocument),
+		WithCompactionContext(map[string]any{
+			"subject": "https://www.w3.org/2018/credentials#credentialSubject",
+			"born":    "http://schema.org/birthDate",
+		}))
+	require.NoError(t, err)
+	val, err = mz.RawValueByDocPath(ctx, "subject.0.born")
+	require.NoError(t, err)
+	require.Equal(t, "1958-07-17", val)
+
+	mz, err = MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+	_, err = mz.TermCompactedDocument(ctx)
+	require.ErrorIs(t, err, ErrorNoCompactionContext)
+}
+
+func TestMerklizer_RawValue_ArrayIndexes(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps,
+		tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument),
+		WithDocumentCompactionContext())
+	require.NoError(t, err)
+
+	const subjectIRI = "https://www.w3.org/2018/credentials#credentialSubject"
+	const birthDateIRI = "http://schema.org/birthDate"
+	rawValue := func(parts ...any) (any, error) {
+		path, err := NewPath(parts...)
+		require.NoError(t, err)
+		return mz.RawValue(path)
+	}
+
+	val, err := rawValue(subjectIRI, -1, birthDateIRI)
+	require.NoError(t, err)
+	require.Equal(t, "1958-07-18", val)
+	val, err = rawValue(subjectIRI, -2, birthDateIRI)
+	require.NoError(t, err)
+	require.Equal(t, "1958-07-17", val)
+	_, err = rawValue(subjectIRI, -3, birthDateIRI)
+	require.EqualError(t, err, "index is out of range at '"+subjectIRI+
+		" / [-3]'")
+
+	val, err = rawValue(subjectIRI, AllElements, birthDateIRI)
+	require.NoError(t, err)
+	require.Equal(t, []any{"1958-07-17", "1958-07-18"}, val)
+	val, err = rawValue(subjectIRI, ArraySlice{Start: -1}, birthDateIRI)
+	require.NoError(t, err)
+	require.Equal(t, []any{"1958-07-18"}, val)
+	val, err = rawValue(subjectIRI, ArraySlice{Start: 0, End: -1},
+		birthDateIRI)
+	require.NoError(t, err)
+	require.Equal(t, []any{"1958-07-17"}, val)
+	_, err = rawValue(subjectIRI, ArraySlice{Start: 1, End: 3},
+		birthDateIRI)
+	require.EqualError(t, err, "slice is out of range at '"+subjectIRI+
+		" / [1:3]'")
+	_, err = rawValue(subjectIRI, AllElements, "http://schema.org/unknown")
+	require.EqualError(t, err, "value not found at '"+subjectIRI+
+		" / [0] / http://schema.org/unknown'")
+
+	// single values are arrays of one element for slices
+	val, err = rawValue("https://www.w3.org/2018/credentials#issuer",
+		AllElements)
+	require.NoError(t, err)
+	require.Len(t, val, 1)
+
+	path, err := NewPath(subjectIRI, AllElements, birthDateIRI)
+	require.NoError(t, err)
+	_, err = path.MtEntry()
+	require.Error(t, err)
+
+	val, err = mz.RawValueByDocPath(ctx, "credentialSubject.-1.birthDate")
+	require.NoError(t, err)
+	require.Equal(t, "1958-07-18", val)
+	val, err = mz.RawValueByDocPath(ctx, "credentialSubject.*.birthDate")
+	require.NoError(t, err)
+	require.Equal(t, []any{"1958-07-17", "1958-07-18"}, val)
+	_, err = mz.RawValueByDocPath(ctx, "credentialSubject.*.unknown")
+	require.EqualError(t, err,
+		"value not found at 'credentialSubject.0.unknown'")
+}
+
 var vcURLMaps = map[string]string{
 	"https://www.w3.org/2018/credentials/v1":                                                           "testdata/httpresp/credentials-v1.jsonld",
 	"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v101.json-ld": "testdata/httpresp/kyc-v101.json-ld",
@@ -1293,7 +1749,7 @@ func TestIncorrectDocument_UnsafeMode(t *testing.T) {
 	t.Run("default safe mode", func(t *testing.T) {
 		_, err := MerklizeJSONLD(ctx, strings.NewReader(docUnknownFields))
 		require.EqualError(t, err,
-			"invalid property: Dropping property that did not expand into an absolute IRI or keyword.")
+			"invalid property: Dropping property that did not expand into an absolute IRI or keyword.; dropped properties: expirationDate, id")
 
 	})
 
@@ -1301,7 +1757,7 @@ func TestIncorrectDocument_UnsafeMode(t *testing.T) {
 		_, err := MerklizeJSONLD(ctx, strings.NewReader(docUnknownFields),
 			WithSafeMode(true))
 		require.EqualError(t, err,
-			"invalid property: Dropping property that did not expand into an absolute IRI or keyword.")
+			"invalid property: Dropping property that did not expand into an absolute IRI or keyword.; dropped properties: expirationDate, id")
 	})
 
 	t.Run("explicitly set unsafe mode", func(t *testing.T) {
@@ -1309,6 +1765,47 @@ func TestIncorrectDocument_UnsafeMode(t *testing.T) {
 			WithSafeMode(false))
 		require.NoError(t, err)
 	})
+
+	t.Run("dropped terms", func(t *testing.T) {
+		_, err := MerklizeJSONLD(ctx, strings.NewReader(docUnknownFields))
+		var droppedErr *DroppedTermsError
+		require.ErrorAs(t, err, &droppedErr)
+		require.Equal(t, []DroppedTerm{
+			{Term: "expirationDate", Path: "expirationDate"},
+			{Term: "id", Path: "id"},
+		}, droppedErr.Terms)
+
+		var ldErr *ld.JsonLdError
+		require.ErrorAs(t, err, &ldErr)
+		require.Equal(t, ld.InvalidProperty, ldErr.Code)
+	})
+
+	t.Run("dropped terms with contexts", func(t *testing.T) {
+		defer tst.MockHTTPClient(t, map[string]string{
+			"https://www.w3.org/2018/credentials/v1": "testdata/httpresp/credentials-v1.jsonld",
+		}, tst.IgnoreUntouchedURLs())()
+
+		doc := `{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:issuer",
+  "credentialSubject": {
+    "@context": {"name": "http://schema.org/name"},
+    "name": "Alice",
+    "unknownField": 1
+  }
+}`
+		_, err := MerklizeJSONLD(ctx, strings.NewReader(doc))
+		var droppedErr *DroppedTermsError
+		require.ErrorAs(t, err, &droppedErr)
+		require.Equal(t, []DroppedTerm{
+			{
+				Term:     "unknownField",
+				Path:     "credentialSubject.unknownField",
+				Contexts: []string{"https://www.w3.org/2018/credentials/v1"},
+			},
+		}, droppedErr.Terms)
+	})
 }
 
 func TestTypeFromContext(t *testing.T) {
@@ -2083,3 +2580,1577 @@ func mergeMaps(ms ...map[string]string) map[string]string {
 
 	return res
 }
+
+type noRemoteDocumentLoader struct{}
+
+func (noRemoteDocumentLoader) LoadDocument(
+	u string) (*ld.RemoteDocument, error) {
+
+	return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed,
+		"remote documents are disabled: "+u)
+}
+
+const untrustedTestDoc = `{
+  "@context": {
+    "@vocab": "http://example.com/",
+    "age": {"@id": "http://example.com/age", "@type": "http://www.w3.org/2001/XMLSchema#integer"},
+    "name": "http://example.com/name"
+  },
+  "age": 42,
+  "name": "Alice"
+}`
+
+func TestMerklizeJSONLDUntrusted(t *testing.T) {
+	ctx := context.Background()
+	loaderOpt := WithDocumentLoader(noRemoteDocumentLoader{})
+
+	mz, err := MerklizeJSONLDUntrusted(ctx,
+		strings.NewReader(untrustedTestDoc), DefaultUntrustedLimits,
+		loaderOpt)
+	require.NoError(t, err)
+	path, err := mz.ResolveDocPath("age")
+	require.NoError(t, err)
+	entry, err := mz.Entry(path)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(42), entry.value)
+
+	testCases := []struct {
+		name   string
+		limits UntrustedLimits
+		err    string
+	}{
+		{
+			name:   "document size",
+			limits: UntrustedLimits{MaxDocumentSize: 10},
+			err:    "document exceeds limits: document size exceeds 10 bytes",
+		},
+		{
+			name:   "depth",
+			limits: UntrustedLimits{MaxDepth: 2},
+			err:    "document exceeds limits: depth exceeds 2",
+		},
+		{
+			name:   "nodes",
+			limits: UntrustedLimits{MaxNodes: 5},
+			err:    "document exceeds limits: number of nodes exceeds 5",
+		},
+		{
+			name:   "string length",
+			limits: UntrustedLimits{MaxStringLength: 20},
+			err:    "document exceeds limits: string length exceeds 20",
+		},
+		{
+			name:   "entries",
+			limits: UntrustedLimits{MaxEntries: 1},
+			err:    "document exceeds limits: number of entries exceeds 1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := MerklizeJSONLDUntrusted(ctx,
+				strings.NewReader(untrustedTestDoc), tc.limits, loaderOpt)
+			require.ErrorIs(t, err, ErrorLimitExceeded)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestMerklizeJSONLDUntrusted_NestedObjectsEntriesLimit(t *testing.T) {
+	// 3 nested objects without @id and 3 literals: 6 quads, 3 entries
+	doc := `{
+  "@context": {"@vocab": "http://example.com/"},
+  "a": {"b": {"c": {"value": 1}}},
+  "d": "x",
+  "e": "y"
+}`
+	ctx := context.Background()
+	loaderOpt := WithDocumentLoader(noRemoteDocumentLoader{})
+
+	mz, err := MerklizeJSONLDUntrusted(ctx, strings.NewReader(doc),
+		UntrustedLimits{MaxEntries: 3}, loaderOpt)
+	require.NoError(t, err)
+	require.Len(t, mz.entries, 3)
+
+	_, err = MerklizeJSONLDUntrusted(ctx, strings.NewReader(doc),
+		UntrustedLimits{MaxEntries: 2}, loaderOpt)
+	require.EqualError(t, err,
+		"document exceeds limits: number of entries exceeds 2")
+}
+
+func TestConvertStringToXSDValue_TooLongInteger(t *testing.T) {
+	_, err := convertStringToXSDValue(ld.XSDInteger,
+		strings.Repeat("1", maxIntegerLength+1), defaultHasher.Prime(),
+		nil)
+	require.EqualError(t, err, "integer value is too long: 257 characters")
+}
+
+func FuzzMerklizeJSONLDUntrusted(f *testing.F) {
+	f.Add([]byte(untrustedTestDoc))
+	f.Add([]byte(`{"@context": {"@vocab": "http://example.com/"}, "a": [1, {"b": true}]}`))
+	f.Add([]byte(`{}`))
+
+	f.Fuzz(func(t *testing.T, doc []byte) {
+		// only errors are expected, not panics
+		_, _ = MerklizeJSONLDUntrusted(context.Background(),
+			bytes.NewReader(doc), DefaultUntrustedLimits,
+			WithDocumentLoader(noRemoteDocumentLoader{}))
+	})
+}
+
+func FuzzConvertStringToXSDValue(f *testing.F) {
+	f.Add(ld.XSDInteger, "123")
+	f.Add(ld.XSDNS+"positiveInteger", "1e10")
+	f.Add(ld.XSDDouble, "1.5E0")
+	f.Add(ld.XSDBoolean, "true")
+	f.Add(ld.XSDNS+"dateTime", "2023-01-01T00:00:00Z")
+
+	f.Fuzz(func(t *testing.T, datatype, value string) {
+		_, _ = convertStringToXSDValue(datatype, value, defaultHasher.Prime(),
+			nil)
+	})
+}
+
+func TestRDFEntry_Accessors(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps)()
+	dataset := getDataset(t, testDocument)
+
+	entries, err := EntriesFromRDF(dataset)
+	require.NoError(t, err)
+
+	for _, e := range entries {
+		entryBytes, err := json.Marshal(e)
+		require.NoError(t, err)
+
+		var e2 RDFEntry
+		err = json.Unmarshal(entryBytes, &e2)
+		require.NoError(t, err)
+		key1, key2 := e.Key(), e2.Key()
+		require.Equal(t, key1.Parts(), key2.Parts())
+		if tm, ok := e.Value().(time.Time); ok {
+			require.True(t, tm.Equal(e2.Value().(time.Time)))
+		} else {
+			require.Equal(t, e.Value(), e2.Value())
+		}
+		require.Equal(t, e.Datatype(), e2.Datatype())
+
+		k1, v1, err := e.KeyValueMtEntries()
+		require.NoError(t, err)
+		k2, v2, err := e2.KeyValueMtEntries()
+		require.NoError(t, err)
+		require.Equal(t, k1, k2)
+		require.Equal(t, v1, v2)
+	}
+
+	q := ld.NewQuad(ld.NewIRI("http://example.com/alice"),
+		ld.NewIRI("http://schema.org/identifier"),
+		ld.NewLiteral("83627465", ld.XSDInteger, ""), "")
+	e, err := NewRDFEntryFromQuad(Path{}, q)
+	require.NoError(t, err)
+	key := e.Key()
+	require.Equal(t, []interface{}{"http://schema.org/identifier"},
+		key.Parts())
+	require.Equal(t, big.NewInt(83627465), e.Value())
+	require.Equal(t, ld.XSDInteger, e.Datatype())
+
+	path, err := NewPath("http://schema.org/identifier")
+	require.NoError(t, err)
+	e2, err := NewRDFEntry(path, 83627465)
+	require.NoError(t, err)
+	v1, err := e.ValueMtEntry()
+	require.NoError(t, err)
+	v2, err := e2.ValueMtEntry()
+	require.NoError(t, err)
+	require.Equal(t, v1, v2)
+
+	q.Object = ld.NewBlankNode("_:b0")
+	_, err = NewRDFEntryFromQuad(Path{}, q)
+	require.EqualError(t, err, "unsupported Quad's Object type: *ld.BlankNode")
+}
+
+func TestNegativeIntegerEncoding(t *testing.T) {
+	prime := constants.Q
+	pow2 := func(n uint) *big.Int {
+		return new(big.Int).Lsh(big.NewInt(1), n)
+	}
+
+	testCases := []struct {
+		encoding NegativeIntegerEncoding
+		value    *big.Int
+		want     *big.Int
+		wantErr  bool
+	}{
+		{NegativeIntegerFieldComplement, big.NewInt(5), big.NewInt(5), false},
+		{NegativeIntegerFieldComplement, big.NewInt(-1),
+			new(big.Int).Sub(prime, big.NewInt(1)), false},
+		{NegativeIntegerFieldComplement, prime, nil, true},
+		{NegativeIntegerTwosComplement, big.NewInt(5), big.NewInt(5), false},
+		{NegativeIntegerTwosComplement, big.NewInt(-1),
+			new(big.Int).Sub(pow2(253), big.NewInt(1)), false},
+		{NegativeIntegerTwosComplement, new(big.Int).Neg(pow2(252)),
+			pow2(252), false},
+		{NegativeIntegerTwosComplement, pow2(252), nil, true},
+		{NegativeIntegerSignMagnitude, big.NewInt(5), big.NewInt(5), false},
+		{NegativeIntegerSignMagnitude, big.NewInt(-1),
+			new(big.Int).Add(pow2(252), big.NewInt(1)), false},
+		{NegativeIntegerSignMagnitude, big.NewInt(-100),
+			new(big.Int).Add(pow2(252), big.NewInt(100)), false},
+		{NegativeIntegerSignMagnitude, pow2(252), nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%v %v", tc.encoding, tc.value), func(t *testing.T) {
+			got, err := tc.encoding.Encode(prime, tc.value)
+			if tc.wantErr {
+				require.ErrorIs(t, err, ErrorIntegerOutOfRange)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 0, tc.want.Cmp(got), got.String())
+
+			decoded, err := tc.encoding.Decode(prime, got)
+			require.NoError(t, err)
+			require.Equal(t, 0, tc.value.Cmp(decoded), decoded.String())
+		})
+	}
+}
+
+func TestEntriesFromRDFWithHasher_NegativeIntegerEncoding(t *testing.T) {
+	doc := strings.Replace(untrustedTestDoc, `"age": 42`, `"age": -42`, 1)
+	ds := getDataset(t, doc)
+
+	for _, enc := range []NegativeIntegerEncoding{
+		NegativeIntegerFieldComplement,
+		NegativeIntegerTwosComplement,
+		NegativeIntegerSignMagnitude,
+	} {
+		t.Run(enc.String(), func(t *testing.T) {
+			hasher := HasherWithNegativeIntegerEncoding(defaultHasher, enc)
+			entries, err := EntriesFromRDFWithHasher(ds, hasher)
+			require.NoError(t, err)
+
+			want, err := enc.Encode(constants.Q, big.NewInt(-42))
+			require.NoError(t, err)
+			found := false
+			for _, e := range entries {
+				parts := e.key.parts
+				if parts[len(parts)-1] != "http://example.com/age" {
+					continue
+				}
+				found = true
+				valueEntry, err := e.ValueMtEntry()
+				require.NoError(t, err)
+				require.Equal(t, want, valueEntry)
+			}
+			require.True(t, found)
+		})
+	}
+}
+
+func TestMerklizeJSONLD_NegativeIntegerEncoding(t *testing.T) {
+	ctx := context.Background()
+	doc := strings.Replace(untrustedTestDoc, `"age": 42`, `"age": -42`, 1)
+
+	roots := make(map[string]bool)
+	for _, enc := range []NegativeIntegerEncoding{
+		NegativeIntegerFieldComplement,
+		NegativeIntegerTwosComplement,
+		NegativeIntegerSignMagnitude,
+	} {
+		mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc),
+			WithDocumentLoader(noRemoteDocumentLoader{}),
+			WithNegativeIntegerEncoding(enc))
+		require.NoError(t, err)
+		roots[mz.Root().String()] = true
+
+		path, err := mz.ResolveDocPath("age")
+		require.NoError(t, err)
+		entry, err := mz.Entry(path)
+		require.NoError(t, err)
+		valueEntry, err := entry.ValueMtEntry()
+		require.NoError(t, err)
+
+		want, err := enc.Encode(constants.Q, big.NewInt(-42))
+		require.NoError(t, err)
+		require.Equal(t, want, valueEntry)
+
+		decoded, err := DecodeIntegerValue(mz.Hasher(), valueEntry)
+		require.NoError(t, err)
+		require.Equal(t, big.NewInt(-42), decoded)
+
+		// the same encoding is used by values created with Options
+		v, err := mz.Options().NewRDFEntry(path, -42)
+		require.NoError(t, err)
+		valueEntry2, err := v.ValueMtEntry()
+		require.NoError(t, err)
+		require.Equal(t, valueEntry, valueEntry2)
+	}
+	require.Len(t, roots, 3)
+}
+
+func TestCheckComparison(t *testing.T) {
+	ctx := context.Background()
+	doc := strings.Replace(untrustedTestDoc, `"name": "Alice"`,
+		`"name": "Alice", "birthday": "1969-12-31T00:00:00Z"`, 1)
+	doc = strings.Replace(doc, `"name": "http://example.com/name"`,
+		`"name": "http://example.com/name", "birthday": {`+
+			`"@id": "http://example.com/birthday", `+
+			`"@type": "http://www.w3.org/2001/XMLSchema#dateTime"}`, 1)
+	doc = strings.Replace(doc, `"age": 42`, `"age": -42`, 1)
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithDocumentLoader(noRemoteDocumentLoader{}),
+		WithNegativeIntegerEncoding(NegativeIntegerTwosComplement))
+	require.NoError(t, err)
+
+	agePath, err := mz.ResolveDocPath("age")
+	require.NoError(t, err)
+	age, err := mz.ComparableValue(agePath)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(-42), age.Int)
+	require.True(t, age.Negative)
+	require.Equal(t, NegativeIntegerTwosComplement, age.Encoding)
+
+	minusOne, err := NewComparableInt(mz.Hasher(), big.NewInt(-1))
+	require.NoError(t, err)
+	require.NoError(t, CheckComparison(age, minusOne, ComparisonLT, true))
+	require.NoError(t, CheckComparison(age, minusOne, ComparisonGT, false))
+	require.EqualError(t, CheckComparison(age, minusOne, ComparisonGT, true),
+		"claimed comparison -42 gt -1 = true is false")
+
+	// negative values are greater than non-negative ones in the field
+	zero, err := NewComparableInt(mz.Hasher(), big.NewInt(0))
+	require.NoError(t, err)
+	require.ErrorIs(t, CheckComparison(age, zero, ComparisonLT, true),
+		ErrorComparisonMismatch)
+
+	// negative values are not ordered with the sign-magnitude encoding
+	smHasher := HasherWithNegativeIntegerEncoding(nil,
+		NegativeIntegerSignMagnitude)
+	ageSM, err := NewComparableInt(smHasher, big.NewInt(-42))
+	require.NoError(t, err)
+	minusOneSM, err := NewComparableInt(smHasher, big.NewInt(-1))
+	require.NoError(t, err)
+	require.ErrorIs(t, CheckComparison(ageSM, minusOneSM, ComparisonLT, true),
+		ErrorComparisonMismatch)
+
+	// dateTime before Unix epoch
+	birthdayPath, err := mz.ResolveDocPath("birthday")
+	require.NoError(t, err)
+	birthday, err := mz.ComparableValue(birthdayPath)
+	require.NoError(t, err)
+	require.True(t, birthday.Negative)
+	require.Equal(t, NegativeIntegerFieldComplement, birthday.Encoding)
+	epoch, err := NewComparableTime(mz.Hasher(), time.Unix(0, 0))
+	require.NoError(t, err)
+	require.ErrorIs(t, CheckComparison(birthday, epoch, ComparisonLT, true),
+		ErrorComparisonMismatch)
+
+	// the value does not match the field element
+	tampered := *age
+	tampered.Int = big.NewInt(-41)
+	require.ErrorIs(t, CheckComparison(&tampered, minusOne, ComparisonLT, true),
+		ErrorComparisonMismatch)
+
+	namePath, err := mz.ResolveDocPath("name")
+	require.NoError(t, err)
+	_, err = mz.ComparableValue(namePath)
+	require.ErrorIs(t, err, ErrorNotComparable)
+}
+
+func TestMerklizerFromEntries(t *testing.T) {
+	ctx := context.Background()
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(untrustedTestDoc),
+		WithDocumentLoader(noRemoteDocumentLoader{}))
+	require.NoError(t, err)
+
+	// persist entries with the binary encoding
+	var entriesBytes [][]byte
+	for _, e := range mz.entries {
+		b, err := e.MarshalBinary()
+		require.NoError(t, err)
+		entriesBytes = append(entriesBytes, b)
+	}
+	entries := make([]RDFEntry, len(entriesBytes))
+	for i, b := range entriesBytes {
+		require.NoError(t, entries[i].UnmarshalBinary(b))
+	}
+
+	mz2, err := MerklizerFromEntries(ctx, entries)
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), mz2.Root())
+
+	path, err := mz.ResolveDocPath("age")
+	require.NoError(t, err)
+	proof, value, err := mz.Proof(ctx, path)
+	require.NoError(t, err)
+	proof2, value2, err := mz2.Proof(ctx, path)
+	require.NoError(t, err)
+	require.Equal(t, proof, proof2)
+	require.Equal(t, value, value2)
+
+	_, err = mz2.ResolveDocPath("age")
+	require.ErrorIs(t, err, ErrorNoSourceDocument)
+
+	_, err = MerklizerFromEntries(ctx, append(entries, entries[0]))
+	require.ErrorContains(t, err, "multiple entries with the same path")
+}
+
+func TestBuildAnchorBatch(t *testing.T) {
+	ctx := context.Background()
+
+	var mzs []*Merklizer
+	for _, age := range []string{"42", "43", "44"} {
+		doc := strings.Replace(untrustedTestDoc, `"age": 42`,
+			`"age": `+age, 1)
+		mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc),
+			WithDocumentLoader(noRemoteDocumentLoader{}))
+		require.NoError(t, err)
+		mzs = append(mzs, mz)
+	}
+
+	roots := make([]*merkletree.Hash, len(mzs))
+	for i, mz := range mzs {
+		roots[i] = mz.Root()
+	}
+
+	batch, err := BuildAnchorBatch(ctx, roots, 0)
+	require.NoError(t, err)
+	require.Len(t, batch.Proofs, len(mzs))
+
+	batchBytes, err := json.Marshal(batch)
+	require.NoError(t, err)
+	var batch2 AnchorBatch
+	err = json.Unmarshal(batchBytes, &batch2)
+	require.NoError(t, err)
+
+	for i, mz := range mzs {
+		require.True(t, VerifyAnchorProof(batch2.Root, batch2.Proofs[i]))
+		require.True(t, VerifyCredentialAnchor(mz, batch2.Root,
+			batch2.Proofs[i]))
+	}
+
+	// proof of another credential
+	require.False(t, VerifyCredentialAnchor(mzs[0], batch2.Root,
+		batch2.Proofs[1]))
+	// wrong chain root
+	require.False(t, VerifyAnchorProof(roots[0], batch2.Proofs[0]))
+
+	_, err = BuildAnchorBatch(ctx, nil, 0)
+	require.ErrorIs(t, err, ErrorAnchorBatchEmpty)
+}
+
+func TestMerklizeJSONLD_WithNodeIDs(t *testing.T) {
+	ctx := context.Background()
+	doc := `{
+  "@context": {
+    "@vocab": "http://example.com/",
+    "id": "@id",
+    "friend": {"@id": "http://example.com/friend"}
+  },
+  "id": "http://example.com/alice",
+  "name": "Alice",
+  "friend": {"id": "http://example.com/bob", "name": "Bob"}
+}`
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithDocumentLoader(noRemoteDocumentLoader{}))
+	require.NoError(t, err)
+	require.Len(t, mz.entries, 3)
+	path, err := mz.ResolveDocPath("id")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"@id"}, path.Parts())
+	_, err = mz.Entry(path)
+	require.ErrorIs(t, err, ErrorEntryNotFound)
+
+	mz, err = MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithDocumentLoader(noRemoteDocumentLoader{}), WithNodeIDs(true))
+	require.NoError(t, err)
+	require.Len(t, mz.entries, 5)
+
+	testCases := []struct {
+		path  string
+		parts []interface{}
+		value string
+	}{
+		{"id", []interface{}{"@id"}, "http://example.com/alice"},
+		{"friend.id", []interface{}{"http://example.com/friend", "@id"},
+			"http://example.com/bob"},
+		{"friend", []interface{}{"http://example.com/friend"},
+			"http://example.com/bob"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			path, err := mz.ResolveDocPath(tc.path)
+			require.NoError(t, err)
+			require.Equal(t, tc.parts, path.Parts())
+			entry, err := mz.Entry(path)
+			require.NoError(t, err)
+			require.Equal(t, tc.value, entry.Value())
+
+			value, err := mz.MkValue(tc.value)
+			require.NoError(t, err)
+			exists, _, err := mz.Proof(ctx, path)
+			require.NoError(t, err)
+			require.True(t, exists.Existence)
+			pathKey, err := path.MtEntry()
+			require.NoError(t, err)
+			valueEntry, err := value.MtEntry()
+			require.NoError(t, err)
+			require.True(t, merkletree.VerifyProof(mz.Root(), exists,
+				pathKey, valueEntry))
+		})
+	}
+}
+
+func TestMerklizeJSONLD_DuplicateEntryPaths(t *testing.T) {
+	// two nodes of the same graph without a parent have the same paths
+	doc := `{
+  "@context": {
+    "ex": "http://example.com/",
+    "name": "ex:name",
+    "age": "ex:age"
+  },
+  "@graph": [{"name": "a", "age": 1}, {"name": "b"}]
+}`
+	_, err := MerklizeJSONLD(context.Background(), strings.NewReader(doc))
+	require.ErrorIs(t, err, ErrorDuplicateEntryPath)
+	require.EqualError(t, err, "multiple entries with the same path: "+
+		"[http://example.com/name]: 2 values")
+
+	dataset := getDataset(t, doc)
+	entries, err := EntriesFromRDF(dataset)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	collisions, err := FindEntryPathCollisions(entries)
+	require.NoError(t, err)
+	require.Len(t, collisions, 1)
+	wantPath, err := NewPath("http://example.com/name")
+	require.NoError(t, err)
+	require.Equal(t, wantPath.Parts(), collisions[0].Path.Parts())
+	require.ElementsMatch(t, []any{"a", "b"}, collisions[0].Values)
+
+	// nodes of different named graphs are disambiguated by index
+	doc = `{
+  "@context": {
+    "ex": "http://example.com/",
+    "items": {"@id": "ex:items", "@container": "@graph"},
+    "name": "ex:name"
+  },
+  "items": [{"name": "a"}, {"name": "b"}]
+}`
+	entries, err = EntriesFromRDF(getDataset(t, doc))
+	require.NoError(t, err)
+	collisions, err = FindEntryPathCollisions(entries)
+	require.NoError(t, err)
+	require.Empty(t, collisions)
+	_, err = MerklizeJSONLD(context.Background(), strings.NewReader(doc))
+	require.NoError(t, err)
+}
+
+func TestMerklizeJSONLD_DedupEntries(t *testing.T) {
+	ctx := context.Background()
+	doc := `{
+  "@context": {
+    "ex": "http://example.com/",
+    "name": "ex:name",
+    "age": "ex:age"
+  },
+  "@graph": [{"name": "a", "age": 1}, {"name": "a"}]
+}`
+	_, err := MerklizeJSONLD(ctx, strings.NewReader(doc))
+	var dupErr *DuplicateEntryError
+	require.ErrorAs(t, err, &dupErr)
+	require.ErrorIs(t, err, ErrorDuplicateEntryPath)
+	require.Len(t, dupErr.Paths(), 1)
+	wantPath, err := NewPath("http://example.com/name")
+	require.NoError(t, err)
+	require.Equal(t, wantPath.Parts(), dupErr.Paths()[0].Parts())
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithDedupEntries(true))
+	require.NoError(t, err)
+	require.Len(t, mz.Entries(), 2)
+	root, err := RecomputeRoot(ctx, mz.Entries(), nil, 40)
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), root)
+	entry, err := mz.Entry(wantPath)
+	require.NoError(t, err)
+	require.Equal(t, "a", entry.Value())
+
+	// different values are not merged
+	doc = `{
+  "@context": {
+    "ex": "http://example.com/",
+    "name": "ex:name",
+    "age": "ex:age"
+  },
+  "@graph": [{"name": "a", "age": 1}, {"name": "a", "age": 2}]
+}`
+	_, err = MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithDedupEntries(true))
+	require.EqualError(t, err, "multiple entries with the same path: "+
+		"[http://example.com/age]: 2 values")
+}
+
+func TestMerklizer_ProofByDocPath(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+
+	proof, value, err := mz.ProofByDocPath(ctx,
+		"credentialSubject.1.birthCountry")
+	require.NoError(t, err)
+	require.True(t, proof.Existence)
+	valueStr, err := value.AsString()
+	require.NoError(t, err)
+	require.Equal(t, "Bahamas", valueStr)
+
+	path, err := mz.ResolveDocPath("credentialSubject.1.birthCountry")
+	require.NoError(t, err)
+	proof2, value2, err := mz.Proof(ctx, path)
+	require.NoError(t, err)
+	require.Equal(t, proof2, proof)
+	require.Equal(t, value2, value)
+
+	_, _, err = mz.ProofByDocPath(ctx, "credentialSubject.1.bla.birthCountry")
+	require.EqualError(t, err, `failed to resolve term "bla" of path `+
+		`"credentialSubject.1.bla.birthCountry": no @id attribute for term: bla`)
+
+	_, _, err = mz.ProofByDocPath(ctx, "bla")
+	require.EqualError(t, err, `failed to resolve term "bla" of path "bla": `+
+		`no @id attribute for term: bla`)
+
+	mz, err = MerklizeJSONLD(ctx, strings.NewReader(testDocument),
+		WithoutSourceDocument())
+	require.NoError(t, err)
+	_, _, err = mz.ProofByDocPath(ctx, "credentialSubject.1.birthCountry")
+	require.ErrorIs(t, err, ErrorNoSourceDocument)
+}
+
+func TestRecomputeRoot(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+
+	entries := mz.Entries()
+	require.NotEmpty(t, entries)
+	require.Equal(t, entries, mz.Entries())
+	for i := 1; i < len(entries); i++ {
+		prev, err := entries[i-1].KeyMtEntry()
+		require.NoError(t, err)
+		cur, err := entries[i].KeyMtEntry()
+		require.NoError(t, err)
+		require.Equal(t, -1, prev.Cmp(cur))
+	}
+
+	root, err := RecomputeRoot(ctx, entries, nil, 40)
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), root)
+
+	// entries restored from JSON produce the same root
+	entriesBytes, err := json.Marshal(entries)
+	require.NoError(t, err)
+	var entries2 []RDFEntry
+	err = json.Unmarshal(entriesBytes, &entries2)
+	require.NoError(t, err)
+	root, err = RecomputeRoot(ctx, entries2, PoseidonHasher{}, 40)
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), root)
+
+	// tampered entry changes the root
+	entries2[0].value = "tampered"
+	root, err = RecomputeRoot(ctx, entries2, nil, 40)
+	require.NoError(t, err)
+	require.NotEqual(t, mz.Root(), root)
+
+	_, err = RecomputeRoot(ctx, append(entries, entries[0]), nil, 40)
+	require.ErrorIs(t, err, ErrorDuplicateEntryPath)
+
+	_, err = RecomputeRoot(ctx, entries, nil, 0)
+	require.EqualError(t, err, "invalid merkle tree depth: 0")
+}
+
+func TestGenerateFixture(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	fixtureBytes, err := GenerateFixture(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+	fixtureBytes2, err := GenerateFixture(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+	require.Equal(t, string(fixtureBytes), string(fixtureBytes2))
+
+	var f Fixture
+	err = json.Unmarshal(fixtureBytes, &f)
+	require.NoError(t, err)
+
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+	require.Equal(t, mz.Root().BigInt().String(), f.Root)
+	require.Len(t, f.Entries, len(mz.Entries()))
+	require.NotEmpty(t, f.Compacted)
+
+	path, err := mz.ResolveDocPath("credentialSubject.1.birthDate")
+	require.NoError(t, err)
+	pathHash, err := path.MtEntry()
+	require.NoError(t, err)
+	entry, err := mz.Entry(path)
+	require.NoError(t, err)
+	valueHash, err := entry.ValueMtEntry()
+	require.NoError(t, err)
+
+	var found bool
+	for _, e := range f.Entries {
+		if e.PathHash == pathHash.String() {
+			found = true
+			require.Equal(t, valueHash.String(), e.ValueHash)
+			require.Equal(t, "http://www.w3.org/2001/XMLSchema#dateTime",
+				e.Datatype)
+			require.Len(t, e.Path, len(path.Parts()))
+		}
+	}
+	require.True(t, found)
+}
+
+func TestMerklizeJSONLD_ArrayOrder(t *testing.T) {
+	ctx := context.Background()
+	root := func(container, values string) string {
+		doc := `{"@context": {"ex": "http://example.com/", "v": ` +
+			`{"@id": "ex:v"` + container + `}}, "v": ` + values + `}`
+		mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc))
+		require.NoError(t, err)
+		return mz.Root().BigInt().String()
+	}
+
+	// sets are ordered canonically
+	require.Equal(t, root("", `["a", "b", "c"]`), root("", `["c", "a", "b"]`))
+	require.Equal(t, root("", `["a", "b", "c"]`),
+		root(`, "@container": "@set"`, `["b", "c", "a"]`))
+	require.Equal(t, root("", `[{"ex:n": "a"}, {"ex:n": "b"}]`),
+		root("", `[{"ex:n": "b"}, {"ex:n": "a"}]`))
+
+	// lists keep the source order
+	require.NotEqual(t, root(`, "@container": "@list"`, `["a", "b", "c"]`),
+		root(`, "@container": "@list"`, `["c", "a", "b"]`))
+
+	// index of the set value in the path is its canonical position
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(
+		`{"@context": {"ex": "http://example.com/"}, "ex:v": ["c", "a"]}`))
+	require.NoError(t, err)
+	path, err := NewPath("http://example.com/v", 0)
+	require.NoError(t, err)
+	entry, err := mz.Entry(path)
+	require.NoError(t, err)
+	require.Equal(t, "a", entry.Value())
+}
+
+func BenchmarkPath_MtEntry(b *testing.B) {
+	path, err := NewPath(
+		"https://www.w3.org/2018/credentials#credentialSubject", 1,
+		"http://schema.org/birthDate")
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := path.MtEntry()
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMkValueMtEntry(b *testing.B) {
+	values := []any{int64(-5), true,
+		time.Date(2019, 12, 3, 12, 19, 52, 0, time.UTC)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, v := range values {
+			_, err := mkValueMtEntry(defaultHasher, v)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkMerklizeJSONLD(b *testing.B) {
+	defer tst.MockHTTPClient(b, testDocumentURLMaps,
+		tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMerklizeJSONLD_StrictDatatypes(t *testing.T) {
+	const doc = `{
+  "@context": {
+    "@vocab": "http://example.com/",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "age": {"@type": "xsd:integer"},
+    "adult": {"@type": "xsd:boolean"},
+    "born": {"@type": "xsd:dateTime"},
+    "height": {"@type": "xsd:double"}
+  },
+  "@id": "http://example.com/subject",
+  "age": "%v",
+  "adult": "%v",
+  "born": "%v",
+  "height": "%v"
+}`
+	ctx := context.Background()
+
+	validDoc := fmt.Sprintf(doc, "+42", "1", "2000-02-29T10:00:00.5Z",
+		"1.5E2")
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(validDoc),
+		WithStrictDatatypes(true))
+	require.NoError(t, err)
+	mz2, err := MerklizeJSONLD(ctx, strings.NewReader(validDoc))
+	require.NoError(t, err)
+	require.Equal(t, mz2.Root(), mz.Root())
+
+	// values accepted by default conversion, but not conforming to XSD
+	invalidDoc := fmt.Sprintf(doc, "1.0", "0.0E0", "2001-02-28",
+		"1.5")
+	_, err = MerklizeJSONLD(ctx, strings.NewReader(invalidDoc))
+	require.NoError(t, err)
+
+	_, err = MerklizeJSONLD(ctx, strings.NewReader(invalidDoc),
+		WithStrictDatatypes(true))
+	var literalsErr *InvalidLiteralsError
+	require.ErrorAs(t, err, &literalsErr)
+	var values []string
+	for _, l := range literalsErr.Literals {
+		values = append(values, l.Value)
+	}
+	require.ElementsMatch(t,
+		[]string{"1.0", "0.0E0", "2001-02-28"}, values)
+
+	agePath, err := NewPath("http://example.com/age")
+	require.NoError(t, err)
+	for _, l := range literalsErr.Literals {
+		if l.Value == "1.0" {
+			require.Equal(t, agePath, l.Path)
+			require.Equal(t, "http://www.w3.org/2001/XMLSchema#integer",
+				l.Datatype)
+			require.Contains(t, literalsErr.Error(),
+				`[http://example.com/age]: "1.0" `+
+					`(http://www.w3.org/2001/XMLSchema#integer): `+
+					`invalid integer`)
+		}
+	}
+
+	t.Run("Options.EntriesFromRDF", func(t *testing.T) {
+		var obj map[string]any
+		require.NoError(t, json.Unmarshal([]byte(invalidDoc), &obj))
+		proc := ld.NewJsonLdProcessor()
+		options := ld.NewJsonLdOptions("")
+		options.Algorithm = ld.AlgorithmURDNA2015
+		normDoc, err := proc.Normalize(obj, options)
+		require.NoError(t, err)
+		ds := normDoc.(*ld.RDFDataset)
+
+		entries, err := Options{}.EntriesFromRDF(ds)
+		require.NoError(t, err)
+		require.Len(t, entries, 4)
+
+		_, err = Options{StrictDatatypes: true}.EntriesFromRDF(ds)
+		require.ErrorAs(t, err, &literalsErr)
+		require.Len(t, literalsErr.Literals, 3)
+	})
+}
+
+func TestValidateXSDLexicalForm(t *testing.T) {
+	testCases := []struct {
+		datatype string
+		value    string
+		valid    bool
+	}{
+		{ld.XSDBoolean, "true", true},
+		{ld.XSDBoolean, "0", true},
+		{ld.XSDBoolean, "TRUE", false},
+		{ld.XSDInteger, "-007", true},
+		{ld.XSDInteger, "1e3", false},
+		{ld.XSDInteger, "3/1", false},
+		{ld.XSDInteger, "abc", false},
+		{ld.XSDNS + "decimal", "-.5", true},
+		{ld.XSDNS + "decimal", "1e3", false},
+		{ld.XSDDouble, "-INF", true},
+		{ld.XSDDouble, "NaN", true},
+		{ld.XSDDouble, "1.e-3", true},
+		{ld.XSDDouble, "Infinity", false},
+		{ld.XSDNS + "dateTime", "2024-01-31T23:59:59+02:00", true},
+		{ld.XSDNS + "dateTime", "12024-01-31T23:59:59", true},
+		{ld.XSDNS + "dateTime", "2024-01-31", false},
+		{ld.XSDNS + "dateTime", "2024-13-01T00:00:00Z", false},
+		{ld.XSDNS + "date", "2024-02-29", true},
+		{ld.XSDNS + "date", "2023-02-29", false},
+		{ld.XSDString, "anything", true},
+	}
+	for _, tc := range testCases {
+		err := validateXSDLexicalForm(tc.datatype, tc.value)
+		if tc.valid {
+			require.NoError(t, err, "%v %v", tc.datatype, tc.value)
+		} else {
+			require.Error(t, err, "%v %v", tc.datatype, tc.value)
+		}
+	}
+}
+
+func TestWithNumberPrecision(t *testing.T) {
+	ctx := context.Background()
+	docTpl := `{
+  "@context": {
+    "@vocab": "http://example.com/vocab#",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "documentNumber": {"@type": "xsd:integer"},
+    "score": {"@type": "xsd:double"}
+  },
+  "documentNumber": %v,
+  "score": 1.5
+}`
+
+	value := func(t *testing.T, mz *Merklizer, docPath string) any {
+		path, err := mz.ResolveDocPath(docPath)
+		require.NoError(t, err)
+		entry, err := mz.Entry(path)
+		require.NoError(t, err)
+		return entry.Value()
+	}
+
+	// 2^53 + 1 is not representable as float64
+	lossyDoc := fmt.Sprintf(docTpl, "9007199254740993")
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(lossyDoc))
+	require.NoError(t, err)
+	require.Equal(t, "9007199254740992",
+		value(t, mz, "documentNumber").(*big.Int).String())
+
+	_, err = MerklizeJSONLD(ctx, strings.NewReader(lossyDoc),
+		WithNumberPrecision(NumberPrecisionStrict))
+	require.ErrorIs(t, err, ErrorNumberPrecisionLoss)
+	require.EqualError(t, err, "number loses precision: 9007199254740993")
+
+	_, err = MerklizeJSONLDUntrusted(ctx, strings.NewReader(lossyDoc),
+		UntrustedLimits{}, WithNumberPrecision(NumberPrecisionStrict))
+	require.ErrorIs(t, err, ErrorNumberPrecisionLoss)
+
+	mz, err = MerklizeJSONLD(ctx, strings.NewReader(lossyDoc),
+		WithNumberPrecision(NumberPrecisionExact))
+	require.NoError(t, err)
+	require.Equal(t, "9007199254740993",
+		value(t, mz, "documentNumber").(*big.Int).String())
+	require.Equal(t, "1.5E0", value(t, mz, "score"))
+
+	// the object decoded with UseNumber
+	dec := json.NewDecoder(strings.NewReader(lossyDoc))
+	dec.UseNumber()
+	var obj map[string]any
+	require.NoError(t, dec.Decode(&obj))
+	mzObj, err := MerklizeJSONLDObject(ctx, obj,
+		WithNumberPrecision(NumberPrecisionExact))
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), mzObj.Root())
+	require.Equal(t, json.Number("9007199254740993"), obj["documentNumber"])
+
+	// numbers without precision loss are merklized the same in all modes
+	exactDoc := fmt.Sprintf(docTpl, "9007199254740992")
+	var roots []string
+	for _, mode := range []NumberPrecision{NumberPrecisionLossy,
+		NumberPrecisionStrict, NumberPrecisionExact} {
+
+		mz, err := MerklizeJSONLD(ctx, strings.NewReader(exactDoc),
+			WithNumberPrecision(mode))
+		require.NoError(t, err)
+		roots = append(roots, mz.Root().Hex())
+	}
+	require.Equal(t, roots[0], roots[1])
+	require.Equal(t, roots[0], roots[2])
+}
+
+func TestNumberLosesPrecision(t *testing.T) {
+	for n, want := range map[string]bool{
+		"1":                      false,
+		"-42":                    false,
+		"1.5":                    false,
+		"1e3":                    false,
+		"9007199254740992":       false,
+		"9007199254740993":       true,
+		"9223372036854775807":    true,
+		"18446744073709551615":   true,
+		"1.00000000000000000001": true,
+		"1e400":                  true,
+	} {
+		_, lossy := numberLosesPrecision(json.Number(n))
+		require.Equal(t, want, lossy, n)
+	}
+}
+
+func TestMerklizer_Stats(t *testing.T) {
+	ctx := context.Background()
+	doc := `{
+  "@context": {
+    "@vocab": "http://example.com/vocab#",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "age": {"@type": "xsd:integer"},
+    "score": {"@type": "xsd:double"},
+    "homepage": {"@type": "@id"}
+  },
+  "name": "Alice",
+  "nickname": "Al",
+  "age": 30,
+  "score": 1.5,
+  "homepage": "http://example.com/alice"
+}`
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc))
+	require.NoError(t, err)
+	require.Equal(t, 5, mz.Len())
+
+	stats, err := mz.Stats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 5, stats.Entries)
+	require.Equal(t, 40, stats.TreeDepth)
+	require.Greater(t, stats.MaxProofDepth, 0)
+	require.LessOrEqual(t, stats.MaxProofDepth, stats.TreeDepth)
+	require.Equal(t, map[string]int{
+		"http://www.w3.org/2001/XMLSchema#string":  2,
+		"http://www.w3.org/2001/XMLSchema#integer": 1,
+		"http://www.w3.org/2001/XMLSchema#double":  1,
+		"": 1,
+	}, stats.Datatypes)
+	require.Equal(t, len(doc), stats.SourceDocumentSize)
+	require.Equal(t, mz.Root(), stats.Root)
+
+	mz, err = MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithoutSourceDocument())
+	require.NoError(t, err)
+	stats, err = mz.Stats(ctx)
+	require.NoError(t, err)
+	require.Zero(t, stats.SourceDocumentSize)
+}
+
+func TestDoubleCanonicalizers(t *testing.T) {
+	// the test vectors are shared with SDKs in other languages
+	vectorsBytes, err := os.ReadFile("testdata/canonical_doubles.json")
+	require.NoError(t, err)
+	var vectors []struct {
+		Value   string `json:"value"`
+		Default string `json:"default"`
+		W3C     string `json:"w3c"`
+	}
+	require.NoError(t, json.Unmarshal(vectorsBytes, &vectors))
+
+	for _, v := range vectors {
+		t.Run(v.Value, func(t *testing.T) {
+			f, err := strconv.ParseFloat(v.Value, 64)
+			require.NoError(t, err)
+			require.Equal(t, v.Default,
+				DefaultDoubleCanonicalizer.CanonicalDouble(f))
+			require.Equal(t, v.W3C, W3CDoubleCanonicalizer.CanonicalDouble(f))
+		})
+	}
+}
+
+func TestWithDoubleCanonicalizer(t *testing.T) {
+	ctx := context.Background()
+	doc := `{
+  "@context": {
+    "@vocab": "http://example.com/vocab#",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "score": {"@type": "xsd:double"}
+  },
+  "score": "5e-324"
+}`
+
+	mzDefault, err := MerklizeJSONLD(ctx, strings.NewReader(doc))
+	require.NoError(t, err)
+	mzW3C, err := MerklizeJSONLD(ctx, strings.NewReader(doc),
+		WithDoubleCanonicalizer(W3CDoubleCanonicalizer))
+	require.NoError(t, err)
+	require.NotEqual(t, mzDefault.Root(), mzW3C.Root())
+
+	path, err := mzW3C.ResolveDocPath("score")
+	require.NoError(t, err)
+	entry, err := mzW3C.Entry(path)
+	require.NoError(t, err)
+	require.Equal(t, "5.0E-324", entry.Value())
+
+	// verifiers hash the value with the same canonicalizer
+	_, value, err := mzW3C.Proof(ctx, path)
+	require.NoError(t, err)
+	valueHash, err := value.MtEntry()
+	require.NoError(t, err)
+	h, err := mzW3C.Options().HashValue(ld.XSDDouble, 5e-324)
+	require.NoError(t, err)
+	require.Equal(t, valueHash, h)
+
+	h, err = HashValue(ld.XSDDouble, 5e-324)
+	require.NoError(t, err)
+	require.NotEqual(t, valueHash, h)
+}
+
+func TestCanonicalLiteral(t *testing.T) {
+	testCases := []struct {
+		datatype string
+		lexical  string
+		want     string
+		wantErr  string
+	}{
+		{datatype: ld.XSDBoolean, lexical: "1", want: "true"},
+		{datatype: ld.XSDBoolean, lexical: "0.0E0", want: "false"},
+		{datatype: ld.XSDBoolean, lexical: "yes",
+			wantErr: "incorrect boolean value"},
+		{datatype: ld.XSDInteger, lexical: "+0042", want: "42"},
+		{datatype: ld.XSDNS + "negativeInteger", lexical: "-1", want: "-1"},
+		{datatype: ld.XSDNS + "positiveInteger", lexical: "-1",
+			wantErr: "integer is below minimum value: -1"},
+		{datatype: ld.XSDNS + "dateTime", lexical: "1958-07-18",
+			want: "1958-07-18T00:00:00Z"},
+		{datatype: ld.XSDNS + "dateTime",
+			lexical: "2023-01-02T03:04:05.123+02:00",
+			want:    "2023-01-02T01:04:05.123Z"},
+		{datatype: ld.XSDDouble, lexical: "1.50", want: "1.5E0"},
+		{datatype: ld.XSDString, lexical: " abc ", want: " abc "},
+		{datatype: "", lexical: "abc", want: "abc"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.datatype+" "+tc.lexical, func(t *testing.T) {
+			got, err := CanonicalLiteral(tc.datatype, tc.lexical)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	// doubles are hashed as strings of the canonical form
+	want, err := PoseidonHasher{}.HashBytes([]byte("1.5E0"))
+	require.NoError(t, err)
+	got, err := HashValue(ld.XSDDouble, "1.50")
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	opts := Options{DoubleCanonicalizer: W3CDoubleCanonicalizer}
+	lit, err := opts.CanonicalLiteral(ld.XSDDouble, "Infinity")
+	require.NoError(t, err)
+	require.Equal(t, "INF", lit)
+}
+
+func TestWithTimeNormalization(t *testing.T) {
+	const doc = `{
+  "@context": {
+    "@vocab": "http://example.com/",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "born": {"@type": "xsd:dateTime"}
+  },
+  "born": "%v"
+}`
+	ctx := context.Background()
+	minusOne := new(big.Int).Sub(defaultHasher.Prime(), big.NewInt(1))
+
+	testCases := []struct {
+		lexical string
+		utc     string
+		entry   *big.Int
+	}{
+		{"2000-01-01T00:00:00Z", "2000-01-01T00:00:00Z",
+			big.NewInt(946684800000000000)},
+		{"2000-01-01T02:00:00+02:00", "2000-01-01T00:00:00Z",
+			big.NewInt(946684800000000000)},
+		{"1999-12-31T14:00:00-10:00", "2000-01-01T00:00:00Z",
+			big.NewInt(946684800000000000)},
+		// leap day with the largest offset, the date changes
+		{"2024-02-29T23:59:59.999999999+14:00",
+			"2024-02-29T09:59:59.999999999Z",
+			big.NewInt(1709200799999999999)},
+		// non-hour offset on the day of the leap second
+		{"2023-06-30T23:59:59.5+05:45", "2023-06-30T18:14:59.5Z",
+			big.NewInt(1688148899500000000)},
+		// the instant before the epoch is negative
+		{"1969-12-31T23:59:59.999999999-00:00",
+			"1969-12-31T23:59:59.999999999Z", minusOne},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.lexical, func(t *testing.T) {
+			docStr := fmt.Sprintf(doc, tc.lexical)
+			path, err := NewPath("http://example.com/born")
+			require.NoError(t, err)
+
+			mzPreserve, err := MerklizeJSONLD(ctx, strings.NewReader(docStr))
+			require.NoError(t, err)
+			mzUTC, err := MerklizeJSONLD(ctx, strings.NewReader(docStr),
+				WithTimeNormalization(TimeNormalizationUTC))
+			require.NoError(t, err)
+			require.Equal(t, mzPreserve.Root(), mzUTC.Root())
+
+			_, value, err := mzPreserve.Proof(ctx, path)
+			require.NoError(t, err)
+			tm, err := value.AsTime()
+			require.NoError(t, err)
+			wantTime, err := time.Parse(time.RFC3339Nano, tc.lexical)
+			require.NoError(t, err)
+			require.Equal(t, wantTime, tm)
+			canonical, err := value.AsCanonicalTime()
+			require.NoError(t, err)
+			require.Equal(t, tc.utc, canonical.Format(time.RFC3339Nano))
+			entry, err := value.MtEntry()
+			require.NoError(t, err)
+			require.Equal(t, tc.entry, entry)
+
+			_, value, err = mzUTC.Proof(ctx, path)
+			require.NoError(t, err)
+			tm, err = value.AsTime()
+			require.NoError(t, err)
+			require.Equal(t, tc.utc, tm.Format(time.RFC3339Nano))
+
+			canonicalLiteral, err := CanonicalLiteral(
+				"http://www.w3.org/2001/XMLSchema#dateTime", tc.lexical)
+			require.NoError(t, err)
+			require.Equal(t, tc.utc, canonicalLiteral)
+
+			mzRequireUTC, err := MerklizeJSONLD(ctx,
+				strings.NewReader(docStr),
+				WithTimeNormalization(TimeNormalizationRequireUTC))
+			if tc.lexical == tc.utc ||
+				strings.HasSuffix(tc.lexical, "-00:00") {
+				require.NoError(t, err)
+				require.Equal(t, mzPreserve.Root(), mzRequireUTC.Root())
+			} else {
+				require.ErrorIs(t, err, ErrorNonUTCTime)
+			}
+		})
+	}
+
+	// leap seconds are not supported by time.Time
+	_, err := MerklizeJSONLD(ctx,
+		strings.NewReader(fmt.Sprintf(doc, "2016-12-31T23:59:60Z")))
+	require.ErrorContains(t, err, "second out of range")
+
+	// offsets of entries created from options are normalized the same way
+	path, err := NewPath("http://example.com/born")
+	require.NoError(t, err)
+	tm := time.Date(2000, 1, 1, 2, 0, 0, 0, time.FixedZone("", 2*3600))
+	e, err := Options{TimeNormalization: TimeNormalizationUTC}.NewRDFEntry(
+		path, tm)
+	require.NoError(t, err)
+	require.Equal(t, tm.UTC(), e.Value())
+	_, err = Options{TimeNormalization: TimeNormalizationRequireUTC}.
+		NewRDFEntry(path, tm)
+	require.ErrorIs(t, err, ErrorNonUTCTime)
+}
+
+func TestWithExcludedPaths(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	expirationPath, err := NewPath(
+		"https://www.w3.org/2018/credentials#expirationDate")
+	require.NoError(t, err)
+	subjectPath, err := NewPath(
+		"https://www.w3.org/2018/credentials#credentialSubject", 1)
+	require.NoError(t, err)
+	birthDatePath, err := NewPath(
+		"https://www.w3.org/2018/credentials#credentialSubject", 1,
+		"http://schema.org/birthDate")
+	require.NoError(t, err)
+	otherBirthDatePath, err := NewPath(
+		"https://www.w3.org/2018/credentials#credentialSubject", 0,
+		"http://schema.org/birthDate")
+	require.NoError(t, err)
+
+	mzFull, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+
+	opts := []MerklizeOption{WithExcludedPaths(expirationPath, subjectPath)}
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument), opts...)
+	require.NoError(t, err)
+	require.NotEqual(t, mzFull.Root(), mz.Root())
+	// expirationDate and 13 entries of the second subject
+	require.Equal(t, mzFull.Len()-14, mz.Len())
+
+	p, _, err := mz.Proof(ctx, birthDatePath)
+	require.NoError(t, err)
+	require.False(t, p.Existence)
+	_, err = mz.Entry(expirationPath)
+	require.ErrorIs(t, err, ErrorEntryNotFound)
+	p, _, err = mz.Proof(ctx, otherBirthDatePath)
+	require.NoError(t, err)
+	require.True(t, p.Existence)
+
+	// the raw value is still available from the source document
+	rv, err := mz.RawValue(expirationPath)
+	require.NoError(t, err)
+	require.Equal(t, "2029-12-03T12:19:52Z", rv)
+
+	// the root does not depend on the values of excluded properties
+	doc2 := strings.Replace(testDocument, "2029-12-03T12:19:52Z",
+		"2030-01-01T00:00:00Z", 1)
+	mz2, err := MerklizeJSONLD(ctx, strings.NewReader(doc2), opts...)
+	require.NoError(t, err)
+	require.Equal(t, mz.Root(), mz2.Root())
+}
+
+func TestEstimateMerklizationCost(t *testing.T) {
+	defer tst.MockHTTPClient(t, testDocumentURLMaps, tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	cost, err := EstimateMerklizationCost(ctx, []byte(testDocument))
+	require.NoError(t, err)
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(testDocument))
+	require.NoError(t, err)
+
+	require.Equal(t, len(testDocument), cost.DocumentSize)
+	require.Equal(t, mz.Len(), cost.Entries)
+	// subjects have IDs, so there are no quads with blank node objects
+	require.Equal(t, cost.Entries, cost.Quads)
+	require.Equal(t, cost.Entries, cost.TreeInsertions)
+	require.Greater(t, cost.HashOperations, 2*cost.Entries)
+
+	cost100, err := EstimateMerklizationCost(ctx, subjectsDocument(100))
+	require.NoError(t, err)
+	cost1000, err := EstimateMerklizationCost(ctx, subjectsDocument(1000))
+	require.NoError(t, err)
+	// subjects without IDs are blank nodes and are not entries
+	require.Equal(t, cost100.Entries+100, cost100.Quads)
+	require.Equal(t, 10*cost100.Entries, cost1000.Entries)
+	require.Greater(t, cost1000.HashOperations, 10*cost100.HashOperations)
+
+	_, err = EstimateMerklizationCost(ctx, []byte(`[`))
+	require.Error(t, err)
+}
+
+// subjectsDocument returns the document with the number of subjects with
+// three properties each
+func subjectsDocument(n int) []byte {
+	subjects := make([]any, n)
+	for i := range subjects {
+		subjects[i] = map[string]any{
+			"name":  fmt.Sprintf("subject %v", i),
+			"index": i,
+			"adult": i%2 == 0,
+		}
+	}
+	doc := map[string]any{
+		"@context": map[string]any{
+			"@vocab": "http://example.com/",
+			"xsd":    "http://www.w3.org/2001/XMLSchema#",
+			"index":  map[string]any{"@type": "xsd:integer"},
+			"adult":  map[string]any{"@type": "xsd:boolean"},
+		},
+		"@id":     "http://example.com/document",
+		"subject": subjects,
+	}
+	docBytes, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	return docBytes
+}
+
+func BenchmarkMerklizeJSONLD_Subjects(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range []int{10, 100, 1000} {
+		doc := subjectsDocument(n)
+		b.Run(fmt.Sprintf("subjects_%v", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := MerklizeJSONLD(ctx, bytes.NewReader(doc))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEstimateMerklizationCost(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range []int{10, 100, 1000} {
+		doc := subjectsDocument(n)
+		b.Run(fmt.Sprintf("subjects_%v", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := EstimateMerklizationCost(ctx, doc)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestValue_Format(t *testing.T) {
+	const doc = `{
+  "@context": {
+    "@vocab": "http://example.com/",
+    "xsd": "http://www.w3.org/2001/XMLSchema#",
+    "birthday": {"@type": "xsd:integer"},
+    "born": {"@type": "xsd:dateTime"},
+    "issued": {"@type": "xsd:dateTime"},
+    "height": {"@type": "xsd:double"},
+    "adult": {"@type": "xsd:boolean"}
+  },
+  "birthday": 19960424,
+  "born": "1996-04-24",
+  "issued": "2024-03-01T10:20:30+02:00",
+  "height": "1234.50",
+  "adult": true,
+  "balance": -1234567,
+  "city": {"@value": "Kyiv", "@language": "en"}
+}`
+	ctx := context.Background()
+	mz, err := MerklizeJSONLD(ctx, strings.NewReader(doc))
+	require.NoError(t, err)
+
+	format := func(field, locale string, hints ...UnitHint) string {
+		path, err := NewPath("http://example.com/" + field)
+		require.NoError(t, err)
+		_, value, err := mz.Proof(ctx, path)
+		require.NoError(t, err)
+		s, err := value.Format(locale, hints...)
+		require.NoError(t, err)
+		return s
+	}
+
+	require.Equal(t, "19,960,424", format("birthday", "en-US"))
+	require.Equal(t, "Apr 24, 1996", format("birthday", "en-US",
+		UnitHintDate))
+	require.Equal(t, "24.04.1996", format("birthday", "uk_UA",
+		UnitHintDate))
+	require.Equal(t, "Apr 24, 1996", format("born", "en"))
+	require.Equal(t, "1996-04-24", format("born", ""))
+	require.Equal(t, "01.03.2024 10:20:30 +02:00", format("issued", "de-DE"))
+	require.Equal(t, "2024-03-01T10:20:30+02:00", format("issued", "xx"))
+	require.Equal(t, "1.234,5 cm", format("height", "de", "cm"))
+	require.Equal(t, "1234.5", format("height", ""))
+	require.Equal(t, "Yes", format("adult", "en-GB"))
+	require.Equal(t, "true", format("adult", ""))
+	require.Equal(t, "-1 234 567 EUR", format("balance", "fr", "EUR"))
+	require.Equal(t, "Kyiv", format("city", "uk", "km"))
+
+	value, err := NewValue(defaultHasher, int64(1709288430))
+	require.NoError(t, err)
+	s, err := value.Format("en", UnitHintUnixTime)
+	require.NoError(t, err)
+	require.Equal(t, "Mar 1, 2024 10:20:30 Z", s)
+
+	value, err = NewValue(defaultHasher, int64(19960431))
+	require.NoError(t, err)
+	_, err = value.Format("en", UnitHintDate)
+	require.EqualError(t, err, "invalid YYYYMMDD date: 19960431")
+
+	value, err = NewValue(defaultHasher, "abc")
+	require.NoError(t, err)
+	_, err = value.Format("en", UnitHintUnixTime)
+	require.EqualError(t, err,
+		"unixtime hint is not applicable to string value")
+}
+
+func TestRDFEntry_DisplayMetadata(t *testing.T) {
+	q := ld.NewQuad(ld.NewIRI("http://example.com/alice"),
+		ld.NewIRI("http://example.com/city"),
+		ld.NewLiteral("Kyiv", ld.RDFLangString, "en"), "")
+	e, err := NewRDFEntryFromQuad(Path{}, q)
+	require.NoError(t, err)
+	require.Equal(t, "Kyiv", e.LexicalForm())
+	require.Equal(t, "en", e.Language())
+	require.Equal(t, ld.RDFLangString, e.Datatype())
+
+	q = ld.NewQuad(ld.NewIRI("http://example.com/alice"),
+		ld.NewIRI("http://example.com/height"),
+		ld.NewLiteral("1234.50", ld.XSDDouble, ""), "")
+	e2, err := NewRDFEntryFromQuad(Path{}, q)
+	require.NoError(t, err)
+	require.Equal(t, "1234.50", e2.LexicalForm())
+	require.Empty(t, e2.Language())
+
+	for _, ent := range []RDFEntry{e, e2} {
+		entJSON, err := json.Marshal(ent)
+		require.NoError(t, err)
+		var ent2 RDFEntry
+		require.NoError(t, json.Unmarshal(entJSON, &ent2))
+		require.Equal(t, ent.LexicalForm(), ent2.LexicalForm())
+		require.Equal(t, ent.Language(), ent2.Language())
+
+		entBytes, err := ent.MarshalBinary()
+		require.NoError(t, err)
+		var ent3 RDFEntry
+		require.NoError(t, ent3.UnmarshalBinary(entBytes))
+		require.Equal(t, ent, ent3)
+	}
+
+	e3, err := NewRDFEntry(e.Key(), "Kyiv")
+	require.NoError(t, err)
+	require.Empty(t, e3.LexicalForm())
+}
+
+func TestMerklizeJSONLD_QuadError(t *testing.T) {
+	doc := `{
+  "@context": {
+    "@vocab": "http://example.com/",
+    "age": {"@type": "http://www.w3.org/2001/XMLSchema#integer"}
+  },
+  "name": "x",
+  "items": [{"age": "12"}, {"age": "abc"}]
+}`
+	_, err := MerklizeJSONLD(context.Background(), strings.NewReader(doc),
+		WithDocumentLoader(noRemoteDocumentLoader{}))
+	var quadErr *QuadError
+	require.ErrorAs(t, err, &quadErr)
+	require.Equal(t, "@default", quadErr.Graph)
+	require.Equal(t, `"abc"^^<http://www.w3.org/2001/XMLSchema#integer>`,
+		nodeString(quadErr.Quad.Object))
+	require.Equal(t, []any{"http://example.com/items", 1,
+		"http://example.com/age"}, quadErr.Path.Parts())
+	require.EqualError(t, err, fmt.Sprintf("quad #%v of graph @default at "+
+		"[http://example.com/items / 1 / http://example.com/age] (%v "+
+		"<http://example.com/age> "+
+		`"abc"^^<http://www.w3.org/2001/XMLSchema#integer>): `+
+		"can't parse number: abc", quadErr.Index,
+		nodeString(quadErr.Quad.Subject)))
+}
+
+// retainingHasher keeps the inputs of Hash
+type retainingHasher struct {
+	md5Hasher
+	inputs [][]*big.Int
+}
+
+func (h *retainingHasher) Hash(inpBI []*big.Int) (*big.Int, error) {
+	h.inputs = append(h.inputs, inpBI)
+	return h.md5Hasher.Hash(inpBI)
+}
+
+func TestHashScratch_CustomHasherRetainsInputs(t *testing.T) {
+	h := &retainingHasher{}
+	opts := Options{Hasher: h}
+	path, err := opts.NewPath("http://schema.org/items", 1)
+	require.NoError(t, err)
+	_, err = path.MtEntry()
+	require.NoError(t, err)
+	_, err = mkValueMtEntry(h, true)
+	require.NoError(t, err)
+
+	path2, err := opts.NewPath("http://schema.org/items", 7)
+	require.NoError(t, err)
+	_, err = path2.MtEntry()
+	require.NoError(t, err)
+	_, err = mkValueMtEntry(h, false)
+	require.NoError(t, err)
+
+	require.Len(t, h.inputs, 4)
+	require.Equal(t, big.NewInt(1), h.inputs[0][1])
+	require.Equal(t, big.NewInt(1), h.inputs[1][0])
+	require.Equal(t, big.NewInt(7), h.inputs[2][1])
+	require.Equal(t, big.NewInt(0), h.inputs[3][0])
+}
@@ -0,0 +1,164 @@
+package merklize
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// NumberPrecision defines how JSON numbers of the source document that
+// can't be represented as float64 without changing their RDF literals are
+// merklized. JSON-LD processing converts numbers to float64, so such
+// numbers (e.g. 64-bit IDs like 12345678901234567891) get merklized with
+// values different from the ones in the document.
+type NumberPrecision uint8
+
+const (
+	// NumberPrecisionLossy merklizes numbers converted to float64 silently.
+	// This is the default mode, it keeps hashes of existing documents.
+	NumberPrecisionLossy NumberPrecision = iota
+	// NumberPrecisionStrict fails the merklization with
+	// ErrorNumberPrecisionLoss if any number would be changed by the
+	// conversion to float64.
+	NumberPrecisionStrict
+	// NumberPrecisionExact merklizes numbers that would be changed by the
+	// conversion to float64 from their exact lexical forms. The number is
+	// passed to JSON-LD processing as a string, so its term must have the
+	// datatype defined by the context (e.g. xsd:integer), otherwise it is
+	// merklized as xsd:string.
+	NumberPrecisionExact
+)
+
+// ErrorNumberPrecisionLoss is returned with NumberPrecisionStrict when a
+// number of the document can't be merklized without loss of precision
+var ErrorNumberPrecisionLoss = errors.New("number loses precision")
+
+// WithNumberPrecision sets the mode of handling numbers of the source
+// document that lose precision when converted to float64. It applies to
+// documents merklized with MerklizeJSONLD, MerklizeJSONLDUntrusted and
+// MerklizeJSONLDObject if the object is decoded with json.Decoder.UseNumber.
+func WithNumberPrecision(mode NumberPrecision) MerklizeOption {
+	return func(m *Merklizer) {
+		m.numberPrecision = mode
+	}
+}
+
+// decodeDocument decodes the source document keeping exact values of numbers
+// if the number precision mode requires them
+func (mz *Merklizer) decodeDocument(srcDoc []byte) (map[string]any,
+	error) {
+
+	var obj map[string]any
+	if mz.numberPrecision == NumberPrecisionLossy {
+		err := json.Unmarshal(srcDoc, &obj)
+		return obj, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(srcDoc))
+	dec.UseNumber()
+	err := dec.Decode(&obj)
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// normalizeNumbers replaces json.Number values of the document according to
+// the number precision mode. Maps and slices with replaced values are
+// copied, the document is not modified. Reports whether anything was
+// replaced.
+func (mz *Merklizer) normalizeNumbers(doc any) (any, bool, error) {
+	switch v := doc.(type) {
+	case json.Number:
+		n, err := mz.normalizeNumber(v)
+		return n, true, err
+	case map[string]any:
+		var out map[string]any
+		for k, v2 := range v {
+			n, changed, err := mz.normalizeNumbers(v2)
+			if err != nil {
+				return nil, false, err
+			}
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]any, len(v))
+				for k2, v3 := range v {
+					out[k2] = v3
+				}
+			}
+			out[k] = n
+		}
+		if out == nil {
+			return v, false, nil
+		}
+		return out, true, nil
+	case []any:
+		var out []any
+		for i, v2 := range v {
+			n, changed, err := mz.normalizeNumbers(v2)
+			if err != nil {
+				return nil, false, err
+			}
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]any(nil), v...)
+			}
+			out[i] = n
+		}
+		if out == nil {
+			return v, false, nil
+		}
+		return out, true, nil
+	default:
+		return doc, false, nil
+	}
+}
+
+func (mz *Merklizer) normalizeNumber(n json.Number) (any, error) {
+	f, lossy := numberLosesPrecision(n)
+	if !lossy {
+		return f, nil
+	}
+
+	switch mz.numberPrecision {
+	case NumberPrecisionStrict:
+		return nil, fmt.Errorf("%w: %v", ErrorNumberPrecisionLoss, n)
+	case NumberPrecisionExact:
+		return n.String(), nil
+	default:
+		return f, nil
+	}
+}
+
+// numberLosesPrecision converts the number to float64 as JSON-LD processing
+// does and reports whether the RDF literal of the float differs from the
+// number: integers must be represented exactly, other numbers must not
+// become integers or overflow.
+func numberLosesPrecision(n json.Number) (float64, bool) {
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return 0, true
+	}
+	f, err := strconv.ParseFloat(n.String(), 64)
+	if err != nil {
+		return f, true
+	}
+
+	if !r.IsInt() {
+		return f, f == math.Trunc(f)
+	}
+
+	// JSON-LD processing writes integer literals as int64
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return f, true
+	}
+	return f, big.NewInt(int64(f)).Cmp(r.Num()) != 0
+}
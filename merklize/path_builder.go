@@ -0,0 +1,143 @@
+package merklize
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// ErrorTermNotDefined is returned by PathBuilder when the term is not
+// defined in the active context
+var ErrorTermNotDefined = errors.New("term is not defined in the context")
+
+// PathBuilder builds the Path step by step from terms of the JSON-LD
+// context, e.g.
+//
+//	path, err := NewPathBuilder(ctxBytes).
+//		Type("KYCAgeCredential").Field("birthday").Build()
+//
+// The IRI of every term is resolved when the step is added, in the context
+// active after the previous steps. The first failed step stops the
+// building, Build returns its error naming the step and the term.
+type PathBuilder struct {
+	ldCtx  *ld.Context
+	hasher Hasher
+	parts  []any
+	steps  int
+	err    error
+}
+
+// NewPathBuilder creates the PathBuilder with the @context of ctxBytes
+func NewPathBuilder(ctxBytes []byte) *PathBuilder {
+	return Options{}.NewPathBuilder(ctxBytes)
+}
+
+// NewPathBuilder creates the PathBuilder with the @context of ctxBytes. The
+// hasher and the document loader of options are used.
+func (o Options) NewPathBuilder(ctxBytes []byte) *PathBuilder {
+	b := &PathBuilder{hasher: o.getHasher()}
+
+	var ctxObj map[string]any
+	err := json.Unmarshal(ctxBytes, &ctxObj)
+	if err != nil {
+		b.err = fmt.Errorf("invalid context: %w", err)
+		return b
+	}
+
+	b.err = callRecover(func() error {
+		var err error
+		b.ldCtx, err = ld.NewContext(nil, o.JSONLDOptions()).
+			Parse(ctxObj["@context"])
+		return err
+	})
+	if b.err != nil {
+		b.err = fmt.Errorf("invalid context: %w", b.err)
+	}
+	return b
+}
+
+// Type applies the type-scoped context of the type term. Like
+// FieldPathFromContext, the type does not add a part to the path.
+func (b *PathBuilder) Type(term string) *PathBuilder {
+	return b.step(fmt.Sprintf("Type(%q)", term), func() error {
+		_, err := b.applyTerm(term)
+		return err
+	})
+}
+
+// Field appends the IRI of the property term and applies its property-scoped
+// context
+func (b *PathBuilder) Field(term string) *PathBuilder {
+	return b.step(fmt.Sprintf("Field(%q)", term), func() error {
+		id, err := b.applyTerm(term)
+		if err != nil {
+			return err
+		}
+		b.parts = append(b.parts, id)
+		return nil
+	})
+}
+
+// Index appends the index of the array element
+func (b *PathBuilder) Index(i int) *PathBuilder {
+	return b.step(fmt.Sprintf("Index(%d)", i), func() error {
+		if i < 0 {
+			return errors.New("index is negative")
+		}
+		b.parts = append(b.parts, i)
+		return nil
+	})
+}
+
+// Build returns the path or the error of the first failed step
+func (b *PathBuilder) Build() (Path, error) {
+	if b.err != nil {
+		return Path{}, b.err
+	}
+	if len(b.parts) == 0 {
+		return Path{}, errors.New("path is empty")
+	}
+	parts := make([]any, len(b.parts))
+	copy(parts, b.parts)
+	return Path{parts: parts, hasher: b.hasher}, nil
+}
+
+func (b *PathBuilder) step(desc string, fn func() error) *PathBuilder {
+	if b.err != nil {
+		return b
+	}
+	b.steps++
+	err := callRecover(fn)
+	if err != nil {
+		b.err = fmt.Errorf("step %d %v: %w", b.steps, desc, err)
+	}
+	return b
+}
+
+// applyTerm returns the @id of the term and makes its scoped context active
+func (b *PathBuilder) applyTerm(term string) (string, error) {
+	m := b.ldCtx.GetTermDefinition(term)
+	if m == nil {
+		return "", ErrorTermNotDefined
+	}
+	id, ok := m["@id"].(string)
+	if !ok {
+		return "", fmt.Errorf("no @id attribute for term: %v", term)
+	}
+
+	if scopedCtx, ok := m["@context"]; ok {
+		ldCtx, err := b.ldCtx.Parse(scopedCtx)
+		if err != nil {
+			return "", err
+		}
+		b.ldCtx = ldCtx
+	}
+	return id, nil
+}
+
+func callRecover(fn func() error) (err error) {
+	defer recoverPanic(&err)
+	return fn()
+}
@@ -0,0 +1,74 @@
+package merklize
+
+import (
+	"math/big"
+	"sync"
+)
+
+// Pools of scratch values used to build hash inputs. Only PoseidonHasher
+// gets the scratch values, see hashScratch.
+var (
+	bigIntPool = sync.Pool{
+		New: func() any { return new(big.Int) },
+	}
+	bigIntSlicePool = sync.Pool{
+		New: func() any {
+			s := make([]*big.Int, 0, 8)
+			return &s
+		},
+	}
+)
+
+// bigTen9 is the number of nanoseconds in a second
+var bigTen9 = big.NewInt(1_000_000_000)
+
+func getBigInt() *big.Int {
+	return bigIntPool.Get().(*big.Int)
+}
+
+func putBigInt(x *big.Int) {
+	bigIntPool.Put(x)
+}
+
+// getBigIntSlice returns the slice of length n from the pool. Elements are
+// nil.
+func getBigIntSlice(n int) *[]*big.Int {
+	s := bigIntSlicePool.Get().(*[]*big.Int)
+	if cap(*s) < n {
+		*s = make([]*big.Int, n)
+	} else {
+		*s = (*s)[:n]
+	}
+	return s
+}
+
+// putBigIntSlice drops references to the elements and returns the slice to
+// the pool
+func putBigIntSlice(s *[]*big.Int) {
+	for i := range *s {
+		(*s)[i] = nil
+	}
+	*s = (*s)[:0]
+	bigIntSlicePool.Put(s)
+}
+
+// hashScratch hashes the inputs built from the pooled scratch values. They
+// are passed as is to PoseidonHasher, which does not modify or retain them.
+// Other hashers get the copy of the inputs, so they may keep them after Hash
+// returns.
+func hashScratch(h Hasher, inputs []*big.Int) (*big.Int, error) {
+	if ih, ok := h.(integerEncodingHasher); ok {
+		if _, ok = ih.Hasher.(PoseidonHasher); ok {
+			return h.Hash(inputs)
+		}
+	}
+	if _, ok := h.(PoseidonHasher); ok {
+		return h.Hash(inputs)
+	}
+
+	inputsCopy := make([]*big.Int, len(inputs))
+	for i := range inputs {
+		inputsCopy[i] = new(big.Int).Set(inputs[i])
+	}
+	return h.Hash(inputsCopy)
+}
@@ -0,0 +1,80 @@
+package merklize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// QuadError is returned when the entry can't be built from the quad of the
+// RDF dataset, e.g. the literal is invalid for its datatype or the object
+// type is not supported. It locates the quad by the graph and the index of
+// the quad in the graph, and by the path of the entry in the document if
+// the path could be built.
+type QuadError struct {
+	Graph string
+	Index int
+	Quad  *ld.Quad
+	// Path is the path of the entry, it is empty if the path is unknown
+	Path Path
+	Err  error
+}
+
+func (e *QuadError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "quad #%v of graph %v", e.Index, e.Graph)
+	if len(e.Path.parts) != 0 {
+		fmt.Fprintf(&b, " at %v", pathString(e.Path))
+	}
+	if e.Quad != nil {
+		fmt.Fprintf(&b, " (%v)", quadString(e.Quad))
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+func (e *QuadError) Unwrap() error {
+	return e.Err
+}
+
+// pathString formats the path like [a / b / 0]
+func pathString(p Path) string {
+	parts := make([]string, len(p.parts))
+	for i, part := range p.parts {
+		parts[i] = fmt.Sprintf("%v", part)
+	}
+	return fmt.Sprintf("[%v]", strings.Join(parts, " / "))
+}
+
+// quadString formats the subject, the predicate and the object of the quad
+// like N-Quads
+func quadString(q *ld.Quad) string {
+	return fmt.Sprintf("%v %v %v", nodeString(q.Subject),
+		nodeString(q.Predicate), nodeString(q.Object))
+}
+
+func nodeString(n ld.Node) string {
+	switch v := n.(type) {
+	case *ld.IRI:
+		if v == nil {
+			return "<nil>"
+		}
+		return "<" + v.Value + ">"
+	case *ld.BlankNode:
+		if v == nil {
+			return "<nil>"
+		}
+		return v.Attribute
+	case *ld.Literal:
+		if v == nil {
+			return "<nil>"
+		}
+		if v.Language != "" {
+			return fmt.Sprintf("%q@%v", v.Value, v.Language)
+		}
+		return fmt.Sprintf("%q^^<%v>", v.Value, v.Datatype)
+	default:
+		return fmt.Sprintf("%T", n)
+	}
+}
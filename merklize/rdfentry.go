@@ -1,8 +1,15 @@
 package merklize
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"math/big"
+	"strconv"
 	"time"
+
+	"github.com/piprate/json-gold/ld"
 )
 
 type rdfEntryValueType interface {
@@ -21,6 +28,10 @@ type RDFEntry struct {
 	// valid types are: int64, string, bool, time.Time, *big.Int
 	value    any
 	datatype string
+	// lexical form and language tag of the RDF literal the value is
+	// converted from
+	lexical  string
+	language string
 	hasher   Hasher
 }
 
@@ -28,6 +39,96 @@ func NewRDFEntry(key Path, value any) (RDFEntry, error) {
 	return Options{}.NewRDFEntry(key, value)
 }
 
+// NewRDFEntryFromQuad creates RDFEntry from the quad with literal or IRI
+// object. The quad alone does not contain the position of its subject in the
+// document, so the key path should be provided by the caller. If the key is
+// empty, the subject is considered a top-level node and the key consists of
+// the quad's predicate only.
+func NewRDFEntryFromQuad(key Path, q *ld.Quad) (RDFEntry, error) {
+	return Options{}.NewRDFEntryFromQuad(key, q)
+}
+
+func (o Options) NewRDFEntryFromQuad(key Path, q *ld.Quad) (RDFEntry, error) {
+	if q == nil {
+		return RDFEntry{}, errors.New("quad is nil")
+	}
+
+	e := RDFEntry{key: key, hasher: o.getHasher()}
+	if len(e.key.parts) == 0 {
+		predicate, err := getIriValue(q.Predicate)
+		if err != nil {
+			return RDFEntry{}, err
+		}
+		e.key = Path{parts: []interface{}{predicate.Value},
+			hasher: o.getHasher()}
+	}
+
+	var err error
+	switch qo := q.Object.(type) {
+	case *ld.Literal:
+		if qo == nil {
+			return RDFEntry{}, errors.New("object Literal is nil")
+		}
+		e.value, err = convertStringToXSDValue(qo.Datatype, qo.Value,
+			e.getHasher().Prime(), o.DoubleCanonicalizer)
+		if err != nil {
+			return RDFEntry{}, err
+		}
+		e.value, err = o.TimeNormalization.normalizeValue(e.value)
+		if err != nil {
+			return RDFEntry{}, err
+		}
+		e.datatype = qo.Datatype
+		e.lexical = qo.Value
+		e.language = qo.Language
+	case *ld.IRI:
+		if qo == nil {
+			return RDFEntry{}, errors.New("object IRI is nil")
+		}
+		e.value = qo.GetValue()
+	default:
+		return RDFEntry{}, fmt.Errorf("unsupported Quad's Object type: %T",
+			q.Object)
+	}
+
+	return e, nil
+}
+
+// Key returns the path of the entry
+func (e RDFEntry) Key() Path {
+	return e.key
+}
+
+// Value returns the value of the entry. Valid types are: int64, string,
+// bool, time.Time, *big.Int.
+func (e RDFEntry) Value() any {
+	if bi, ok := e.value.(*big.Int); ok {
+		return new(big.Int).Set(bi)
+	}
+	return e.value
+}
+
+// Datatype returns the XSD datatype of the value if the entry was created
+// from an RDF literal. It is empty for IRIs and entries created with
+// NewRDFEntry.
+func (e RDFEntry) Datatype() string {
+	return e.datatype
+}
+
+// LexicalForm returns the original lexical form of the RDF literal as it
+// was in the document, e.g. "1996-04-24" or "1.50", before conversion to
+// the value. It is empty for IRIs and entries created with NewRDFEntry.
+func (e RDFEntry) LexicalForm() string {
+	return e.lexical
+}
+
+// Language returns the language tag of the RDF literal, e.g. "en" for
+// {"@value": "Kyiv", "@language": "en"}. It is empty for literals without
+// language.
+func (e RDFEntry) Language() string {
+	return e.language
+}
+
 func (e RDFEntry) KeyMtEntry() (*big.Int, error) {
 	return e.key.MtEntry()
 }
@@ -59,3 +160,114 @@ func (e RDFEntry) getHasher() Hasher {
 	}
 	return h
 }
+
+const (
+	rdfEntryValueInt64  = "int64"
+	rdfEntryValueString = "string"
+	rdfEntryValueBool   = "bool"
+	rdfEntryValueTime   = "time"
+	rdfEntryValueBigInt = "bigint"
+)
+
+type rdfEntryJSON struct {
+	Key       []interface{} `json:"key"`
+	Value     string        `json:"value"`
+	ValueType string        `json:"valueType"`
+	Datatype  string        `json:"datatype,omitempty"`
+	Lexical   string        `json:"lexicalForm,omitempty"`
+	Language  string        `json:"language,omitempty"`
+}
+
+// MarshalJSON encodes the entry as an object with key parts, value as a
+// string, value type, datatype, lexical form and language. The hasher is not
+// encoded.
+func (e RDFEntry) MarshalJSON() ([]byte, error) {
+	j := rdfEntryJSON{Key: e.key.parts, Datatype: e.datatype,
+		Lexical: e.lexical, Language: e.language}
+	if j.Key == nil {
+		j.Key = []interface{}{}
+	}
+
+	switch v := e.value.(type) {
+	case int64:
+		j.ValueType = rdfEntryValueInt64
+		j.Value = strconv.FormatInt(v, 10)
+	case string:
+		j.ValueType = rdfEntryValueString
+		j.Value = v
+	case bool:
+		j.ValueType = rdfEntryValueBool
+		j.Value = strconv.FormatBool(v)
+	case time.Time:
+		j.ValueType = rdfEntryValueTime
+		j.Value = v.Format(time.RFC3339Nano)
+	case *big.Int:
+		j.ValueType = rdfEntryValueBigInt
+		j.Value = v.String()
+	default:
+		return nil, fmt.Errorf("unexpected value type: %T", e.value)
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes the entry encoded with MarshalJSON. The default
+// hasher is used for the decoded entry.
+func (e *RDFEntry) UnmarshalJSON(in []byte) error {
+	var j rdfEntryJSON
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.UseNumber()
+	err := dec.Decode(&j)
+	if err != nil {
+		return err
+	}
+
+	parts := make([]interface{}, 0, len(j.Key))
+	for _, p := range j.Key {
+		switch pt := p.(type) {
+		case string:
+			parts = append(parts, pt)
+		case json.Number:
+			var i int64
+			i, err = pt.Int64()
+			if err != nil {
+				return fmt.Errorf("invalid key index: %w", err)
+			}
+			parts = append(parts, int(i))
+		default:
+			return fmt.Errorf("unexpected key part type: %T", p)
+		}
+	}
+
+	var value any
+	switch j.ValueType {
+	case rdfEntryValueInt64:
+		value, err = strconv.ParseInt(j.Value, 10, 64)
+	case rdfEntryValueString:
+		value = j.Value
+	case rdfEntryValueBool:
+		value, err = strconv.ParseBool(j.Value)
+	case rdfEntryValueTime:
+		value, err = time.Parse(time.RFC3339Nano, j.Value)
+	case rdfEntryValueBigInt:
+		bi, ok := new(big.Int).SetString(j.Value, 10)
+		if !ok {
+			err = fmt.Errorf("invalid big integer value: %v", j.Value)
+		}
+		value = bi
+	default:
+		err = fmt.Errorf("unexpected value type: %v", j.ValueType)
+	}
+	if err != nil {
+		return err
+	}
+
+	*e = RDFEntry{
+		key:      Path{parts: parts},
+		value:    value,
+		datatype: j.Datatype,
+		lexical:  j.Lexical,
+		language: j.Language,
+	}
+	return nil
+}
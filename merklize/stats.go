@@ -0,0 +1,70 @@
+package merklize
+
+import (
+	"context"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+)
+
+// Stats describes the merklized document
+type Stats struct {
+	// Entries is the number of entries in the merkle tree
+	Entries int
+	// MaxProofDepth is the maximum number of siblings of the proofs of
+	// the entries, i.e. the depth of the deepest leaf of the tree
+	MaxProofDepth int
+	// TreeDepth is the maximum depth of the merkle tree. It is zero if the
+	// tree is set with WithMerkleTree and its depth is unknown.
+	TreeDepth int
+	// Datatypes is the number of entries by their XSD datatypes. Entries
+	// without datatype (IRIs) are counted with the empty key.
+	Datatypes map[string]int
+	// SourceDocumentSize is the size in bytes of the JSON encoding of the
+	// source document. It is zero if the source document is not stored.
+	SourceDocumentSize int
+	// Root is the root of the merkle tree
+	Root *merkletree.Hash
+}
+
+// Len returns the number of entries in the merkle tree
+func (mz *Merklizer) Len() int {
+	return len(mz.entries)
+}
+
+// Stats returns the characteristics of the merklized document. Proofs of
+// all entries are generated to find the maximum proof depth, so the call
+// is linear in the number of entries.
+func (mz *Merklizer) Stats(ctx context.Context) (Stats, error) {
+	stats := Stats{
+		Entries:   len(mz.entries),
+		Datatypes: make(map[string]int),
+		Root:      mz.mt.Root(),
+	}
+	if mt, ok := mz.mt.(*mtSQLAdapter); ok {
+		stats.TreeDepth = (*merkletree.MerkleTree)(mt).MaxLevels()
+	}
+
+	for _, e := range mz.entries {
+		stats.Datatypes[e.datatype]++
+
+		key, err := e.KeyMtEntry()
+		if err != nil {
+			return Stats{}, err
+		}
+		proof, err := mz.mt.GenerateProof(ctx, key)
+		if err != nil {
+			return Stats{}, err
+		}
+		if depth := len(proof.AllSiblings()); depth > stats.MaxProofDepth {
+			stats.MaxProofDepth = depth
+		}
+	}
+
+	srcDoc, err := mz.sourceDocument()
+	if err != nil {
+		return Stats{}, err
+	}
+	stats.SourceDocumentSize = len(srcDoc)
+
+	return stats, nil
+}
@@ -0,0 +1,108 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// InvalidLiteral is a literal which value does not conform to its datatype
+type InvalidLiteral struct {
+	Path     Path
+	Value    string
+	Datatype string
+	Err      error
+}
+
+func (l InvalidLiteral) String() string {
+	return fmt.Sprintf("%v: %q (%v): %v", pathString(l.Path), l.Value,
+		l.Datatype, l.Err)
+}
+
+// InvalidLiteralsError is returned in strict datatypes mode (see
+// WithStrictDatatypes) and lists all literals with invalid values in the
+// order of entries
+type InvalidLiteralsError struct {
+	Literals []InvalidLiteral
+}
+
+func (e *InvalidLiteralsError) Error() string {
+	descs := make([]string, len(e.Literals))
+	for i, l := range e.Literals {
+		descs[i] = l.String()
+	}
+	return fmt.Sprintf("invalid literals: %v", strings.Join(descs, "; "))
+}
+
+var (
+	xsdIntegerRE = regexp.MustCompile(`^[+-]?\d+$`)
+	xsdDecimalRE = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	xsdDoubleRE  = regexp.MustCompile(
+		`^([+-]?((\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?|INF)|NaN)$`)
+	xsdDateTimeRE = regexp.MustCompile(
+		`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?` +
+			`(Z|[+-]\d{2}:\d{2})?$`)
+	xsdDateRE = regexp.MustCompile(
+		`^-?\d{4,}-\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`)
+)
+
+// validateXSDLexicalForm checks that the value is in the lexical space of
+// the XSD datatype. Values of other datatypes are not checked.
+func validateXSDLexicalForm(datatype, value string) error {
+	switch datatype {
+	case ld.XSDBoolean:
+		switch value {
+		case "true", "false", "1", "0":
+			return nil
+		}
+		return errors.New("invalid boolean")
+	case ld.XSDInteger,
+		ld.XSDNS + "positiveInteger",
+		ld.XSDNS + "nonNegativeInteger",
+		ld.XSDNS + "negativeInteger",
+		ld.XSDNS + "nonPositiveInteger":
+		if !xsdIntegerRE.MatchString(value) {
+			return errors.New("invalid integer")
+		}
+	case ld.XSDNS + "decimal":
+		if !xsdDecimalRE.MatchString(value) {
+			return errors.New("invalid decimal")
+		}
+	case ld.XSDDouble, ld.XSDNS + "float":
+		if !xsdDoubleRE.MatchString(value) {
+			return errors.New("invalid floating point number")
+		}
+	case ld.XSDNS + "dateTime":
+		if !xsdDateTimeRE.MatchString(value) {
+			return errors.New("invalid dateTime")
+		}
+		return validateDatePart(value)
+	case ld.XSDNS + "date":
+		if !xsdDateRE.MatchString(value) {
+			return errors.New("invalid date")
+		}
+		return validateDatePart(value)
+	}
+	return nil
+}
+
+// validateDatePart checks the month and the day of the date matched by
+// xsdDateRE or xsdDateTimeRE
+func validateDatePart(value string) error {
+	date := strings.TrimPrefix(value, "-")
+	date = date[:strings.IndexByte(date, '-')+6]
+	if len(date) > len("2006-01-02") {
+		// time.Parse does not support years with more than 4 digits, check
+		// the month and the day in a leap year
+		date = "2000" + date[len(date)-6:]
+	}
+	_, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return errors.New("invalid date")
+	}
+	return nil
+}
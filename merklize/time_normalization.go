@@ -0,0 +1,64 @@
+package merklize
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeNormalization defines how offsets of xsd:dateTime values are handled.
+// The merkle tree value of the time is always the number of nanoseconds
+// since the Unix epoch of its instant (see CanonicalLiteral), so
+// "2000-01-01T02:00:00+02:00" and "2000-01-01T00:00:00Z" have equal hashes
+// regardless of the normalization. The normalization changes the time
+// values returned by Value.AsTime and whether non-UTC offsets are accepted.
+// RawValue always returns the value of the source document as is.
+type TimeNormalization uint8
+
+const (
+	// TimeNormalizationPreserve keeps offsets of times in values. This is
+	// the default.
+	TimeNormalizationPreserve TimeNormalization = iota
+	// TimeNormalizationUTC converts times to UTC, so values of entries
+	// compare equal with time.Time == for equal instants.
+	TimeNormalizationUTC
+	// TimeNormalizationRequireUTC fails on times with non-UTC offsets with
+	// ErrorNonUTCTime. Offsets "Z", "+00:00" and "-00:00" are accepted.
+	TimeNormalizationRequireUTC
+)
+
+// ErrorNonUTCTime is returned with TimeNormalizationRequireUTC for
+// xsd:dateTime values with non-UTC offsets
+var ErrorNonUTCTime = errors.New("time offset is not UTC")
+
+// WithTimeNormalization sets the normalization of xsd:dateTime values of
+// entries
+func WithTimeNormalization(n TimeNormalization) MerklizeOption {
+	return func(m *Merklizer) {
+		m.timeNormalization = n
+	}
+}
+
+// normalizeValue applies the normalization to time values, other values are
+// returned as is
+func (n TimeNormalization) normalizeValue(v any) (any, error) {
+	tm, ok := v.(time.Time)
+	if !ok {
+		return v, nil
+	}
+
+	switch n {
+	case TimeNormalizationPreserve:
+		return tm, nil
+	case TimeNormalizationUTC:
+		return tm.UTC(), nil
+	case TimeNormalizationRequireUTC:
+		if _, offset := tm.Zone(); offset != 0 {
+			return nil, fmt.Errorf("%w: %v", ErrorNonUTCTime,
+				tm.Format(time.RFC3339Nano))
+		}
+		return tm.UTC(), nil
+	default:
+		return nil, fmt.Errorf("unsupported time normalization: %v", n)
+	}
+}
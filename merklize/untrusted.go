@@ -0,0 +1,189 @@
+package merklize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrorLimitExceeded is returned by MerklizeJSONLDUntrusted when the
+	// document exceeds one of UntrustedLimits
+	ErrorLimitExceeded = errors.New("document exceeds limits")
+	// ErrorPanicRecovered is returned when processing of the document caused
+	// a panic that was recovered
+	ErrorPanicRecovered = errors.New("panic recovered")
+)
+
+// maxIntegerLength is the maximum length of the lexical representation of
+// an integer literal. The field element has less than 80 decimal digits,
+// longer strings are rejected before parsing.
+const maxIntegerLength = 256
+
+// UntrustedLimits are the limits applied to documents received from third
+// parties. Zero value of any field means no limit.
+type UntrustedLimits struct {
+	// MaxDocumentSize is the maximum size of the document in bytes
+	MaxDocumentSize int
+	// MaxDepth is the maximum nesting level of JSON objects and arrays
+	MaxDepth int
+	// MaxNodes is the maximum total number of JSON values in the document
+	MaxNodes int
+	// MaxStringLength is the maximum length of any JSON string including
+	// object keys
+	MaxStringLength int
+	// MaxEntries is the maximum number of RDF entries (merkle tree leaves)
+	MaxEntries int
+}
+
+// DefaultUntrustedLimits are limits suitable for typical credentials
+var DefaultUntrustedLimits = UntrustedLimits{
+	MaxDocumentSize: 1 << 20,
+	MaxDepth:        32,
+	MaxNodes:        10000,
+	MaxStringLength: 64 << 10,
+	MaxEntries:      1000,
+}
+
+// WithUntrustedLimits sets limits checked during merklization. Only
+// MaxEntries is checked by MerklizeJSONLD and MerklizeJSONLDObject, use
+// MerklizeJSONLDUntrusted to check all limits.
+func WithUntrustedLimits(limits UntrustedLimits) MerklizeOption {
+	return func(m *Merklizer) {
+		m.limits = &limits
+	}
+}
+
+// MerklizeJSONLDUntrusted is the same as MerklizeJSONLD but is intended for
+// documents received from third parties. The document is checked against
+// limits before JSON-LD processing and any panic during processing is
+// returned as an error wrapping ErrorPanicRecovered. Remote contexts are
+// still loaded with the configured document loader, so it is recommended to
+// use a loader with a restricted set of allowed URLs.
+func MerklizeJSONLDUntrusted(ctx context.Context, in io.Reader,
+	limits UntrustedLimits, opts ...MerklizeOption) (mz *Merklizer,
+	err error) {
+
+	defer recoverPanic(&err)
+
+	var srcDoc []byte
+	if limits.MaxDocumentSize > 0 {
+		srcDoc, err = io.ReadAll(io.LimitReader(in,
+			int64(limits.MaxDocumentSize)+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(srcDoc) > limits.MaxDocumentSize {
+			return nil, fmt.Errorf("%w: document size exceeds %v bytes",
+				ErrorLimitExceeded, limits.MaxDocumentSize)
+		}
+	} else {
+		srcDoc, err = io.ReadAll(in)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts = append(opts, WithUntrustedLimits(limits))
+	mz, err = newMerklizer(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := mz.decodeDocument(srcDoc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkDocumentLimits(obj, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, _, err := mz.normalizeNumbers(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if !mz.noSrcDoc {
+		mz.srcDoc = srcDoc
+	}
+
+	err = mz.merklizeObj(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return mz, nil
+}
+
+func checkDocumentLimits(doc any, limits UntrustedLimits) error {
+	var nodes int
+	checkStr := func(s string) error {
+		if limits.MaxStringLength > 0 && len(s) > limits.MaxStringLength {
+			return fmt.Errorf("%w: string length exceeds %v",
+				ErrorLimitExceeded, limits.MaxStringLength)
+		}
+		return nil
+	}
+
+	var walk func(v any, depth int) error
+	walk = func(v any, depth int) error {
+		nodes++
+		if limits.MaxNodes > 0 && nodes > limits.MaxNodes {
+			return fmt.Errorf("%w: number of nodes exceeds %v",
+				ErrorLimitExceeded, limits.MaxNodes)
+		}
+
+		switch vt := v.(type) {
+		case string:
+			return checkStr(vt)
+		case json.Number:
+			return checkStr(vt.String())
+		case []any:
+			if limits.MaxDepth > 0 && depth >= limits.MaxDepth {
+				return fmt.Errorf("%w: depth exceeds %v",
+					ErrorLimitExceeded, limits.MaxDepth)
+			}
+			for _, v2 := range vt {
+				if err := walk(v2, depth+1); err != nil {
+					return err
+				}
+			}
+		case map[string]any:
+			if limits.MaxDepth > 0 && depth >= limits.MaxDepth {
+				return fmt.Errorf("%w: depth exceeds %v",
+					ErrorLimitExceeded, limits.MaxDepth)
+			}
+			for k, v2 := range vt {
+				if err := checkStr(k); err != nil {
+					return err
+				}
+				if err := walk(v2, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return walk(doc, 0)
+}
+
+func (mz *Merklizer) checkEntriesLimit(n int) error {
+	if mz.limits != nil && mz.limits.MaxEntries > 0 &&
+		n > mz.limits.MaxEntries {
+
+		return fmt.Errorf("%w: number of entries exceeds %v",
+			ErrorLimitExceeded, mz.limits.MaxEntries)
+	}
+	return nil
+}
+
+// recoverPanic should be deferred by functions with named error result to
+// convert a panic into ErrorPanicRecovered error
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%w: %v", ErrorPanicRecovered, r)
+	}
+}
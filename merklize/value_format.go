@@ -0,0 +1,206 @@
+package merklize
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// UnitHint tells Value.Format how to interpret or annotate the value.
+// Hints other than the ones defined here are unit symbols appended to
+// numeric values, e.g. "kg" or "EUR".
+type UnitHint string
+
+const (
+	// UnitHintDate formats the integer value encoded as YYYYMMDD, e.g. the
+	// birthday 19960424, as the date
+	UnitHintDate UnitHint = "date"
+	// UnitHintUnixTime formats the integer value as the time of the number
+	// of seconds since the Unix epoch
+	UnitHintUnixTime UnitHint = "unixtime"
+)
+
+type localeFormat struct {
+	groupSep   string
+	decimalSep string
+	dateLayout string
+	timeLayout string
+	trueText   string
+	falseText  string
+}
+
+// neutralLocaleFormat is used for unknown locales. It keeps the values close
+// to their lexical forms.
+var neutralLocaleFormat = localeFormat{
+	decimalSep: ".",
+	dateLayout: "2006-01-02",
+	timeLayout: time.RFC3339Nano,
+	trueText:   "true",
+	falseText:  "false",
+}
+
+// localeFormats are keyed by the language subtag of the locale
+var localeFormats = map[string]localeFormat{
+	"en": {",", ".", "Jan 2, 2006", "Jan 2, 2006 15:04:05 Z07:00", "Yes",
+		"No"},
+	"de": {".", ",", "02.01.2006", "02.01.2006 15:04:05 Z07:00", "Ja",
+		"Nein"},
+	"es": {".", ",", "02/01/2006", "02/01/2006 15:04:05 Z07:00", "Sí",
+		"No"},
+	"fr": {" ", ",", "02/01/2006", "02/01/2006 15:04:05 Z07:00", "Oui",
+		"Non"},
+	"uk": {" ", ",", "02.01.2006", "02.01.2006 15:04:05 Z07:00", "Так",
+		"Ні"},
+}
+
+// localeFormatOf returns the format of the BCP 47 locale, e.g. "en-US" or
+// "uk_UA"
+func localeFormatOf(locale string) localeFormat {
+	lang, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	lf, ok := localeFormats[strings.ToLower(lang)]
+	if !ok {
+		return neutralLocaleFormat
+	}
+	return lf
+}
+
+// Format returns the value formatted for display in the locale, e.g.
+// "en-US". Numbers are grouped by thousands, booleans and dates are
+// localized. Unknown locales get the neutral format: ISO 8601 dates and
+// numbers without grouping. Strings are returned as is.
+func (v *value) Format(locale string, unitHints ...UnitHint) (string,
+	error) {
+
+	lf := localeFormatOf(locale)
+
+	var unit string
+	for _, h := range unitHints {
+		switch h {
+		case UnitHintDate, UnitHintUnixTime:
+			i, ok := v.asInt64()
+			if !ok {
+				return "", fmt.Errorf("%v hint is not applicable to %T value",
+					h, v.value)
+			}
+			if h == UnitHintUnixTime {
+				return time.Unix(i, 0).UTC().Format(lf.timeLayout), nil
+			}
+			tm, err := dateFromInt(i)
+			if err != nil {
+				return "", err
+			}
+			return tm.Format(lf.dateLayout), nil
+		default:
+			unit = string(h)
+		}
+	}
+
+	var s string
+	switch val := v.value.(type) {
+	case time.Time:
+		if v.datatype == ld.XSDNS+"date" || dateRE.MatchString(v.lexical) {
+			return val.Format(lf.dateLayout), nil
+		}
+		return val.Format(lf.timeLayout), nil
+	case bool:
+		if val {
+			return lf.trueText, nil
+		}
+		return lf.falseText, nil
+	case int64:
+		s = groupDigits(strconv.FormatInt(val, 10), lf.groupSep)
+	case *big.Int:
+		s = groupDigits(val.String(), lf.groupSep)
+	case string:
+		if v.datatype != ld.XSDDouble {
+			return val, nil
+		}
+		lexical := v.lexical
+		if lexical == "" {
+			lexical = val
+		}
+		f, err := strconv.ParseFloat(lexical, 64)
+		if err != nil {
+			return val, nil
+		}
+		intPart, fracPart, hasFrac := strings.Cut(
+			strconv.FormatFloat(f, 'f', -1, 64), ".")
+		s = groupDigits(intPart, lf.groupSep)
+		if hasFrac {
+			s += lf.decimalSep + fracPart
+		}
+	default:
+		return "", fmt.Errorf("unexpected value type: %T", v.value)
+	}
+
+	if unit != "" {
+		s += " " + unit
+	}
+	return s, nil
+}
+
+// asInt64 returns the int64 or *big.Int value that fits into int64
+func (v *value) asInt64() (int64, bool) {
+	switch val := v.value.(type) {
+	case int64:
+		return val, true
+	case *big.Int:
+		return val.Int64(), val.IsInt64()
+	default:
+		return 0, false
+	}
+}
+
+// groupDigits inserts sep between groups of three digits of the integer
+// number
+func groupDigits(s, sep string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	if sep == "" || len(s) <= 3 {
+		return sign + s
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(s) % 3
+	if head == 0 {
+		head = 3
+	}
+	b.WriteString(s[:head])
+	for i := head; i < len(s); i += 3 {
+		b.WriteString(sep)
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+// dateFromInt converts the date encoded as YYYYMMDD integer to time
+func dateFromInt(i int64) (time.Time, error) {
+	year, month, day := int(i/10000), time.Month(i/100%100), int(i%100)
+	tm := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if i <= 0 || tm.Year() != year || tm.Month() != month ||
+		tm.Day() != day {
+
+		return time.Time{}, fmt.Errorf("invalid YYYYMMDD date: %v", i)
+	}
+	return tm, nil
+}
+
+// newEntryValue creates the Value of the entry keeping the datatype and the
+// lexical form of the literal for Format
+func newEntryValue(hasher Hasher, e RDFEntry) (Value, error) {
+	v, err := NewValue(hasher, e.value)
+	if err != nil {
+		return nil, err
+	}
+	ev := v.(*value)
+	ev.datatype = e.datatype
+	ev.lexical = e.lexical
+	return ev, nil
+}
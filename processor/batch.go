@@ -0,0 +1,237 @@
+package processor
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"github.com/piprate/json-gold/ld"
+)
+
+// BatchItem is a credential to parse with its core claim options
+type BatchItem struct {
+	Credential verifiable.W3CCredential
+	// Options are copied before parsing, so the same options may be shared
+	// between items
+	Options *CoreClaimOptions
+}
+
+// BatchResult is the result of parsing of one BatchItem
+type BatchResult struct {
+	Claim *core.Claim
+	Err   error
+}
+
+type batchConfig struct {
+	workers int
+}
+
+// BatchOpt is an option for ParseClaimsBatch and ParseClaimsStream
+type BatchOpt func(cfg *batchConfig)
+
+// WithBatchWorkers sets the number of concurrent workers. By default,
+// GOMAXPROCS workers are used.
+func WithBatchWorkers(n int) BatchOpt {
+	return func(cfg *batchConfig) {
+		cfg.workers = n
+	}
+}
+
+func newBatchConfig(opts []BatchOpt) batchConfig {
+	cfg := batchConfig{workers: runtime.GOMAXPROCS(0)}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if cfg.workers < 1 {
+		cfg.workers = 1
+	}
+	return cfg
+}
+
+// batchState is shared between all items of a batch
+type batchState struct {
+	loader      ld.DocumentLoader
+	schemaCache *verifiable.SchemaCache
+}
+
+func (s *Processor) newBatchState() batchState {
+	st := batchState{schemaCache: verifiable.NewSchemaCache()}
+	if s.DocumentLoader != nil {
+		st.loader = newBatchDocumentLoader(s.DocumentLoader)
+	}
+	return st
+}
+
+// ParseClaimsBatch parses core claims of many credentials concurrently.
+// Results are returned in the order of items. Remote documents (schemas and
+// contexts) loaded with the Processor's DocumentLoader are cached for the
+// duration of the batch, so every document is loaded once, and the
+// serialization attributes of credential types are parsed once for all
+// credentials with the same contexts (see verifiable.SchemaCache). If the
+// context is canceled, the items that were not started get the context
+// error.
+func (s *Processor) ParseClaimsBatch(ctx context.Context, items []BatchItem,
+	opts ...BatchOpt) []BatchResult {
+
+	cfg := newBatchConfig(opts)
+
+	results := make([]BatchResult, len(items))
+	if s.Parser == nil {
+		for i := range results {
+			results[i].Err = errParserNotDefined
+		}
+		return results
+	}
+
+	st := s.newBatchState()
+
+	idxCh := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idxCh {
+				results[i] = s.parseBatchItem(ctx, items[i], st)
+			}
+		}()
+	}
+
+	for i := range items {
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		idxCh <- i
+	}
+	close(idxCh)
+	wg.Wait()
+
+	return results
+}
+
+// ParseClaimsStream is the same as ParseClaimsBatch but reads items from the
+// channel, so the whole batch does not have to be kept in memory. Results
+// are sent to the returned channel in the order of items. At most the number
+// of workers items are parsed concurrently, reading of items pauses until
+// the results of previous items are received. The returned channel is closed after items is closed and all results are sent,
+// so the caller must close items and receive all results. If the context is
+// canceled, the items that were not started get the context error.
+func (s *Processor) ParseClaimsStream(ctx context.Context,
+	items <-chan BatchItem, opts ...BatchOpt) <-chan BatchResult {
+
+	cfg := newBatchConfig(opts)
+	st := s.newBatchState()
+
+	out := make(chan BatchResult)
+	// results of started items in the order of items
+	pending := make(chan chan BatchResult, cfg.workers)
+
+	go func() {
+		defer close(pending)
+		sem := make(chan struct{}, cfg.workers)
+		for item := range items {
+			resCh := make(chan BatchResult, 1)
+			pending <- resCh
+
+			if s.Parser == nil {
+				resCh <- BatchResult{Err: errParserNotDefined}
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				resCh <- BatchResult{Err: err}
+				continue
+			}
+
+			sem <- struct{}{}
+			go func(item BatchItem) {
+				defer func() { <-sem }()
+				resCh <- s.parseBatchItem(ctx, item, st)
+			}(item)
+		}
+	}()
+
+	go func() {
+		defer close(out)
+		for resCh := range pending {
+			out <- <-resCh
+		}
+	}()
+
+	return out
+}
+
+func (s *Processor) parseBatchItem(ctx context.Context, item BatchItem,
+	st batchState) BatchResult {
+
+	if err := ctx.Err(); err != nil {
+		return BatchResult{Err: err}
+	}
+
+	// ToCoreClaim modifies options, make a copy for every item
+	var opts CoreClaimOptions
+	if item.Options != nil {
+		opts = *item.Options
+	} else {
+		opts = CoreClaimOptions{
+			SubjectPosition:       verifiable.CredentialSubjectPositionIndex,
+			MerklizedRootPosition: verifiable.CredentialMerklizedRootPositionNone,
+		}
+	}
+	if st.loader != nil {
+		opts.MerklizerOpts = append(
+			append([]merklize.MerklizeOption{}, opts.MerklizerOpts...),
+			merklize.WithDocumentLoader(st.loader))
+	}
+	if opts.SchemaCache == nil {
+		opts.SchemaCache = st.schemaCache
+	}
+
+	claim, err := s.Parser.ParseClaim(ctx, item.Credential, &opts)
+	return BatchResult{Claim: claim, Err: err}
+}
+
+type batchLoaderEntry struct {
+	done chan struct{}
+	doc  *ld.RemoteDocument
+	err  error
+}
+
+// batchDocumentLoader loads every document once and shares it between
+// concurrent callers
+type batchDocumentLoader struct {
+	loader ld.DocumentLoader
+	m      sync.Mutex
+	docs   map[string]*batchLoaderEntry
+}
+
+func newBatchDocumentLoader(loader ld.DocumentLoader) *batchDocumentLoader {
+	return &batchDocumentLoader{
+		loader: loader,
+		docs:   make(map[string]*batchLoaderEntry),
+	}
+}
+
+func (l *batchDocumentLoader) LoadDocument(
+	u string) (*ld.RemoteDocument, error) {
+
+	l.m.Lock()
+	e, ok := l.docs[u]
+	if !ok {
+		e = &batchLoaderEntry{done: make(chan struct{})}
+		l.docs[u] = e
+	}
+	l.m.Unlock()
+
+	if ok {
+		<-e.done
+		return e.doc, e.err
+	}
+
+	e.doc, e.err = l.loader.LoadDocument(u)
+	close(e.done)
+	return e.doc, e.err
+}
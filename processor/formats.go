@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"context"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrFormatNotRegistered is returned when there is no schema format
+// registered for the given extension or MIME type
+var ErrFormatNotRegistered = errors.New("schema format is not registered")
+
+// SchemaFormat describes a handler for one schema format (JSON, JSON-LD,
+// YAML, SHACL, etc).
+type SchemaFormat struct {
+	// Name is a unique name of the format, e.g. "json-ld"
+	Name string
+	// Extensions is a list of file extensions without leading dot, e.g.
+	// "json-ld", "jsonld"
+	Extensions []string
+	// MIMETypes is a list of media types, e.g. "application/ld+json"
+	MIMETypes []string
+
+	// Load is an optional function to load raw schema by URL. If nil,
+	// the Processor's DocumentLoader is used.
+	Load func(ctx context.Context, url string) ([]byte, error)
+	// Validator is an optional validator for the format. If nil, the
+	// Processor's Validator is used.
+	Validator Validator
+	// Parser is an optional parser for the format. If nil, the Processor's
+	// Parser is used.
+	Parser Parser
+}
+
+// FormatRegistry is a registry of schema formats keyed by extension and
+// MIME type. It is safe for concurrent use.
+type FormatRegistry struct {
+	m     sync.RWMutex
+	byExt map[string]*SchemaFormat
+	byMT  map[string]*SchemaFormat
+}
+
+// NewFormatRegistry creates new empty FormatRegistry
+func NewFormatRegistry() *FormatRegistry {
+	return &FormatRegistry{
+		byExt: make(map[string]*SchemaFormat),
+		byMT:  make(map[string]*SchemaFormat),
+	}
+}
+
+// Register adds new format to the registry. Registering an extension or a
+// MIME type that is already registered by another format is an error.
+func (r *FormatRegistry) Register(f SchemaFormat) error {
+	if f.Name == "" {
+		return errors.New("schema format name is empty")
+	}
+	if len(f.Extensions) == 0 && len(f.MIMETypes) == 0 {
+		return errors.Errorf(
+			"schema format %v has no extensions and MIME types", f.Name)
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for _, ext := range f.Extensions {
+		if f2, ok := r.byExt[normalizeExt(ext)]; ok && f2.Name != f.Name {
+			return errors.Errorf("extension %v is already registered by %v",
+				ext, f2.Name)
+		}
+	}
+	for _, mt := range f.MIMETypes {
+		if f2, ok := r.byMT[normalizeMIMEType(mt)]; ok && f2.Name != f.Name {
+			return errors.Errorf("MIME type %v is already registered by %v",
+				mt, f2.Name)
+		}
+	}
+
+	fCopy := f
+	for _, ext := range f.Extensions {
+		r.byExt[normalizeExt(ext)] = &fCopy
+	}
+	for _, mt := range f.MIMETypes {
+		r.byMT[normalizeMIMEType(mt)] = &fCopy
+	}
+	return nil
+}
+
+// ByExtension returns format registered for the file extension. Leading dot
+// is optional.
+func (r *FormatRegistry) ByExtension(ext string) (SchemaFormat, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	f, ok := r.byExt[normalizeExt(ext)]
+	if !ok {
+		return SchemaFormat{}, errors.WithMessagef(ErrFormatNotRegistered,
+			"extension %v", ext)
+	}
+	return *f, nil
+}
+
+// ByMIMEType returns format registered for the media type. Media type
+// parameters (like charset) are ignored.
+func (r *FormatRegistry) ByMIMEType(mt string) (SchemaFormat, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	f, ok := r.byMT[normalizeMIMEType(mt)]
+	if !ok {
+		return SchemaFormat{}, errors.WithMessagef(ErrFormatNotRegistered,
+			"MIME type %v", mt)
+	}
+	return *f, nil
+}
+
+// ByURL returns format by the extension of the last URL path segment.
+func (r *FormatRegistry) ByURL(schemaURL string) (SchemaFormat, error) {
+	u, err := url.Parse(schemaURL)
+	if err != nil {
+		return SchemaFormat{}, err
+	}
+	p := u.Path
+	if p == "" {
+		// for URLs like urn:example:kyc.yaml the path is empty
+		p = u.Opaque
+	}
+	ext := path.Ext(p)
+	if ext == "" {
+		return SchemaFormat{}, errors.WithMessagef(ErrFormatNotRegistered,
+			"no extension in URL %v", schemaURL)
+	}
+	return r.ByExtension(ext)
+}
+
+// Lookup returns format for the schema reference, which is either a media
+// type or a schema URL. The reference is first looked up as a registered
+// MIME type and then by the extension of the URL path.
+func (r *FormatRegistry) Lookup(ref string) (SchemaFormat, error) {
+	f, err := r.ByMIMEType(ref)
+	if err == nil {
+		return f, nil
+	}
+	return r.ByURL(ref)
+}
+
+func normalizeExt(ext string) string {
+	return strings.ToLower(strings.TrimPrefix(ext, "."))
+}
+
+func normalizeMIMEType(mt string) string {
+	mediaType, _, err := mime.ParseMediaType(mt)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(mt))
+	}
+	return mediaType
+}
@@ -0,0 +1,172 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type stubValidator struct{ err error }
+
+func (v stubValidator) ValidateData(_, _ []byte) error {
+	return v.err
+}
+
+type stubParser struct{ idx int }
+
+func (p stubParser) ParseClaim(_ context.Context, _ verifiable.W3CCredential,
+	_ *CoreClaimOptions) (*core.Claim, error) {
+
+	var sh core.SchemaHash
+	sh[0] = byte(p.idx)
+	return core.NewClaim(sh)
+}
+
+func (p stubParser) GetFieldSlotIndex(_ string, _ string,
+	_ []byte) (int, error) {
+
+	return p.idx, nil
+}
+
+type stubLoader struct{}
+
+func (l stubLoader) LoadDocument(u string) (*ld.RemoteDocument, error) {
+	return &ld.RemoteDocument{DocumentURL: u,
+		Document: map[string]any{"loader": "default"}}, nil
+}
+
+var errYAMLValidator = errors.New("yaml validator")
+
+func newTestRegistry(t testing.TB) *FormatRegistry {
+	r := NewFormatRegistry()
+	err := r.Register(SchemaFormat{
+		Name:       "yaml",
+		Extensions: []string{".yaml", "yml"},
+		MIMETypes:  []string{"application/yaml"},
+		Load: func(_ context.Context, url string) ([]byte, error) {
+			return []byte("loaded: " + url), nil
+		},
+		Validator: stubValidator{err: errYAMLValidator},
+		Parser:    stubParser{idx: 7},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func TestFormatRegistry(t *testing.T) {
+	r := newTestRegistry(t)
+
+	f, err := r.ByExtension("YML")
+	require.NoError(t, err)
+	require.Equal(t, "yaml", f.Name)
+
+	f, err = r.ByMIMEType("Application/YAML; charset=utf-8")
+	require.NoError(t, err)
+	require.Equal(t, "yaml", f.Name)
+
+	f, err = r.ByURL("https://example.com/schemas/kyc.yaml?v=1")
+	require.NoError(t, err)
+	require.Equal(t, "yaml", f.Name)
+
+	f, err = r.Lookup("application/yaml")
+	require.NoError(t, err)
+	require.Equal(t, "yaml", f.Name)
+	f, err = r.Lookup("ipfs://QmXwhDvNcfRmNLnaCS5LYAXm1WkjUCS2ow1FL1M6EnGPeU/kyc.yml")
+	require.NoError(t, err)
+	require.Equal(t, "yaml", f.Name)
+
+	f, err = r.Lookup("urn:example:kyc.yaml")
+	require.NoError(t, err)
+	require.Equal(t, "yaml", f.Name)
+
+	_, err = r.ByExtension("json")
+	require.ErrorIs(t, err, ErrFormatNotRegistered)
+	_, err = r.ByMIMEType("application/json")
+	require.ErrorIs(t, err, ErrFormatNotRegistered)
+	_, err = r.ByURL("https://example.com/schemas/kyc")
+	require.ErrorIs(t, err, ErrFormatNotRegistered)
+	_, err = r.Lookup("application/json")
+	require.ErrorIs(t, err, ErrFormatNotRegistered)
+
+	// re-registering the same format is allowed
+	err = r.Register(SchemaFormat{Name: "yaml", Extensions: []string{"yaml"}})
+	require.NoError(t, err)
+
+	err = r.Register(SchemaFormat{Name: "yaml2", Extensions: []string{"yml"}})
+	require.EqualError(t, err, "extension yml is already registered by yaml")
+	err = r.Register(SchemaFormat{Name: "yaml2",
+		MIMETypes: []string{"application/yaml"}})
+	require.EqualError(t, err,
+		"MIME type application/yaml is already registered by yaml")
+	err = r.Register(SchemaFormat{Name: "empty"})
+	require.EqualError(t, err,
+		"schema format empty has no extensions and MIME types")
+	err = r.Register(SchemaFormat{Extensions: []string{"txt"}})
+	require.EqualError(t, err, "schema format name is empty")
+}
+
+func TestProcessor_FormatDispatch(t *testing.T) {
+	ctx := context.Background()
+	errDefaultValidator := errors.New("default validator")
+	p := InitProcessorOptions(&Processor{},
+		WithValidator(stubValidator{err: errDefaultValidator}),
+		WithParser(stubParser{idx: 1}),
+		WithDocumentLoader(stubLoader{}),
+		WithFormatRegistry(newTestRegistry(t)))
+
+	yamlURL := "https://example.com/schemas/kyc.yaml"
+	jsonURL := "https://example.com/schemas/kyc.json"
+
+	t.Run("Load", func(t *testing.T) {
+		schema, err := p.Load(ctx, yamlURL)
+		require.NoError(t, err)
+		require.Equal(t, "loaded: "+yamlURL, string(schema))
+
+		schema, err = p.Load(ctx, jsonURL)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"loader":"default"}`, string(schema))
+	})
+
+	t.Run("ValidateData", func(t *testing.T) {
+		err := p.ValidateData(nil, nil)
+		require.ErrorIs(t, err, errDefaultValidator)
+
+		err = p.ValidateDataForSchema(yamlURL, nil, nil)
+		require.ErrorIs(t, err, errYAMLValidator)
+		err = p.ValidateDataForSchema("application/yaml", nil, nil)
+		require.ErrorIs(t, err, errYAMLValidator)
+		err = p.ValidateDataForSchema(jsonURL, nil, nil)
+		require.ErrorIs(t, err, errDefaultValidator)
+		err = p.ValidateDataForSchema("application/json", nil, nil)
+		require.ErrorIs(t, err, errDefaultValidator)
+	})
+
+	t.Run("ParseClaim", func(t *testing.T) {
+		vc := verifiable.W3CCredential{
+			CredentialSchema: verifiable.CredentialSchema{ID: yamlURL}}
+		claim, err := p.ParseClaim(ctx, vc, nil)
+		require.NoError(t, err)
+		require.Equal(t, byte(7), claim.GetSchemaHash()[0])
+
+		vc.CredentialSchema.ID = jsonURL
+		claim, err = p.ParseClaim(ctx, vc, nil)
+		require.NoError(t, err)
+		require.Equal(t, byte(1), claim.GetSchemaHash()[0])
+	})
+
+	t.Run("GetFieldSlotIndex", func(t *testing.T) {
+		idx, err := p.GetFieldSlotIndexForSchema("application/yaml", "f",
+			"T", nil)
+		require.NoError(t, err)
+		require.Equal(t, 7, idx)
+
+		idx, err = p.GetFieldSlotIndexForSchema(jsonURL, "f", "T", nil)
+		require.NoError(t, err)
+		require.Equal(t, 1, idx)
+	})
+}
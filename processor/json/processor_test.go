@@ -2,12 +2,15 @@ package json
 
 import (
 	"context"
+	stdjson "encoding/json"
+	"fmt"
 	"testing"
 
 	"github.com/iden3/go-schema-processor/v2/json"
 	"github.com/iden3/go-schema-processor/v2/loaders"
 	"github.com/iden3/go-schema-processor/v2/processor"
 	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
 	"github.com/stretchr/testify/require"
 )
 
@@ -79,3 +82,100 @@ func TestValidatorWithInvalidField(t *testing.T) {
 	err = jsonProcessor.ValidateData(dataBytes, schema)
 	require.ErrorContains(t, err, "missing properties: 'birthday'")
 }
+
+func TestParseClaimsBatch(t *testing.T) {
+	defer tst.MockHTTPClient(t, map[string]string{
+		"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../../merklize/testdata/httpresp/kyc-v3.json-ld",
+		"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../../merklize/testdata/httpresp/iden3proofs.json-ld",
+		"https://www.w3.org/2018/credentials/v1":                                                         "../../merklize/testdata/httpresp/credentials-v1.jsonld",
+	}, tst.IgnoreUntouchedURLs())()
+
+	jsonProcessor := New(processor.WithParser(json.Parser{}),
+		processor.WithDocumentLoader(loaders.NewDocumentLoader(nil, "")))
+
+	ctx := context.Background()
+	var items []processor.BatchItem
+	for i := 0; i < 5; i++ {
+		var vc verifiable.W3CCredential
+		err := stdjson.Unmarshal([]byte(fmt.Sprintf(`{
+  "id": "urn:uuid:3a8d1822-a00e-11ee-8f57-a27b3ddbdc29",
+  "@context": [
+    "https://www.w3.org/2018/credentials/v1",
+    "https://schema.iden3.io/core/jsonld/iden3proofs.jsonld",
+    "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld"
+  ],
+  "type": ["VerifiableCredential", "KYCAgeCredential"],
+  "issuanceDate": "2023-12-21T16:35:46.737547+02:00",
+  "credentialSubject": {
+    "birthday": %d,
+    "documentType": 2,
+    "id": "did:polygonid:polygon:mumbai:2qH2mPVRN7ZDCnEofjeh8Qd2Uo3YsEhTVhKhjB8xs4",
+    "type": "KYCAgeCredential"
+  },
+  "issuer": "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf",
+  "credentialSchema": {
+    "id": "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json/KYCAgeCredential-v3.json",
+    "type": "JsonSchema2023"
+  }
+}`, 19960424+i)), &vc)
+		require.NoError(t, err)
+		items = append(items, processor.BatchItem{
+			Credential: vc,
+			Options: &processor.CoreClaimOptions{
+				RevNonce:        uint64(i),
+				SubjectPosition: verifiable.CredentialSubjectPositionIndex,
+			},
+		})
+	}
+
+	results := jsonProcessor.ParseClaimsBatch(ctx, items,
+		processor.WithBatchWorkers(3))
+	require.Len(t, results, len(items))
+
+	for i, r := range results {
+		require.NoError(t, r.Err)
+		require.Equal(t, uint64(i), r.Claim.GetRevocationNonce())
+
+		opts := verifiable.CoreClaimOptions(*items[i].Options)
+		want, err := items[i].Credential.ToCoreClaim(ctx, &opts)
+		require.NoError(t, err)
+		require.Equal(t, want, r.Claim)
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		itemsCh := make(chan processor.BatchItem)
+		go func() {
+			defer close(itemsCh)
+			for _, item := range items {
+				itemsCh <- item
+			}
+		}()
+
+		var streamResults []processor.BatchResult
+		for r := range jsonProcessor.ParseClaimsStream(ctx, itemsCh,
+			processor.WithBatchWorkers(2)) {
+
+			streamResults = append(streamResults, r)
+		}
+		require.Equal(t, results, streamResults)
+	})
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	results = jsonProcessor.ParseClaimsBatch(canceledCtx, items)
+	for _, r := range results {
+		require.ErrorIs(t, r.Err, context.Canceled)
+	}
+
+	itemsCh := make(chan processor.BatchItem, len(items))
+	for _, item := range items {
+		itemsCh <- item
+	}
+	close(itemsCh)
+	var n int
+	for r := range jsonProcessor.ParseClaimsStream(canceledCtx, itemsCh) {
+		require.ErrorIs(t, r.Err, context.Canceled)
+		n++
+	}
+	require.Equal(t, len(items), n)
+}
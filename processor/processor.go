@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-schema-processor/v2/loaders"
 	"github.com/iden3/go-schema-processor/v2/verifiable"
 	"github.com/piprate/json-gold/ld"
 	"github.com/pkg/errors"
@@ -15,6 +16,10 @@ type Processor struct {
 	Validator      Validator
 	DocumentLoader ld.DocumentLoader
 	Parser         Parser
+	// Formats is an optional registry of schema formats. If set, format
+	// handlers registered for the schema MIME type or URL extension take
+	// precedence over DocumentLoader, Validator and Parser.
+	Formats *FormatRegistry
 }
 
 // Validator is interface to validate data and documents
@@ -64,6 +69,13 @@ func WithParser(s Parser) Opt {
 	}
 }
 
+// WithFormatRegistry return new options
+func WithFormatRegistry(r *FormatRegistry) Opt {
+	return func(opts *Processor) {
+		opts.Formats = r
+	}
+}
+
 // InitProcessorOptions initializes processor with options.
 func InitProcessorOptions(processor *Processor, opts ...Opt) *Processor {
 	for _, opt := range opts {
@@ -72,23 +84,33 @@ func InitProcessorOptions(processor *Processor, opts ...Opt) *Processor {
 	return processor
 }
 
-// Load will load a schema by given url.
+// Load will load a schema by given url. If the format registered for the
+// URL extension has a Load function, it is used instead of DocumentLoader.
 func (s *Processor) Load(ctx context.Context, url string) (schema []byte, err error) {
+	if f, ok := s.formatFor(url); ok && f.Load != nil {
+		return f.Load(ctx, url)
+	}
 	if s.DocumentLoader == nil {
 		return nil, errLoaderNotDefined
 	}
-	doc, err := s.DocumentLoader.LoadDocument(url)
+	doc, err := loaders.LoadDocument(ctx, s.DocumentLoader, url)
 	if err != nil {
 		return nil, err
 	}
 	return json.Marshal(doc.Document)
 }
 
-// ParseClaim will serialize input data to index and value fields.
+// ParseClaim will serialize input data to index and value fields. If the
+// format registered for the credential schema URL has a Parser, it is used
+// instead of the Processor's Parser.
 func (s *Processor) ParseClaim(ctx context.Context,
 	credential verifiable.W3CCredential,
 	opts *CoreClaimOptions) (*core.Claim, error) {
 
+	f, ok := s.formatFor(credential.CredentialSchema.ID)
+	if ok && f.Parser != nil {
+		return f.Parser.ParseClaim(ctx, credential, opts)
+	}
 	if s.Parser == nil {
 		return nil, errParserNotDefined
 	}
@@ -105,10 +127,50 @@ func (s *Processor) GetFieldSlotIndex(field string, typeName string,
 	return s.Parser.GetFieldSlotIndex(field, typeName, schema)
 }
 
-// ValidateData will validate a claim content by given schema.
+// ValidateData will validate a claim content by given schema with the
+// Processor's Validator. The schema format is not known here, use
+// ValidateDataForSchema to dispatch to the validator of the registered
+// format.
 func (s *Processor) ValidateData(data, schema []byte) error {
 	if s.Validator == nil {
 		return errValidatorNotDefined
 	}
 	return s.Validator.ValidateData(data, schema)
 }
+
+// ValidateDataForSchema validates a claim content by given schema using the
+// validator of the format registered for schemaRef. schemaRef is either the
+// schema URL or the schema media type, see FormatRegistry.Lookup. If no
+// format is registered, the Processor's Validator is used.
+func (s *Processor) ValidateDataForSchema(schemaRef string,
+	data, schema []byte) error {
+
+	if f, ok := s.formatFor(schemaRef); ok && f.Validator != nil {
+		return f.Validator.ValidateData(data, schema)
+	}
+	return s.ValidateData(data, schema)
+}
+
+// GetFieldSlotIndexForSchema returns index of slot for specified field
+// using the parser of the format registered for schemaRef. schemaRef is
+// either the schema URL or the schema media type, see FormatRegistry.Lookup.
+// If no format is registered, the Processor's Parser is used.
+func (s *Processor) GetFieldSlotIndexForSchema(schemaRef string, field string,
+	typeName string, schema []byte) (int, error) {
+
+	if f, ok := s.formatFor(schemaRef); ok && f.Parser != nil {
+		return f.Parser.GetFieldSlotIndex(field, typeName, schema)
+	}
+	return s.GetFieldSlotIndex(field, typeName, schema)
+}
+
+func (s *Processor) formatFor(schemaRef string) (SchemaFormat, bool) {
+	if s.Formats == nil || schemaRef == "" {
+		return SchemaFormat{}, false
+	}
+	f, err := s.Formats.Lookup(schemaRef)
+	if err != nil {
+		return SchemaFormat{}, false
+	}
+	return f, true
+}
@@ -1,3 +1,5 @@
+// Package testing contains helpers to test code that loads JSON-LD
+// documents, schemas and DID documents over HTTP without network access.
 package testing
 
 import (
@@ -6,6 +8,7 @@ import (
 	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,6 +16,7 @@ import (
 type mockedRouterTripper struct {
 	t         testing.TB
 	routes    map[string]string
+	opts      mockHTTPClientOptions
 	seenURLsM sync.Mutex
 	seenURLs  map[string]struct{}
 }
@@ -39,6 +43,27 @@ func (m *mockedRouterTripper) RoundTrip(
 		}
 	}
 
+	if m.opts.recorder != nil {
+		m.opts.recorder.record(RecordedRequest{
+			Method: request.Method,
+			URL:    urlStr,
+			Body:   postData,
+		})
+	}
+
+	if m.opts.latency > 0 {
+		select {
+		case <-time.After(m.opts.latency):
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		}
+	}
+
+	if err, ok := m.opts.errors[routerKey]; ok {
+		m.markSeen(routerKey)
+		return nil, err
+	}
+
 	respFile, ok := m.routes[routerKey]
 	if !ok {
 		var requestBodyStr = string(postData)
@@ -55,12 +80,7 @@ func (m *mockedRouterTripper) RoundTrip(
 		return httpResp, nil
 	}
 
-	m.seenURLsM.Lock()
-	if m.seenURLs == nil {
-		m.seenURLs = make(map[string]struct{})
-	}
-	m.seenURLs[routerKey] = struct{}{}
-	m.seenURLsM.Unlock()
+	m.markSeen(routerKey)
 
 	http.ServeFile(rr, request, respFile)
 
@@ -69,18 +89,108 @@ func (m *mockedRouterTripper) RoundTrip(
 	return rr2, nil
 }
 
+func (m *mockedRouterTripper) markSeen(routerKey string) {
+	m.seenURLsM.Lock()
+	if m.seenURLs == nil {
+		m.seenURLs = make(map[string]struct{})
+	}
+	m.seenURLs[routerKey] = struct{}{}
+	m.seenURLsM.Unlock()
+}
+
 type mockHTTPClientOptions struct {
 	ignoreUntouchedURLs bool
+	latency             time.Duration
+	errors              map[string]error
+	recorder            *RequestRecorder
 }
 
+// MockHTTPClientOption is an option for MockHTTPClient
 type MockHTTPClientOption func(*mockHTTPClientOptions)
 
+// IgnoreUntouchedURLs disables the check that all routes were requested
 func IgnoreUntouchedURLs() MockHTTPClientOption {
 	return func(opts *mockHTTPClientOptions) {
 		opts.ignoreUntouchedURLs = true
 	}
 }
 
+// WithLatency delays every response by d. The request fails with the
+// request context error if the context is done earlier.
+func WithLatency(d time.Duration) MockHTTPClientOption {
+	return func(opts *mockHTTPClientOptions) {
+		opts.latency = d
+	}
+}
+
+// WithRouteError makes requests to the URL fail with err as a transport
+// error. The URL has the same format as keys of routes (for POST requests the
+// body is appended after "%%%"). The URL does not need to be in routes.
+func WithRouteError(url string, err error) MockHTTPClientOption {
+	return func(opts *mockHTTPClientOptions) {
+		if opts.errors == nil {
+			opts.errors = make(map[string]error)
+		}
+		opts.errors[url] = err
+	}
+}
+
+// WithRequestRecorder records all requests made with the mocked client,
+// including unexpected ones.
+func WithRequestRecorder(r *RequestRecorder) MockHTTPClientOption {
+	return func(opts *mockHTTPClientOptions) {
+		opts.recorder = r
+	}
+}
+
+// RecordedRequest is a request made with the mocked HTTP client
+type RecordedRequest struct {
+	Method string
+	URL    string
+	// Body is the body of POST request
+	Body []byte
+}
+
+// RequestRecorder collects requests made with the mocked HTTP client. It is
+// safe for concurrent use.
+type RequestRecorder struct {
+	m        sync.Mutex
+	requests []RecordedRequest
+}
+
+func (r *RequestRecorder) record(req RecordedRequest) {
+	r.m.Lock()
+	r.requests = append(r.requests, req)
+	r.m.Unlock()
+}
+
+// Requests returns recorded requests in the order they were made
+func (r *RequestRecorder) Requests() []RecordedRequest {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return append([]RecordedRequest(nil), r.requests...)
+}
+
+// Count returns the number of requests made to the URL
+func (r *RequestRecorder) Count(url string) int {
+	r.m.Lock()
+	defer r.m.Unlock()
+	var n int
+	for _, req := range r.requests {
+		if req.URL == url {
+			n++
+		}
+	}
+	return n
+}
+
+// MockHTTPClient replaces http.DefaultTransport with the transport that
+// serves files from routes. routes maps request URL (for POST requests the
+// URL followed by "%%%" and the request body) to the file path. Requests to
+// unknown URLs fail the test. The returned function restores the original
+// transport and checks that all routes were requested, unless
+// IgnoreUntouchedURLs option is set. Tests using MockHTTPClient must not run
+// in parallel.
 func MockHTTPClient(t testing.TB, routes map[string]string,
 	opts ...MockHTTPClientOption) func() {
 
@@ -90,7 +200,7 @@ func MockHTTPClient(t testing.TB, routes map[string]string,
 	}
 
 	oldRoundTripper := http.DefaultTransport
-	transport := &mockedRouterTripper{t: t, routes: routes}
+	transport := &mockedRouterTripper{t: t, routes: routes, opts: op}
 	http.DefaultTransport = transport
 	return func() {
 		http.DefaultTransport = oldRoundTripper
@@ -0,0 +1,79 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFixture(t *testing.T, content string) string {
+	fName := filepath.Join(t.TempDir(), "fixture.json")
+	err := os.WriteFile(fName, []byte(content), 0o600)
+	require.NoError(t, err)
+	return fName
+}
+
+func TestMockHTTPClient(t *testing.T) {
+	fixture := writeFixture(t, `{"a":1}`)
+	errUnavailable := errors.New("service unavailable")
+	var rec RequestRecorder
+	defer MockHTTPClient(t, map[string]string{
+		"https://example.com/doc.json":             fixture,
+		"https://example.com/post%%%{\"q\":\"x\"}": fixture,
+	}, WithRequestRecorder(&rec),
+		WithRouteError("https://example.com/down.json", errUnavailable))()
+
+	resp, err := http.Get("https://example.com/doc.json")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, `{"a":1}`, string(body))
+
+	resp, err = http.Post("https://example.com/post", "application/json",
+		strings.NewReader(`{"q":"x"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+
+	_, err = http.Get("https://example.com/down.json")
+	require.ErrorIs(t, err, errUnavailable)
+
+	require.Equal(t, []RecordedRequest{
+		{Method: http.MethodGet, URL: "https://example.com/doc.json"},
+		{Method: http.MethodPost, URL: "https://example.com/post",
+			Body: []byte(`{"q":"x"}`)},
+		{Method: http.MethodGet, URL: "https://example.com/down.json"},
+	}, rec.Requests())
+	require.Equal(t, 1, rec.Count("https://example.com/doc.json"))
+}
+
+func TestMockHTTPClient_WithLatency(t *testing.T) {
+	fixture := writeFixture(t, `{}`)
+	defer MockHTTPClient(t,
+		map[string]string{"https://example.com/doc.json": fixture},
+		WithLatency(50*time.Millisecond), IgnoreUntouchedURLs())()
+
+	start := time.Now()
+	resp, err := http.Get("https://example.com/doc.json")
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"https://example.com/doc.json", http.NoBody)
+	require.NoError(t, err)
+	_, err = http.DefaultClient.Do(req)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,57 @@
+package verifiable
+
+import (
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/pkg/errors"
+)
+
+// ErrNotAuthBJJClaim is returned when the core claim is not an
+// AuthBJJCredential claim
+var ErrNotAuthBJJClaim = errors.New("not an AuthBJJCredential core claim")
+
+// NewAuthBJJCoreClaim creates the AuthBJJCredential core claim for the
+// babyjub public key. X and Y coordinates of the key are put into index
+// slots A and B.
+func NewAuthBJJCoreClaim(publicKey *babyjub.PublicKey,
+	revocationNonce uint64) (*core.Claim, error) {
+
+	if publicKey == nil || publicKey.X == nil || publicKey.Y == nil {
+		return nil, errors.New("public key is empty")
+	}
+
+	return core.NewClaim(core.AuthSchemaHash,
+		core.WithIndexDataInts(publicKey.X, publicKey.Y),
+		core.WithRevocationNonce(revocationNonce))
+}
+
+// AuthBJJPublicKey extracts the babyjub public key from the AuthBJJCredential
+// core claim. ErrNotAuthBJJClaim is returned if the claim has other schema
+// hash or its key is not a point of the curve.
+func AuthBJJPublicKey(claim *core.Claim) (*babyjub.PublicKey, error) {
+	if claim == nil {
+		return nil, errors.New("claim is nil")
+	}
+
+	if claim.GetSchemaHash() != core.AuthSchemaHash {
+		return nil, errors.Wrapf(ErrNotAuthBJJClaim, "schema hash %x",
+			claim.GetSchemaHash())
+	}
+
+	publicKey := publicKeyFromClaim(claim)
+	if !publicKey.Point().InCurve() {
+		return nil, errors.Wrap(ErrNotAuthBJJClaim,
+			"public key is not on the curve")
+	}
+	return publicKey, nil
+}
+
+// AuthPublicKey returns the babyjub public key of the issuer's
+// AuthBJJCredential core claim.
+func (id *IssuerData) AuthPublicKey() (*babyjub.PublicKey, error) {
+	authClaim, err := id.authClaim()
+	if err != nil {
+		return nil, err
+	}
+	return AuthBJJPublicKey(authClaim)
+}
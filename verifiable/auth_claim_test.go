@@ -0,0 +1,51 @@
+package verifiable
+
+import (
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthBJJCoreClaim(t *testing.T) {
+	privKey := babyjub.NewRandPrivKey()
+	publicKey := privKey.Public()
+
+	claim, err := NewAuthBJJCoreClaim(publicKey, 15)
+	require.NoError(t, err)
+	require.Equal(t, core.AuthSchemaHash, claim.GetSchemaHash())
+	require.Equal(t, uint64(15), claim.GetRevocationNonce())
+
+	publicKey2, err := AuthBJJPublicKey(claim)
+	require.NoError(t, err)
+	require.Equal(t, publicKey.Compress(), publicKey2.Compress())
+
+	claimHex, err := claim.Hex()
+	require.NoError(t, err)
+	issuerData := IssuerData{AuthCoreClaim: claimHex}
+	publicKey3, err := issuerData.AuthPublicKey()
+	require.NoError(t, err)
+	require.Equal(t, publicKey.Compress(), publicKey3.Compress())
+
+	_, err = NewAuthBJJCoreClaim(nil, 0)
+	require.EqualError(t, err, "public key is empty")
+
+	t.Run("other schema", func(t *testing.T) {
+		var schemaHash core.SchemaHash
+		schemaHash[0] = 1
+		otherClaim, err := core.NewClaim(schemaHash,
+			core.WithIndexDataInts(publicKey.X, publicKey.Y))
+		require.NoError(t, err)
+		_, err = AuthBJJPublicKey(otherClaim)
+		require.ErrorIs(t, err, ErrNotAuthBJJClaim)
+	})
+
+	t.Run("not on curve", func(t *testing.T) {
+		badKey := babyjub.PublicKey{X: publicKey.X, Y: publicKey.X}
+		badClaim, err := NewAuthBJJCoreClaim(&badKey, 0)
+		require.NoError(t, err)
+		_, err = AuthBJJPublicKey(badClaim)
+		require.ErrorIs(t, err, ErrNotAuthBJJClaim)
+	})
+}
@@ -0,0 +1,237 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"sync"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+)
+
+// BatchVerificationResult is the result of verification of a credential by
+// VerifyBatch. Err is nil if the credential is valid.
+type BatchVerificationResult struct {
+	Credential *W3CCredential
+	Err        error
+}
+
+type batchVerificationConfig struct {
+	proofType      ProofType
+	parallelism    int
+	proofOpts      []W3CProofVerificationOpt
+	statusRegistry *CredentialStatusResolverRegistry
+}
+
+// BatchVerificationOpt is an option for VerifyBatch
+type BatchVerificationOpt func(*batchVerificationConfig)
+
+// WithBatchProofType sets the type of the proof to verify. By default, the
+// first proof of every credential is verified.
+func WithBatchProofType(proofType ProofType) BatchVerificationOpt {
+	return func(cfg *batchVerificationConfig) {
+		cfg.proofType = proofType
+	}
+}
+
+// WithBatchParallelism sets the maximal number of credentials verified
+// concurrently. By default, it is GOMAXPROCS.
+func WithBatchParallelism(n int) BatchVerificationOpt {
+	return func(cfg *batchVerificationConfig) {
+		cfg.parallelism = n
+	}
+}
+
+// WithBatchProofVerificationOpts sets options used to verify the proof of
+// every credential
+func WithBatchProofVerificationOpts(
+	opts ...W3CProofVerificationOpt) BatchVerificationOpt {
+
+	return func(cfg *batchVerificationConfig) {
+		cfg.proofOpts = append(cfg.proofOpts, opts...)
+	}
+}
+
+// WithBatchStatusResolverRegistry sets the registry used to resolve
+// credential statuses. By default, DefaultCredentialStatusResolverRegistry
+// is used.
+func WithBatchStatusResolverRegistry(
+	registry *CredentialStatusResolverRegistry) BatchVerificationOpt {
+
+	return func(cfg *batchVerificationConfig) {
+		cfg.statusRegistry = registry
+	}
+}
+
+// VerifyBatch verifies the proofs and the credential statuses of the
+// credentials concurrently. DID documents and revocation statuses are
+// resolved once for all credentials of the batch: successful results are
+// cached until VerifyBatch returns. Results are in the order of credentials.
+func VerifyBatch(ctx context.Context, creds []*W3CCredential,
+	didResolver DIDResolver,
+	opts ...BatchVerificationOpt) []BatchVerificationResult {
+
+	cfg := batchVerificationConfig{
+		parallelism:    runtime.GOMAXPROCS(0),
+		statusRegistry: DefaultCredentialStatusResolverRegistry,
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if cfg.parallelism < 1 {
+		cfg.parallelism = 1
+	}
+
+	resolver := &cachingDIDResolver{resolver: didResolver}
+	registry := &CredentialStatusResolverRegistry{}
+	for statusType, r := range cfg.statusRegistry.resolvers {
+		registry.Register(statusType, &cachingStatusResolver{resolver: r})
+	}
+	proofOpts := append(cfg.proofOpts[:len(cfg.proofOpts):len(cfg.proofOpts)],
+		WithStatusResolverRegistry(registry))
+	statusOpts := []CredentialStatusValidationOption{
+		WithValidationStatusResolverRegistry(registry)}
+
+	results := make([]BatchVerificationResult, len(creds))
+	idxCh := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.parallelism && w < len(creds); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idxCh {
+				results[i] = BatchVerificationResult{
+					Credential: creds[i],
+					Err: creds[i].verifyBatchItem(ctx, cfg.proofType,
+						resolver, proofOpts, statusOpts),
+				}
+			}
+		}()
+	}
+	for i := range creds {
+		idxCh <- i
+	}
+	close(idxCh)
+	wg.Wait()
+
+	return results
+}
+
+func (vc *W3CCredential) verifyBatchItem(ctx context.Context,
+	proofType ProofType, didResolver DIDResolver,
+	proofOpts []W3CProofVerificationOpt,
+	statusOpts []CredentialStatusValidationOption) error {
+
+	if proofType == "" {
+		if len(vc.Proof) == 0 {
+			return ErrProofNotFound
+		}
+		proofType = vc.Proof[0].ProofType()
+	}
+	return vc.verifyProofAndStatus(ctx, proofType, didResolver, proofOpts,
+		statusOpts)
+}
+
+// verifyProofAndStatus verifies the proof of the credential and validates
+// its credential status if it is set
+func (vc *W3CCredential) verifyProofAndStatus(ctx context.Context,
+	proofType ProofType, didResolver DIDResolver,
+	proofOpts []W3CProofVerificationOpt,
+	statusOpts []CredentialStatusValidationOption) error {
+
+	err := vc.VerifyProof(ctx, proofType, didResolver, proofOpts...)
+	if err != nil {
+		return err
+	}
+
+	if vc.CredentialStatus == nil {
+		return nil
+	}
+	credStatus, err := coerceCredentialStatus(vc.CredentialStatus)
+	if err != nil {
+		return err
+	}
+	issuerDID, err := w3c.ParseDID(vc.Issuer)
+	if err != nil {
+		return err
+	}
+	_, err = ValidateCredentialStatus(WithIssuerDID(ctx, issuerDID),
+		*credStatus, statusOpts...)
+	return err
+}
+
+// batchCache keeps results of calls by key. Concurrent calls with the same
+// key wait for the first one. Errors are not cached.
+type batchCache[T any] struct {
+	m       sync.Mutex
+	entries map[string]*batchCacheEntry[T]
+}
+
+type batchCacheEntry[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func (c *batchCache[T]) get(key string, fn func() (T, error)) (T, error) {
+	c.m.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*batchCacheEntry[T])
+	}
+	e, ok := c.entries[key]
+	if !ok {
+		e = &batchCacheEntry[T]{done: make(chan struct{})}
+		c.entries[key] = e
+	}
+	c.m.Unlock()
+
+	if ok {
+		<-e.done
+		return e.value, e.err
+	}
+
+	e.value, e.err = fn()
+	close(e.done)
+	if e.err != nil {
+		c.m.Lock()
+		delete(c.entries, key)
+		c.m.Unlock()
+	}
+	return e.value, e.err
+}
+
+type cachingDIDResolver struct {
+	resolver DIDResolver
+	cache    batchCache[DIDDocument]
+}
+
+func (r *cachingDIDResolver) Resolve(ctx context.Context,
+	did *w3c.DID) (DIDDocument, error) {
+
+	return r.cache.get(did.String(), func() (DIDDocument, error) {
+		return r.resolver.Resolve(ctx, did)
+	})
+}
+
+type cachingStatusResolver struct {
+	resolver CredentialStatusResolver
+	cache    batchCache[RevocationStatus]
+}
+
+func (r *cachingStatusResolver) Resolve(ctx context.Context,
+	credentialStatus CredentialStatus) (RevocationStatus, error) {
+
+	statusBytes, err := json.Marshal(credentialStatus)
+	if err != nil {
+		return RevocationStatus{}, err
+	}
+	// resolvers may check the issuer DID from the context
+	key := string(statusBytes)
+	if issuerDID := GetIssuerDID(ctx); issuerDID != nil {
+		key = issuerDID.String() + "\x00" + key
+	}
+
+	return r.cache.get(key, func() (RevocationStatus, error) {
+		return r.resolver.Resolve(ctx, credentialStatus)
+	})
+}
@@ -0,0 +1,77 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+type countingStatusResolver struct {
+	resolver CredentialStatusResolver
+	n        int32
+}
+
+func (r *countingStatusResolver) Resolve(ctx context.Context,
+	credentialStatus CredentialStatus) (RevocationStatus, error) {
+
+	atomic.AddInt32(&r.n, 1)
+	return r.resolver.Resolve(ctx, credentialStatus)
+}
+
+func TestVerifyBatch(t *testing.T) {
+	resolverURL := "http://my-universal-resolver/1.0/identifiers"
+	didURL := "http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e"
+	var recorder tst.RequestRecorder
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+			"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			didURL:                                                                                           `./testdata/verifycred//my-universal-resolver-1.json`,
+		}, tst.IgnoreUntouchedURLs(), tst.WithRequestRecorder(&recorder))()
+
+	statusResolver := &countingStatusResolver{resolver: test1Resolver{}}
+	registry := &CredentialStatusResolverRegistry{}
+	registry.Register(Iden3ReverseSparseMerkleTreeProof, statusResolver)
+
+	var creds []*W3CCredential
+	for i := 0; i < 5; i++ {
+		var vc W3CCredential
+		err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+		require.NoError(t, err)
+		creds = append(creds, &vc)
+	}
+	noProofVC, err := creds[0].Clone()
+	require.NoError(t, err)
+	noProofVC.Proof = nil
+	creds = append(creds, noProofVC)
+
+	results := VerifyBatch(context.Background(), creds,
+		HTTPDIDResolver{resolverURL: resolverURL},
+		WithBatchParallelism(3),
+		WithBatchStatusResolverRegistry(registry))
+	require.Len(t, results, len(creds))
+	for i, r := range results[:5] {
+		require.Same(t, creds[i], r.Credential)
+		require.NoError(t, r.Err)
+	}
+	require.Same(t, noProofVC, results[5].Credential)
+	require.ErrorIs(t, results[5].Err, ErrProofNotFound)
+
+	require.Equal(t, 1, recorder.Count(didURL))
+	// the auth claim status of the issuer and the credential status
+	require.Equal(t, int32(2), atomic.LoadInt32(&statusResolver.n))
+
+	t.Run("proof type", func(t *testing.T) {
+		results := VerifyBatch(context.Background(), creds[:1],
+			HTTPDIDResolver{resolverURL: resolverURL},
+			WithBatchProofType(Iden3SparseMerkleTreeProofType),
+			WithBatchStatusResolverRegistry(registry))
+		require.Len(t, results, 1)
+		require.ErrorIs(t, results[0].Err, ErrProofNotFound)
+	})
+}
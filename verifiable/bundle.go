@@ -0,0 +1,242 @@
+package verifiable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+)
+
+// VerificationBundle contains the credential and everything needed to
+// verify it later without network access: resolved DID documents,
+// revocation statuses and JSON-LD documents of the credential contexts.
+type VerificationBundle struct {
+	Credential W3CCredential `json:"credential"`
+	ProofType  ProofType     `json:"proofType"`
+	// DIDDocuments are keyed by DID with query, e.g.
+	// did:polygonid:polygon:mumbai:2qLx...?state=da61...
+	DIDDocuments map[string]DIDDocument `json:"didDocuments"`
+	// RevocationStatuses contains statuses of the credential itself and of
+	// the issuer's auth claim (for BJJSignature2021 proof)
+	RevocationStatuses []NonRevocationSnapshot `json:"revocationStatuses"`
+	// Contexts are JSON-LD documents keyed by URL
+	Contexts  map[string]json.RawMessage `json:"contexts"`
+	CreatedAt time.Time                  `json:"createdAt"`
+}
+
+type verificationBundleConfig struct {
+	documentLoader ld.DocumentLoader
+	statusRegistry *CredentialStatusResolverRegistry
+}
+
+// VerificationBundleOpt is an option for ExportVerificationBundle
+type VerificationBundleOpt func(*verificationBundleConfig)
+
+// WithBundleDocumentLoader sets the document loader used to fetch JSON-LD
+// contexts. By default, the merklize package default loader is used.
+func WithBundleDocumentLoader(loader ld.DocumentLoader) VerificationBundleOpt {
+	return func(cfg *verificationBundleConfig) {
+		cfg.documentLoader = loader
+	}
+}
+
+// WithBundleStatusResolverRegistry sets the registry used to resolve
+// credential statuses. By default, DefaultCredentialStatusResolverRegistry
+// is used.
+func WithBundleStatusResolverRegistry(
+	registry *CredentialStatusResolverRegistry) VerificationBundleOpt {
+
+	return func(cfg *verificationBundleConfig) {
+		cfg.statusRegistry = registry
+	}
+}
+
+// ExportVerificationBundle verifies the credential proof of given type and
+// the credential status, recording every remote document used during
+// verification. The result may be stored and verified later with
+// VerifyOffline.
+func ExportVerificationBundle(ctx context.Context, vc *W3CCredential,
+	proofType ProofType, didResolver DIDResolver,
+	opts ...VerificationBundleOpt) (*VerificationBundle, error) {
+
+	cfg := verificationBundleConfig{
+		documentLoader: merklize.Options{}.JSONLDOptions().DocumentLoader,
+		statusRegistry: DefaultCredentialStatusResolverRegistry,
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	vcCopy, err := vc.Clone()
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &bundleRecorder{didDocs: make(map[string]DIDDocument)}
+	loader := loaders.NewRecordingDocumentLoader(cfg.documentLoader)
+	resolver := &recordingDIDResolver{resolver: didResolver, rec: rec}
+	registry := &CredentialStatusResolverRegistry{}
+	for statusType, r := range cfg.statusRegistry.resolvers {
+		registry.Register(statusType,
+			&recordingStatusResolver{resolver: r, rec: rec})
+	}
+
+	err = vcCopy.VerifyProof(ctx, proofType, resolver,
+		WithMerklizeOptions(merklize.WithDocumentLoader(loader)),
+		WithStatusResolverRegistry(registry))
+	if err != nil {
+		return nil, err
+	}
+
+	if vcCopy.CredentialStatus != nil {
+		_, err = vcCopy.SnapshotNonRevocation(ctx,
+			WithValidationStatusResolverRegistry(registry))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &VerificationBundle{
+		Credential:         *vcCopy,
+		ProofType:          proofType,
+		DIDDocuments:       rec.didDocs,
+		RevocationStatuses: rec.statuses,
+		Contexts:           loader.Contexts(),
+		CreatedAt:          time.Now().UTC(),
+	}, nil
+}
+
+// VerifyOffline verifies the credential proof and status using only the
+// bundle contents. Revocation statuses are checked as of the bundle
+// creation time.
+func VerifyOffline(ctx context.Context, bundle *VerificationBundle,
+	opts ...W3CProofVerificationOpt) error {
+
+	loader := &bundleDocumentLoader{contexts: bundle.Contexts}
+	resolver := NewStaticDIDResolver(bundle.DIDDocuments)
+	registry := &CredentialStatusResolverRegistry{}
+	statusResolver := &bundleStatusResolver{
+		statuses: bundle.RevocationStatuses,
+	}
+	for _, s := range bundle.RevocationStatuses {
+		registry.Register(s.CredentialStatus.Type, statusResolver)
+	}
+
+	opts = append(opts,
+		WithMerklizeOptions(merklize.WithDocumentLoader(loader)),
+		WithStatusResolverRegistry(registry),
+		WithStaticDIDResolution())
+	err := bundle.Credential.VerifyProof(ctx, bundle.ProofType, resolver,
+		opts...)
+	if err != nil {
+		return err
+	}
+
+	if bundle.Credential.CredentialStatus != nil {
+		_, err = bundle.Credential.SnapshotNonRevocation(ctx,
+			WithValidationStatusResolverRegistry(registry))
+	}
+	return err
+}
+
+type bundleRecorder struct {
+	m        sync.Mutex
+	didDocs  map[string]DIDDocument
+	statuses []NonRevocationSnapshot
+}
+
+type recordingDIDResolver struct {
+	resolver DIDResolver
+	rec      *bundleRecorder
+}
+
+func (r *recordingDIDResolver) Resolve(ctx context.Context,
+	did *w3c.DID) (DIDDocument, error) {
+
+	didDoc, err := r.resolver.Resolve(ctx, did)
+	if err != nil {
+		return didDoc, err
+	}
+
+	r.rec.m.Lock()
+	r.rec.didDocs[did.String()] = didDoc
+	r.rec.m.Unlock()
+	return didDoc, nil
+}
+
+type recordingStatusResolver struct {
+	resolver CredentialStatusResolver
+	rec      *bundleRecorder
+}
+
+func (r *recordingStatusResolver) Resolve(ctx context.Context,
+	credentialStatus CredentialStatus) (RevocationStatus, error) {
+
+	revStatus, err := r.resolver.Resolve(ctx, credentialStatus)
+	if err != nil {
+		return revStatus, err
+	}
+
+	r.rec.m.Lock()
+	defer r.rec.m.Unlock()
+	for _, s := range r.rec.statuses {
+		if sameCredentialStatus(s.CredentialStatus, credentialStatus) {
+			return revStatus, nil
+		}
+	}
+	r.rec.statuses = append(r.rec.statuses, NonRevocationSnapshot{
+		CredentialStatus: credentialStatus,
+		RevocationStatus: revStatus,
+		CheckedAt:        time.Now().UTC(),
+	})
+	return revStatus, nil
+}
+
+type bundleDocumentLoader struct {
+	contexts map[string]json.RawMessage
+}
+
+func (l *bundleDocumentLoader) LoadDocument(
+	u string) (*ld.RemoteDocument, error) {
+
+	docBytes, ok := l.contexts[u]
+	if !ok {
+		return nil, ld.NewJsonLdError(ld.LoadingDocumentFailed,
+			fmt.Sprintf("document %v is not in the bundle", u))
+	}
+
+	doc, err := ld.DocumentFromReader(bytes.NewReader(docBytes))
+	if err != nil {
+		return nil, err
+	}
+	return &ld.RemoteDocument{DocumentURL: u, Document: doc}, nil
+}
+
+type bundleStatusResolver struct {
+	statuses []NonRevocationSnapshot
+}
+
+func (r *bundleStatusResolver) Resolve(_ context.Context,
+	credentialStatus CredentialStatus) (RevocationStatus, error) {
+
+	for _, s := range r.statuses {
+		if sameCredentialStatus(s.CredentialStatus, credentialStatus) {
+			return s.RevocationStatus, nil
+		}
+	}
+	return RevocationStatus{}, errors.Errorf(
+		"credential status %v is not in the bundle", credentialStatus.ID)
+}
+
+func sameCredentialStatus(a, b CredentialStatus) bool {
+	return a.ID == b.ID && a.Type == b.Type &&
+		a.RevocationNonce == b.RevocationNonce
+}
@@ -0,0 +1,183 @@
+package verifiable
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/pkg/errors"
+)
+
+// ErrBrokenCredentialChain is returned when the issuer of a credential in
+// the chain is not the subject of the previous credential
+var ErrBrokenCredentialChain = errors.New(
+	"credential issuer is not the subject of the previous credential")
+
+// ErrUntrustedCredentialChain is returned when the first credential of the
+// chain is not issued by a trust anchor
+var ErrUntrustedCredentialChain = errors.New(
+	"credential chain is not issued by a trust anchor")
+
+// CredentialChain is a chain of credentials delegating the issuance
+// authority. The first credential is issued by a trust anchor and every next
+// credential is issued by the subject of the previous one. The last
+// credential is the one being verified.
+type CredentialChain []*W3CCredential
+
+// CredentialChainError is returned by CredentialChain.Verify when the
+// verification of a chain link fails
+type CredentialChainError struct {
+	// Index of the failed credential in the chain
+	Index int
+	Err   error
+}
+
+func (e *CredentialChainError) Error() string {
+	return fmt.Sprintf("credential chain link %d: %v", e.Index, e.Err)
+}
+
+func (e *CredentialChainError) Unwrap() error {
+	return e.Err
+}
+
+type credentialChainVerificationConfig struct {
+	trustAnchors         map[string]struct{}
+	proofOpts            []W3CProofVerificationOpt
+	statusValidationOpts []CredentialStatusValidationOption
+}
+
+// CredentialChainVerificationOpt is an option for CredentialChain.Verify
+type CredentialChainVerificationOpt func(*credentialChainVerificationConfig)
+
+// WithTrustAnchors adds DIDs of issuers trusted to issue the first
+// credential of the chain. At least one trust anchor is required.
+func WithTrustAnchors(dids ...*w3c.DID) CredentialChainVerificationOpt {
+	return func(cfg *credentialChainVerificationConfig) {
+		if cfg.trustAnchors == nil {
+			cfg.trustAnchors = make(map[string]struct{})
+		}
+		for _, did := range dids {
+			cfg.trustAnchors[did.String()] = struct{}{}
+		}
+	}
+}
+
+// WithChainProofVerificationOpts sets options used to verify the proof of
+// every credential in the chain
+func WithChainProofVerificationOpts(
+	opts ...W3CProofVerificationOpt) CredentialChainVerificationOpt {
+
+	return func(cfg *credentialChainVerificationConfig) {
+		cfg.proofOpts = append(cfg.proofOpts, opts...)
+	}
+}
+
+// WithChainStatusValidationOpts sets options used to validate the credential
+// status of every credential in the chain
+func WithChainStatusValidationOpts(
+	opts ...CredentialStatusValidationOption) CredentialChainVerificationOpt {
+
+	return func(cfg *credentialChainVerificationConfig) {
+		cfg.statusValidationOpts = append(cfg.statusValidationOpts, opts...)
+	}
+}
+
+// BuildCredentialChain walks from the credential to a trust anchor through
+// the credentials issued to its issuer and returns the chain starting with
+// the credential issued by the trust anchor. Returns
+// ErrUntrustedCredentialChain if there is no such path. The first
+// credential found for an issuer is used.
+func BuildCredentialChain(vc *W3CCredential, credentials []*W3CCredential,
+	trustAnchors ...*w3c.DID) (CredentialChain, error) {
+
+	anchors := make(map[string]struct{}, len(trustAnchors))
+	for _, did := range trustAnchors {
+		anchors[did.String()] = struct{}{}
+	}
+
+	bySubject := make(map[string]*W3CCredential)
+	for _, c := range credentials {
+		subjectID, err := credentialSubjectID(c)
+		if err != nil {
+			continue
+		}
+		if _, ok := bySubject[subjectID]; !ok {
+			bySubject[subjectID] = c
+		}
+	}
+
+	chain := CredentialChain{vc}
+	visited := map[string]struct{}{}
+	for {
+		issuer := chain[0].Issuer
+		if _, ok := anchors[issuer]; ok {
+			return chain, nil
+		}
+		if _, ok := visited[issuer]; ok {
+			return nil, errors.Wrapf(ErrUntrustedCredentialChain,
+				"cycle at issuer %v", issuer)
+		}
+		visited[issuer] = struct{}{}
+
+		issuerVC, ok := bySubject[issuer]
+		if !ok {
+			return nil, errors.Wrapf(ErrUntrustedCredentialChain,
+				"no credential issued to %v", issuer)
+		}
+		chain = append(CredentialChain{issuerVC}, chain...)
+	}
+}
+
+// Verify checks that the chain is linked and starts with a trust anchor,
+// then verifies the proof of given type and the credential status of every
+// credential in the chain. Failures of a link are returned as
+// CredentialChainError.
+func (c CredentialChain) Verify(ctx context.Context, proofType ProofType,
+	didResolver DIDResolver, opts ...CredentialChainVerificationOpt) error {
+
+	cfg := credentialChainVerificationConfig{}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	if len(c) == 0 {
+		return errors.New("credential chain is empty")
+	}
+	if len(cfg.trustAnchors) == 0 {
+		return errors.New("trust anchors are not set")
+	}
+
+	if _, ok := cfg.trustAnchors[c[0].Issuer]; !ok {
+		return &CredentialChainError{Index: 0,
+			Err: errors.Wrapf(ErrUntrustedCredentialChain, "issuer %v",
+				c[0].Issuer)}
+	}
+	for i := 1; i < len(c); i++ {
+		subjectID, err := credentialSubjectID(c[i-1])
+		if err != nil {
+			return &CredentialChainError{Index: i - 1, Err: err}
+		}
+		if c[i].Issuer != subjectID {
+			return &CredentialChainError{Index: i,
+				Err: errors.Wrapf(ErrBrokenCredentialChain, "%v != %v",
+					c[i].Issuer, subjectID)}
+		}
+	}
+
+	for i, vc := range c {
+		err := vc.verifyProofAndStatus(ctx, proofType, didResolver,
+			cfg.proofOpts, cfg.statusValidationOpts)
+		if err != nil {
+			return &CredentialChainError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+func credentialSubjectID(vc *W3CCredential) (string, error) {
+	subjectID, ok := vc.CredentialSubject["id"].(string)
+	if !ok || subjectID == "" {
+		return "", errors.New("credential subject id is empty")
+	}
+	return subjectID, nil
+}
@@ -0,0 +1,118 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func chainTestCredential(issuer, subject string) *W3CCredential {
+	return &W3CCredential{
+		Issuer:            issuer,
+		CredentialSubject: map[string]any{"id": subject},
+	}
+}
+
+func TestBuildCredentialChain(t *testing.T) {
+	anchor := "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf"
+	anchorDID, err := w3c.ParseDID(anchor)
+	require.NoError(t, err)
+
+	vcA := chainTestCredential(anchor, "did:example:a")
+	vcB := chainTestCredential("did:example:a", "did:example:b")
+	vcC := chainTestCredential("did:example:b", "did:example:c")
+	other := chainTestCredential("did:example:x", "did:example:y")
+
+	chain, err := BuildCredentialChain(vcC, []*W3CCredential{other, vcB, vcA},
+		anchorDID)
+	require.NoError(t, err)
+	require.Equal(t, CredentialChain{vcA, vcB, vcC}, chain)
+
+	chain, err = BuildCredentialChain(vcA, nil, anchorDID)
+	require.NoError(t, err)
+	require.Equal(t, CredentialChain{vcA}, chain)
+
+	_, err = BuildCredentialChain(vcC, []*W3CCredential{vcB}, anchorDID)
+	require.ErrorIs(t, err, ErrUntrustedCredentialChain)
+	require.ErrorContains(t, err, "no credential issued to did:example:a")
+
+	cycle := chainTestCredential("did:example:b", "did:example:a")
+	_, err = BuildCredentialChain(vcC, []*W3CCredential{vcB, cycle},
+		anchorDID)
+	require.ErrorIs(t, err, ErrUntrustedCredentialChain)
+	require.ErrorContains(t, err, "cycle at issuer")
+}
+
+func TestCredentialChain_Verify(t *testing.T) {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	issuerDID, err := w3c.ParseDID(vc.Issuer)
+	require.NoError(t, err)
+	otherDID, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qH2mPVRN7ZDCnEofjeh8Qd2Uo3YsEhTVhKhjB8xs4")
+	require.NoError(t, err)
+
+	resolverURL := "http://my-universal-resolver/1.0/identifiers"
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+			"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			"http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e": `./testdata/verifycred//my-universal-resolver-1.json`,
+		}, tst.IgnoreUntouchedURLs())()
+	resolverRegistry := &CredentialStatusResolverRegistry{}
+	resolverRegistry.Register(Iden3ReverseSparseMerkleTreeProof,
+		test1Resolver{})
+	didResolver := HTTPDIDResolver{resolverURL: resolverURL}
+	ctx := context.Background()
+
+	opts := []CredentialChainVerificationOpt{
+		WithTrustAnchors(issuerDID),
+		WithChainProofVerificationOpts(
+			WithStatusResolverRegistry(resolverRegistry)),
+		WithChainStatusValidationOpts(
+			WithValidationStatusResolverRegistry(resolverRegistry)),
+	}
+	err = CredentialChain{&vc}.Verify(ctx, BJJSignatureProofType,
+		didResolver, opts...)
+	require.NoError(t, err)
+
+	t.Run("untrusted", func(t *testing.T) {
+		err := CredentialChain{&vc}.Verify(ctx, BJJSignatureProofType,
+			didResolver, WithTrustAnchors(otherDID))
+		require.ErrorIs(t, err, ErrUntrustedCredentialChain)
+		var chainErr *CredentialChainError
+		require.True(t, errors.As(err, &chainErr))
+		require.Equal(t, 0, chainErr.Index)
+	})
+
+	t.Run("no trust anchors", func(t *testing.T) {
+		err := CredentialChain{&vc}.Verify(ctx, BJJSignatureProofType,
+			didResolver)
+		require.EqualError(t, err, "trust anchors are not set")
+	})
+
+	t.Run("broken link", func(t *testing.T) {
+		err := CredentialChain{&vc, &vc}.Verify(ctx, BJJSignatureProofType,
+			didResolver, opts...)
+		require.ErrorIs(t, err, ErrBrokenCredentialChain)
+		var chainErr *CredentialChainError
+		require.True(t, errors.As(err, &chainErr))
+		require.Equal(t, 1, chainErr.Index)
+	})
+
+	t.Run("invalid proof", func(t *testing.T) {
+		err := CredentialChain{&vc}.Verify(ctx,
+			Iden3SparseMerkleTreeProofType, didResolver, opts...)
+		require.ErrorIs(t, err, ErrProofNotFound)
+		require.EqualError(t, err,
+			"credential chain link 0: proof not found")
+	})
+}
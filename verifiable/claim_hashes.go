@@ -0,0 +1,69 @@
+package verifiable
+
+import (
+	"context"
+	"math/big"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+)
+
+// CoreClaimHashes contains hashes of the core claim used as merkle tree
+// entries and for signing
+type CoreClaimHashes struct {
+	// IndexHash (hi) is the hash of the index slots of the claim, the key of
+	// the claim in the claims tree
+	IndexHash *big.Int
+	// ValueHash (hv) is the hash of the value slots of the claim, the value
+	// of the claim in the claims tree
+	ValueHash *big.Int
+	// ClaimHash is poseidon(hi, hv), the hash signed by BJJSignature2021
+	// proof
+	ClaimHash *big.Int
+	// RevocationNonce is the key of the claim in the revocation tree
+	RevocationNonce uint64
+}
+
+// ClaimHashes computes hashes of the core claim
+func ClaimHashes(claim *core.Claim) (CoreClaimHashes, error) {
+	hi, hv, err := claim.HiHv()
+	if err != nil {
+		return CoreClaimHashes{}, err
+	}
+
+	claimHash, err := poseidon.Hash([]*big.Int{hi, hv})
+	if err != nil {
+		return CoreClaimHashes{}, err
+	}
+
+	return CoreClaimHashes{
+		IndexHash:       hi,
+		ValueHash:       hv,
+		ClaimHash:       claimHash,
+		RevocationNonce: claim.GetRevocationNonce(),
+	}, nil
+}
+
+// ClaimHashes builds the core claim from the credential with given options
+// and computes its hashes
+func (vc *W3CCredential) ClaimHashes(ctx context.Context,
+	opts *CoreClaimOptions) (CoreClaimHashes, error) {
+
+	claim, err := vc.ToCoreClaim(ctx, opts)
+	if err != nil {
+		return CoreClaimHashes{}, err
+	}
+	return ClaimHashes(claim)
+}
+
+// ClaimHashesFromProof computes hashes of the core claim stored in the
+// credential proof of given type
+func (vc *W3CCredential) ClaimHashesFromProof(
+	proofType ProofType) (CoreClaimHashes, error) {
+
+	claim, err := vc.GetCoreClaimFromProof(proofType)
+	if err != nil {
+		return CoreClaimHashes{}, err
+	}
+	return ClaimHashes(claim)
+}
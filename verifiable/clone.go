@@ -0,0 +1,146 @@
+package verifiable
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Clone returns a deep copy of the credential. The credential subject and
+// credential status are copied recursively, proofs and the proof chain are
+// copied by marshaling them to JSON and back, so their concrete types are
+// preserved for the known proof types. Values of the credential subject that are not
+// JSON objects or arrays are copied as is.
+func (vc *W3CCredential) Clone() (*W3CCredential, error) {
+	vc2 := vc.WithoutProofs()
+
+	if vc.Proof != nil {
+		vc2.Proof = make(CredentialProofs, 0, len(vc.Proof))
+		for _, p := range vc.Proof {
+			p2, err := cloneProof(p)
+			if err != nil {
+				return nil, err
+			}
+			vc2.Proof = append(vc2.Proof, p2)
+		}
+	}
+
+	if vc.ProofChain != nil {
+		vc2.ProofChain = make(ProofChain, 0, len(vc.ProofChain))
+		for _, l := range vc.ProofChain {
+			p2, err := cloneProof(l.Proof)
+			if err != nil {
+				return nil, err
+			}
+			l.Proof = p2
+			vc2.ProofChain = append(vc2.ProofChain, l)
+		}
+	}
+	return vc2, nil
+}
+
+// WithoutProofs returns a deep copy of the credential without proofs. This is
+// the shape of the document used for merklization.
+func (vc *W3CCredential) WithoutProofs() *W3CCredential {
+	vc2 := *vc
+	vc2.Proof = nil
+	vc2.ProofChain = nil
+	vc2.Context = cloneStrings(vc.Context)
+	vc2.Type = cloneStrings(vc.Type)
+	vc2.Expiration = cloneTime(vc.Expiration)
+	vc2.IssuanceDate = cloneTime(vc.IssuanceDate)
+	if vc.CredentialSubject != nil {
+		vc2.CredentialSubject = deepCopyJSONValue(
+			vc.CredentialSubject).(jsonObj)
+	}
+	vc2.CredentialStatus = cloneCredentialStatus(vc.CredentialStatus)
+	if vc.RefreshService != nil {
+		rs := *vc.RefreshService
+		vc2.RefreshService = &rs
+	}
+	if vc.DisplayMethod != nil {
+		dm := *vc.DisplayMethod
+		vc2.DisplayMethod = &dm
+	}
+	if vc.RelatedResource != nil {
+		vc2.RelatedResource = append([]RelatedResource(nil),
+			vc.RelatedResource...)
+	}
+	return &vc2
+}
+
+func cloneProof(p CredentialProof) (CredentialProof, error) {
+	proofBytes, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var proofObj any
+	err = json.Unmarshal(proofBytes, &proofObj)
+	if err != nil {
+		return nil, err
+	}
+	return extractProof(proofObj)
+}
+
+func cloneCredentialStatus(credStatus any) any {
+	switch cs := credStatus.(type) {
+	case *CredentialStatus:
+		if cs == nil {
+			return cs
+		}
+		return cloneCredentialStatusStruct(cs)
+	case CredentialStatus:
+		return *cloneCredentialStatusStruct(&cs)
+	default:
+		return deepCopyJSONValue(credStatus)
+	}
+}
+
+func cloneCredentialStatusStruct(cs *CredentialStatus) *CredentialStatus {
+	cs2 := *cs
+	if cs.StatusIssuer != nil {
+		cs2.StatusIssuer = cloneCredentialStatusStruct(cs.StatusIssuer)
+	}
+	return &cs2
+}
+
+// deepCopyJSONValue copies JSON objects and arrays recursively. All other
+// values are returned as is.
+func deepCopyJSONValue(v any) any {
+	switch vt := v.(type) {
+	case jsonObj:
+		if vt == nil {
+			return vt
+		}
+		m := make(jsonObj, len(vt))
+		for k, v2 := range vt {
+			m[k] = deepCopyJSONValue(v2)
+		}
+		return m
+	case []any:
+		if vt == nil {
+			return vt
+		}
+		a := make([]any, len(vt))
+		for i := range vt {
+			a[i] = deepCopyJSONValue(vt[i])
+		}
+		return a
+	default:
+		return v
+	}
+}
+
+func cloneStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(s)), s...)
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	t2 := *t
+	return &t2
+}
@@ -28,10 +28,10 @@ const (
 
 	// JSONSchemaValidator2018 JSON schema for verification of W3CCredential
 	// Deprecated: https://www.w3.org/2018/credentials/#JsonSchemaValidator2018
-	JSONSchemaValidator2018 = "JsonSchemaValidator2018"
+	JSONSchemaValidator2018 CredentialSchemaType = "JsonSchemaValidator2018"
 
 	// JSONSchema2023 JSON schema for verification of W3CCredential (https://www.w3.org/TR/vc-json-schema/#jsonschema2023)
-	JSONSchema2023 = "JsonSchema2023"
+	JSONSchema2023 CredentialSchemaType = "JsonSchema2023"
 
 	// BJJSignatureProofType is a proof type for BJJ signature proofs
 	BJJSignatureProofType ProofType = "BJJSignature2021"
@@ -77,6 +77,14 @@ const (
 	// CredentialSubjectPositionValue is subject position of W3CCredential in value (core claim)
 	CredentialSubjectPositionValue = "value"
 
+	// CredentialSubjectIDModeHash converts subject DIDs of methods unknown
+	// to iden3 core (like did:pkh) to the ID built from the DID hash
+	CredentialSubjectIDModeHash = "hash"
+
+	// CredentialSubjectIDModeStrict returns an error if subject DID method is
+	// unknown to iden3 core
+	CredentialSubjectIDModeStrict = "strict"
+
 	// CredentialSubjectRootPositionValue is subject position of W3CCredential in value (core claim)
 	// Deprecated: use CredentialSubjectPositionValue instead
 	CredentialSubjectRootPositionValue = "value"
@@ -87,9 +95,45 @@ const (
 	// Iden3OnсhainSparseMerkleTreeProof2023 is a proof type for MTP proofs with iden3 metadata from blockchain
 	Iden3OnchainSparseMerkleTreeProof2023 CredentialStatusType = "Iden3OnchainSparseMerkleTreeProof2023"
 
+	// StatusList2021Entry is CredentialStatusType for W3C status list 2021
+	// (https://www.w3.org/TR/2023/WD-vc-status-list-20230427/)
+	StatusList2021Entry CredentialStatusType = "StatusList2021Entry"
+
+	// JSONLDSchemaStatusList2021 is a schema for context with StatusList2021
+	// types
+	JSONLDSchemaStatusList2021 = "https://w3id.org/vc/status-list/2021/v1"
+
 	// Iden3RefreshService2023 is the type of refresh service
 	Iden3RefreshService2023 RefreshServiceType = "Iden3RefreshService2023"
 
 	// Iden3BasicDisplayMethodV1 is the type fof basic display method
 	Iden3BasicDisplayMethodV1 DisplayMethodType = "Iden3BasicDisplayMethodV1"
+
+	// WebVerificationFormV1 is the type of credential proposal with the URL
+	// of a web form the holder fills to get the credential
+	WebVerificationFormV1 ProposalType = "WebVerificationFormV1.0"
+
+	// Iden3PaymentRequestCryptoV1 is the type of payment request of crypto
+	// currency transfer to the address
+	Iden3PaymentRequestCryptoV1 PaymentRequestType = "Iden3PaymentRequestCryptoV1"
+
+	// Iden3PaymentRailsRequestV1 is the type of payment request of native
+	// currency through the payment rails contract
+	Iden3PaymentRailsRequestV1 PaymentRequestType = "Iden3PaymentRailsRequestV1"
+
+	// Iden3PaymentRailsERC20RequestV1 is the type of payment request of ERC20
+	// tokens through the payment rails contract
+	Iden3PaymentRailsERC20RequestV1 PaymentRequestType = "Iden3PaymentRailsERC20RequestV1"
+
+	// Iden3PaymentCryptoV1 is the type of payment of Iden3PaymentRequestCryptoV1
+	// request
+	Iden3PaymentCryptoV1 PaymentType = "Iden3PaymentCryptoV1"
+
+	// Iden3PaymentRailsV1 is the type of payment of Iden3PaymentRailsRequestV1
+	// request
+	Iden3PaymentRailsV1 PaymentType = "Iden3PaymentRailsV1"
+
+	// Iden3PaymentRailsERC20V1 is the type of payment of
+	// Iden3PaymentRailsERC20RequestV1 request
+	Iden3PaymentRailsERC20V1 PaymentType = "Iden3PaymentRailsERC20V1"
 )
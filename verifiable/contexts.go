@@ -0,0 +1,148 @@
+package verifiable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+)
+
+// requiredContexts returns the contexts the credential needs for its own
+// fields in the order they should appear in the @context: W3C credentials
+// context first, then iden3 contexts defining proof, status and refresh
+// service types and JsonSchema2023 schema type, then display method context.
+func (vc *W3CCredential) requiredContexts() []string {
+	contexts := []string{JSONLDSchemaW3CCredential2018}
+	schemaValidation, _ := vc.CredentialSchema.Type.Validation()
+	if len(vc.Proof) != 0 || len(vc.ProofChain) != 0 ||
+		vc.CredentialStatus != nil ||
+		vc.RefreshService != nil ||
+		schemaValidation.RequiresIden3Context {
+
+		contexts = append(contexts, JSONLDSchemaIden3Credential)
+	}
+	if vc.DisplayMethod != nil {
+		contexts = append(contexts, JSONLDSchemaIden3DisplayMethod)
+	}
+	return contexts
+}
+
+// EnsureContexts updates the credential's @context so it includes all
+// contexts needed for its proofs, status, schema type, refresh service and
+// display method and the schemaContexts. Required iden3 contexts are moved to
+// the beginning of the @context after the W3C credentials context, because
+// they are protected and must not be redefined by schema contexts. Other
+// contexts keep their relative order, missing schemaContexts are appended to
+// the end.
+//
+// EnsureContexts does not load the contexts. Use CheckContexts to verify that
+// all the terms used in the credential are defined.
+func (vc *W3CCredential) EnsureContexts(schemaContexts ...string) {
+	required := vc.requiredContexts()
+	isRequired := make(map[string]bool, len(required))
+	for _, c := range required {
+		isRequired[c] = true
+	}
+
+	contexts := make([]string, 0,
+		len(required)+len(vc.Context)+len(schemaContexts))
+	contexts = append(contexts, required...)
+	seen := make(map[string]bool, cap(contexts))
+	for _, c := range contexts {
+		seen[c] = true
+	}
+	for _, cs := range [][]string{vc.Context, schemaContexts} {
+		for _, c := range cs {
+			if seen[c] || isRequired[c] {
+				continue
+			}
+			seen[c] = true
+			contexts = append(contexts, c)
+		}
+	}
+
+	vc.Context = contexts
+}
+
+// CheckContexts returns an error if any of the types or properties used in
+// the credential including its proofs is not defined by its @context.
+// Undefined properties are detected by merklization in safe mode; if they
+// can be identified, the error is *merklize.DroppedTermsError listing them.
+// If documentLoader is nil, the default merklize document loader is used.
+func (vc *W3CCredential) CheckContexts(ctx context.Context,
+	documentLoader ld.DocumentLoader) error {
+
+	credentialBytes, err := json.Marshal(vc)
+	if err != nil {
+		return err
+	}
+
+	var doc any
+	err = json.Unmarshal(credentialBytes, &doc)
+	if err != nil {
+		return err
+	}
+
+	// undefined types are not dropped by the expansion but kept as relative
+	// IRIs, that fail the normalization with an obscure N-Quads syntax error
+	options := merklize.Options{DocumentLoader: documentLoader}.JSONLDOptions()
+	options.DocumentLoader = loaders.BindContext(ctx, options.DocumentLoader)
+	options.SafeMode = false
+	expanded, err := ld.NewJsonLdProcessor().Expand(doc, options)
+	if err != nil {
+		return err
+	}
+	types := make(map[string]struct{})
+	undefinedTypes(expanded, types)
+	if len(types) != 0 {
+		typesList := make([]string, 0, len(types))
+		for tp := range types {
+			typesList = append(typesList, tp)
+		}
+		sort.Strings(typesList)
+		return errors.Errorf("types are not defined by the credential "+
+			"@context: %v", strings.Join(typesList, ", "))
+	}
+
+	var opts []merklize.MerklizeOption
+	if documentLoader != nil {
+		opts = append(opts, merklize.WithDocumentLoader(documentLoader))
+	}
+	opts = append(opts, merklize.WithSafeMode(true))
+	_, err = merklize.MerklizeJSONLD(ctx, bytes.NewReader(credentialBytes),
+		opts...)
+	return err
+}
+
+// undefinedTypes collects @type values of the expanded document that are not
+// absolute IRIs
+func undefinedTypes(doc any, types map[string]struct{}) {
+	switch d := doc.(type) {
+	case []any:
+		for _, v := range d {
+			undefinedTypes(v, types)
+		}
+	case map[string]any:
+		for k, v := range d {
+			switch k {
+			case "@type":
+				tps, _ := v.([]any)
+				for _, tp := range tps {
+					tpStr, ok := tp.(string)
+					if ok && !ld.IsAbsoluteIri(tpStr) {
+						types[tpStr] = struct{}{}
+					}
+				}
+			case "@value":
+			default:
+				undefinedTypes(v, types)
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestW3CCredential_EnsureContexts(t *testing.T) {
+	const kycContext = "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld"
+
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	vc.Context = []string{kycContext, JSONLDSchemaW3CCredential2018,
+		"https://example.com/other.jsonld"}
+	vc.EnsureContexts(kycContext, "https://example.com/another.jsonld")
+	require.Equal(t, []string{
+		JSONLDSchemaW3CCredential2018,
+		JSONLDSchemaIden3Credential,
+		kycContext,
+		"https://example.com/other.jsonld",
+		"https://example.com/another.jsonld",
+	}, vc.Context)
+
+	vc.DisplayMethod, err = NewIden3BasicDisplayMethod(
+		"https://example.com/display.json")
+	require.NoError(t, err)
+	vc.EnsureContexts()
+	require.Equal(t, []string{
+		JSONLDSchemaW3CCredential2018,
+		JSONLDSchemaIden3Credential,
+		JSONLDSchemaIden3DisplayMethod,
+		kycContext,
+		"https://example.com/other.jsonld",
+		"https://example.com/another.jsonld",
+	}, vc.Context)
+
+	vc2 := W3CCredential{Context: []string{kycContext}}
+	vc2.EnsureContexts()
+	require.Equal(t, []string{JSONLDSchemaW3CCredential2018, kycContext},
+		vc2.Context)
+}
+
+func TestW3CCredential_CheckContexts(t *testing.T) {
+	const kycContext = "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld"
+
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			JSONLDSchemaW3CCredential2018: "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			JSONLDSchemaIden3Credential:   "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			kycContext:                    "../merklize/testdata/httpresp/kyc-v3.json-ld",
+		},
+		tst.IgnoreUntouchedURLs())()
+	ctx := context.Background()
+
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	vc.Context = []string{JSONLDSchemaW3CCredential2018, kycContext}
+	err = vc.CheckContexts(ctx, nil)
+	require.EqualError(t, err, "types are not defined by the credential "+
+		"@context: BJJSignature2021, Iden3ReverseSparseMerkleTreeProof, "+
+		"JsonSchema2023")
+
+	vc.EnsureContexts()
+	err = vc.CheckContexts(ctx, nil)
+	require.NoError(t, err)
+
+	vc.CredentialSubject["undefinedField"] = 1
+	err = vc.CheckContexts(ctx, nil)
+	require.ErrorContains(t, err, "invalid property")
+}
@@ -0,0 +1,139 @@
+package verifiable
+
+import (
+	"encoding/json"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidSubjectPosition is returned when CoreClaimOptions.SubjectPosition
+// is not one of CredentialSubjectPosition* constants
+var ErrInvalidSubjectPosition = errors.New("invalid subject position")
+
+// ErrInvalidMerklizedRootPosition is returned when
+// CoreClaimOptions.MerklizedRootPosition is not one of
+// CredentialMerklizedRootPosition* constants or does not match the
+// credential type
+var ErrInvalidMerklizedRootPosition = errors.New(
+	"invalid merklized root position")
+
+// ErrInvalidSubjectIDMode is returned when CoreClaimOptions.SubjectIDMode is
+// not one of CredentialSubjectIDMode* constants
+var ErrInvalidSubjectIDMode = errors.New("invalid subject id mode")
+
+// Validate checks that positions and the subject ID mode of the options are
+// known values
+func (opts CoreClaimOptions) Validate() error {
+	switch opts.SubjectPosition {
+	case "", CredentialSubjectPositionIndex, CredentialSubjectPositionValue:
+	default:
+		return errors.Wrapf(ErrInvalidSubjectPosition, "%q",
+			opts.SubjectPosition)
+	}
+
+	switch opts.MerklizedRootPosition {
+	case CredentialMerklizedRootPositionNone,
+		CredentialMerklizedRootPositionIndex,
+		CredentialMerklizedRootPositionValue:
+	default:
+		return errors.Wrapf(ErrInvalidMerklizedRootPosition, "%q",
+			opts.MerklizedRootPosition)
+	}
+
+	switch opts.SubjectIDMode {
+	case "", CredentialSubjectIDModeHash, CredentialSubjectIDModeStrict:
+	default:
+		return errors.Wrapf(ErrInvalidSubjectIDMode, "%q", opts.SubjectIDMode)
+	}
+
+	return nil
+}
+
+// DefaultCoreClaimOptions returns options to build core claims of
+// credentials of the JSON schema. The credential type is read from
+// $metadata.type and $metadata.uris.jsonLdContext of the schema: the
+// merklized root is in the index for merklized types and is not set for
+// types with iden3_serialization. The subject is in the index.
+func DefaultCoreClaimOptions(schemaBytes []byte,
+	documentLoader ld.DocumentLoader) (CoreClaimOptions, error) {
+
+	var metadata credentialSchemaMetadata
+	err := json.Unmarshal(schemaBytes, &metadata)
+	if err != nil {
+		return CoreClaimOptions{}, err
+	}
+	if metadata.Metadata.Type == "" ||
+		metadata.Metadata.URIs.JSONLDContext == "" {
+
+		return CoreClaimOptions{}, errors.New(
+			"schema has no $metadata type or jsonLdContext")
+	}
+
+	jsonLDOpts := merklize.Options{DocumentLoader: documentLoader}.
+		JSONLDOptions()
+	ldCtx, err := ld.NewContext(nil, jsonLDOpts).Parse(
+		[]any{metadata.Metadata.URIs.JSONLDContext})
+	if err != nil {
+		return CoreClaimOptions{}, err
+	}
+	serAttr, err := GetSerializationAttrFromParsedContext(ldCtx,
+		metadata.Metadata.Type)
+	if err != nil {
+		return CoreClaimOptions{}, err
+	}
+
+	opts := CoreClaimOptions{
+		SubjectPosition:       CredentialSubjectPositionIndex,
+		MerklizedRootPosition: CredentialMerklizedRootPositionIndex,
+	}
+	if serAttr != "" {
+		opts.MerklizedRootPosition = CredentialMerklizedRootPositionNone
+	}
+	return opts, nil
+}
+
+// CoreClaimOptionsFromClaim returns options the core claim was built with:
+// positions of the subject and the merklized root, revocation nonce, version
+// and updatable flag
+func CoreClaimOptionsFromClaim(claim *core.Claim) (CoreClaimOptions, error) {
+	merklizedPosition, err := claim.GetMerklizedPosition()
+	if err != nil {
+		return CoreClaimOptions{}, errors.New(
+			"can't get core claim merklized position")
+	}
+	var merklizedPositionString string
+	switch merklizedPosition {
+	case core.MerklizedRootPositionNone:
+		merklizedPositionString = CredentialMerklizedRootPositionNone
+	case core.MerklizedRootPositionIndex:
+		merklizedPositionString = CredentialMerklizedRootPositionIndex
+	case core.MerklizedRootPositionValue:
+		merklizedPositionString = CredentialMerklizedRootPositionValue
+	}
+
+	idPosition, err := claim.GetIDPosition()
+	if err != nil {
+		return CoreClaimOptions{}, errors.New(
+			"can't get core claim id position")
+	}
+	var subjectPositionString string
+	switch idPosition {
+	case core.IDPositionNone:
+		subjectPositionString = ""
+	case core.IDPositionIndex:
+		subjectPositionString = CredentialSubjectPositionIndex
+	case core.IDPositionValue:
+		subjectPositionString = CredentialSubjectPositionValue
+	}
+
+	return CoreClaimOptions{
+		RevNonce:              claim.GetRevocationNonce(),
+		Version:               claim.GetVersion(),
+		SubjectPosition:       subjectPositionString,
+		MerklizedRootPosition: merklizedPositionString,
+		Updatable:             claim.GetFlagUpdatable(),
+	}, nil
+}
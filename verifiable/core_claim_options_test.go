@@ -0,0 +1,87 @@
+package verifiable
+
+import (
+	"math/big"
+	"os"
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoreClaimOptions_Validate(t *testing.T) {
+	require.NoError(t, CoreClaimOptions{}.Validate())
+	require.NoError(t, CoreClaimOptions{
+		SubjectPosition:       CredentialSubjectPositionValue,
+		MerklizedRootPosition: CredentialMerklizedRootPositionValue,
+		SubjectIDMode:         CredentialSubjectIDModeStrict,
+	}.Validate())
+
+	err := CoreClaimOptions{SubjectPosition: "Index"}.Validate()
+	require.ErrorIs(t, err, ErrInvalidSubjectPosition)
+	require.EqualError(t, err, `"Index": invalid subject position`)
+
+	err = CoreClaimOptions{MerklizedRootPosition: "none"}.Validate()
+	require.ErrorIs(t, err, ErrInvalidMerklizedRootPosition)
+
+	err = CoreClaimOptions{SubjectIDMode: "other"}.Validate()
+	require.ErrorIs(t, err, ErrInvalidSubjectIDMode)
+}
+
+func TestDefaultCoreClaimOptions(t *testing.T) {
+	defer tst.MockHTTPClient(t, map[string]string{
+		"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+		"https://example.com/schema-delivery-address.json-ld":                                            "../json/testdata/schema-delivery-address.json-ld",
+	}, tst.IgnoreUntouchedURLs())()
+
+	schemaBytes, err := os.ReadFile("testdata/KYCAgeCredential-v3.json")
+	require.NoError(t, err)
+	opts, err := DefaultCoreClaimOptions(schemaBytes, nil)
+	require.NoError(t, err)
+	require.Equal(t, CoreClaimOptions{
+		SubjectPosition:       CredentialSubjectPositionIndex,
+		MerklizedRootPosition: CredentialMerklizedRootPositionIndex,
+	}, opts)
+
+	// non-merklized credential type with iden3_serialization
+	opts, err = DefaultCoreClaimOptions([]byte(`{
+  "$metadata": {
+    "uris": {
+      "jsonLdContext": "https://example.com/schema-delivery-address.json-ld"
+    },
+    "type": "DeliverAddressMultiTestForked"
+  }
+}`), nil)
+	require.NoError(t, err)
+	require.Equal(t, CoreClaimOptions{
+		SubjectPosition:       CredentialSubjectPositionIndex,
+		MerklizedRootPosition: CredentialMerklizedRootPositionNone,
+	}, opts)
+
+	_, err = DefaultCoreClaimOptions([]byte(`{"type":"object"}`), nil)
+	require.EqualError(t, err, "schema has no $metadata type or jsonLdContext")
+}
+
+func TestCoreClaimOptionsFromClaim(t *testing.T) {
+	id, err := core.IDFromString(
+		"x3HstHLj2rTp6HHXk2WczYP7w3rpCsRbwCMeaQ2H2")
+	require.NoError(t, err)
+	claim, err := core.NewClaim(core.SchemaHash{},
+		core.WithValueID(id),
+		core.WithIndexMerklizedRoot(big.NewInt(1)),
+		core.WithRevocationNonce(10),
+		core.WithVersion(2),
+		core.WithFlagUpdatable(true))
+	require.NoError(t, err)
+
+	opts, err := CoreClaimOptionsFromClaim(claim)
+	require.NoError(t, err)
+	require.Equal(t, CoreClaimOptions{
+		RevNonce:              10,
+		Version:               2,
+		SubjectPosition:       CredentialSubjectPositionValue,
+		MerklizedRootPosition: CredentialMerklizedRootPositionIndex,
+		Updatable:             true,
+	}, opts)
+}
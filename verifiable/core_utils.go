@@ -4,8 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
 	"github.com/iden3/go-schema-processor/v2/merklize"
-	"github.com/iden3/go-schema-processor/v2/utils"
 	"github.com/piprate/json-gold/ld"
 	"github.com/pkg/errors"
 )
@@ -28,10 +29,68 @@ type CoreClaimOptions struct {
 	SubjectPosition       string `json:"subjectPosition"`
 	MerklizedRootPosition string `json:"merklizedRootPosition"`
 	Updatable             bool   `json:"updatable"`
-	MerklizerOpts         []merklize.MerklizeOption
+	// SubjectIDMode defines how credentialSubject.id is converted to the
+	// core claim ID. See CredentialSubjectIDModeHash and
+	// CredentialSubjectIDModeStrict. Empty value is the same as
+	// CredentialSubjectIDModeHash.
+	SubjectIDMode string `json:"subjectIdMode"`
+	MerklizerOpts []merklize.MerklizeOption
+	// SchemaCache is an optional cache to share the processing of credential
+	// contexts between calls. If nil, contexts are processed on every call.
+	SchemaCache *SchemaCache `json:"-"`
+	// CredentialSchema is an optional JSON schema of the credential. If set,
+	// const and default values of credentialSubject fields of the schema are
+	// set in the credential before the claim is built (see
+	// W3CCredential.ApplySchemaDefaults), so the credential must be issued
+	// after the claim.
+	CredentialSchema []byte `json:"-"`
 }
 
-func findCredentialType(mz *merklize.Merklizer) (string, error) {
+// ErrUnsupportedSubjectDID is returned when credentialSubject.id is a DID of
+// a method that is not supported by iden3 core and SubjectIDMode is set to
+// CredentialSubjectIDModeStrict.
+var ErrUnsupportedSubjectDID = errors.New("unsupported credential subject DID")
+
+// subjectIDFromDID converts credentialSubject.id to core.ID according to the
+// subject ID mode. For DIDs not supported by iden3 core (like did:pkh) the ID
+// is built from the hash of the DID string in
+// CredentialSubjectIDModeHash mode.
+func subjectIDFromDID(subjectID string, mode string) (core.ID, error) {
+	did, err := w3c.ParseDID(subjectID)
+	if err != nil {
+		return core.ID{}, err
+	}
+
+	id, err := core.IDFromDID(*did)
+	if err != nil {
+		return core.ID{}, err
+	}
+
+	switch mode {
+	case "", CredentialSubjectIDModeHash:
+		return id, nil
+	case CredentialSubjectIDModeStrict:
+		_, err = core.MethodFromID(id)
+		if errors.Is(err, core.ErrMethodUnknown) {
+			return core.ID{}, errors.Wrapf(ErrUnsupportedSubjectDID, "%v",
+				subjectID)
+		} else if err != nil {
+			return core.ID{}, err
+		}
+		return id, nil
+	default:
+		return core.ID{}, errors.New("unknown subject id mode")
+	}
+}
+
+// findCredentialType returns the IRI of the credential schema type. It is
+// credentialSubject.@type if set, otherwise the top level type other than
+// VerifiableCredential. If there are several such types (e.g. marker types
+// of composite credentials), the most derived type is chosen with lookup in
+// ldContext, the @context of the credential (see mostDerivedType).
+func findCredentialType(mz *merklize.Merklizer,
+	ldContext []any) (string, error) {
+
 	opts := mz.Options()
 
 	// try to look into credentialSubject.@type to get type of credentials
@@ -48,8 +107,8 @@ func findCredentialType(mz *merklize.Merklizer) (string, error) {
 	}
 
 	// if type of credentials not found in credentialSubject.@type, loop at
-	// top level @types if it contains two elements: type we are looking for
-	// and "VerifiableCredential" type.
+	// top level @types: it should contain "VerifiableCredential" type and
+	// the type we are looking for.
 	path2, err := opts.NewPath(typeFullKey)
 	if err != nil {
 		return "", err
@@ -68,19 +127,93 @@ func findCredentialType(mz *merklize.Merklizer) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	if len(topLevelTypes) != 2 {
-		return "", fmt.Errorf("top level @type expected to be of length 2")
-	}
 
-	switch verifiableCredentialFullKey {
-	case topLevelTypes[0]:
-		return topLevelTypes[1], nil
-	case topLevelTypes[1]:
-		return topLevelTypes[0], nil
-	default:
+	var candidates []string
+	hasVC := false
+	for _, tp := range topLevelTypes {
+		if tp == verifiableCredentialFullKey {
+			hasVC = true
+			continue
+		}
+		candidates = append(candidates, tp)
+	}
+	if !hasVC {
 		return "", fmt.Errorf(
 			"@type(s) are expected to contain VerifiableCredential type")
 	}
+	switch len(candidates) {
+	case 0:
+		return "", fmt.Errorf(
+			"top level @type expected to contain credential type")
+	case 1:
+		return candidates[0], nil
+	default:
+		return mostDerivedType(candidates, ldContext,
+			opts.JSONLDOptions())
+	}
+}
+
+// mostDerivedType chooses the schema type among the types of the
+// credential. Schema types are defined with the scoped @context, unlike
+// marker types, and contexts of the credential go from generic to specific
+// ones, so the type with the scoped context introduced by the latest entry
+// of ldContext is chosen.
+func mostDerivedType(types []string, ldContext []any,
+	opts *ld.JsonLdOptions) (string, error) {
+
+	type typeRank struct {
+		scoped bool
+		// index of the @context entry which introduced the type, -1 if the
+		// type is not defined by the context
+		ctxIdx int
+	}
+	ranks := make(map[string]typeRank, len(types))
+	for _, tp := range types {
+		ranks[tp] = typeRank{ctxIdx: -1}
+	}
+
+	for i := range ldContext {
+		ldCtx, err := ld.NewContext(nil, opts).Parse(ldContext[:i+1])
+		if err != nil {
+			return "", err
+		}
+		termDefs, _ := ldCtx.AsMap()["termDefinitions"].(map[string]any)
+		for _, typeDef := range termDefs {
+			typeDefM, ok := typeDef.(map[string]any)
+			if !ok {
+				continue
+			}
+			typeID, _ := typeDefM["@id"].(string)
+			rank, ok := ranks[typeID]
+			if !ok || rank.ctxIdx != -1 {
+				continue
+			}
+			_, rank.scoped = typeDefM[contextFullKey]
+			rank.ctxIdx = i
+			ranks[typeID] = rank
+		}
+	}
+
+	best := types[0]
+	ambiguous := false
+	for _, tp := range types[1:] {
+		r, b := ranks[tp], ranks[best]
+		switch {
+		case r.scoped != b.scoped:
+			if r.scoped {
+				best, ambiguous = tp, false
+			}
+		case r.ctxIdx > b.ctxIdx:
+			best, ambiguous = tp, false
+		case r.ctxIdx == b.ctxIdx:
+			ambiguous = true
+		}
+	}
+	if ambiguous {
+		return "", fmt.Errorf("credential type is ambiguous: %v",
+			strings.Join(types, ", "))
+	}
+	return best, nil
 }
 
 func toStringSlice(in []any) ([]string, error) {
@@ -95,6 +228,10 @@ func toStringSlice(in []any) ([]string, error) {
 	return out, nil
 }
 
+// ErrSlotOverflow is returned when the value of the credential subject field
+// does not fit into the claim slot
+var ErrSlotOverflow = errors.New("value does not fit into the claim slot")
+
 // parsedSlots is struct that represents iden3 claim specification
 type parsedSlots struct {
 	IndexA, IndexB []byte
@@ -104,7 +241,7 @@ type parsedSlots struct {
 // parseSlots converts payload to claim slots using provided schema
 func parseSlots(mz *merklize.Merklizer,
 	credential W3CCredential,
-	credentialType string) (parsedSlots, bool, error) {
+	credentialType string, cache *SchemaCache) (parsedSlots, bool, error) {
 
 	slots := parsedSlots{
 		IndexA: make([]byte, 32),
@@ -114,7 +251,7 @@ func parseSlots(mz *merklize.Merklizer,
 	}
 
 	jsonLDOpts := mz.Options().JSONLDOptions()
-	serAttr, err := getSerializationAttr(credential, jsonLDOpts,
+	serAttr, err := cache.serializationAttr(credential, jsonLDOpts,
 		credentialType)
 	if err != nil {
 		return slots, false, err
@@ -133,19 +270,19 @@ func parseSlots(mz *merklize.Merklizer,
 		return slots, true, nil
 	}
 
-	err = fillSlot(slots.IndexA, mz, sPaths.IndexAPath)
+	err = fillSlot(slots.IndexA, mz, "slotIndexA", sPaths.IndexAPath)
 	if err != nil {
 		return slots, true, err
 	}
-	err = fillSlot(slots.IndexB, mz, sPaths.IndexBPath)
+	err = fillSlot(slots.IndexB, mz, "slotIndexB", sPaths.IndexBPath)
 	if err != nil {
 		return slots, true, err
 	}
-	err = fillSlot(slots.ValueA, mz, sPaths.ValueAPath)
+	err = fillSlot(slots.ValueA, mz, "slotValueA", sPaths.ValueAPath)
 	if err != nil {
 		return slots, true, err
 	}
-	err = fillSlot(slots.ValueB, mz, sPaths.ValueBPath)
+	err = fillSlot(slots.ValueB, mz, "slotValueB", sPaths.ValueBPath)
 	if err != nil {
 		return slots, true, err
 	}
@@ -266,7 +403,11 @@ func (p slotsPaths) isEmpty() bool {
 		p.ValueAPath == "" && p.ValueBPath == ""
 }
 
-func fillSlot(slotData []byte, mz *merklize.Merklizer, path string) error {
+// fillSlot puts the value of the credential subject field to the slot. The
+// path may point to the field of a nested object, e.g. "address.city".
+func fillSlot(slotData []byte, mz *merklize.Merklizer, slot,
+	path string) error {
+
 	if path == "" {
 		return nil
 	}
@@ -274,22 +415,28 @@ func fillSlot(slotData []byte, mz *merklize.Merklizer, path string) error {
 	path = credentialSubjectKey + "." + path
 	p, err := mz.ResolveDocPath(path)
 	if err != nil {
-		return errors.Wrapf(err, "field not found in credential %s", path)
+		return errors.Wrapf(err, "%s: field not found in credential %s", slot,
+			path)
 	}
 
 	entry, err := mz.Entry(p)
 	if errors.Is(err, merklize.ErrorEntryNotFound) {
-		return errors.Wrapf(err, "field not found in credential %s", path)
+		return errors.Wrapf(err, "%s: field not found in credential %s", slot,
+			path)
 	} else if err != nil {
 		return err
 	}
 
 	intVal, err := entry.ValueMtEntry()
 	if err != nil {
-		return err
+		return errors.WithMessagef(err, "%s: field %s", slot, path)
 	}
 
-	bytesVal := utils.SwapEndianness(intVal.Bytes())
-	copy(slotData, bytesVal)
+	elem, err := core.NewElemBytesFromInt(intVal)
+	if err != nil {
+		return errors.Wrapf(ErrSlotOverflow, "%s: field %s value %v", slot,
+			path, intVal)
+	}
+	copy(slotData, elem[:])
 	return nil
 }
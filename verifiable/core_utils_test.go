@@ -3,10 +3,14 @@ package verifiable
 import (
 	"context"
 	"encoding/json"
+	"math/big"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-crypto/poseidon"
 	"github.com/iden3/go-schema-processor/v2/merklize"
 	tst "github.com/iden3/go-schema-processor/v2/testing"
 	"github.com/piprate/json-gold/ld"
@@ -34,10 +38,11 @@ func TestParser_parseSlots(t *testing.T) {
 	mz, err := credential.Merklize(ctx)
 	require.NoError(t, err)
 
-	credentialType, err := findCredentialType(mz)
+	credentialType, err := findCredentialType(mz,
+		anySlice(credential.Context))
 	require.NoError(t, err)
 
-	slots, nonMerklized, err := parseSlots(mz, credential, credentialType)
+	slots, nonMerklized, err := parseSlots(mz, credential, credentialType, nil)
 	require.True(t, nonMerklized)
 	require.NoError(t, err)
 	require.NotEqual(t, nullSlot, slots.IndexA)
@@ -88,6 +93,76 @@ func TestGetSerializationAttr(t *testing.T) {
 	})
 }
 
+type countingDocumentLoader struct {
+	ld.DocumentLoader
+	m     sync.Mutex
+	calls int
+}
+
+func (l *countingDocumentLoader) LoadDocument(
+	u string) (*ld.RemoteDocument, error) {
+
+	l.m.Lock()
+	l.calls++
+	l.m.Unlock()
+	return l.DocumentLoader.LoadDocument(u)
+}
+
+func TestSchemaCache(t *testing.T) {
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://www.w3.org/2018/credentials/v1":              "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			"https://example.com/schema-delivery-address.json-ld": "../json/testdata/schema-delivery-address.json-ld",
+		},
+		tst.IgnoreUntouchedURLs())()
+
+	vc := W3CCredential{
+		Context: []string{
+			"https://www.w3.org/2018/credentials/v1",
+			"https://example.com/schema-delivery-address.json-ld",
+		},
+	}
+	loader := &countingDocumentLoader{
+		DocumentLoader: ld.NewDefaultDocumentLoader(nil)}
+	options := ld.NewJsonLdOptions("")
+	options.DocumentLoader = loader
+
+	want, err := getSerializationAttr(vc, options,
+		"DeliverAddressMultiTestForked")
+	require.NoError(t, err)
+	require.Equal(t, 2, loader.calls)
+
+	cache := NewSchemaCache()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serAttr, err := cache.serializationAttr(vc, options,
+				"DeliverAddressMultiTestForked")
+			require.NoError(t, err)
+			require.Equal(t, want, serAttr)
+		}()
+	}
+	wg.Wait()
+	// contexts are loaded only once more for all cached calls
+	require.Equal(t, 4, loader.calls)
+
+	// another type of the same contexts is not cached yet
+	serAttr, err := cache.serializationAttr(vc, options, "bla-bla")
+	require.NoError(t, err)
+	require.Equal(t, "", serAttr)
+	require.Equal(t, 6, loader.calls)
+
+	// nil cache processes contexts on every call
+	var nilCache *SchemaCache
+	serAttr, err = nilCache.serializationAttr(vc, options,
+		"DeliverAddressMultiTestForked")
+	require.NoError(t, err)
+	require.Equal(t, want, serAttr)
+	require.Equal(t, 8, loader.calls)
+}
+
 func TestFindCredentialType(t *testing.T) {
 	mockHTTP := func(t testing.TB) func() {
 		return tst.MockHTTPClient(t,
@@ -100,6 +175,10 @@ func TestFindCredentialType(t *testing.T) {
 			tst.IgnoreUntouchedURLs(),
 		)
 	}
+	deliveryAddressContext := []any{
+		"https://www.w3.org/2018/credentials/v1",
+		"https://example.com/schema-delivery-address.json-ld",
+	}
 
 	ctx := context.Background()
 
@@ -127,7 +206,7 @@ func TestFindCredentialType(t *testing.T) {
 }`)
 		mz, err := merklize.MerklizeJSONLD(ctx, rdr)
 		require.NoError(t, err)
-		typeID, err := findCredentialType(mz)
+		typeID, err := findCredentialType(mz, deliveryAddressContext)
 		require.NoError(t, err)
 		require.Equal(t, "urn:uuid:ac2ede19-b3b9-454d-b1a9-a7b3d5763100", typeID)
 	})
@@ -155,7 +234,7 @@ func TestFindCredentialType(t *testing.T) {
 }`)
 		mz, err := merklize.MerklizeJSONLD(ctx, rdr)
 		require.NoError(t, err)
-		typeID, err := findCredentialType(mz)
+		typeID, err := findCredentialType(mz, deliveryAddressContext)
 		require.NoError(t, err)
 		require.Equal(t, "urn:uuid:ac2ede19-b3b9-454d-b1a9-a7b3d5763100", typeID)
 	})
@@ -184,7 +263,7 @@ func TestFindCredentialType(t *testing.T) {
 }`)
 		mz, err := merklize.MerklizeJSONLD(ctx, rdr)
 		require.NoError(t, err)
-		typeID, err := findCredentialType(mz)
+		typeID, err := findCredentialType(mz, deliveryAddressContext)
 		require.NoError(t, err)
 		require.Equal(t, "urn:uuid:ac2ede19-b3b9-454d-b1a9-a7b3d5763100", typeID)
 	})
@@ -205,8 +284,60 @@ func TestFindCredentialType(t *testing.T) {
 }`)
 		mz, err := merklize.MerklizeJSONLD(ctx, rdr)
 		require.NoError(t, err)
-		_, err = findCredentialType(mz)
-		require.EqualError(t, err, "top level @type expected to be of length 2")
+		typeID, err := findCredentialType(mz, deliveryAddressContext)
+		require.NoError(t, err)
+		require.Equal(t, "urn:uuid:ac2ede19-b3b9-454d-b1a9-a7b3d5763100", typeID)
+	})
+
+	t.Run("marker type", func(t *testing.T) {
+		defer mockHTTP(t)()
+		rdr := strings.NewReader(`
+{
+    "@context": [
+        "https://www.w3.org/2018/credentials/v1",
+        "https://example.com/schema-delivery-address.json-ld",
+        {"KYCMarker": "https://example.com/markers#KYCMarker"}
+    ],
+    "@type": [
+        "VerifiableCredential",
+        "KYCMarker",
+        "DeliverAddressMultiTestForked"
+    ]
+}`)
+		mz, err := merklize.MerklizeJSONLD(ctx, rdr)
+		require.NoError(t, err)
+		ldContext := []any{
+			"https://www.w3.org/2018/credentials/v1",
+			"https://example.com/schema-delivery-address.json-ld",
+			map[string]any{
+				"KYCMarker": "https://example.com/markers#KYCMarker",
+			},
+		}
+		typeID, err := findCredentialType(mz, ldContext)
+		require.NoError(t, err)
+		require.Equal(t, "urn:uuid:ac2ede19-b3b9-454d-b1a9-a7b3d5763100", typeID)
+	})
+
+	t.Run("ambiguous top level", func(t *testing.T) {
+		defer mockHTTP(t)()
+		rdr := strings.NewReader(`
+{
+    "@context": [
+        "https://www.w3.org/2018/credentials/v1",
+        "https://example.com/schema-delivery-address.json-ld"
+    ],
+    "@type": [
+        "VerifiableCredential",
+        "EcdsaSecp256k1Signature2019",
+        "EcdsaSecp256r1Signature2019"
+    ]
+}`)
+		mz, err := merklize.MerklizeJSONLD(ctx, rdr)
+		require.NoError(t, err)
+		_, err = findCredentialType(mz, deliveryAddressContext)
+		require.EqualError(t, err, "credential type is ambiguous: "+
+			"https://w3id.org/security#EcdsaSecp256k1Signature2019, "+
+			"https://w3id.org/security#EcdsaSecp256r1Signature2019")
 	})
 
 	t.Run("unexpected top level 2", func(t *testing.T) {
@@ -221,9 +352,63 @@ func TestFindCredentialType(t *testing.T) {
 }`)
 		mz, err := merklize.MerklizeJSONLD(ctx, rdr)
 		require.NoError(t, err)
-		_, err = findCredentialType(mz)
+		_, err = findCredentialType(mz, deliveryAddressContext)
 		require.EqualError(t, err,
 			"@type(s) are expected to contain VerifiableCredential type")
 	})
 
 }
+
+func TestSubjectIDFromDID(t *testing.T) {
+	iden3DID := "did:polygonid:polygon:mumbai:2qH2mPVRN7ZDCnEofjeh8Qd2Uo3YsEhTVhKhjB8xs4"
+	pkhDID := "did:pkh:eip155:1:0xb9c5714089478a327f09197987f16f9e5d936e8a"
+
+	id1, err := subjectIDFromDID(iden3DID, "")
+	require.NoError(t, err)
+	id2, err := subjectIDFromDID(iden3DID, CredentialSubjectIDModeStrict)
+	require.NoError(t, err)
+	require.Equal(t, id1, id2)
+
+	pkhID1, err := subjectIDFromDID(pkhDID, CredentialSubjectIDModeHash)
+	require.NoError(t, err)
+	pkhID2, err := subjectIDFromDID(pkhDID, CredentialSubjectIDModeHash)
+	require.NoError(t, err)
+	require.Equal(t, pkhID1, pkhID2)
+
+	_, err = subjectIDFromDID(pkhDID, CredentialSubjectIDModeStrict)
+	require.ErrorIs(t, err, ErrUnsupportedSubjectDID)
+
+	_, err = subjectIDFromDID(iden3DID, "unknown")
+	require.EqualError(t, err, "unknown subject id mode")
+}
+
+func TestW3CCredential_ClaimHashesFromProof(t *testing.T) {
+	claim, err := core.NewClaim(core.SchemaHash{1, 2, 3},
+		core.WithRevocationNonce(100500), core.WithIndexDataInts(
+			big.NewInt(10), big.NewInt(20)))
+	require.NoError(t, err)
+	claimHex, err := claim.Hex()
+	require.NoError(t, err)
+
+	vc := W3CCredential{Proof: CredentialProofs{
+		&CommonProof{"type": "CustomProof", "coreClaim": claimHex},
+	}}
+
+	hashes, err := vc.ClaimHashesFromProof("CustomProof")
+	require.NoError(t, err)
+
+	hi, hv, err := claim.HiHv()
+	require.NoError(t, err)
+	claimHash, err := poseidon.Hash([]*big.Int{hi, hv})
+	require.NoError(t, err)
+
+	require.Equal(t, CoreClaimHashes{
+		IndexHash:       hi,
+		ValueHash:       hv,
+		ClaimHash:       claimHash,
+		RevocationNonce: 100500,
+	}, hashes)
+
+	_, err = vc.ClaimHashesFromProof(BJJSignatureProofType)
+	require.ErrorIs(t, err, ErrProofNotFound)
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"net/http"
 	"time"
 
 	core "github.com/iden3/go-iden3-core/v2"
@@ -16,6 +17,7 @@ import (
 	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/iden3/go-schema-processor/v2/merklize"
 	"github.com/iden3/go-schema-processor/v2/utils"
+	"github.com/piprate/json-gold/ld"
 	"github.com/pkg/errors"
 )
 
@@ -32,101 +34,142 @@ type W3CCredential struct {
 	Issuer            string                 `json:"issuer"`
 	CredentialSchema  CredentialSchema       `json:"credentialSchema"`
 	Proof             CredentialProofs       `json:"proof,omitempty"`
+	ProofChain        ProofChain             `json:"proofChain,omitempty"`
 	RefreshService    *RefreshService        `json:"refreshService,omitempty"`
 	DisplayMethod     *DisplayMethod         `json:"displayMethod,omitempty"`
+	// RelatedResource is the integrity metadata of the resources the
+	// credential uses. It must be defined by the credential @context.
+	RelatedResource []RelatedResource `json:"relatedResource,omitempty"`
 }
 
 // VerifyProof verify credential proof
 func (vc *W3CCredential) VerifyProof(ctx context.Context, proofType ProofType,
 	didResolver DIDResolver, opts ...W3CProofVerificationOpt) error {
 
-	verifyConfig := w3CProofVerificationConfig{}
-	for _, o := range opts {
-		o(&verifyConfig)
+	verifyConfig, err := vc.prepareProofVerification(ctx, didResolver, opts)
+	if err != nil {
+		return err
 	}
 
-	var credProof CredentialProof
-	for _, p := range vc.Proof {
-		if p.ProofType() == proofType {
-			credProof = p
-			break
-		}
-	}
-	if credProof == nil {
+	credProof, ok := vc.Proof.ByType(proofType)
+	if !ok {
 		return ErrProofNotFound
 	}
 
+	return vc.verifyProof(ctx, credProof, didResolver, verifyConfig)
+}
+
+// prepareProofVerification builds the verification config from the options
+// and checks the credential validity period. It is shared by VerifyProof,
+// VerifyDetachedProof and VerifyProofChain.
+func (vc *W3CCredential) prepareProofVerification(ctx context.Context,
+	didResolver DIDResolver,
+	opts []W3CProofVerificationOpt) (w3CProofVerificationConfig, error) {
+
+	verifyConfig, err := newProofVerificationConfig(didResolver, opts)
+	if err != nil {
+		return verifyConfig, err
+	}
+
+	start := time.Now()
+	err = vc.verifyValidityPeriod(verifyConfig, start)
+	logVerificationStep(ctx, verifyConfig.logger,
+		VerificationStepValidityPeriod, start, err, nil)
+	return verifyConfig, err
+}
+
+func (vc *W3CCredential) verifyProof(ctx context.Context,
+	credProof CredentialProof, didResolver DIDResolver,
+	verifyConfig w3CProofVerificationConfig) error {
+
 	coreClaim, err := credProof.GetCoreClaim()
 	if err != nil {
 		return errors.New("can't get core claim")
 	}
 
-	err = vc.verifyCredentialCoreClaim(ctx, coreClaim, verifyConfig.merklizeOptions)
+	start := time.Now()
+	err = vc.verifyCredentialCoreClaim(ctx, coreClaim, verifyConfig)
+	logVerificationStep(ctx, verifyConfig.logger, VerificationStepCoreClaim,
+		start, err, nil)
 	if err != nil {
 		return errors.WithStack(err)
 	}
 
-	switch proofType {
+	if verifyConfig.validateCredentialSchema {
+		start = time.Now()
+		err = vc.verifyCredentialSchema(ctx, coreClaim,
+			verifyConfig.schemaDocumentLoader)
+		logVerificationStep(ctx, verifyConfig.logger,
+			VerificationStepCredentialSchema, start, err,
+			map[string]any{"schema": vc.CredentialSchema.ID})
+		if err != nil {
+			return err
+		}
+	}
+
+	switch credProof.ProofType() {
 	case BJJSignatureProofType:
-		var proof BJJSignatureProof2021
-		err = remarshalObj(&proof, credProof)
+		proof, err := asBJJSignatureProof(credProof)
 		if err != nil {
 			return err
 		}
-		return verifyBJJSignatureProof(ctx, proof, coreClaim, didResolver,
+		return verifyBJJSignatureProof(ctx, *proof, coreClaim, didResolver,
 			verifyConfig)
 	case Iden3SparseMerkleTreeProofType:
-		var proof Iden3SparseMerkleTreeProof
-		err = remarshalObj(&proof, credProof)
+		proof, err := asIden3SparseMerkleTreeProof(credProof)
 		if err != nil {
 			return err
 		}
-		return verifyIden3SparseMerkleTreeProof(ctx, proof, coreClaim,
-			didResolver)
+		return verifyIden3SparseMerkleTreeProof(ctx, *proof, coreClaim,
+			didResolver, verifyConfig)
 	default:
 		return ErrProofNotSupported
 	}
 }
 
-func (vc *W3CCredential) verifyCredentialCoreClaim(ctx context.Context, proofCoreClaim *core.Claim, merklizeOptions []merklize.MerklizeOption) error {
-	merklizedPosition, err := proofCoreClaim.GetMerklizedPosition()
-	if err != nil {
-		return errors.New("can't get core claim merklized position")
+// verifyValidityPeriod checks expirationDate and issuanceDate of the
+// credential if corresponding checks are enabled in the config.
+func (vc *W3CCredential) verifyValidityPeriod(
+	verifyConfig w3CProofVerificationConfig, now time.Time) error {
+
+	if verifyConfig.checkExpiration && vc.Expiration != nil &&
+		now.After(vc.Expiration.Add(verifyConfig.expirationLeeway)) {
+
+		return errors.Wrapf(ErrCredentialExpired, "expired at %v",
+			vc.Expiration.Format(time.RFC3339))
 	}
-	var merklizedPositionString string
-	switch merklizedPosition {
-	case core.MerklizedRootPositionNone:
-		merklizedPositionString = CredentialMerklizedRootPositionNone
-	case core.MerklizedRootPositionIndex:
-		merklizedPositionString = CredentialMerklizedRootPositionIndex
-	case core.MerklizedRootPositionValue:
-		merklizedPositionString = CredentialMerklizedRootPositionValue
+
+	if verifyConfig.checkIssuanceDate && vc.IssuanceDate != nil &&
+		now.Add(verifyConfig.issuanceDateLeeway).Before(*vc.IssuanceDate) {
+
+		return errors.Wrapf(ErrCredentialNotYetValid, "issued at %v",
+			vc.IssuanceDate.Format(time.RFC3339))
 	}
 
-	idPosition, err := proofCoreClaim.GetIDPosition()
+	return nil
+}
+
+func (vc *W3CCredential) verifyCredentialCoreClaim(ctx context.Context,
+	proofCoreClaim *core.Claim, verifyConfig w3CProofVerificationConfig) error {
+
+	coreClaimOpts, err := CoreClaimOptionsFromClaim(proofCoreClaim)
 	if err != nil {
-		return errors.New("can't get core claim id position")
+		return err
 	}
 
-	var subjectPositionString string
-	switch idPosition {
-	case core.IDPositionNone:
-		subjectPositionString = ""
-	case core.IDPositionIndex:
-		subjectPositionString = CredentialSubjectPositionIndex
-	case core.IDPositionValue:
-		subjectPositionString = CredentialSubjectPositionValue
+	start := time.Now()
+	mz, err := vc.Merklize(ctx, verifyConfig.merklizeOptions...)
+	var mzAttrs map[string]any
+	if err == nil {
+		mzAttrs = map[string]any{"root": mz.Root().String()}
 	}
-
-	coreClaimOpts := CoreClaimOptions{
-		RevNonce:              proofCoreClaim.GetRevocationNonce(),
-		Version:               proofCoreClaim.GetVersion(),
-		SubjectPosition:       subjectPositionString,
-		MerklizedRootPosition: merklizedPositionString,
-		Updatable:             proofCoreClaim.GetFlagUpdatable(),
-		MerklizerOpts:         merklizeOptions,
+	logVerificationStep(ctx, verifyConfig.logger, VerificationStepMerklization,
+		start, err, mzAttrs)
+	if err != nil {
+		return errors.WithStack(err)
 	}
-	credentialClaim, err := vc.ToCoreClaim(ctx, &coreClaimOpts)
+
+	credentialClaim, err := vc.coreClaimFromMerklizer(mz, &coreClaimOpts)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -164,24 +207,61 @@ func verifyBJJSignatureProof(ctx context.Context, proof BJJSignatureProof2021,
 		return err
 	}
 
+	start := time.Now()
 	err = verifyClaimSignature(coreClaim, sig, authClaim)
+	logVerificationStep(ctx, verifyConfig.logger, VerificationStepSignature,
+		start, err, map[string]any{"issuer": proof.IssuerData.ID})
 	if err != nil {
 		return err
 	}
 
-	issuerDID, err := w3c.ParseDID(proof.IssuerData.ID)
+	err = verifyIssuerState(ctx, proof.IssuerData, didResolver,
+		verifyConfig.logger)
+	if err != nil && verifyConfig.issuerStateUpdate &&
+		proof.IssuerData.UpdateURL != "" {
+
+		err = verifyUpdatedIssuerState(ctx, proof.IssuerData, authClaim,
+			didResolver, verifyConfig)
+	}
 	if err != nil {
 		return err
 	}
 
-	issuerStateHash, err := merkletree.NewHashFromHex(*proof.IssuerData.State.Value)
+	err = validateAuthClaimRevocation(ctx, proof.IssuerData,
+		verifyConfig.credStatusValidationOpts...)
+	if err != nil {
+		return err
+	}
+
+	return err
+}
+
+// verifyIssuerState checks that the issuer state is published or is the
+// genesis state of the issuer
+func verifyIssuerState(ctx context.Context, issuerData IssuerData,
+	didResolver DIDResolver, logger Logger) error {
+
+	issuerDID, err := w3c.ParseDID(issuerData.ID)
+	if err != nil {
+		return err
+	}
+
+	if issuerData.State.Value == nil {
+		return errors.New("issuer state is empty")
+	}
+
+	issuerStateHash, err := merkletree.NewHashFromHex(*issuerData.State.Value)
 	if err != nil {
 		return fmt.Errorf("invalid state formant: %v", err)
 	}
 
-	issuerDID.Query = fmt.Sprintf("state=%s", issuerStateHash.Hex())
+	issuerDID, err = DIDAtVersion(issuerDID, WithIdentityState(issuerStateHash))
+	if err != nil {
+		return err
+	}
 
-	didDoc, err := didResolver.Resolve(ctx, issuerDID)
+	didDoc, err := resolveIssuerDIDDocument(ctx, didResolver, issuerDID,
+		logger)
 	if err != nil {
 		return err
 	}
@@ -210,19 +290,16 @@ func verifyBJJSignatureProof(ctx context.Context, proof BJJSignatureProof2021,
 		}
 	}
 
-	err = validateAuthClaimRevocation(ctx, proof.IssuerData,
-		verifyConfig.credStatusValidationOpts...)
-	if err != nil {
-		return err
-	}
-
-	return err
+	return nil
 }
 
 func verifyClaimSignature(claim *core.Claim, sig *babyjub.Signature,
 	authClaim *core.Claim) error {
 
-	publicKey := publicKeyFromClaim(authClaim)
+	publicKey, err := AuthBJJPublicKey(authClaim)
+	if err != nil {
+		return err
+	}
 
 	// core claim hash
 	hi, hv, err := claim.HiHv()
@@ -274,50 +351,25 @@ func validateAuthClaimRevocation(ctx context.Context, issuerData IssuerData,
 
 func verifyIden3SparseMerkleTreeProof(ctx context.Context,
 	proof Iden3SparseMerkleTreeProof, coreClaim *core.Claim,
-	didResolver DIDResolver) error {
-
-	var err error
+	didResolver DIDResolver, verifyConfig w3CProofVerificationConfig) error {
 
-	issuerDID, err := w3c.ParseDID(proof.IssuerData.ID)
+	// the claim is proven to be in the claims tree of this state, so the
+	// state can't be updated
+	err := verifyIssuerState(ctx, proof.IssuerData, didResolver,
+		verifyConfig.logger)
 	if err != nil {
 		return err
 	}
 
-	issuerStateHash, err := merkletree.NewHashFromHex(*proof.IssuerData.State.Value)
-	if err != nil {
-		return fmt.Errorf("invalid state formant: %v", err)
-	}
-
-	issuerDID.Query = fmt.Sprintf("state=%s", issuerStateHash.Hex())
-
-	didDoc, err := didResolver.Resolve(ctx, issuerDID)
-	if err != nil {
-		return err
-	}
-
-	vm, err := getIden3StateInfo2023FromDIDDocument(didDoc)
-	if err != nil {
-		return err
-	}
+	start := time.Now()
+	err = verifyClaimInClaimsTree(proof, coreClaim)
+	logVerificationStep(ctx, verifyConfig.logger, VerificationStepMTProof,
+		start, err, map[string]any{"issuer": proof.IssuerData.ID})
+	return err
+}
 
-	// Published or genesis
-	if !*vm.IdentityState.Published {
-		var (
-			isGenesisState bool
-			issuerID       core.ID
-		)
-		issuerID, err = core.IDFromDID(*issuerDID)
-		if err != nil {
-			return err
-		}
-		isGenesisState, err = core.CheckGenesisStateID(issuerID.BigInt(), issuerStateHash.BigInt())
-		if err != nil {
-			return err
-		}
-		if !isGenesisState {
-			return errors.New("issuer state not published and not genesis")
-		}
-	}
+func verifyClaimInClaimsTree(proof Iden3SparseMerkleTreeProof,
+	coreClaim *core.Claim) error {
 
 	// 3. root from proof == issuerData.state.сlaimsTreeRoot
 	hi, hv, err := coreClaim.HiHv()
@@ -341,6 +393,16 @@ func verifyIden3SparseMerkleTreeProof(ctx context.Context,
 	return nil
 }
 
+func resolveIssuerDIDDocument(ctx context.Context, didResolver DIDResolver,
+	issuerDID *w3c.DID, logger Logger) (DIDDocument, error) {
+
+	start := time.Now()
+	didDoc, err := didResolver.Resolve(ctx, issuerDID)
+	logVerificationStep(ctx, logger, VerificationStepDIDResolution, start, err,
+		map[string]any{"did": issuerDID.String()})
+	return didDoc, err
+}
+
 func bjjSignatureFromHexString(sigHex string) (*babyjub.Signature, error) {
 	signatureBytes, err := hex.DecodeString(sigHex)
 	if err != nil {
@@ -368,34 +430,50 @@ func getIden3StateInfo2023FromDIDDocument(document DIDDocument) (*CommonVerifica
 	return iden3StateInfo2023, nil
 }
 
-// Merklize merklizes verifiable credential
+// Merklize merklizes verifiable credential. Remote contexts listed in
+// RelatedResource are verified against their digests.
 func (vc *W3CCredential) Merklize(ctx context.Context,
 	opts ...merklize.MerklizeOption) (*merklize.Merklizer, error) {
 
-	credentialBytes, err := json.Marshal(vc)
+	ctx = vc.IntegrityContext(ctx)
+	credentialWithoutProofBytes, err := json.Marshal(vc.WithoutProofs())
 	if err != nil {
 		return nil, err
 	}
 
-	var credentialAsMap map[string]interface{}
-	err = json.Unmarshal(credentialBytes, &credentialAsMap)
+	mk, err := merklize.MerklizeJSONLD(ctx,
+		bytes.NewReader(credentialWithoutProofBytes), opts...)
 	if err != nil {
 		return nil, err
 	}
-	delete(credentialAsMap, "proof")
+	return mk, nil
 
-	credentialWithoutProofBytes, err := json.Marshal(credentialAsMap)
-	if err != nil {
-		return nil, err
+}
+
+// MerklizeSubject merklizes the credential subject alone with the
+// credential's @context. Its root commits to the subject data only, so the
+// rest of the credential may be signed separately. Paths of the subject
+// merklizer have no credentialSubject prefix, e.g. "birthday" instead of
+// "credentialSubject.birthday".
+func (vc *W3CCredential) MerklizeSubject(ctx context.Context,
+	opts ...merklize.MerklizeOption) (*merklize.Merklizer, error) {
+
+	if len(vc.CredentialSubject) == 0 {
+		return nil, errors.New("credential subject is empty")
 	}
 
-	mk, err := merklize.MerklizeJSONLD(ctx,
-		bytes.NewReader(credentialWithoutProofBytes), opts...)
+	subject := make(map[string]any, len(vc.CredentialSubject)+1)
+	for k, v := range vc.CredentialSubject {
+		subject[k] = v
+	}
+	subject["@context"] = vc.Context
+	subjectBytes, err := json.Marshal(subject)
 	if err != nil {
 		return nil, err
 	}
-	return mk, nil
 
+	return merklize.MerklizeJSONLD(vc.IntegrityContext(ctx),
+		bytes.NewReader(subjectBytes), opts...)
 }
 
 // ErrProofNotFound is an error when specific proof is not found in the credential
@@ -404,14 +482,21 @@ var ErrProofNotFound = errors.New("proof not found")
 // ErrProofNotSupported is an error when specific proof is not supported for validation
 var ErrProofNotSupported = errors.New("proof not supported")
 
+// ErrCredentialExpired is an error when the credential expiration date is in
+// the past
+var ErrCredentialExpired = errors.New("credential is expired")
+
+// ErrCredentialNotYetValid is an error when the credential issuance date is
+// in the future
+var ErrCredentialNotYetValid = errors.New("credential is not yet valid")
+
 // GetCoreClaimFromProof returns  core claim from given proof
 func (vc *W3CCredential) GetCoreClaimFromProof(proofType ProofType) (*core.Claim, error) {
-	for _, p := range vc.Proof {
-		if p.ProofType() == proofType {
-			return p.GetCoreClaim()
-		}
+	p, ok := vc.Proof.ByType(proofType)
+	if !ok {
+		return nil, ErrProofNotFound
 	}
-	return nil, ErrProofNotFound
+	return p.GetCoreClaim()
 }
 
 // ToCoreClaim returns Claim object from W3CCredential
@@ -427,19 +512,38 @@ func (vc *W3CCredential) ToCoreClaim(ctx context.Context, opts *CoreClaimOptions
 		}
 	}
 
+	if opts.CredentialSchema != nil {
+		err := vc.ApplySchemaDefaults(opts.CredentialSchema)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	mz, err := vc.Merklize(ctx, opts.MerklizerOpts...)
 	if err != nil {
 		return nil, err
 	}
 
-	credentialType, err := findCredentialType(mz)
+	return vc.coreClaimFromMerklizer(mz, opts)
+}
+
+func (vc *W3CCredential) coreClaimFromMerklizer(mz *merklize.Merklizer,
+	opts *CoreClaimOptions) (*core.Claim, error) {
+
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	credentialType, err := findCredentialType(mz, anySlice(vc.Context))
 	if err != nil {
 		return nil, err
 	}
 
 	subjectID := vc.CredentialSubject["id"]
 
-	slots, nonMerklized, err := parseSlots(mz, *vc, credentialType)
+	slots, nonMerklized, err := parseSlots(mz, *vc, credentialType,
+		opts.SchemaCache)
 	if err != nil {
 		return nil, err
 	}
@@ -452,7 +556,7 @@ func (vc *W3CCredential) ToCoreClaim(ctx context.Context, opts *CoreClaimOptions
 		}
 	} else {
 		if opts.MerklizedRootPosition != CredentialMerklizedRootPositionNone {
-			return nil, errors.New(
+			return nil, errors.Wrap(ErrInvalidMerklizedRootPosition,
 				"merklized root position is not supported for non-merklized claims")
 		}
 	}
@@ -474,14 +578,9 @@ func (vc *W3CCredential) ToCoreClaim(ctx context.Context, opts *CoreClaimOptions
 		claim.SetExpirationDate(*vc.Expiration)
 	}
 	if subjectID != nil {
-		var did *w3c.DID
-		did, err = w3c.ParseDID(fmt.Sprintf("%v", subjectID))
-		if err != nil {
-			return nil, err
-		}
-
 		var id core.ID
-		id, err = core.IDFromDID(*did)
+		id, err = subjectIDFromDID(fmt.Sprintf("%v", subjectID),
+			opts.SubjectIDMode)
 		if err != nil {
 			return nil, err
 		}
@@ -519,8 +618,8 @@ func (vc *W3CCredential) ToCoreClaim(ctx context.Context, opts *CoreClaimOptions
 
 // CredentialSchema represent the information about credential schema
 type CredentialSchema struct {
-	ID   string `json:"id"`
-	Type string `json:"type"`
+	ID   string               `json:"id"`
+	Type CredentialSchemaType `json:"type"`
 }
 
 // CredentialStatus represents the URL to fetch claim revocation info directly from the issuer.
@@ -529,6 +628,15 @@ type CredentialStatus struct {
 	Type            CredentialStatusType `json:"type"`
 	RevocationNonce uint64               `json:"revocationNonce"`
 	StatusIssuer    *CredentialStatus    `json:"statusIssuer,omitempty"`
+
+	// StatusPurpose, StatusListIndex and StatusListCredential are set for
+	// StatusList2021Entry status type only
+	StatusPurpose        string `json:"statusPurpose,omitempty"`
+	StatusListIndex      string `json:"statusListIndex,omitempty"`
+	StatusListCredential string `json:"statusListCredential,omitempty"`
+
+	// params are fields of the status not defined above, see Params
+	params map[string]json.RawMessage
 }
 
 // RHSCredentialStatus contains type, url to fetch RHS info, issuer ID and revocation nonce and backup option to fetch credential status
@@ -564,6 +672,47 @@ func WithStatusResolverRegistry(registry *CredentialStatusResolverRegistry) W3CP
 	}
 }
 
+// WithMerklizeOptions sets options for merklization of the credential
+// during the core claim verification, e.g. the document loader.
+func WithMerklizeOptions(
+	opts ...merklize.MerklizeOption) W3CProofVerificationOpt {
+
+	return func(cfg *w3CProofVerificationConfig) {
+		cfg.merklizeOptions = append(cfg.merklizeOptions, opts...)
+	}
+}
+
+// WithExpirationCheck enables the check that the credential is not expired.
+// The credential is considered expired if its expirationDate plus leeway is
+// in the past. Credentials without expirationDate never expire.
+func WithExpirationCheck(leeway time.Duration) W3CProofVerificationOpt {
+	return func(opts *w3CProofVerificationConfig) {
+		opts.checkExpiration = true
+		opts.expirationLeeway = leeway
+	}
+}
+
+// WithIssuanceNotInFuture enables the check that the credential issuanceDate
+// is not in the future. leeway allows for clock skew between the issuer and
+// the verifier.
+func WithIssuanceNotInFuture(leeway time.Duration) W3CProofVerificationOpt {
+	return func(opts *w3CProofVerificationConfig) {
+		opts.checkIssuanceDate = true
+		opts.issuanceDateLeeway = leeway
+	}
+}
+
+// WithLogger sets the logger to receive events for each verification step,
+// including merklization of the credential and credential status
+// validation. The duration of the core claim step includes merklization.
+func WithLogger(logger Logger) W3CProofVerificationOpt {
+	return func(opts *w3CProofVerificationConfig) {
+		opts.logger = logger
+		opts.credStatusValidationOpts = append(opts.credStatusValidationOpts,
+			WithValidationLogger(logger))
+	}
+}
+
 // W3CProofVerificationOpt returns configuration options for W3C proof verification
 type W3CProofVerificationOpt func(opts *w3CProofVerificationConfig)
 
@@ -571,4 +720,14 @@ type W3CProofVerificationOpt func(opts *w3CProofVerificationConfig)
 type w3CProofVerificationConfig struct {
 	credStatusValidationOpts []CredentialStatusValidationOption
 	merklizeOptions          []merklize.MerklizeOption
+	checkExpiration          bool
+	expirationLeeway         time.Duration
+	checkIssuanceDate        bool
+	issuanceDateLeeway       time.Duration
+	logger                   Logger
+	issuerStateUpdate        bool
+	httpClient               *http.Client
+	validateCredentialSchema bool
+	schemaDocumentLoader     ld.DocumentLoader
+	staticDIDResolution      bool
 }
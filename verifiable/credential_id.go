@@ -0,0 +1,104 @@
+package verifiable
+
+import (
+	"crypto/sha1" //nolint:gosec // UUID v5 is defined with SHA-1
+	"encoding/binary"
+	"encoding/hex"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/pkg/errors"
+)
+
+// uuidNamespaceURL is the name space of URLs from RFC 4122
+var uuidNamespaceURL = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11,
+	0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// credentialIDNamespace is the name space of UUIDs of credential IDs
+var credentialIDNamespace = uuidV5(uuidNamespaceURL,
+	[]byte("https://iden3.io/credential-id"))
+
+type credentialIDOpts struct {
+	didURL bool
+}
+
+// CredentialIDOpt is an option for GenerateCredentialID
+type CredentialIDOpt func(*credentialIDOpts)
+
+// WithDIDURLCredentialID generates the ID as the DID URL of the issuer,
+// e.g. did:iden3:polygon:amoy:x6x...#credential-<uuid>, instead of urn:uuid
+func WithDIDURLCredentialID() CredentialIDOpt {
+	return func(o *credentialIDOpts) {
+		o.didURL = true
+	}
+}
+
+// GenerateCredentialID returns the deterministic ID of the credential in the
+// form of urn:uuid:<uuid>, where <uuid> is the name based UUID (version 5)
+// of the issuer DID, the schema hash, the subject ID (may be empty) and the
+// nonce, e.g. the revocation nonce. Re-issuance of the same credential gets
+// the same ID, and any difference in the inputs gives a different ID: the
+// inputs are encoded unambiguously, and 122 bits of the SHA-1 hash are
+// kept, so accidental collisions are negligible. IDs are not secret and
+// don't hide the inputs from anyone able to guess them.
+func GenerateCredentialID(issuerDID *w3c.DID, schemaHash core.SchemaHash,
+	subjectID string, nonce uint64, opts ...CredentialIDOpt) (string, error) {
+
+	if issuerDID == nil {
+		return "", errors.New("issuer DID is required")
+	}
+	var o credentialIDOpts
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	issuer := issuerDID.String()
+	name := make([]byte, 0, 8+len(issuer)+len(schemaHash)+8+len(subjectID)+8)
+	name = appendLengthPrefixed(name, []byte(issuer))
+	name = append(name, schemaHash[:]...)
+	name = appendLengthPrefixed(name, []byte(subjectID))
+	name = appendUint64(name, nonce)
+
+	id := formatUUID(uuidV5(credentialIDNamespace, name))
+	if o.didURL {
+		return issuer + "#credential-" + id, nil
+	}
+	return "urn:uuid:" + id, nil
+}
+
+func appendLengthPrefixed(buf, data []byte) []byte {
+	buf = appendUint64(buf, uint64(len(data)))
+	return append(buf, data...)
+}
+
+func appendUint64(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
+
+// uuidV5 returns the name based UUID with SHA-1 as defined in RFC 4122
+func uuidV5(namespace [16]byte, name []byte) [16]byte {
+	h := sha1.New() //nolint:gosec // UUID v5 is defined with SHA-1
+	h.Write(namespace[:])
+	h.Write(name)
+	var u [16]byte
+	copy(u[:], h.Sum(nil))
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+	return u
+}
+
+func formatUUID(u [16]byte) string {
+	buf := make([]byte, 36)
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf)
+}
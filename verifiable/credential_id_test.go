@@ -0,0 +1,67 @@
+package verifiable
+
+import (
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUUIDv5(t *testing.T) {
+	// uuid.uuid5(uuid.NAMESPACE_URL, "http://python.org/") in Python
+	require.Equal(t, "4c565f0d-3f5a-5890-b41b-20cf47701c5e",
+		formatUUID(uuidV5(uuidNamespaceURL, []byte("http://python.org/"))))
+}
+
+func TestGenerateCredentialID(t *testing.T) {
+	issuerDID, err := w3c.ParseDID(
+		"did:iden3:polygon:mumbai:wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ")
+	require.NoError(t, err)
+	var schemaHash core.SchemaHash
+	for i := range schemaHash {
+		schemaHash[i] = byte(i)
+	}
+	subjectID := "did:iden3:polygon:mumbai:x3HstHLj2rTp6HHXk2WczYP7w3rpCsRbwCMeaQ2H2"
+
+	id, err := GenerateCredentialID(issuerDID, schemaHash, subjectID, 42)
+	require.NoError(t, err)
+	require.Equal(t, "urn:uuid:9159c234-ba3a-5c94-b935-56b38c0135bb", id)
+
+	id2, err := GenerateCredentialID(issuerDID, schemaHash, subjectID, 42)
+	require.NoError(t, err)
+	require.Equal(t, id, id2)
+
+	id, err = GenerateCredentialID(issuerDID, schemaHash, subjectID, 42,
+		WithDIDURLCredentialID())
+	require.NoError(t, err)
+	require.Equal(t, issuerDID.String()+
+		"#credential-9159c234-ba3a-5c94-b935-56b38c0135bb", id)
+
+	otherSchemaHash := schemaHash
+	otherSchemaHash[0] = 0xff
+	otherIssuerDID, err := w3c.ParseDID(subjectID)
+	require.NoError(t, err)
+	ids := map[string]struct{}{}
+	for _, args := range []struct {
+		issuer     *w3c.DID
+		schemaHash core.SchemaHash
+		subjectID  string
+		nonce      uint64
+	}{
+		{issuerDID, schemaHash, subjectID, 42},
+		{issuerDID, schemaHash, subjectID, 43},
+		{issuerDID, schemaHash, "", 42},
+		{issuerDID, otherSchemaHash, subjectID, 42},
+		{otherIssuerDID, schemaHash, subjectID, 42},
+	} {
+		id, err := GenerateCredentialID(args.issuer, args.schemaHash,
+			args.subjectID, args.nonce)
+		require.NoError(t, err)
+		ids[id] = struct{}{}
+	}
+	require.Len(t, ids, 5)
+
+	_, err = GenerateCredentialID(nil, schemaHash, subjectID, 42)
+	require.EqualError(t, err, "issuer DID is required")
+}
@@ -0,0 +1,190 @@
+package verifiable
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/iden3/go-schema-processor/v2/utils"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ErrCredentialSchemaMismatch is returned when the credential does not match
+// the schema from its credentialSchema
+var ErrCredentialSchemaMismatch = errors.New(
+	"credential does not match its schema")
+
+// ErrUnsupportedCredentialSchemaType is returned when credential schema type
+// is not one of the known types
+var ErrUnsupportedCredentialSchemaType = errors.New(
+	"unsupported credential schema type")
+
+// CredentialSchemaType represent credential schema types
+type CredentialSchemaType string
+
+// Validate returns ErrUnsupportedCredentialSchemaType if credential schema
+// type is not known
+func (t CredentialSchemaType) Validate() error {
+	_, err := t.Validation()
+	return err
+}
+
+// CredentialSchemaValidation describes how credentials with the credential
+// schema type are validated
+type CredentialSchemaValidation struct {
+	// JSONSchema is true if the credential is validated against the JSON
+	// schema downloaded from credentialSchema.id
+	JSONSchema bool
+	// RequiresIden3Context is true if the type is defined by the iden3
+	// credential context, so the context should be in the @context of the
+	// credential
+	RequiresIden3Context bool
+	// Deprecated is true if the type should not be used for new credentials
+	Deprecated bool
+}
+
+// Validation returns the validation behavior of the credential schema type
+// or ErrUnsupportedCredentialSchemaType if the type is not known
+func (t CredentialSchemaType) Validation() (CredentialSchemaValidation,
+	error) {
+
+	switch t {
+	case JSONSchema2023:
+		return CredentialSchemaValidation{JSONSchema: true,
+			RequiresIden3Context: true}, nil
+	case JSONSchemaValidator2018:
+		return CredentialSchemaValidation{JSONSchema: true,
+			Deprecated: true}, nil
+	default:
+		return CredentialSchemaValidation{}, errors.Wrapf(
+			ErrUnsupportedCredentialSchemaType, "%q", string(t))
+	}
+}
+
+// WithCredentialSchemaValidation enables validation of the credential
+// against its credentialSchema. The JSON schema is downloaded from
+// credentialSchema.id and the credential, including credentialSubject, is
+// validated against it. Then the schema hash of the core claim from the
+// proof is checked to be the hash of the type from $metadata.type of the
+// schema expanded with the $metadata.uris.jsonLdContext context. The
+// documentLoader is used to download the schema and the context. If it is
+// nil, the default loader of the merklize package is used.
+func WithCredentialSchemaValidation(
+	documentLoader ld.DocumentLoader) W3CProofVerificationOpt {
+
+	return func(opts *w3CProofVerificationConfig) {
+		opts.validateCredentialSchema = true
+		opts.schemaDocumentLoader = documentLoader
+	}
+}
+
+type credentialSchemaMetadata struct {
+	Metadata struct {
+		URIs struct {
+			JSONLDContext string `json:"jsonLdContext"`
+		} `json:"uris"`
+		Type string `json:"type"`
+	} `json:"$metadata"`
+}
+
+func (vc *W3CCredential) verifyCredentialSchema(ctx context.Context,
+	coreClaim *core.Claim, documentLoader ld.DocumentLoader) error {
+
+	validation, err := vc.CredentialSchema.Type.Validation()
+	if err != nil {
+		return err
+	}
+	if !validation.JSONSchema {
+		return errors.Errorf(
+			"credential schema type %v is not validated with JSON schema",
+			vc.CredentialSchema.Type)
+	}
+	if vc.CredentialSchema.ID == "" {
+		return errors.New("credential schema id is empty")
+	}
+
+	jsonLDOpts := merklize.Options{DocumentLoader: documentLoader}.
+		JSONLDOptions()
+	schemaDoc, err := loaders.LoadDocument(vc.IntegrityContext(ctx),
+		jsonLDOpts.DocumentLoader, vc.CredentialSchema.ID)
+	if err != nil {
+		return errors.WithMessage(err, "can't load credential schema")
+	}
+	schemaBytes, err := json.Marshal(schemaDoc.Document)
+	if err != nil {
+		return err
+	}
+
+	err = validateCredentialJSONSchema(vc, vc.CredentialSchema.ID,
+		schemaBytes)
+	if err != nil {
+		return err
+	}
+
+	err = vc.CheckSchemaConstants(schemaBytes)
+	if err != nil {
+		return err
+	}
+
+	var metadata credentialSchemaMetadata
+	err = json.Unmarshal(schemaBytes, &metadata)
+	if err != nil {
+		return err
+	}
+	if metadata.Metadata.Type == "" ||
+		metadata.Metadata.URIs.JSONLDContext == "" {
+
+		return errors.New(
+			"credential schema has no $metadata type or jsonLdContext")
+	}
+
+	typeIRI, err := expandTypeIRI(SchemaType{
+		Context: []string{metadata.Metadata.URIs.JSONLDContext},
+		Type:    metadata.Metadata.Type,
+	}, jsonLDOpts)
+	if err != nil {
+		return err
+	}
+	schemaHash := utils.CreateSchemaHash([]byte(typeIRI))
+	if coreClaim.GetSchemaHash() != schemaHash {
+		return errors.Wrapf(ErrCredentialSchemaMismatch,
+			"core claim schema hash is not the hash of %v", typeIRI)
+	}
+
+	return nil
+}
+
+func validateCredentialJSONSchema(vc *W3CCredential, schemaID string,
+	schemaBytes []byte) error {
+
+	compiler := jsonschema.NewCompiler()
+	err := compiler.AddResource(schemaID, bytes.NewReader(schemaBytes))
+	if err != nil {
+		return err
+	}
+	schema, err := compiler.Compile(schemaID)
+	if err != nil {
+		return errors.WithMessage(err, "invalid credential schema")
+	}
+
+	credentialBytes, err := json.Marshal(vc)
+	if err != nil {
+		return err
+	}
+	var credential any
+	err = json.Unmarshal(credentialBytes, &credential)
+	if err != nil {
+		return err
+	}
+
+	err = schema.Validate(credential)
+	if err != nil {
+		return errors.Wrapf(ErrCredentialSchemaMismatch, "%v", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestW3CCredential_VerifyProof_CredentialSchema(t *testing.T) {
+	const schemaURL = "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json/KYCAgeCredential-v3.json"
+
+	testCases := []struct {
+		name       string
+		schemaFile string
+		err        string
+	}{
+		{
+			name:       "valid schema",
+			schemaFile: "./testdata/KYCAgeCredential-v3.json",
+		},
+		{
+			name:       "subject does not match schema",
+			schemaFile: "./testdata/KYCAgeCredential-v3-country-code.json",
+			err:        "missing properties: 'countryCode'",
+		},
+		{
+			name:       "schema hash does not match $metadata type",
+			schemaFile: "./testdata/KYCAgeCredential-v3-wrong-type.json",
+			err:        "core claim schema hash is not the hash of https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld#KYCCountryOfResidenceCredential: credential does not match its schema",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer tst.MockHTTPClient(t,
+				map[string]string{
+					"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+					"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../merklize/testdata/httpresp/iden3proofs.json-ld",
+					"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+					schemaURL:                                                                                        tc.schemaFile,
+					"http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e": `./testdata/verifycred//my-universal-resolver-1.json`,
+				}, tst.IgnoreUntouchedURLs())()
+
+			var vc W3CCredential
+			err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+			require.NoError(t, err)
+
+			resolverRegistry := &CredentialStatusResolverRegistry{}
+			resolverRegistry.Register(Iden3ReverseSparseMerkleTreeProof,
+				test1Resolver{})
+
+			err = vc.VerifyProof(context.Background(), BJJSignatureProofType,
+				HTTPDIDResolver{
+					resolverURL: "http://my-universal-resolver/1.0/identifiers"},
+				WithStatusResolverRegistry(resolverRegistry),
+				WithCredentialSchemaValidation(
+					loaders.NewDocumentLoader(nil, "")))
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrCredentialSchemaMismatch)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestCredentialSchemaType_Validation(t *testing.T) {
+	validation, err := JSONSchema2023.Validation()
+	require.NoError(t, err)
+	require.Equal(t, CredentialSchemaValidation{JSONSchema: true,
+		RequiresIden3Context: true}, validation)
+
+	validation, err = JSONSchemaValidator2018.Validation()
+	require.NoError(t, err)
+	require.Equal(t, CredentialSchemaValidation{JSONSchema: true,
+		Deprecated: true}, validation)
+
+	_, err = CredentialSchemaType("").Validation()
+	require.ErrorIs(t, err, ErrUnsupportedCredentialSchemaType)
+	require.ErrorIs(t, CredentialSchemaType("JsonSchema").Validate(),
+		ErrUnsupportedCredentialSchemaType)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"time"
 
 	"github.com/iden3/go-iden3-crypto/poseidon"
 	"github.com/iden3/go-merkletree-sql/v2"
@@ -15,6 +16,8 @@ var ErrCredentialIsRevoked = errors.New("credential is revoked")
 
 type credentialStatusValidationOpts struct {
 	statusResolverRegistry *CredentialStatusResolverRegistry
+	logger                 Logger
+	statusTypePreference   []CredentialStatusType
 }
 
 type CredentialStatusValidationOption func(*credentialStatusValidationOpts) error
@@ -27,9 +30,20 @@ func WithValidationStatusResolverRegistry(
 	}
 }
 
+// WithValidationLogger sets the logger to receive the credential status
+// validation event
+func WithValidationLogger(logger Logger) CredentialStatusValidationOption {
+	return func(opts *credentialStatusValidationOpts) error {
+		opts.logger = logger
+		return nil
+	}
+}
+
 // ValidateCredentialStatus resolves the credential status (possibly download
 // proofs from outer world) and validates the proof. May return
 // ErrCredentialIsRevoked if the credential was revoked.
+// The VerificationStepCredentialStatus event is reported to the logger set
+// with WithValidationLogger, no events are reported without it.
 func ValidateCredentialStatus(ctx context.Context, credStatus CredentialStatus,
 	opts ...CredentialStatusValidationOption) (RevocationStatus, error) {
 
@@ -43,40 +57,55 @@ func ValidateCredentialStatus(ctx context.Context, credStatus CredentialStatus,
 		}
 	}
 
+	start := time.Now()
 	revocationStatus, err := resolveRevStatus(ctx, credStatus,
 		o.statusResolverRegistry)
-	if err != nil {
-		return revocationStatus, err
-	}
+	if err == nil {
+		err = verifyRevocationStatus(revocationStatus,
+			credStatus.RevocationNonce)
+	}
+	logVerificationStep(ctx, o.logger, VerificationStepCredentialStatus, start,
+		err, map[string]any{
+			"type":            credStatus.Type,
+			"revocationNonce": credStatus.RevocationNonce,
+		})
+	return revocationStatus, err
+}
+
+// verifyRevocationStatus validates the issuer's tree state and the proof of
+// the revocation nonce in the revocation tree without any network requests.
+// Returns ErrCredentialIsRevoked if the nonce is found in the tree.
+func verifyRevocationStatus(revocationStatus RevocationStatus,
+	revocationNonce uint64) error {
 
 	treeStateOk, err := validateTreeState(revocationStatus.Issuer)
 	if err != nil {
-		return revocationStatus, err
+		return err
 	}
 	if !treeStateOk {
-		return revocationStatus, errors.New("signature proof: invalid tree state of the issuer while checking credential status of singing key")
+		return errors.New("signature proof: invalid tree state of the issuer while checking credential status of singing key")
 	}
 
 	revocationRootHash := &merkletree.HashZero
 	if revocationStatus.Issuer.RevocationTreeRoot != nil {
 		revocationRootHash, err = merkletree.NewHashFromHex(*revocationStatus.Issuer.RevocationTreeRoot)
 		if err != nil {
-			return revocationStatus, err
+			return err
 		}
 	}
 
-	revNonce := new(big.Int).SetUint64(credStatus.RevocationNonce)
+	revNonce := new(big.Int).SetUint64(revocationNonce)
 	proofValid := merkletree.VerifyProof(revocationRootHash,
 		&revocationStatus.MTP, revNonce, big.NewInt(0))
 	if !proofValid {
-		return revocationStatus, fmt.Errorf("proof validation failed. revNonce=%d", revNonce)
+		return fmt.Errorf("proof validation failed. revNonce=%d", revNonce)
 	}
 
 	if revocationStatus.MTP.Existence {
-		return revocationStatus, ErrCredentialIsRevoked
+		return ErrCredentialIsRevoked
 	}
 
-	return revocationStatus, nil
+	return nil
 }
 
 func coerceCredentialStatus(credStatus any) (*CredentialStatus, error) {
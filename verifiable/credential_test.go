@@ -2,14 +2,22 @@ package verifiable
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"math/big"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/babyjub"
 	mt "github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/iden3/go-schema-processor/v2/merklize"
 	tst "github.com/iden3/go-schema-processor/v2/testing"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
 )
 
 type test1Resolver struct{}
@@ -20,8 +28,8 @@ func (test1Resolver) Resolve(context context.Context, status CredentialStatus) (
 	_ = json.Unmarshal([]byte(statusJSON), &rs)
 	return rs, nil
 }
-func TestW3CCredential_ValidateBJJSignatureProof(t *testing.T) {
-	in := `{
+
+const bjjSignatureProofCredential = `{
     "id": "urn:uuid:3a8d1822-a00e-11ee-8f57-a27b3ddbdc29",
     "@context": [
         "https://www.w3.org/2018/credentials/v1",
@@ -85,6 +93,9 @@ func TestW3CCredential_ValidateBJJSignatureProof(t *testing.T) {
         }
     ]
 }`
+
+func TestW3CCredential_ValidateBJJSignatureProof(t *testing.T) {
+	in := bjjSignatureProofCredential
 	var vc W3CCredential
 	err := json.Unmarshal([]byte(in), &vc)
 	require.NoError(t, err)
@@ -105,6 +116,113 @@ func TestW3CCredential_ValidateBJJSignatureProof(t *testing.T) {
 	err = vc.VerifyProof(context.Background(), BJJSignatureProofType,
 		HTTPDIDResolver{resolverURL: resolverURL}, verifyConfig...)
 	require.NoError(t, err)
+
+	t.Run("logger", func(t *testing.T) {
+		var events []VerificationEvent
+		logger := LoggerFunc(func(_ context.Context, e VerificationEvent) {
+			require.NoError(t, e.Err)
+			events = append(events, e)
+		})
+		err = vc.VerifyProof(context.Background(), BJJSignatureProofType,
+			HTTPDIDResolver{resolverURL: resolverURL},
+			append(verifyConfig, WithLogger(logger))...)
+		require.NoError(t, err)
+
+		var steps []string
+		for _, e := range events {
+			steps = append(steps, e.Step)
+		}
+		require.Equal(t, []string{
+			VerificationStepValidityPeriod,
+			VerificationStepMerklization,
+			VerificationStepCoreClaim,
+			VerificationStepSignature,
+			VerificationStepDIDResolution,
+			VerificationStepCredentialStatus,
+		}, steps)
+
+		mz, err := vc.Merklize(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, mz.Root().String(), events[1].Attrs["root"])
+		require.Equal(t,
+			"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf",
+			events[3].Attrs["issuer"])
+		require.Equal(t, Iden3ReverseSparseMerkleTreeProof,
+			events[5].Attrs["type"])
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		proof, ok := vc.Proof.BJJSignature()
+		require.True(t, ok)
+
+		err = vc.WithoutProofs().VerifyDetachedProof(context.Background(),
+			proof, HTTPDIDResolver{resolverURL: resolverURL},
+			verifyConfig...)
+		require.NoError(t, err)
+
+		err = VerifyDetachedBJJSignature(context.Background(),
+			proof.CoreClaim, proof.IssuerData, proof.Signature,
+			HTTPDIDResolver{resolverURL: resolverURL}, verifyConfig...)
+		require.NoError(t, err)
+
+		otherKey := babyjub.NewRandPrivKey()
+		otherSig, err := otherKey.SignPoseidon(big.NewInt(1)).
+			Compress().MarshalText()
+		require.NoError(t, err)
+		err = VerifyDetachedBJJSignature(context.Background(),
+			proof.CoreClaim, proof.IssuerData, string(otherSig),
+			HTTPDIDResolver{resolverURL: resolverURL}, verifyConfig...)
+		require.EqualError(t, err, "claim signature validation failed")
+
+		vc2 := vc.WithoutProofs()
+		vc2.CredentialSubject["birthday"] = 1
+		err = vc2.VerifyDetachedProof(context.Background(), proof,
+			HTTPDIDResolver{resolverURL: resolverURL}, verifyConfig...)
+		require.EqualError(t, err, "proof generated for another credential")
+	})
+}
+
+func TestValidateCredentialStatus_Logger(t *testing.T) {
+	registry := CredentialStatusResolverRegistry{}
+	registry.Register(Iden3ReverseSparseMerkleTreeProof, test1Resolver{})
+	credStatus := CredentialStatus{
+		ID:              "https://rhs-staging.polygonid.me/node",
+		Type:            Iden3ReverseSparseMerkleTreeProof,
+		RevocationNonce: 74881362,
+	}
+
+	var events []VerificationEvent
+	logger := LoggerFunc(func(_ context.Context, e VerificationEvent) {
+		events = append(events, e)
+	})
+
+	// no events without WithValidationLogger
+	_, err := ValidateCredentialStatus(context.Background(), credStatus,
+		WithValidationStatusResolverRegistry(&registry))
+	require.NoError(t, err)
+	require.Empty(t, events)
+
+	_, err = ValidateCredentialStatus(context.Background(), credStatus,
+		WithValidationStatusResolverRegistry(&registry),
+		WithValidationLogger(logger))
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, VerificationStepCredentialStatus, events[0].Step)
+	require.NoError(t, events[0].Err)
+	require.Equal(t, map[string]any{
+		"type":            Iden3ReverseSparseMerkleTreeProof,
+		"revocationNonce": uint64(74881362),
+	}, events[0].Attrs)
+
+	// resolver is not registered for the status type
+	events = nil
+	credStatus.Type = SparseMerkleTreeProof
+	_, err = ValidateCredentialStatus(context.Background(), credStatus,
+		WithValidationStatusResolverRegistry(&registry),
+		WithValidationLogger(logger))
+	require.Error(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, err, events[0].Err)
 }
 
 type test2Resolver struct{}
@@ -306,9 +424,42 @@ func TestW3CCredential_ValidateIden3SparseMerkleTreeProof(t *testing.T) {
 			"http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=34824a8e1defc326f935044e32e9f513377dbfc031d79475a0190830554d4409": `./testdata/verifycred//my-universal-resolver-3.json`,
 		}, tst.IgnoreUntouchedURLs())()
 
+	var steps []string
+	logger := LoggerFunc(func(_ context.Context, e VerificationEvent) {
+		require.NoError(t, e.Err)
+		steps = append(steps, e.Step)
+	})
+
 	err = vc.VerifyProof(context.Background(), Iden3SparseMerkleTreeProofType,
-		HTTPDIDResolver{resolverURL: resolverURL})
+		HTTPDIDResolver{resolverURL: resolverURL}, WithLogger(logger))
 	require.NoError(t, err)
+	require.Equal(t, []string{
+		VerificationStepValidityPeriod,
+		VerificationStepMerklization,
+		VerificationStepCoreClaim,
+		VerificationStepDIDResolution,
+		VerificationStepMTProof,
+	}, steps)
+
+	t.Run("detached", func(t *testing.T) {
+		proof, ok := vc.Proof.SMT()
+		require.True(t, ok)
+
+		err = VerifyDetachedIden3SparseMerkleTreeProof(context.Background(),
+			proof.CoreClaim, proof.IssuerData, proof.MTP,
+			HTTPDIDResolver{resolverURL: resolverURL})
+		require.NoError(t, err)
+
+		err = VerifyDetachedIden3SparseMerkleTreeProof(context.Background(),
+			proof.CoreClaim, proof.IssuerData, nil,
+			HTTPDIDResolver{resolverURL: resolverURL})
+		require.EqualError(t, err, "merkle tree proof is empty")
+
+		err = VerifyDetachedIden3SparseMerkleTreeProof(context.Background(),
+			"bad", proof.IssuerData, proof.MTP,
+			HTTPDIDResolver{resolverURL: resolverURL})
+		require.ErrorContains(t, err, "invalid core claim")
+	})
 }
 
 type test3Resolver struct{}
@@ -711,3 +862,448 @@ func TestW3CCredential_MerklizationWithEmptyID(t *testing.T) {
 	_, err = mz.Entry(path)
 	require.NoError(t, err)
 }
+
+func TestW3CCredential_MerklizeSubject(t *testing.T) {
+	defer tst.MockHTTPClient(t, map[string]string{
+		"https://www.w3.org/2018/credentials/v1":              "../merklize/testdata/httpresp/credentials-v1.jsonld",
+		"https://example.com/schema-delivery-address.json-ld": "../json/testdata/schema-delivery-address.json-ld",
+	}, tst.IgnoreUntouchedURLs())()
+
+	vcData, err := os.ReadFile("../json/testdata/non-merklized-1.json-ld")
+	require.NoError(t, err)
+	var vc W3CCredential
+	err = json.Unmarshal(vcData, &vc)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	mz, err := vc.Merklize(ctx)
+	require.NoError(t, err)
+	subjectMz, err := vc.MerklizeSubject(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, mz.Root(), subjectMz.Root())
+
+	entryValue := func(mz *merklize.Merklizer, docPath string) any {
+		path, err := mz.ResolveDocPath(docPath)
+		require.NoError(t, err)
+		entry, err := mz.Entry(path)
+		require.NoError(t, err)
+		return entry.Value()
+	}
+	require.Equal(t, entryValue(mz, "credentialSubject.price"),
+		entryValue(subjectMz, "price"))
+	require.Equal(t,
+		entryValue(mz, "credentialSubject.postalProviderInformation.weight"),
+		entryValue(subjectMz, "postalProviderInformation.weight"))
+
+	// the subject root does not depend on other credential fields
+	vc.Issuer = "did:example:other"
+	subjectMz2, err := vc.MerklizeSubject(ctx)
+	require.NoError(t, err)
+	require.Equal(t, subjectMz.Root(), subjectMz2.Root())
+
+	vc.CredentialSubject = nil
+	_, err = vc.MerklizeSubject(ctx)
+	require.EqualError(t, err, "credential subject is empty")
+}
+
+func TestParseW3CCredential_StrictTypes(t *testing.T) {
+	credJSON := func(refreshType, displayType string) []byte {
+		return []byte(`{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:issuer",
+  "credentialSubject": {"id": "did:example:subject"},
+  "refreshService": {"id": "https://issuer.example/refresh", "type": "` +
+			refreshType + `"},
+  "displayMethod": {"id": "ipfs://QmS8eY8ZCiAAW8qgx3T6SQ3HDGeddwLZsjPXNAZExQwRY4", "type": "` +
+			displayType + `"}
+}`)
+	}
+
+	// known types are accepted in both modes
+	known := credJSON("Iden3RefreshService2023", "Iden3BasicDisplayMethodV1")
+	vc, err := ParseW3CCredential(known, WithStrictTypes())
+	require.NoError(t, err)
+	require.Equal(t, Iden3RefreshService2023, vc.RefreshService.Type)
+	require.Equal(t, Iden3BasicDisplayMethodV1, vc.DisplayMethod.Type)
+
+	// json.Unmarshal and ParseW3CCredential without options are lenient
+	unknownRefresh := credJSON("Iden3RefreshService2024",
+		"Iden3BasicDisplayMethodV1")
+	var vc2 W3CCredential
+	err = json.Unmarshal(unknownRefresh, &vc2)
+	require.NoError(t, err)
+	require.Equal(t, RefreshServiceType("Iden3RefreshService2024"),
+		vc2.RefreshService.Type)
+	vc, err = ParseW3CCredential(unknownRefresh)
+	require.NoError(t, err)
+	require.Equal(t, &vc2, vc)
+
+	_, err = ParseW3CCredential(unknownRefresh, WithStrictTypes())
+	require.ErrorIs(t, err, ErrUnsupportedRefreshServiceType)
+
+	unknownDisplay := credJSON("Iden3RefreshService2023",
+		"Iden3BasicDisplayMethodV2")
+	_, err = ParseW3CCredential(unknownDisplay)
+	require.NoError(t, err)
+	_, err = ParseW3CCredential(unknownDisplay, WithStrictTypes())
+	require.ErrorIs(t, err, ErrUnsupportedDisplayMethodType)
+}
+
+func TestParseW3CCredential_StrictCredentialSchemaType(t *testing.T) {
+	credJSON := func(schemaType string) []byte {
+		return []byte(`{
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "type": ["VerifiableCredential"],
+  "issuer": "did:example:issuer",
+  "credentialSubject": {"id": "did:example:subject"},
+  "credentialSchema": {"id": "https://example.com/schema.json", "type": "` +
+			schemaType + `"}
+}`)
+	}
+
+	vc, err := ParseW3CCredential(credJSON("JsonSchema2023"),
+		WithStrictTypes())
+	require.NoError(t, err)
+	require.Equal(t, JSONSchema2023, vc.CredentialSchema.Type)
+
+	vc, err = ParseW3CCredential(credJSON("JsonSchemaValidator2018"),
+		WithStrictTypes())
+	require.NoError(t, err)
+	require.Equal(t, JSONSchemaValidator2018, vc.CredentialSchema.Type)
+
+	unknown := credJSON("JsonSchema2024")
+	vc, err = ParseW3CCredential(unknown)
+	require.NoError(t, err)
+	require.Equal(t, CredentialSchemaType("JsonSchema2024"),
+		vc.CredentialSchema.Type)
+	_, err = ParseW3CCredential(unknown, WithStrictTypes())
+	require.ErrorIs(t, err, ErrUnsupportedCredentialSchemaType)
+	require.EqualError(t, err, `credentialSchema: "JsonSchema2024": `+
+		`unsupported credential schema type`)
+}
+
+func TestNewIden3RefreshService(t *testing.T) {
+	rs, err := NewIden3RefreshService("https://issuer.example/refresh")
+	require.NoError(t, err)
+	require.Equal(t, &RefreshService{ID: "https://issuer.example/refresh",
+		Type: Iden3RefreshService2023}, rs)
+
+	_, err = NewIden3RefreshService("/refresh")
+	require.EqualError(t, err, "service id is not an absolute URL")
+
+	dm, err := NewIden3BasicDisplayMethod("https://issuer.example/display.json")
+	require.NoError(t, err)
+	require.Equal(t, Iden3BasicDisplayMethodV1, dm.Type)
+}
+
+func TestW3CCredential_verifyValidityPeriod(t *testing.T) {
+	issuanceDate := time.Date(2023, 12, 21, 16, 35, 46, 0, time.UTC)
+	expirationDate := time.Date(2024, 12, 21, 16, 35, 46, 0, time.UTC)
+	vc := W3CCredential{
+		IssuanceDate: &issuanceDate,
+		Expiration:   &expirationDate,
+	}
+
+	cfg := w3CProofVerificationConfig{}
+	WithExpirationCheck(time.Minute)(&cfg)
+	WithIssuanceNotInFuture(time.Minute)(&cfg)
+
+	err := vc.verifyValidityPeriod(cfg, issuanceDate.Add(time.Hour))
+	require.NoError(t, err)
+
+	err = vc.verifyValidityPeriod(cfg, expirationDate.Add(30*time.Second))
+	require.NoError(t, err)
+
+	err = vc.verifyValidityPeriod(cfg, expirationDate.Add(2*time.Minute))
+	require.ErrorIs(t, err, ErrCredentialExpired)
+
+	err = vc.verifyValidityPeriod(cfg, issuanceDate.Add(-30*time.Second))
+	require.NoError(t, err)
+
+	err = vc.verifyValidityPeriod(cfg, issuanceDate.Add(-2*time.Minute))
+	require.ErrorIs(t, err, ErrCredentialNotYetValid)
+
+	// checks are disabled by default
+	err = vc.verifyValidityPeriod(w3CProofVerificationConfig{},
+		expirationDate.Add(time.Hour))
+	require.NoError(t, err)
+}
+
+func TestW3CCredential_SnapshotNonRevocation(t *testing.T) {
+	vc := W3CCredential{
+		CredentialStatus: CredentialStatus{
+			ID:              "https://rhs-staging.polygonid.me/node",
+			Type:            Iden3ReverseSparseMerkleTreeProof,
+			RevocationNonce: 74881362,
+		},
+	}
+
+	registry := CredentialStatusResolverRegistry{}
+	registry.Register(Iden3ReverseSparseMerkleTreeProof, test1Resolver{})
+
+	snapshot, err := vc.SnapshotNonRevocation(context.Background(),
+		WithValidationStatusResolverRegistry(&registry))
+	require.NoError(t, err)
+
+	snapshotBytes, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+
+	var snapshot2 NonRevocationSnapshot
+	err = json.Unmarshal(snapshotBytes, &snapshot2)
+	require.NoError(t, err)
+	require.NoError(t, snapshot2.VerifyForCredential(&vc))
+
+	vc2 := vc
+	vc2.CredentialStatus = CredentialStatus{
+		ID:              "https://rhs-staging.polygonid.me/node",
+		Type:            Iden3ReverseSparseMerkleTreeProof,
+		RevocationNonce: 1,
+	}
+	require.EqualError(t, snapshot2.VerifyForCredential(&vc2),
+		"snapshot was made for another credential status")
+
+	wrongState := "0000000000000000000000000000000000000000000000000000000000000000"
+	snapshot2.RevocationStatus.Issuer.State = &wrongState
+	require.Error(t, snapshot2.Verify())
+}
+
+func TestW3CCredential_Clone(t *testing.T) {
+	in := `{
+  "id": "urn:uuid:3a8d1822-a00e-11ee-8f57-a27b3ddbdc29",
+  "@context": ["https://www.w3.org/2018/credentials/v1"],
+  "type": ["VerifiableCredential", "KYCAgeCredential"],
+  "issuanceDate": "2023-12-21T13:15:31.233735Z",
+  "credentialSubject": {
+    "id": "did:polygonid:polygon:mumbai:2qFDziX3k3h7To2jDJbQiXFtcozbgSNNasvBLt5nTZ",
+    "address": {"city": "Kyiv"},
+    "tags": ["a", {"b": "c"}]
+  },
+  "credentialStatus": {
+    "id": "https://example.com/status/1",
+    "type": "SparseMerkleTreeProof",
+    "revocationNonce": 1
+  },
+  "issuer": "did:polygonid:polygon:mumbai:2qLx3hTJBV8REpNDK2RiG7eNBVzXMoZdPfi2uhF7Ks",
+  "credentialSchema": {
+    "id": "https://example.com/schema.json",
+    "type": "JsonSchema2023"
+  },
+  "proof": [{"type": "CustomProof", "nested": {"key": "value"}}]
+}`
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(in), &vc)
+	require.NoError(t, err)
+
+	vc2, err := vc.Clone()
+	require.NoError(t, err)
+	require.Equal(t, &vc, vc2)
+
+	vc2.CredentialSubject["address"].(map[string]any)["city"] = "Lviv"
+	vc2.CredentialSubject["tags"].([]any)[1].(map[string]any)["b"] = "d"
+	vc2.CredentialStatus.(map[string]any)["revocationNonce"] = 2
+	(*vc2.Proof[0].(*CommonProof))["nested"].(map[string]any)["key"] = "x"
+	vc2.Type[1] = "Other"
+	*vc2.IssuanceDate = vc2.IssuanceDate.AddDate(1, 0, 0)
+
+	require.Equal(t, "Kyiv",
+		vc.CredentialSubject["address"].(map[string]any)["city"])
+	require.Equal(t, "c",
+		vc.CredentialSubject["tags"].([]any)[1].(map[string]any)["b"])
+	require.Equal(t, float64(1),
+		vc.CredentialStatus.(map[string]any)["revocationNonce"])
+	require.Equal(t, "value",
+		(*vc.Proof[0].(*CommonProof))["nested"].(map[string]any)["key"])
+	require.Equal(t, "KYCAgeCredential", vc.Type[1])
+	require.Equal(t, 2023, vc.IssuanceDate.Year())
+
+	vc3 := vc.WithoutProofs()
+	require.Nil(t, vc3.Proof)
+	require.Len(t, vc.Proof, 1)
+	vc3.Proof = vc.Proof
+	require.Equal(t, &vc, vc3)
+}
+
+func TestExportVerificationBundle(t *testing.T) {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	resolverURL := "http://my-universal-resolver/1.0/identifiers"
+	restoreHTTPClient := tst.MockHTTPClient(t,
+		map[string]string{
+			"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+			"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			"http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e": `./testdata/verifycred//my-universal-resolver-1.json`,
+		}, tst.IgnoreUntouchedURLs())
+
+	registry := CredentialStatusResolverRegistry{}
+	registry.Register(Iden3ReverseSparseMerkleTreeProof, test1Resolver{})
+
+	bundle, err := ExportVerificationBundle(context.Background(), &vc,
+		BJJSignatureProofType, HTTPDIDResolver{resolverURL: resolverURL},
+		WithBundleStatusResolverRegistry(&registry),
+		WithBundleDocumentLoader(loaders.NewDocumentLoader(nil, "")))
+	require.NoError(t, err)
+	restoreHTTPClient()
+
+	require.Contains(t, bundle.Contexts,
+		"https://www.w3.org/2018/credentials/v1")
+	require.Contains(t, bundle.Contexts,
+		"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld")
+	require.Contains(t, bundle.Contexts,
+		"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld")
+	require.Contains(t, bundle.DIDDocuments,
+		"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e")
+	require.Len(t, bundle.RevocationStatuses, 2)
+
+	bundleBytes, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	var bundle2 VerificationBundle
+	err = json.Unmarshal(bundleBytes, &bundle2)
+	require.NoError(t, err)
+
+	// no http requests are expected during offline verification
+	defer tst.MockHTTPClient(t, map[string]string{})()
+
+	err = VerifyOffline(context.Background(), &bundle2)
+	require.NoError(t, err)
+
+	delete(bundle2.Contexts, "https://www.w3.org/2018/credentials/v1")
+	err = VerifyOffline(context.Background(), &bundle2)
+	require.ErrorContains(t, err,
+		"document https://www.w3.org/2018/credentials/v1 is not in the bundle")
+}
+
+func TestW3CCredential_EncryptSubjectFields(t *testing.T) {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+			"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+		}, tst.IgnoreUntouchedURLs())()
+
+	var privKey [32]byte
+	_, err = rand.Read(privKey[:])
+	require.NoError(t, err)
+	pubKey, err := curve25519.X25519(privKey[:], curve25519.Basepoint)
+	require.NoError(t, err)
+
+	didDoc := DIDDocument{
+		ID: "did:example:123",
+		KeyAgreement: []interface{}{
+			map[string]interface{}{
+				"id":   "did:example:123#key-x25519",
+				"type": "JsonWebKey2020",
+				"publicKeyJwk": map[string]interface{}{
+					"kty": "OKP",
+					"crv": "X25519",
+					"x":   base64.RawURLEncoding.EncodeToString(pubKey),
+				},
+			},
+		},
+	}
+	recipient, err := X25519KeyFromDIDDocument(didDoc, "")
+	require.NoError(t, err)
+	require.Equal(t, "did:example:123#key-x25519", recipient.KeyID)
+
+	encVC, err := vc.EncryptSubjectFields(context.Background(), recipient,
+		[]string{"birthday"})
+	require.NoError(t, err)
+	require.NotContains(t, encVC.Credential.CredentialSubject, "birthday")
+	require.Contains(t, vc.CredentialSubject, "birthday")
+	require.Equal(t, vc.Proof, encVC.Credential.Proof)
+	require.Len(t, encVC.EncryptedFields, 1)
+	require.Equal(t, "birthday", encVC.EncryptedFields[0].Path)
+	require.Equal(t, "19960424", encVC.EncryptedFields[0].ValueHash)
+
+	encBytes, err := json.Marshal(encVC)
+	require.NoError(t, err)
+	var encVC2 EncryptedCredential
+	err = json.Unmarshal(encBytes, &encVC2)
+	require.NoError(t, err)
+
+	vc2, err := encVC2.Decrypt(privKey)
+	require.NoError(t, err)
+	require.Equal(t, vc.CredentialSubject, vc2.CredentialSubject)
+
+	var wrongKey [32]byte
+	_, err = rand.Read(wrongKey[:])
+	require.NoError(t, err)
+	_, err = encVC2.Decrypt(wrongKey)
+	require.ErrorContains(t, err, "can't decrypt field birthday")
+}
+
+func TestW3CCredential_ValidateStructure(t *testing.T) {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+	require.Empty(t, vc.ValidateStructure())
+
+	vc.Context = []string{vc.Context[1], vc.Context[0]}
+	vc.Type = []string{"KYCAgeCredential"}
+	vc.Issuer = "not a uri"
+	expiration := vc.IssuanceDate.Add(-time.Hour)
+	vc.Expiration = &expiration
+	vc.CredentialSubject["id"] = 123
+	vc.CredentialSchema.Type = ""
+	vc.CredentialStatus = map[string]any{"type": "SparseMerkleTreeProof"}
+
+	var violations []string
+	for _, v := range vc.ValidateStructure() {
+		violations = append(violations, v.String())
+	}
+	require.Equal(t, []string{
+		"@context: first entry must be https://www.w3.org/2018/credentials/v1, got https://schema.iden3.io/core/jsonld/iden3proofs.jsonld",
+		"type: must contain VerifiableCredential",
+		"issuer: must be a URI: not a uri",
+		"expirationDate: must not be before issuanceDate",
+		"credentialSubject.id: must be a URI: 123",
+		"credentialSchema.type: is required",
+		"credentialStatus.id: is required",
+	}, violations)
+
+	require.Equal(t, []StructureViolation{
+		{Property: "@context", Message: "is required"},
+		{Property: "type", Message: "is required"},
+		{Property: "issuer", Message: "is required"},
+		{Property: "issuanceDate", Message: "is required"},
+		{Property: "credentialSubject", Message: "is required"},
+	}, (&W3CCredential{}).ValidateStructure())
+}
+
+func TestHTTPDIDResolver_ResolveAtState(t *testing.T) {
+	did, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf")
+	require.NoError(t, err)
+	state, err := mt.NewHashFromHex(
+		"f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e")
+	require.NoError(t, err)
+	gist, err := mt.NewHashFromHex(
+		"0d1e4a2b3c5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b")
+	require.NoError(t, err)
+
+	resolverURL := "http://my-universal-resolver/1.0/identifiers"
+	defer tst.MockHTTPClient(t, map[string]string{
+		"http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?gist=0d1e4a2b3c5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b&state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e": `./testdata/verifycred/my-universal-resolver-1.json`,
+	})()
+
+	didDoc, err := HTTPDIDResolver{resolverURL: resolverURL}.ResolveAtState(
+		context.Background(), did, state, gist)
+	require.NoError(t, err)
+	require.NotEmpty(t, didDoc.VerificationMethod)
+	require.Empty(t, did.Query)
+
+	versionTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	didAtTime, err := DIDAtVersion(did, WithVersionTime(versionTime),
+		WithVersionID("7"))
+	require.NoError(t, err)
+	require.Equal(t,
+		"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?versionId=7&versionTime=2024-01-02T03%3A04%3A05Z",
+		didAtTime.String())
+}
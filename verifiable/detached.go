@@ -0,0 +1,109 @@
+package verifiable
+
+import (
+	"context"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	mt "github.com/iden3/go-merkletree-sql/v2"
+	"github.com/pkg/errors"
+)
+
+// VerifyDetachedProof verifies the proof of the credential stored separately
+// from it, e.g. in the other database table. The credential must not contain
+// the proof, but the proof's core claim must be issued for the credential.
+// The checks are the same as for VerifyProof.
+func (vc *W3CCredential) VerifyDetachedProof(ctx context.Context,
+	proof CredentialProof, didResolver DIDResolver,
+	opts ...W3CProofVerificationOpt) error {
+
+	if proof == nil {
+		return ErrProofNotFound
+	}
+
+	verifyConfig, err := vc.prepareProofVerification(ctx, didResolver, opts)
+	if err != nil {
+		return err
+	}
+
+	return vc.verifyProof(ctx, proof, didResolver, verifyConfig)
+}
+
+// VerifyDetachedBJJSignature verifies BJJSignature2021 signature of the core
+// claim without the credential: the issuer's auth claim signature, state and
+// auth claim revocation status are checked. It does not check that the core
+// claim was issued for some credential, use VerifyDetachedProof for that.
+// Validity period options are ignored.
+func VerifyDetachedBJJSignature(ctx context.Context, coreClaimHex string,
+	issuerData IssuerData, signatureHex string, didResolver DIDResolver,
+	opts ...W3CProofVerificationOpt) error {
+
+	coreClaim, err := coreClaimFromHex(coreClaimHex)
+	if err != nil {
+		return err
+	}
+
+	if issuerData.State.Value == nil {
+		return errors.New("issuer state is empty")
+	}
+
+	verifyConfig, err := newProofVerificationConfig(didResolver, opts)
+	if err != nil {
+		return err
+	}
+
+	proof := BJJSignatureProof2021{
+		Type:       BJJSignatureProofType,
+		IssuerData: issuerData,
+		CoreClaim:  coreClaimHex,
+		Signature:  signatureHex,
+	}
+	return verifyBJJSignatureProof(ctx, proof, coreClaim, didResolver,
+		verifyConfig)
+}
+
+// VerifyDetachedIden3SparseMerkleTreeProof verifies Iden3SparseMerkleTreeProof
+// of the core claim without the credential: the issuer's state and the
+// inclusion of the core claim into the issuer's claims tree are checked. It
+// does not check that the core claim was issued for some credential, use
+// VerifyDetachedProof for that. Validity period options are ignored.
+func VerifyDetachedIden3SparseMerkleTreeProof(ctx context.Context,
+	coreClaimHex string, issuerData IssuerData, mtp *mt.Proof,
+	didResolver DIDResolver, opts ...W3CProofVerificationOpt) error {
+
+	coreClaim, err := coreClaimFromHex(coreClaimHex)
+	if err != nil {
+		return err
+	}
+
+	if mtp == nil {
+		return errors.New("merkle tree proof is empty")
+	}
+	if issuerData.State.Value == nil ||
+		issuerData.State.ClaimsTreeRoot == nil {
+
+		return errors.New("issuer state is empty")
+	}
+
+	verifyConfig, err := newProofVerificationConfig(didResolver, opts)
+	if err != nil {
+		return err
+	}
+
+	proof := Iden3SparseMerkleTreeProof{
+		Type:       Iden3SparseMerkleTreeProofType,
+		IssuerData: issuerData,
+		CoreClaim:  coreClaimHex,
+		MTP:        mtp,
+	}
+	return verifyIden3SparseMerkleTreeProof(ctx, proof, coreClaim,
+		didResolver, verifyConfig)
+}
+
+func coreClaimFromHex(coreClaimHex string) (*core.Claim, error) {
+	var coreClaim core.Claim
+	err := coreClaim.FromHex(coreClaimHex)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid core claim")
+	}
+	return &coreClaim, nil
+}
@@ -1,6 +1,7 @@
 package verifiable
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
@@ -124,6 +125,9 @@ type StateInfo struct {
 	ReplacedAtTimestamp string `json:"replacedAtTimestamp"`
 	CreatedAtBlock      string `json:"createdAtBlock"`
 	ReplacedAtBlock     string `json:"replacedAtBlock"`
+	// Proofs of the state. Unmarshals both from a single proof object and
+	// from an array of proofs.
+	Proofs GistInfoProofs `json:"proof,omitempty"`
 }
 
 // GistInfo representation state of gist root.
@@ -135,16 +139,76 @@ type GistInfo struct {
 	CreatedAtBlock      string         `json:"createdAtBlock"`
 	ReplacedAtBlock     string         `json:"replacedAtBlock"`
 	Proof               *GistInfoProof `json:"proof,omitempty"`
+	// OtherProofs contains the proofs following Proof if the resolver
+	// returned an array of proofs. Proof is always the first one, so every
+	// proof is stored only once.
+	OtherProofs GistInfoProofs `json:"-"`
+}
+
+// AllProofs returns Proof followed by OtherProofs
+func (g GistInfo) AllProofs() GistInfoProofs {
+	var proofs GistInfoProofs
+	if g.Proof != nil {
+		proofs = append(proofs, *g.Proof)
+	}
+	return append(proofs, g.OtherProofs...)
+}
+
+// MarshalJSON for GistInfo. If there are OtherProofs, the proof field is an
+// array of all proofs, otherwise it is the single Proof object.
+func (g GistInfo) MarshalJSON() ([]byte, error) {
+	type Alias GistInfo
+	var obj struct {
+		Alias
+		Proof any `json:"proof,omitempty"`
+	}
+	obj.Alias = Alias(g)
+	if len(g.OtherProofs) > 0 {
+		obj.Proof = g.AllProofs()
+	} else if g.Proof != nil {
+		obj.Proof = g.Proof
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON for GistInfo. The proof field may be either a single proof
+// object or an array of proofs. The first proof is set to Proof and the rest
+// to OtherProofs.
+func (g *GistInfo) UnmarshalJSON(data []byte) error {
+	type Alias GistInfo
+	var obj struct {
+		Alias
+		Proof GistInfoProofs `json:"proof,omitempty"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*g = GistInfo(obj.Alias)
+	if len(obj.Proof) > 0 {
+		g.Proof = &obj.Proof[0]
+	}
+	if len(obj.Proof) > 1 {
+		g.OtherProofs = obj.Proof[1:]
+	}
+	return nil
 }
 
 // GistInfoProof representation proof of GistInfo object.
 type GistInfoProof struct {
 	merkletree.Proof
 	Type ProofType `json:"type"`
+	// Raw is the original JSON of the proof if its type is not
+	// Iden3SparseMerkleTreeProof. Such proofs are marshaled back from Raw
+	// as is, changes of Proof are not marshaled. Clear Raw to marshal Proof.
+	Raw json.RawMessage `json:"-"`
 }
 
 // MarshalJSON for GistInfoProof
 func (g GistInfoProof) MarshalJSON() ([]byte, error) {
+	if len(g.Raw) != 0 {
+		return g.Raw, nil
+	}
+
 	proofData, err := json.Marshal(g.Proof)
 	if err != nil {
 		return nil, err
@@ -157,13 +221,11 @@ func (g GistInfoProof) MarshalJSON() ([]byte, error) {
 	return json.Marshal(proof)
 }
 
-// UnmarshalJSON for GistInfoProof
+// UnmarshalJSON for GistInfoProof. Proofs of types other than
+// Iden3SparseMerkleTreeProof are kept in Raw. Their merkletree.Proof fields
+// are filled if the JSON has a compatible shape and are left zero otherwise
+// instead of returning an error.
 func (g *GistInfoProof) UnmarshalJSON(data []byte) error {
-	var proof merkletree.Proof
-	if err := json.Unmarshal(data, &proof); err != nil {
-		return err
-	}
-
 	typeStruct := struct {
 		Type ProofType `json:"type"`
 	}{}
@@ -171,11 +233,65 @@ func (g *GistInfoProof) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	if typeStruct.Type != "" &&
+		typeStruct.Type != Iden3SparseMerkleTreeProofType {
+
+		*g = GistInfoProof{
+			Type: typeStruct.Type,
+			Raw:  append(json.RawMessage(nil), data...),
+		}
+		var proof merkletree.Proof
+		if err := json.Unmarshal(data, &proof); err == nil {
+			g.Proof = proof
+		}
+		return nil
+	}
+
+	var proof merkletree.Proof
+	if err := json.Unmarshal(data, &proof); err != nil {
+		return err
+	}
+
 	g.Proof = proof
 	g.Type = typeStruct.Type
+	g.Raw = nil
 	return nil
 }
 
+// GistInfoProofs is a list of proofs of different types. Unmarshals both from
+// a single proof object and from an array of proofs.
+type GistInfoProofs []GistInfoProof
+
+// UnmarshalJSON for GistInfoProofs
+func (g *GistInfoProofs) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var proof GistInfoProof
+		if err := json.Unmarshal(data, &proof); err != nil {
+			return err
+		}
+		*g = GistInfoProofs{proof}
+		return nil
+	}
+
+	var proofs []GistInfoProof
+	if err := json.Unmarshal(data, &proofs); err != nil {
+		return err
+	}
+	*g = proofs
+	return nil
+}
+
+// ByType returns the first proof of the given type
+func (g GistInfoProofs) ByType(proofType ProofType) (*GistInfoProof, bool) {
+	for i := range g {
+		if g[i].Type == proofType {
+			return &g[i], true
+		}
+	}
+	return nil, false
+}
+
 // IdentityState representation all info about identity.
 type IdentityState struct {
 	Published *bool      `json:"published,omitempty"`
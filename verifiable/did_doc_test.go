@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/iden3/go-merkletree-sql/v2"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -103,3 +105,96 @@ func TestAuthenticationMarshalUnmarshal(t *testing.T) {
 	require.NoError(t, err)
 	require.JSONEq(t, in, string(marshaled))
 }
+
+func TestGistInfo_JSON_MultipleProofs(t *testing.T) {
+	in := `{
+  "root": "1",
+  "replacedByRoot": "0",
+  "createdAtTimestamp": "1",
+  "replacedAtTimestamp": "0",
+  "createdAtBlock": "1",
+  "replacedAtBlock": "0",
+  "proof": [
+    {
+      "type": "Iden3SparseMerkleTreeProof",
+      "existence": false,
+      "siblings": ["1", "0", "2"]
+    },
+    {
+      "type": "SomeFutureProof",
+      "data": {"key": "value"}
+    }
+  ]
+}`
+
+	var gistInfo GistInfo
+	err := json.Unmarshal([]byte(in), &gistInfo)
+	require.NoError(t, err)
+	require.NotNil(t, gistInfo.Proof)
+	require.Equal(t, Iden3SparseMerkleTreeProofType, gistInfo.Proof.Type)
+	require.Len(t, gistInfo.OtherProofs, 1)
+	require.Len(t, gistInfo.AllProofs(), 2)
+
+	futureProof, ok := gistInfo.AllProofs().ByType("SomeFutureProof")
+	require.True(t, ok)
+	require.JSONEq(t, `{"type": "SomeFutureProof", "data": {"key": "value"}}`,
+		string(futureProof.Raw))
+	require.Equal(t, merkletree.Proof{}, futureProof.Proof)
+
+	marshaled, err := json.Marshal(gistInfo)
+	require.NoError(t, err)
+	require.JSONEq(t, in, string(marshaled))
+
+	// changes of Proof are marshaled together with other proofs
+	gistInfo.Proof.Existence = true
+	marshaled, err = json.Marshal(gistInfo)
+	require.NoError(t, err)
+	var gistInfo2 GistInfo
+	err = json.Unmarshal(marshaled, &gistInfo2)
+	require.NoError(t, err)
+	require.True(t, gistInfo2.Proof.Existence)
+	require.Len(t, gistInfo2.OtherProofs, 1)
+	require.JSONEq(t, string(gistInfo.OtherProofs[0].Raw),
+		string(gistInfo2.OtherProofs[0].Raw))
+
+	// replacing Proof is marshaled too
+	gistInfo.Proof = &GistInfoProof{Type: Iden3SparseMerkleTreeProofType,
+		Proof: merkletree.Proof{Existence: false}}
+	gistInfo.OtherProofs = nil
+	marshaled, err = json.Marshal(gistInfo)
+	require.NoError(t, err)
+	// single proof is marshaled as an object for backward compatibility
+	var obj map[string]any
+	err = json.Unmarshal(marshaled, &obj)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{
+		"type":      string(Iden3SparseMerkleTreeProofType),
+		"existence": false,
+		"siblings":  []any{},
+	}, obj["proof"])
+}
+
+func TestGistInfoProof_UnmarshalUnknownType(t *testing.T) {
+	// unknown proof with merkletree.Proof compatible fields
+	in := `{"type": "SomeFutureProof", "existence": true, "siblings": ["0"]}`
+	var proof GistInfoProof
+	err := json.Unmarshal([]byte(in), &proof)
+	require.NoError(t, err)
+	require.Equal(t, ProofType("SomeFutureProof"), proof.Type)
+	require.True(t, proof.Existence)
+	require.JSONEq(t, in, string(proof.Raw))
+
+	// Raw has priority over changed Proof fields
+	proof.Existence = false
+	marshaled, err := json.Marshal(proof)
+	require.NoError(t, err)
+	require.JSONEq(t, in, string(marshaled))
+
+	// unknown proof with incompatible fields keeps zero merkletree.Proof
+	in = `{"type": "SomeFutureProof", "existence": "maybe"}`
+	proof = GistInfoProof{}
+	err = json.Unmarshal([]byte(in), &proof)
+	require.NoError(t, err)
+	require.Equal(t, merkletree.Proof{}, proof.Proof)
+	require.JSONEq(t, in, string(proof.Raw))
+}
@@ -0,0 +1,125 @@
+package verifiable
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Error codes of DID resolution metadata
+const (
+	DIDResolutionErrorNotFound                   = "notFound"
+	DIDResolutionErrorInvalidDID                 = "invalidDid"
+	DIDResolutionErrorRepresentationNotSupported = "representationNotSupported"
+)
+
+// ErrDIDNotFound is returned when the DID resolver reports the notFound
+// error
+var ErrDIDNotFound = errors.New("DID not found")
+
+// ErrInvalidDID is returned when the DID resolver reports the invalidDid
+// error
+var ErrInvalidDID = errors.New("invalid DID")
+
+// DIDResolutionResult is the result of DID resolution returned by the
+// universal resolver
+type DIDResolutionResult struct {
+	Context               interface{}           `json:"@context,omitempty"`
+	DIDDocument           *DIDDocument          `json:"didDocument"`
+	DIDResolutionMetadata DIDResolutionMetadata `json:"didResolutionMetadata"`
+	DIDDocumentMetadata   DIDDocumentMetadata   `json:"didDocumentMetadata"`
+}
+
+// DIDResolutionMetadata is the metadata of the resolution process
+type DIDResolutionMetadata struct {
+	ContentType string `json:"contentType,omitempty"`
+	// Retrieved is the time of the resolution as formatted by the resolver
+	Retrieved string `json:"retrieved,omitempty"`
+	Pattern   string `json:"pattern,omitempty"`
+	DriverURL string `json:"driverUrl,omitempty"`
+	// Duration of the resolution in milliseconds
+	Duration int64             `json:"duration,omitempty"`
+	DID      *DIDResolutionDID `json:"did,omitempty"`
+	// Error is the error code, e.g. notFound or invalidDid
+	Error        string `json:"error,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// DIDResolutionDID is the parsed DID of the resolution metadata
+type DIDResolutionDID struct {
+	DIDString        string `json:"didString"`
+	MethodSpecificID string `json:"methodSpecificId"`
+	Method           string `json:"method"`
+}
+
+// DIDDocumentMetadata is the metadata of the resolved DID document. Iden3
+// drivers may add the identity state info.
+type DIDDocumentMetadata struct {
+	Created       string   `json:"created,omitempty"`
+	Updated       string   `json:"updated,omitempty"`
+	Deactivated   bool     `json:"deactivated,omitempty"`
+	VersionID     string   `json:"versionId,omitempty"`
+	NextUpdate    string   `json:"nextUpdate,omitempty"`
+	NextVersionID string   `json:"nextVersionId,omitempty"`
+	EquivalentID  []string `json:"equivalentId,omitempty"`
+	CanonicalID   string   `json:"canonicalId,omitempty"`
+	IdentityState
+}
+
+// Err returns DIDResolutionError if the resolution metadata has an error
+func (r DIDResolutionResult) Err() error {
+	if r.DIDResolutionMetadata.Error == "" {
+		return nil
+	}
+	return &DIDResolutionError{
+		Code:    r.DIDResolutionMetadata.Error,
+		Message: r.DIDResolutionMetadata.ErrorMessage,
+	}
+}
+
+// IdentityState returns the iden3 identity state info from the DID document
+// metadata or, if it is not there, from the Iden3StateInfo2023 verification
+// method of the DID document
+func (r DIDResolutionResult) IdentityState() (IdentityState, bool) {
+	if r.DIDDocumentMetadata.Published != nil ||
+		r.DIDDocumentMetadata.Info != nil ||
+		r.DIDDocumentMetadata.Global != nil {
+
+		return r.DIDDocumentMetadata.IdentityState, true
+	}
+	if r.DIDDocument == nil {
+		return IdentityState{}, false
+	}
+	vm, err := getIden3StateInfo2023FromDIDDocument(*r.DIDDocument)
+	if err != nil {
+		return IdentityState{}, false
+	}
+	return vm.IdentityState, true
+}
+
+// DIDResolutionError is the error of DID resolution reported by the
+// resolver. The notFound and invalidDid errors unwrap to ErrDIDNotFound and
+// ErrInvalidDID.
+type DIDResolutionError struct {
+	// Code is the error of the resolution metadata
+	Code    string
+	Message string
+}
+
+func (e *DIDResolutionError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("DID resolution failed: %v", e.Code)
+	}
+	return fmt.Sprintf("DID resolution failed: %v: %v", e.Code, e.Message)
+}
+
+func (e *DIDResolutionError) Unwrap() error {
+	switch e.Code {
+	case DIDResolutionErrorNotFound:
+		return ErrDIDNotFound
+	case DIDResolutionErrorInvalidDID:
+		return ErrInvalidDID
+	default:
+		return nil
+	}
+}
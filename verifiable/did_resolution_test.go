@@ -0,0 +1,134 @@
+package verifiable
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPDIDResolver_ResolveDID(t *testing.T) {
+	defer tst.MockHTTPClient(t, map[string]string{
+		"http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=34824a8e1defc326f935044e32e9f513377dbfc031d79475a0190830554d4409": "./testdata/verifycred//my-universal-resolver-1.json",
+	}, tst.IgnoreUntouchedURLs())()
+
+	did, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=34824a8e1defc326f935044e32e9f513377dbfc031d79475a0190830554d4409")
+	require.NoError(t, err)
+
+	resolver := HTTPDIDResolver{
+		resolverURL: "http://my-universal-resolver/1.0/identifiers"}
+	res, err := resolver.ResolveDID(context.Background(), did)
+	require.NoError(t, err)
+	require.Equal(t, "https://w3id.org/did-resolution/v1", res.Context)
+	require.NotNil(t, res.DIDDocument)
+	require.Equal(t,
+		"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf",
+		res.DIDDocument.ID)
+	require.Equal(t, DIDResolutionMetadata{
+		ContentType: "application/did+ld+json",
+		Retrieved:   "2024-01-05T08:05:13.413770024Z",
+		Pattern:     "^(did:polygonid:.+)$",
+		DriverURL:   "http://driver-did-polygonid:8080/1.0/identifiers/",
+		Duration:    429,
+		DID: &DIDResolutionDID{
+			DIDString:        "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf",
+			MethodSpecificID: "polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf",
+			Method:           "polygonid",
+		},
+	}, res.DIDResolutionMetadata)
+
+	state, ok := res.IdentityState()
+	require.True(t, ok)
+	require.True(t, *state.Published)
+	require.Equal(t,
+		"34824a8e1defc326f935044e32e9f513377dbfc031d79475a0190830554d4409",
+		state.Info.State)
+}
+
+func TestHTTPDIDResolver_ResolveDIDErrors(t *testing.T) {
+	did, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name:    "not found in metadata",
+			status:  http.StatusNotFound,
+			body:    `{"didDocument":null,"didResolutionMetadata":{"error":"notFound","errorMessage":"identity not found"},"didDocumentMetadata":{}}`,
+			wantErr: ErrDIDNotFound,
+			wantMsg: "DID resolution failed: notFound: identity not found",
+		},
+		{
+			name:    "invalid DID in metadata",
+			status:  http.StatusOK,
+			body:    `{"didResolutionMetadata":{"error":"invalidDid"}}`,
+			wantErr: ErrInvalidDID,
+			wantMsg: "DID resolution failed: invalidDid",
+		},
+		{
+			name:    "not found status",
+			status:  http.StatusNotFound,
+			body:    `not found`,
+			wantErr: ErrDIDNotFound,
+			wantMsg: "DID resolution failed: notFound",
+		},
+		{
+			name:    "bad request status",
+			status:  http.StatusBadRequest,
+			body:    `bad request`,
+			wantErr: ErrInvalidDID,
+			wantMsg: "DID resolution failed: invalidDid",
+		},
+		{
+			name:    "server error",
+			status:  http.StatusInternalServerError,
+			body:    `internal error`,
+			wantMsg: "DID resolution failed: unexpected status code 500",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(
+				func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(tc.status)
+					_, _ = w.Write([]byte(tc.body))
+				}))
+			defer srv.Close()
+
+			resolver := HTTPDIDResolver{resolverURL: srv.URL}
+			_, err := resolver.Resolve(context.Background(), did)
+			require.EqualError(t, err, tc.wantMsg)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewHTTPDIDResolver_HTTPSOnly(t *testing.T) {
+	httpClient, err := loaders.NewSecureHTTPClient(nil,
+		loaders.WithHTTPSOnly())
+	require.NoError(t, err)
+
+	did, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf")
+	require.NoError(t, err)
+
+	resolver := NewHTTPDIDResolver(
+		"http://my-universal-resolver/1.0/identifiers", httpClient)
+	_, err = resolver.Resolve(context.Background(), did)
+	require.ErrorIs(t, err, loaders.ErrInsecureURL)
+}
@@ -7,11 +7,20 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/pkg/errors"
 )
 
+const (
+	didQueryVersionTime = "versionTime"
+	didQueryVersionID   = "versionId"
+	didQueryState       = "state"
+	didQueryGist        = "gist"
+)
+
 type DIDResolver interface {
 	Resolve(ctx context.Context, did *w3c.DID) (DIDDocument, error)
 }
@@ -21,18 +30,38 @@ type HTTPDIDResolver struct {
 	customHTTPClient *http.Client
 }
 
-func (r HTTPDIDResolver) Resolve(ctx context.Context, did *w3c.DID) (out DIDDocument, err error) {
-	type didResolutionResult struct {
-		DIDDocument DIDDocument `json:"didDocument"`
+// NewHTTPDIDResolver creates the resolver of DIDs with the universal
+// resolver at resolverURL, e.g. "https://resolver.example.com/1.0/identifiers".
+// If httpClient is nil, http.DefaultClient is used. To enforce https and
+// certificate pins, use the client created with loaders.NewSecureHTTPClient.
+func NewHTTPDIDResolver(resolverURL string,
+	httpClient *http.Client) *HTTPDIDResolver {
+
+	return &HTTPDIDResolver{
+		resolverURL:      resolverURL,
+		customHTTPClient: httpClient,
 	}
-	res := &didResolutionResult{}
+}
 
-	var (
-		resp       *http.Response
-		httpClient *http.Client
-	)
+func (r HTTPDIDResolver) Resolve(ctx context.Context, did *w3c.DID) (DIDDocument, error) {
+	res, err := r.ResolveDID(ctx, did)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+	if res.DIDDocument == nil {
+		return DIDDocument{}, errors.New(
+			"DID resolution result has no DID document")
+	}
+	return *res.DIDDocument, nil
+}
+
+// ResolveDID returns the full DID resolution result. Errors of the
+// resolution metadata and 404 and 400 responses without the result are
+// returned as DIDResolutionError.
+func (r HTTPDIDResolver) ResolveDID(ctx context.Context,
+	did *w3c.DID) (out DIDResolutionResult, err error) {
 
-	httpClient = http.DefaultClient
+	httpClient := http.DefaultClient
 	if r.customHTTPClient != nil {
 		httpClient = r.customHTTPClient
 	}
@@ -42,11 +71,12 @@ func (r HTTPDIDResolver) Resolve(ctx context.Context, did *w3c.DID) (out DIDDocu
 		didEscaped := url.QueryEscape(didParts[0])
 		didStr = fmt.Sprintf("%s?%s", didEscaped, didParts[1])
 	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/%s", strings.Trim(r.resolverURL, "/"), didStr), nil)
 	if err != nil {
-		return out, err
+		return out, errors.WithStack(err)
 	}
-	resp, err = httpClient.Get(fmt.Sprintf("%s/%s", strings.Trim(r.resolverURL, "/"), didStr))
-
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return out, err
 	}
@@ -58,10 +88,102 @@ func (r HTTPDIDResolver) Resolve(ctx context.Context, did *w3c.DID) (out DIDDocu
 		}
 	}()
 
+	var res DIDResolutionResult
 	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return out, err
+		case http.StatusNotFound:
+			return out, &DIDResolutionError{Code: DIDResolutionErrorNotFound}
+		case http.StatusBadRequest:
+			return out, &DIDResolutionError{Code: DIDResolutionErrorInvalidDID}
+		default:
+			return out, errors.Errorf(
+				"DID resolution failed: unexpected status code %v",
+				resp.StatusCode)
+		}
+	}
+
+	err = res.Err()
 	if err != nil {
 		return out, err
 	}
+	if resp.StatusCode != http.StatusOK {
+		return out, errors.Errorf(
+			"DID resolution failed: unexpected status code %v",
+			resp.StatusCode)
+	}
+	return res, nil
+}
+
+// DIDResolutionOpt sets a query parameter of the DID URL to resolve the DID
+// document at a specific version
+type DIDResolutionOpt func(q url.Values)
+
+// WithVersionTime requests the DID document as it was at the given time
+func WithVersionTime(t time.Time) DIDResolutionOpt {
+	return func(q url.Values) {
+		q.Set(didQueryVersionTime, t.UTC().Format(time.RFC3339))
+	}
+}
+
+// WithVersionID requests the DID document of the given version
+func WithVersionID(versionID string) DIDResolutionOpt {
+	return func(q url.Values) {
+		q.Set(didQueryVersionID, versionID)
+	}
+}
+
+// WithIdentityState requests the iden3 DID document at the given identity
+// state
+func WithIdentityState(state *merkletree.Hash) DIDResolutionOpt {
+	return func(q url.Values) {
+		q.Set(didQueryState, state.Hex())
+	}
+}
 
-	return res.DIDDocument, nil
+// WithGistRoot requests the iden3 DID document with the state published in
+// the given global identities state tree root
+func WithGistRoot(gist *merkletree.Hash) DIDResolutionOpt {
+	return func(q url.Values) {
+		q.Set(didQueryGist, gist.Hex())
+	}
+}
+
+// DIDAtVersion returns a copy of the DID with the query built from opts.
+// Query parameters already present in the DID are kept unless overridden.
+func DIDAtVersion(did *w3c.DID, opts ...DIDResolutionOpt) (*w3c.DID, error) {
+	q, err := url.ParseQuery(did.Query)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid DID query")
+	}
+	for _, o := range opts {
+		o(q)
+	}
+
+	didCopy := *did
+	didCopy.Query = q.Encode()
+	return &didCopy, nil
+}
+
+// ResolveAtState resolves the iden3 DID document at the identity state and
+// (or) the global identities state tree root. Nil values are not added to
+// the query.
+func (r HTTPDIDResolver) ResolveAtState(ctx context.Context, did *w3c.DID,
+	state, gist *merkletree.Hash) (DIDDocument, error) {
+
+	var opts []DIDResolutionOpt
+	if state != nil {
+		opts = append(opts, WithIdentityState(state))
+	}
+	if gist != nil {
+		opts = append(opts, WithGistRoot(gist))
+	}
+
+	didAtState, err := DIDAtVersion(did, opts...)
+	if err != nil {
+		return DIDDocument{}, err
+	}
+	return r.Resolve(ctx, didAtState)
 }
@@ -0,0 +1,93 @@
+package verifiable
+
+import (
+	"context"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/pkg/errors"
+)
+
+// ErrDIDResolverNotStatic is returned by VerifyProof with
+// WithStaticDIDResolution option if the DID resolver is not
+// StaticDIDResolver or other options require network access
+var ErrDIDResolverNotStatic = errors.New(
+	"DID resolution is restricted to StaticDIDResolver")
+
+// StaticDIDResolver resolves DIDs to the DID documents resolved earlier,
+// without network access. It allows to verify proofs in unit tests and in
+// air-gapped environments.
+type StaticDIDResolver struct {
+	didDocs map[string]DIDDocument
+}
+
+// NewStaticDIDResolver creates the StaticDIDResolver with DID documents
+// keyed by DID as formatted by DID.String(), including the query for DIDs at
+// the specific state, e.g. did:polygonid:polygon:mumbai:2qLx...?state=da61...
+// (see DIDAtVersion). The DID is looked up as is: the document of the DID
+// without query is not returned for the DID at some state and vice versa.
+func NewStaticDIDResolver(
+	didDocs map[string]DIDDocument) *StaticDIDResolver {
+
+	r := &StaticDIDResolver{
+		didDocs: make(map[string]DIDDocument, len(didDocs)),
+	}
+	for did, doc := range didDocs {
+		r.didDocs[did] = doc
+	}
+	return r
+}
+
+// Resolve returns the DID document of the DID or the error wrapping
+// ErrDIDNotFound
+func (r *StaticDIDResolver) Resolve(_ context.Context,
+	did *w3c.DID) (DIDDocument, error) {
+
+	didDoc, ok := r.didDocs[did.String()]
+	if !ok {
+		return DIDDocument{}, errors.Wrapf(ErrDIDNotFound,
+			"DID document of %v is not pre-resolved", did)
+	}
+	return didDoc, nil
+}
+
+// WithStaticDIDResolution requires that all DIDs are resolved with the
+// StaticDIDResolver passed to VerifyProof (or VerifyProofChain, the
+// VerifyDetached* functions and W3CPresentation.VerifyProof). Verification
+// fails with ErrDIDResolverNotStatic if the resolver is of any other type
+// (wrappers of StaticDIDResolver included) or if WithIssuerStateUpdate is
+// used.
+func WithStaticDIDResolution() W3CProofVerificationOpt {
+	return func(opts *w3CProofVerificationConfig) {
+		opts.staticDIDResolution = true
+	}
+}
+
+// newProofVerificationConfig builds the verification config from the
+// options and checks the DID resolver if WithStaticDIDResolution is used.
+// All proof verification entry points build their config with it.
+func newProofVerificationConfig(didResolver DIDResolver,
+	opts []W3CProofVerificationOpt) (w3CProofVerificationConfig, error) {
+
+	verifyConfig := w3CProofVerificationConfig{}
+	for _, o := range opts {
+		o(&verifyConfig)
+	}
+	return verifyConfig, checkStaticDIDResolution(didResolver, verifyConfig)
+}
+
+func checkStaticDIDResolution(didResolver DIDResolver,
+	verifyConfig w3CProofVerificationConfig) error {
+
+	if !verifyConfig.staticDIDResolution {
+		return nil
+	}
+	if _, ok := didResolver.(*StaticDIDResolver); !ok {
+		return errors.Wrapf(ErrDIDResolverNotStatic,
+			"unexpected DID resolver %T", didResolver)
+	}
+	if verifyConfig.issuerStateUpdate {
+		return errors.Wrap(ErrDIDResolverNotStatic,
+			"issuer state update is not allowed")
+	}
+	return nil
+}
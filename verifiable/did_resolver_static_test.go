@@ -0,0 +1,116 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticDIDResolver(t *testing.T) {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	resBytes, err := os.ReadFile(
+		"testdata/verifycred/my-universal-resolver-1.json")
+	require.NoError(t, err)
+	var res DIDResolutionResult
+	require.NoError(t, json.Unmarshal(resBytes, &res))
+
+	issuerAtState := "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e"
+	resolver := NewStaticDIDResolver(map[string]DIDDocument{
+		issuerAtState: *res.DIDDocument,
+	})
+
+	// only JSON-LD contexts are fetched, DID resolution is offline
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+			"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+		}, tst.IgnoreUntouchedURLs())()
+
+	registry := CredentialStatusResolverRegistry{}
+	registry.Register(Iden3ReverseSparseMerkleTreeProof, test1Resolver{})
+
+	err = vc.VerifyProof(context.Background(), BJJSignatureProofType,
+		resolver, WithStatusResolverRegistry(&registry),
+		WithStaticDIDResolution())
+	require.NoError(t, err)
+
+	// the DID is looked up with the query
+	did, err := w3c.ParseDID(res.DIDDocument.ID)
+	require.NoError(t, err)
+	_, err = resolver.Resolve(context.Background(), did)
+	require.ErrorIs(t, err, ErrDIDNotFound)
+	did, err = w3c.ParseDID(issuerAtState)
+	require.NoError(t, err)
+	didDoc, err := resolver.Resolve(context.Background(), did)
+	require.NoError(t, err)
+	require.Equal(t, *res.DIDDocument, didDoc)
+
+	err = vc.VerifyProof(context.Background(), BJJSignatureProofType,
+		HTTPDIDResolver{resolverURL: "http://my-universal-resolver"},
+		WithStatusResolverRegistry(&registry), WithStaticDIDResolution())
+	require.ErrorIs(t, err, ErrDIDResolverNotStatic)
+	require.EqualError(t, err, "unexpected DID resolver "+
+		"verifiable.HTTPDIDResolver: "+ErrDIDResolverNotStatic.Error())
+
+	err = vc.VerifyProof(context.Background(), BJJSignatureProofType,
+		resolver, WithStatusResolverRegistry(&registry),
+		WithIssuerStateUpdate(http.DefaultClient), WithStaticDIDResolution())
+	require.ErrorIs(t, err, ErrDIDResolverNotStatic)
+
+	resolver = NewStaticDIDResolver(nil)
+	err = vc.VerifyProof(context.Background(), BJJSignatureProofType,
+		resolver, WithStatusResolverRegistry(&registry),
+		WithStaticDIDResolution())
+	require.ErrorIs(t, err, ErrDIDNotFound)
+}
+
+func TestStaticDIDResolution_DetachedAndChain(t *testing.T) {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+	credProof, ok := vc.Proof.ByType(BJJSignatureProofType)
+	require.True(t, ok)
+	bjjProof, err := asBJJSignatureProof(credProof)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	httpResolver := HTTPDIDResolver{resolverURL: "http://my-universal-resolver"}
+	staticResolver := NewStaticDIDResolver(nil)
+
+	chainVC := vc
+	chainVC.Proof = nil
+	chainVC.ProofChain = ProofChain{{ID: "urn:proof:1", Proof: credProof}}
+	err = chainVC.VerifyProofChain(ctx, httpResolver,
+		WithStaticDIDResolution())
+	require.ErrorIs(t, err, ErrDIDResolverNotStatic)
+	err = chainVC.VerifyProofChain(ctx, staticResolver,
+		WithIssuerStateUpdate(http.DefaultClient), WithStaticDIDResolution())
+	require.ErrorIs(t, err, ErrDIDResolverNotStatic)
+
+	detachedVC := vc
+	detachedVC.Proof = nil
+	err = detachedVC.VerifyDetachedProof(ctx, credProof, httpResolver,
+		WithStaticDIDResolution())
+	require.ErrorIs(t, err, ErrDIDResolverNotStatic)
+
+	err = VerifyDetachedBJJSignature(ctx, bjjProof.CoreClaim,
+		bjjProof.IssuerData, bjjProof.Signature, httpResolver,
+		WithStaticDIDResolution())
+	require.ErrorIs(t, err, ErrDIDResolverNotStatic)
+
+	issuerData := bjjProof.IssuerData
+	issuerData.State.ClaimsTreeRoot = issuerData.State.Value
+	err = VerifyDetachedIden3SparseMerkleTreeProof(ctx, bjjProof.CoreClaim,
+		issuerData, issuerData.MTP, httpResolver, WithStaticDIDResolution())
+	require.ErrorIs(t, err, ErrDIDResolverNotStatic)
+}
@@ -0,0 +1,241 @@
+package verifiable
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/pkg/errors"
+)
+
+const (
+	didURLQueryService     = "service"
+	didURLQueryRelativeRef = "relativeRef"
+)
+
+// ErrDIDURLNotFound is returned when the DID URL does not match any resource
+// of the DID document
+var ErrDIDURLNotFound = errors.New("DID URL resource not found")
+
+// DIDURLResource is the result of DID URL dereferencing. VerificationMethod
+// is set for fragments of verification methods. Service is set for
+// fragments of services and for the service parameter. ServiceEndpoint is
+// set for the service parameter only.
+type DIDURLResource struct {
+	VerificationMethod *CommonVerificationMethod
+	Service            map[string]any
+	// ServiceEndpoint is the service endpoint URL with relativeRef and the
+	// fragment of the DID URL applied
+	ServiceEndpoint string
+}
+
+// DereferenceDIDURL selects the resource of the DID document the DID URL
+// points to, following DID URL dereferencing of the DID Core specification:
+//
+//   - did:example:123?service=agent&relativeRef=/path selects the service
+//     with "agent" fragment of its ID and resolves relativeRef against its
+//     endpoint;
+//   - did:example:123#key-1 selects the verification method or the service
+//     with "key-1" fragment. Verification methods embedded into
+//     verification relationships are found as well.
+//
+// The DID of the DID URL must be the ID of the document. Relative IDs of
+// the document (e.g. "#key-1") are supported. Returns ErrDIDURLNotFound if
+// there is no such resource.
+func DereferenceDIDURL(doc DIDDocument, didURL *w3c.DID) (DIDURLResource,
+	error) {
+
+	q, err := url.ParseQuery(didURL.Query)
+	if err != nil {
+		return DIDURLResource{}, errors.Wrap(err, "invalid DID URL query")
+	}
+
+	did := bareDID(didURL)
+	if doc.ID != "" && doc.ID != did {
+		return DIDURLResource{}, errors.Errorf(
+			"DID URL %v is not of DID document %v", did, doc.ID)
+	}
+
+	m := didURLMatcher{docID: doc.ID, did: did}
+	if serviceName := q.Get(didURLQueryService); serviceName != "" {
+		return dereferenceService(doc, m, serviceName,
+			q.Get(didURLQueryRelativeRef), didURL.Fragment)
+	}
+
+	if didURL.Fragment == "" {
+		return DIDURLResource{}, errors.New(
+			"DID URL has neither fragment nor service parameter")
+	}
+
+	vm, ok := doc.verificationMethodByID(m, didURL.Fragment)
+	if ok {
+		return DIDURLResource{VerificationMethod: &vm}, nil
+	}
+	service, ok, err := doc.serviceByID(m, didURL.Fragment)
+	if err != nil {
+		return DIDURLResource{}, err
+	}
+	if ok {
+		return DIDURLResource{Service: service}, nil
+	}
+	return DIDURLResource{}, errors.Wrapf(ErrDIDURLNotFound, "fragment %v",
+		didURL.Fragment)
+}
+
+// ResolveDIDURL resolves the DID document of the DID URL with the resolver
+// and dereferences the DID URL with DereferenceDIDURL. Query parameters
+// other than service and relativeRef, e.g. state, are passed to the
+// resolver.
+func ResolveDIDURL(ctx context.Context, resolver DIDResolver,
+	didURL *w3c.DID) (DIDURLResource, error) {
+
+	if didURL.Path != "" || len(didURL.PathSegments) != 0 {
+		return DIDURLResource{}, errors.New("DID URL path is not supported")
+	}
+
+	q, err := url.ParseQuery(didURL.Query)
+	if err != nil {
+		return DIDURLResource{}, errors.Wrap(err, "invalid DID URL query")
+	}
+	q.Del(didURLQueryService)
+	q.Del(didURLQueryRelativeRef)
+
+	did := *didURL
+	did.Query = q.Encode()
+	did.Fragment = ""
+	doc, err := resolver.Resolve(ctx, &did)
+	if err != nil {
+		return DIDURLResource{}, err
+	}
+
+	return DereferenceDIDURL(doc, didURL)
+}
+
+func dereferenceService(doc DIDDocument, m didURLMatcher, serviceName,
+	relativeRef, fragment string) (DIDURLResource, error) {
+
+	service, ok, err := doc.serviceByID(m, serviceName)
+	if err != nil {
+		return DIDURLResource{}, err
+	}
+	if !ok {
+		return DIDURLResource{}, errors.Wrapf(ErrDIDURLNotFound, "service %v",
+			serviceName)
+	}
+
+	endpoint, ok := service["serviceEndpoint"].(string)
+	if !ok {
+		return DIDURLResource{}, errors.Errorf(
+			"service %v endpoint is not a URL", serviceName)
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return DIDURLResource{}, errors.Wrapf(err,
+			"invalid service %v endpoint", serviceName)
+	}
+	if relativeRef != "" {
+		ref, err := url.Parse(relativeRef)
+		if err != nil {
+			return DIDURLResource{}, errors.Wrap(err, "invalid relativeRef")
+		}
+		endpointURL = endpointURL.ResolveReference(ref)
+	}
+	if fragment != "" && endpointURL.Fragment == "" {
+		endpointURL.Fragment = fragment
+	}
+
+	return DIDURLResource{Service: service,
+		ServiceEndpoint: endpointURL.String()}, nil
+}
+
+// didURLMatcher matches absolute and relative IDs of the DID document
+// resources with the fragment
+type didURLMatcher struct {
+	docID string
+	did   string
+}
+
+func (m didURLMatcher) matches(id, fragment string) bool {
+	if strings.HasPrefix(id, "#") {
+		return id[1:] == fragment
+	}
+	return (m.docID != "" && id == m.docID+"#"+fragment) ||
+		(m.did != "" && id == m.did+"#"+fragment)
+}
+
+// verificationMethodByID finds the verification method by the fragment of
+// its ID in verificationMethod and in embedded verification methods of
+// verification relationships
+func (doc DIDDocument) verificationMethodByID(m didURLMatcher,
+	fragment string) (CommonVerificationMethod, bool) {
+
+	for _, vm := range doc.VerificationMethod {
+		if m.matches(vm.ID, fragment) {
+			return vm, true
+		}
+	}
+	for _, rel := range [][]Authentication{doc.Authentication,
+		doc.AssertionMethod} {
+
+		for _, a := range rel {
+			if !a.IsDID() && m.matches(a.ID, fragment) {
+				return a.CommonVerificationMethod, true
+			}
+		}
+	}
+	for _, ka := range doc.KeyAgreement {
+		if _, isRef := ka.(string); isRef {
+			continue
+		}
+		var vm CommonVerificationMethod
+		if err := remarshalObj(&vm, ka); err != nil {
+			continue
+		}
+		if m.matches(vm.ID, fragment) {
+			return vm, true
+		}
+	}
+	return CommonVerificationMethod{}, false
+}
+
+// verificationMethodByRef finds the verification method by the absolute or
+// relative DID URL, e.g. the reference from a verification relationship
+func (doc DIDDocument) verificationMethodByRef(
+	ref string) (CommonVerificationMethod, bool) {
+
+	for _, vm := range doc.VerificationMethod {
+		if vm.ID == ref {
+			return vm, true
+		}
+	}
+
+	did, fragment, ok := strings.Cut(ref, "#")
+	if !ok || (did != "" && did != doc.ID) {
+		return CommonVerificationMethod{}, false
+	}
+	return doc.verificationMethodByID(didURLMatcher{docID: doc.ID}, fragment)
+}
+
+func (doc DIDDocument) serviceByID(m didURLMatcher,
+	fragment string) (map[string]any, bool, error) {
+
+	for _, s := range doc.Service {
+		var service map[string]any
+		if err := remarshalObj(&service, s); err != nil {
+			return nil, false, errors.Wrap(err, "invalid DID document service")
+		}
+		id, _ := service["id"].(string)
+		if m.matches(id, fragment) {
+			return service, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// bareDID returns the DID of the DID URL without path, query and fragment
+func bareDID(didURL *w3c.DID) string {
+	did := w3c.DID{Method: didURL.Method, ID: didURL.ID,
+		IDStrings: didURL.IDStrings}
+	return did.String()
+}
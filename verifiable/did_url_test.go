@@ -0,0 +1,140 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+const didURLTestDoc = `{
+  "@context": "https://www.w3.org/ns/did/v1",
+  "id": "did:example:123",
+  "verificationMethod": [
+    {
+      "id": "did:example:123#key-1",
+      "type": "JsonWebKey2020",
+      "controller": "did:example:123"
+    },
+    {
+      "id": "#key-2",
+      "type": "JsonWebKey2020",
+      "controller": "did:example:123"
+    }
+  ],
+  "authentication": [
+    "did:example:123#key-1",
+    {
+      "id": "did:example:123#auth-1",
+      "type": "Ed25519VerificationKey2020",
+      "controller": "did:example:123"
+    }
+  ],
+  "keyAgreement": ["#key-2"],
+  "service": [
+    {
+      "id": "did:example:123#agent",
+      "type": "DIDCommMessaging",
+      "serviceEndpoint": "https://agent.example.com/messages/8377464"
+    },
+    {
+      "id": "#push",
+      "type": "Iden3PushServiceV1",
+      "serviceEndpoint": "https://push.example.com/api/v1"
+    }
+  ]
+}`
+
+func TestDereferenceDIDURL(t *testing.T) {
+	var doc DIDDocument
+	err := json.Unmarshal([]byte(didURLTestDoc), &doc)
+	require.NoError(t, err)
+
+	dereference := func(t *testing.T, didURL string) (DIDURLResource, error) {
+		did, err := w3c.ParseDID(didURL)
+		require.NoError(t, err)
+		return DereferenceDIDURL(doc, did)
+	}
+
+	t.Run("verification method", func(t *testing.T) {
+		for fragment, wantID := range map[string]string{
+			"key-1":  "did:example:123#key-1",
+			"key-2":  "#key-2",
+			"auth-1": "did:example:123#auth-1",
+		} {
+			res, err := dereference(t, "did:example:123#"+fragment)
+			require.NoError(t, err)
+			require.NotNil(t, res.VerificationMethod)
+			require.Equal(t, wantID, res.VerificationMethod.ID)
+			require.Nil(t, res.Service)
+		}
+	})
+
+	t.Run("service by fragment", func(t *testing.T) {
+		res, err := dereference(t, "did:example:123#push")
+		require.NoError(t, err)
+		require.Nil(t, res.VerificationMethod)
+		require.Equal(t, "Iden3PushServiceV1", res.Service["type"])
+		require.Empty(t, res.ServiceEndpoint)
+	})
+
+	t.Run("service endpoint", func(t *testing.T) {
+		res, err := dereference(t,
+			"did:example:123?service=agent&relativeRef=%2Fsome%2Fpath%3Fquery#frag")
+		require.NoError(t, err)
+		require.Equal(t, "did:example:123#agent", res.Service["id"])
+		require.Equal(t, "https://agent.example.com/some/path?query#frag",
+			res.ServiceEndpoint)
+
+		res, err = dereference(t, "did:example:123?service=push")
+		require.NoError(t, err)
+		require.Equal(t, "https://push.example.com/api/v1",
+			res.ServiceEndpoint)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := dereference(t, "did:example:123#key-3")
+		require.ErrorIs(t, err, ErrDIDURLNotFound)
+		_, err = dereference(t, "did:example:123?service=other")
+		require.ErrorIs(t, err, ErrDIDURLNotFound)
+		// fragment of another DID
+		_, err = dereference(t, "did:example:456#key-1")
+		require.EqualError(t, err,
+			"DID URL did:example:456 is not of DID document did:example:123")
+	})
+
+	t.Run("no fragment", func(t *testing.T) {
+		_, err := dereference(t, "did:example:123")
+		require.EqualError(t, err,
+			"DID URL has neither fragment nor service parameter")
+	})
+}
+
+type fixedDIDResolver struct {
+	doc  DIDDocument
+	dids []string
+}
+
+func (r *fixedDIDResolver) Resolve(_ context.Context,
+	did *w3c.DID) (DIDDocument, error) {
+
+	r.dids = append(r.dids, did.String())
+	return r.doc, nil
+}
+
+func TestResolveDIDURL(t *testing.T) {
+	var doc DIDDocument
+	err := json.Unmarshal([]byte(didURLTestDoc), &doc)
+	require.NoError(t, err)
+	resolver := &fixedDIDResolver{doc: doc}
+
+	did, err := w3c.ParseDID(
+		"did:example:123?state=abc&service=agent&relativeRef=%2Finbox")
+	require.NoError(t, err)
+	res, err := ResolveDIDURL(context.Background(), resolver, did)
+	require.NoError(t, err)
+	require.Equal(t, "https://agent.example.com/inbox", res.ServiceEndpoint)
+	require.Equal(t, []string{"did:example:123?state=abc"}, resolver.dids)
+}
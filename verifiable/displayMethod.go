@@ -1,8 +1,38 @@
 package verifiable
 
+import "github.com/pkg/errors"
+
+// ErrUnsupportedDisplayMethodType is returned when display method type is
+// not one of the known types
+var ErrUnsupportedDisplayMethodType = errors.New(
+	"unsupported display method type")
+
+// DisplayMethodType represent display method types
 type DisplayMethodType string
 
+// Validate returns ErrUnsupportedDisplayMethodType if display method type is
+// not known
+func (t DisplayMethodType) Validate() error {
+	switch t {
+	case Iden3BasicDisplayMethodV1:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnsupportedDisplayMethodType, "%q", string(t))
+	}
+}
+
+// DisplayMethod is struct that represents display method json-ld document
 type DisplayMethod struct {
 	ID   string            `json:"id"`
 	Type DisplayMethodType `json:"type"`
 }
+
+// NewIden3BasicDisplayMethod creates a new display method of
+// Iden3BasicDisplayMethodV1 type. id should be an absolute URL.
+func NewIden3BasicDisplayMethod(id string) (*DisplayMethod, error) {
+	err := validateServiceURL(id)
+	if err != nil {
+		return nil, err
+	}
+	return &DisplayMethod{ID: id, Type: Iden3BasicDisplayMethodV1}, nil
+}
@@ -0,0 +1,273 @@
+package verifiable
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+)
+
+// FieldEncryptionAlgorithm is the algorithm of credential subject fields
+// encryption: ECDH with X25519 ephemeral key, HKDF-SHA256 key derivation and
+// ChaCha20-Poly1305 AEAD with the field path as additional data.
+const FieldEncryptionAlgorithm = "ECDH-ES+X25519+HKDF-SHA256+C20P"
+
+var fieldEncryptionInfo = []byte("iden3 credential field encryption")
+
+// EncryptedField is the encrypted value of a credentialSubject field
+type EncryptedField struct {
+	// Path is the dot separated path of the field inside credentialSubject
+	Path      string `json:"path"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	// EphemeralPublicKey, Nonce and Ciphertext are base64url encoded
+	EphemeralPublicKey string `json:"epk"`
+	Nonce              string `json:"nonce"`
+	Ciphertext         string `json:"ciphertext"`
+	// ValueHash is the merkle tree value entry of the plaintext value as a
+	// decimal string. It allows to build merkle proofs for the field without
+	// decryption. Empty if the field is not a literal.
+	ValueHash string `json:"valueHash,omitempty"`
+}
+
+// EncryptedCredential is the credential with some credentialSubject fields
+// removed and stored encrypted. Proofs of the credential are kept as is and
+// remain valid for the decrypted credential.
+type EncryptedCredential struct {
+	Credential      W3CCredential    `json:"credential"`
+	EncryptedFields []EncryptedField `json:"encryptedFields"`
+}
+
+// EncryptSubjectFields encrypts given credentialSubject fields for the
+// recipient. Fields are removed from the resulting credential. Merkle tree
+// value hashes of the plaintext values are computed from the original
+// credential, so the holder may prove the values later.
+func (vc *W3CCredential) EncryptSubjectFields(ctx context.Context,
+	recipient X25519PublicKey, fields []string,
+	opts ...merklize.MerklizeOption) (*EncryptedCredential, error) {
+
+	mz, err := vc.Merklize(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	vc2 := vc.WithoutProofs()
+	vc2.Proof = vc.Proof
+
+	result := &EncryptedCredential{}
+	for _, field := range fields {
+		value, ok := popSubjectField(vc2.CredentialSubject, field)
+		if !ok {
+			return nil, errors.Errorf(
+				"credentialSubject field %v not found", field)
+		}
+
+		var encField EncryptedField
+		encField, err = encryptField(recipient, field, value)
+		if err != nil {
+			return nil, err
+		}
+
+		encField.ValueHash, err = subjectFieldValueHash(mz, field)
+		if err != nil {
+			return nil, err
+		}
+
+		result.EncryptedFields = append(result.EncryptedFields, encField)
+	}
+
+	result.Credential = *vc2
+	return result, nil
+}
+
+// Decrypt decrypts all fields with the recipient's X25519 private key and
+// returns the original credential.
+func (ec *EncryptedCredential) Decrypt(
+	privateKey [32]byte) (*W3CCredential, error) {
+
+	vc, err := ec.Credential.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if vc.CredentialSubject == nil {
+		vc.CredentialSubject = make(map[string]any)
+	}
+
+	for _, f := range ec.EncryptedFields {
+		var value any
+		value, err = decryptField(privateKey, f)
+		if err != nil {
+			return nil, err
+		}
+		err = setSubjectField(vc.CredentialSubject, f.Path, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vc, nil
+}
+
+func encryptField(recipient X25519PublicKey, path string,
+	value any) (EncryptedField, error) {
+
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return EncryptedField{}, err
+	}
+
+	ephemeralPub, sharedSecret, err := x25519EphemeralAgreement(
+		recipient.Key)
+	if err != nil {
+		return EncryptedField{}, err
+	}
+
+	aead, err := fieldAEAD(sharedSecret, ephemeralPub, recipient.Key[:])
+	if err != nil {
+		return EncryptedField{}, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return EncryptedField{}, err
+	}
+	ciphertext := aead.Seal(nil, nonce, plaintext, []byte(path))
+
+	return EncryptedField{
+		Path:               path,
+		Algorithm:          FieldEncryptionAlgorithm,
+		KeyID:              recipient.KeyID,
+		EphemeralPublicKey: base64.RawURLEncoding.EncodeToString(ephemeralPub),
+		Nonce:              base64.RawURLEncoding.EncodeToString(nonce),
+		Ciphertext:         base64.RawURLEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+func decryptField(privateKey [32]byte, f EncryptedField) (any, error) {
+	if f.Algorithm != FieldEncryptionAlgorithm {
+		return nil, errors.Errorf("unsupported field encryption algorithm: %v",
+			f.Algorithm)
+	}
+
+	ephemeralPub, err := base64.RawURLEncoding.DecodeString(
+		f.EphemeralPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := base64.RawURLEncoding.DecodeString(f.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := base64.RawURLEncoding.DecodeString(f.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	recipientPub, err := curve25519.X25519(privateKey[:],
+		curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := x25519Agreement(privateKey, ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := fieldAEAD(sharedSecret, ephemeralPub, recipientPub)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid nonce length")
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(f.Path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't decrypt field %v", f.Path)
+	}
+
+	var value any
+	err = json.Unmarshal(plaintext, &value)
+	return value, err
+}
+
+func fieldAEAD(sharedSecret, ephemeralPub,
+	recipientPub []byte) (cipher.AEAD, error) {
+
+	salt := append(append([]byte{}, ephemeralPub...), recipientPub...)
+	kdf := hkdf.New(sha256.New, sharedSecret, salt, fieldEncryptionInfo)
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(kdf, key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+func subjectFieldValueHash(mz *merklize.Merklizer,
+	field string) (string, error) {
+
+	path, err := mz.ResolveDocPath(credentialSubjectKey + "." + field)
+	if err != nil {
+		return "", err
+	}
+	entry, err := mz.Entry(path)
+	if errors.Is(err, merklize.ErrorEntryNotFound) {
+		// not a literal, e.g. nested object
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	valueHash, err := entry.ValueMtEntry()
+	if err != nil {
+		return "", err
+	}
+	return valueHash.String(), nil
+}
+
+func popSubjectField(subject map[string]any, path string) (any, bool) {
+	parts := strings.Split(path, ".")
+	obj := subject
+	for _, p := range parts[:len(parts)-1] {
+		var ok bool
+		obj, ok = obj[p].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+	}
+	last := parts[len(parts)-1]
+	value, ok := obj[last]
+	if ok {
+		delete(obj, last)
+	}
+	return value, ok
+}
+
+func setSubjectField(subject map[string]any, path string, value any) error {
+	parts := strings.Split(path, ".")
+	obj := subject
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := obj[p]
+		if !ok {
+			nextObj := make(map[string]any)
+			obj[p] = nextObj
+			obj = nextObj
+			continue
+		}
+		obj, ok = next.(map[string]any)
+		if !ok {
+			return errors.Errorf("credentialSubject field %v is not an object",
+				p)
+		}
+	}
+	obj[parts[len(parts)-1]] = value
+	return nil
+}
@@ -0,0 +1,346 @@
+package verifiable
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/pkg/errors"
+)
+
+// Ed25519SignatureProofType is the type of holder binding proofs signed
+// with Ed25519 key from the DID document of the holder
+const Ed25519SignatureProofType ProofType = "Ed25519Signature2020"
+
+// ErrHolderBindingFailed is returned by VerifyHolderBinding when the proof
+// does not show that the holder controls the credential subject DID
+var ErrHolderBindingFailed = errors.New("holder binding verification failed")
+
+// HolderBindingChallenge is the message the credential holder signs to prove
+// they control the DID of the credential subject. The nonce is chosen by the
+// verifier to prevent replays.
+type HolderBindingChallenge struct {
+	Nonce        string `json:"nonce"`
+	CredentialID string `json:"credentialId,omitempty"`
+	SubjectID    string `json:"subjectId"`
+}
+
+// NewHolderBindingChallenge creates the challenge for the credential subject
+// of the credential
+func NewHolderBindingChallenge(vc *W3CCredential,
+	nonce string) (HolderBindingChallenge, error) {
+
+	if nonce == "" {
+		return HolderBindingChallenge{}, errors.New("nonce is empty")
+	}
+	subjectID, err := credentialSubjectID(vc)
+	if err != nil {
+		return HolderBindingChallenge{}, err
+	}
+	return HolderBindingChallenge{Nonce: nonce, CredentialID: vc.ID,
+		SubjectID: subjectID}, nil
+}
+
+// message returns the signed bytes of the challenge: its JSON encoding
+func (c HolderBindingChallenge) message() ([]byte, error) {
+	return json.Marshal(c)
+}
+
+// bjjMessage returns the poseidon hash of the challenge signed with BJJ key
+func (c HolderBindingChallenge) bjjMessage() (*big.Int, error) {
+	msg, err := c.message()
+	if err != nil {
+		return nil, err
+	}
+	return poseidon.HashBytes(msg)
+}
+
+// HolderBindingProof is the signature of HolderBindingChallenge.
+//
+// For BJJSignature2021 proofs HolderData is the auth BJJ claim of the
+// holder with the MTP of the claim in the claims tree of the holder's state,
+// like IssuerData of BJJSignature2021 credential proofs. For
+// Ed25519Signature2020 proofs VerificationMethod is the DID URL of the key
+// in the authentication relationship of the holder's DID document.
+type HolderBindingProof struct {
+	Type               ProofType              `json:"type"`
+	Challenge          HolderBindingChallenge `json:"challenge"`
+	HolderData         *IssuerData            `json:"holderData,omitempty"`
+	VerificationMethod string                 `json:"verificationMethod,omitempty"`
+	// Signature is hex encoded compressed BJJ signature or Ed25519
+	// signature
+	Signature string `json:"signature"`
+}
+
+// HolderBindingResult is the result of successful holder binding
+// verification
+type HolderBindingResult struct {
+	SubjectID string
+	ProofType ProofType
+	// KeyID is the verification method ID for Ed25519Signature2020 proofs
+	// and the hex of the auth claim for BJJSignature2021 proofs
+	KeyID string
+}
+
+// SignHolderBindingBJJ signs the challenge with the BJJ key of the auth
+// claim in holderData
+func SignHolderBindingBJJ(challenge HolderBindingChallenge,
+	key babyjub.PrivateKey, holderData IssuerData) (HolderBindingProof,
+	error) {
+
+	msg, err := challenge.bjjMessage()
+	if err != nil {
+		return HolderBindingProof{}, err
+	}
+	sig := key.SignPoseidon(msg).Compress()
+	return HolderBindingProof{
+		Type:       BJJSignatureProofType,
+		Challenge:  challenge,
+		HolderData: &holderData,
+		Signature:  hex.EncodeToString(sig[:]),
+	}, nil
+}
+
+// SignHolderBindingEd25519 signs the challenge with the Ed25519 key of the
+// verification method of the holder's DID document
+func SignHolderBindingEd25519(challenge HolderBindingChallenge,
+	key ed25519.PrivateKey, verificationMethod string) (HolderBindingProof,
+	error) {
+
+	msg, err := challenge.message()
+	if err != nil {
+		return HolderBindingProof{}, err
+	}
+	return HolderBindingProof{
+		Type:               Ed25519SignatureProofType,
+		Challenge:          challenge,
+		VerificationMethod: verificationMethod,
+		Signature:          hex.EncodeToString(ed25519.Sign(key, msg)),
+	}, nil
+}
+
+// VerifyHolderBinding checks that the proof signs the challenge with the
+// nonce for the subject of the credential, and that the signing key belongs
+// to the subject DID: the auth claim is in the published or genesis state of
+// the subject and is not revoked, or the Ed25519 key is in the authentication
+// relationship of the subject's DID document. Options are used to validate
+// the revocation status of the auth claim. Mismatches of the challenge, the
+// subject, the key and the signature are returned wrapping
+// ErrHolderBindingFailed.
+func VerifyHolderBinding(ctx context.Context, vc *W3CCredential,
+	proof HolderBindingProof, nonce string, didResolver DIDResolver,
+	opts ...CredentialStatusValidationOption) (HolderBindingResult, error) {
+
+	challenge, err := NewHolderBindingChallenge(vc, nonce)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+	if proof.Challenge != challenge {
+		return HolderBindingResult{}, errors.Wrap(ErrHolderBindingFailed,
+			"challenge does not match the credential and the nonce")
+	}
+
+	switch proof.Type {
+	case BJJSignatureProofType:
+		return verifyHolderBindingBJJ(ctx, proof, didResolver, opts)
+	case Ed25519SignatureProofType:
+		return verifyHolderBindingEd25519(ctx, proof, didResolver)
+	default:
+		return HolderBindingResult{}, ErrProofNotSupported
+	}
+}
+
+func verifyHolderBindingBJJ(ctx context.Context, proof HolderBindingProof,
+	didResolver DIDResolver,
+	opts []CredentialStatusValidationOption) (HolderBindingResult, error) {
+
+	holderData := proof.HolderData
+	if holderData == nil {
+		return HolderBindingResult{}, errors.New("holder data is empty")
+	}
+	if holderData.ID != proof.Challenge.SubjectID {
+		return HolderBindingResult{}, errors.Wrapf(ErrHolderBindingFailed,
+			"holder %v is not the credential subject", holderData.ID)
+	}
+	msg, err := proof.Challenge.bjjMessage()
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+	return verifyHolderSignatureBJJ(ctx, *holderData, msg, proof.Signature,
+		didResolver, opts, ErrHolderBindingFailed)
+}
+
+// verifyHolderSignatureBJJ checks the BJJ signature of the message with the
+// key of the auth claim of holderData, and that the auth claim is in the
+// published or genesis state of the holder and is not revoked. Mismatches
+// are returned wrapping errFailed.
+func verifyHolderSignatureBJJ(ctx context.Context, holderData IssuerData,
+	msg *big.Int, signature string, didResolver DIDResolver,
+	opts []CredentialStatusValidationOption,
+	errFailed error) (HolderBindingResult, error) {
+
+	if holderData.MTP == nil || holderData.State.ClaimsTreeRoot == nil {
+		return HolderBindingResult{}, errors.New(
+			"holder data has no auth claim proof")
+	}
+
+	authClaim, err := holderData.authClaim()
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+	publicKey, err := AuthBJJPublicKey(authClaim)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+	sig, err := bjjSignatureFromHexString(signature)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+	if !publicKey.VerifyPoseidon(msg, sig) {
+		return HolderBindingResult{}, errors.Wrap(errFailed,
+			"invalid signature")
+	}
+
+	err = verifyClaimInClaimsTree(Iden3SparseMerkleTreeProof{
+		IssuerData: holderData, MTP: holderData.MTP}, authClaim)
+	if err != nil {
+		return HolderBindingResult{}, errors.Wrapf(errFailed,
+			"auth claim is not in the claims tree: %v", err)
+	}
+	err = verifyIssuerState(ctx, holderData, didResolver, nil)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+	err = validateAuthClaimRevocation(ctx, holderData, opts...)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+
+	return HolderBindingResult{
+		SubjectID: holderData.ID,
+		ProofType: BJJSignatureProofType,
+		KeyID:     holderData.AuthCoreClaim,
+	}, nil
+}
+
+func verifyHolderBindingEd25519(ctx context.Context,
+	proof HolderBindingProof,
+	didResolver DIDResolver) (HolderBindingResult, error) {
+
+	vmDID, _, _ := strings.Cut(proof.VerificationMethod, "#")
+	if vmDID != "" && vmDID != proof.Challenge.SubjectID {
+		return HolderBindingResult{}, errors.Wrapf(ErrHolderBindingFailed,
+			"verification method %v is not of the credential subject",
+			proof.VerificationMethod)
+	}
+
+	msg, err := proof.Challenge.message()
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+	return verifyHolderSignatureEd25519(ctx, proof.Challenge.SubjectID,
+		proof.VerificationMethod, msg, proof.Signature, didResolver,
+		ErrHolderBindingFailed)
+}
+
+// verifyHolderSignatureEd25519 checks the Ed25519 signature of the message
+// with the key of the verification method in the authentication relationship
+// of the holder's DID document. Mismatches are returned wrapping errFailed.
+func verifyHolderSignatureEd25519(ctx context.Context, holderID,
+	verificationMethod string, msg []byte, signature string,
+	didResolver DIDResolver, errFailed error) (HolderBindingResult, error) {
+
+	holderDID, err := w3c.ParseDID(holderID)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+
+	doc, err := didResolver.Resolve(ctx, holderDID)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+	vm, ok := doc.authenticationMethodByRef(verificationMethod)
+	if !ok {
+		return HolderBindingResult{}, errors.Wrapf(errFailed,
+			"verification method %v is not in authentication of %v",
+			verificationMethod, holderID)
+	}
+	publicKey, ok := ed25519KeyFromVerificationMethod(vm)
+	if !ok {
+		return HolderBindingResult{}, errors.Errorf(
+			"verification method %v has no Ed25519 key", vm.ID)
+	}
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return HolderBindingResult{}, errors.WithStack(err)
+	}
+	if !ed25519.Verify(publicKey, msg, sig) {
+		return HolderBindingResult{}, errors.Wrap(errFailed,
+			"invalid signature")
+	}
+
+	return HolderBindingResult{
+		SubjectID: holderID,
+		ProofType: Ed25519SignatureProofType,
+		KeyID:     vm.ID,
+	}, nil
+}
+
+// authenticationMethodByRef finds the verification method of the
+// authentication relationship by the absolute or relative DID URL
+func (doc DIDDocument) authenticationMethodByRef(
+	ref string) (CommonVerificationMethod, bool) {
+
+	_, fragment, ok := strings.Cut(ref, "#")
+	if !ok {
+		return CommonVerificationMethod{}, false
+	}
+	m := didURLMatcher{docID: doc.ID}
+	for _, a := range doc.Authentication {
+		if a.IsDID() {
+			if a.DID() != ref && !m.matches(a.DID(), fragment) {
+				continue
+			}
+			return doc.verificationMethodByRef(a.DID())
+		}
+		if a.ID == ref || m.matches(a.ID, fragment) {
+			return a.CommonVerificationMethod, true
+		}
+	}
+	return CommonVerificationMethod{}, false
+}
+
+// ed25519KeyFromVerificationMethod returns Ed25519 key defined with
+// publicKeyJwk (kty OKP, crv Ed25519) or publicKeyHex
+func ed25519KeyFromVerificationMethod(
+	vm CommonVerificationMethod) (ed25519.PublicKey, bool) {
+
+	var keyBytes []byte
+	var err error
+	switch {
+	case vm.PublicKeyJwk != nil:
+		if vm.PublicKeyJwk["kty"] != "OKP" ||
+			vm.PublicKeyJwk["crv"] != "Ed25519" {
+			return nil, false
+		}
+		x, _ := vm.PublicKeyJwk["x"].(string)
+		keyBytes, err = base64.RawURLEncoding.DecodeString(x)
+	case vm.PublicKeyHex != "" &&
+		strings.HasPrefix(vm.Type, "Ed25519VerificationKey"):
+		keyBytes, err = hex.DecodeString(vm.PublicKeyHex)
+	default:
+		return nil, false
+	}
+	if err != nil || len(keyBytes) != ed25519.PublicKeySize {
+		return nil, false
+	}
+	return keyBytes, true
+}
@@ -0,0 +1,188 @@
+package verifiable
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"math/big"
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type holderBindingStatusResolver struct {
+	state TreeState
+}
+
+func (r holderBindingStatusResolver) Resolve(_ context.Context,
+	_ CredentialStatus) (RevocationStatus, error) {
+
+	return RevocationStatus{Issuer: r.state}, nil
+}
+
+// newGenesisHolder creates the identity with the auth BJJ claim in the
+// genesis state
+func newGenesisHolder(t *testing.T, key babyjub.PrivateKey) (IssuerData,
+	TreeState) {
+
+	ctx := context.Background()
+	pub := key.Public()
+	authClaim, err := core.NewClaim(core.AuthSchemaHash,
+		core.WithIndexDataInts(pub.X, pub.Y))
+	require.NoError(t, err)
+
+	claimsTree, err := merkletree.NewMerkleTree(ctx,
+		memory.NewMemoryStorage(), 40)
+	require.NoError(t, err)
+	hi, hv, err := authClaim.HiHv()
+	require.NoError(t, err)
+	require.NoError(t, claimsTree.Add(ctx, hi, hv))
+	mtp, _, err := claimsTree.GenerateProof(ctx, hi, nil)
+	require.NoError(t, err)
+
+	state, err := merkletree.HashElems(claimsTree.Root().BigInt(),
+		big.NewInt(0), big.NewInt(0))
+	require.NoError(t, err)
+	didType, err := core.BuildDIDType(core.DIDMethodPolygonID, core.Polygon,
+		core.Mumbai)
+	require.NoError(t, err)
+	id, err := core.NewIDFromIdenState(didType, state.BigInt())
+	require.NoError(t, err)
+	did, err := core.ParseDIDFromID(*id)
+	require.NoError(t, err)
+
+	authClaimHex, err := authClaim.Hex()
+	require.NoError(t, err)
+	stateHex := state.Hex()
+	ctrHex := claimsTree.Root().Hex()
+	zeroHex := merkletree.HashZero.Hex()
+	treeState := TreeState{State: &stateHex, ClaimsTreeRoot: &ctrHex,
+		RevocationTreeRoot: &zeroHex, RootOfRoots: &zeroHex}
+
+	return IssuerData{
+		ID: did.String(),
+		State: State{Value: &stateHex, ClaimsTreeRoot: &ctrHex,
+			RevocationTreeRoot: &zeroHex, RootOfRoots: &zeroHex},
+		AuthCoreClaim: authClaimHex,
+		MTP:           mtp,
+		CredentialStatus: CredentialStatus{ID: "https://rhs.example.com/node",
+			Type: SparseMerkleTreeProof},
+	}, treeState
+}
+
+func TestHolderBinding_BJJ(t *testing.T) {
+	ctx := context.Background()
+	key := babyjub.NewRandPrivKey()
+	holderData, treeState := newGenesisHolder(t, key)
+
+	vc := &W3CCredential{ID: "urn:uuid:1",
+		CredentialSubject: map[string]any{"id": holderData.ID}}
+	published := false
+	didResolver := &fixedDIDResolver{doc: DIDDocument{
+		ID: holderData.ID,
+		VerificationMethod: []CommonVerificationMethod{{
+			ID:            holderData.ID + "#state-info",
+			Type:          "Iden3StateInfo2023",
+			IdentityState: IdentityState{Published: &published},
+		}},
+	}}
+	registry := &CredentialStatusResolverRegistry{}
+	registry.Register(SparseMerkleTreeProof,
+		holderBindingStatusResolver{state: treeState})
+	statusOpts := []CredentialStatusValidationOption{
+		WithValidationStatusResolverRegistry(registry)}
+
+	challenge, err := NewHolderBindingChallenge(vc, "nonce-1")
+	require.NoError(t, err)
+	proof, err := SignHolderBindingBJJ(challenge, key, holderData)
+	require.NoError(t, err)
+
+	res, err := VerifyHolderBinding(ctx, vc, proof, "nonce-1", didResolver,
+		statusOpts...)
+	require.NoError(t, err)
+	require.Equal(t, HolderBindingResult{SubjectID: holderData.ID,
+		ProofType: BJJSignatureProofType,
+		KeyID:     holderData.AuthCoreClaim}, res)
+
+	t.Run("other nonce", func(t *testing.T) {
+		_, err := VerifyHolderBinding(ctx, vc, proof, "nonce-2", didResolver,
+			statusOpts...)
+		require.ErrorIs(t, err, ErrHolderBindingFailed)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		proof, err := SignHolderBindingBJJ(challenge,
+			babyjub.NewRandPrivKey(), holderData)
+		require.NoError(t, err)
+		_, err = VerifyHolderBinding(ctx, vc, proof, "nonce-1", didResolver,
+			statusOpts...)
+		require.ErrorIs(t, err, ErrHolderBindingFailed)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("other subject", func(t *testing.T) {
+		otherKey := babyjub.NewRandPrivKey()
+		otherHolder, _ := newGenesisHolder(t, otherKey)
+		proof, err := SignHolderBindingBJJ(challenge, otherKey, otherHolder)
+		require.NoError(t, err)
+		_, err = VerifyHolderBinding(ctx, vc, proof, "nonce-1", didResolver,
+			statusOpts...)
+		require.ErrorIs(t, err, ErrHolderBindingFailed)
+		require.ErrorContains(t, err, "is not the credential subject")
+	})
+}
+
+func TestHolderBinding_Ed25519(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	subject := "did:example:holder"
+	keyID := subject + "#key-1"
+	didResolver := &fixedDIDResolver{doc: DIDDocument{
+		ID: subject,
+		VerificationMethod: []CommonVerificationMethod{{
+			ID:         keyID,
+			Type:       "JsonWebKey2020",
+			Controller: subject,
+			PublicKeyJwk: map[string]any{"kty": "OKP", "crv": "Ed25519",
+				"x": base64.RawURLEncoding.EncodeToString(pub)},
+		}},
+		Authentication: []Authentication{{did: keyID}},
+	}}
+	vc := &W3CCredential{CredentialSubject: map[string]any{"id": subject}}
+
+	challenge, err := NewHolderBindingChallenge(vc, "nonce-1")
+	require.NoError(t, err)
+	proof, err := SignHolderBindingEd25519(challenge, priv, keyID)
+	require.NoError(t, err)
+
+	res, err := VerifyHolderBinding(ctx, vc, proof, "nonce-1", didResolver)
+	require.NoError(t, err)
+	require.Equal(t, HolderBindingResult{SubjectID: subject,
+		ProofType: Ed25519SignatureProofType, KeyID: keyID}, res)
+
+	// relative reference
+	proof.VerificationMethod = "#key-1"
+	_, err = VerifyHolderBinding(ctx, vc, proof, "nonce-1", didResolver)
+	require.NoError(t, err)
+
+	t.Run("not authentication key", func(t *testing.T) {
+		proof := proof
+		proof.VerificationMethod = subject + "#key-2"
+		_, err := VerifyHolderBinding(ctx, vc, proof, "nonce-1", didResolver)
+		require.ErrorIs(t, err, ErrHolderBindingFailed)
+	})
+
+	t.Run("tampered challenge", func(t *testing.T) {
+		proof := proof
+		proof.Challenge.Nonce = "nonce-2"
+		_, err := VerifyHolderBinding(ctx, vc, proof, "nonce-2", didResolver)
+		require.ErrorIs(t, err, ErrHolderBindingFailed)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+}
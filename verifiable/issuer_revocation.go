@@ -0,0 +1,235 @@
+package verifiable
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"math/big"
+	"sync"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/pkg/errors"
+)
+
+// RevocationTreeLevels is the depth of the revocation tree of iden3
+// identities
+const RevocationTreeLevels = 40
+
+// ErrRevocationNonceUsed is returned when the revocation nonce is already
+// assigned to another credential
+var ErrRevocationNonceUsed = errors.New("revocation nonce is already used")
+
+const maxNonceAttempts = 16
+
+// IssuerRevocationTree manages revocation nonces of the issuer's credentials
+// and the revocation tree they are revoked in. It builds RevocationStatus
+// responses verified by credential status resolvers and may be registered as
+// CredentialStatusResolver itself. Methods are safe for concurrent use.
+type IssuerRevocationTree struct {
+	mu             sync.Mutex
+	mt             *merkletree.MerkleTree
+	claimsTreeRoot *merkletree.Hash
+	rootOfRoots    *merkletree.Hash
+	usedNonces     map[uint64]struct{}
+}
+
+// NewIssuerRevocationTree creates the revocation tree in the storage. The
+// claims tree root and the root of roots are used to calculate the issuer's
+// state; nil roots are zero hashes.
+func NewIssuerRevocationTree(ctx context.Context,
+	storage merkletree.Storage, claimsTreeRoot,
+	rootOfRoots *merkletree.Hash) (*IssuerRevocationTree, error) {
+
+	mt, err := merkletree.NewMerkleTree(ctx, storage, RevocationTreeLevels)
+	if err != nil {
+		return nil, err
+	}
+	t := &IssuerRevocationTree{mt: mt, usedNonces: make(map[uint64]struct{})}
+	t.SetRoots(claimsTreeRoot, rootOfRoots)
+	return t, nil
+}
+
+// SetRoots updates the claims tree root and the root of roots after the
+// issuer's claims or roots trees are changed
+func (t *IssuerRevocationTree) SetRoots(claimsTreeRoot,
+	rootOfRoots *merkletree.Hash) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if claimsTreeRoot == nil {
+		claimsTreeRoot = &merkletree.HashZero
+	}
+	if rootOfRoots == nil {
+		rootOfRoots = &merkletree.HashZero
+	}
+	t.claimsTreeRoot = claimsTreeRoot
+	t.rootOfRoots = rootOfRoots
+}
+
+// MarkNoncesUsed registers nonces assigned to credentials outside of the
+// tree, e.g. loaded from the issuer's database, so that NewNonce does not
+// return them
+func (t *IssuerRevocationTree) MarkNoncesUsed(nonces ...uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, n := range nonces {
+		t.usedNonces[n] = struct{}{}
+	}
+}
+
+// NewNonce returns a random revocation nonce that is not used by other
+// credentials and is not revoked, and marks it used
+func (t *IssuerRevocationTree) NewNonce(ctx context.Context) (uint64, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var buf [8]byte
+	for i := 0; i < maxNonceAttempts; i++ {
+		_, err := rand.Read(buf[:])
+		if err != nil {
+			return 0, errors.WithStack(err)
+		}
+		nonce := binary.BigEndian.Uint64(buf[:])
+		if _, ok := t.usedNonces[nonce]; ok {
+			continue
+		}
+		revoked, err := t.isRevoked(ctx, nonce)
+		if err != nil {
+			return 0, err
+		}
+		if revoked {
+			continue
+		}
+		t.usedNonces[nonce] = struct{}{}
+		return nonce, nil
+	}
+	return 0, errors.New("failed to generate unique revocation nonce")
+}
+
+// ReserveNonce marks the nonce chosen by the caller used. Returns
+// ErrRevocationNonceUsed if it is used or revoked.
+func (t *IssuerRevocationTree) ReserveNonce(ctx context.Context,
+	nonce uint64) error {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.usedNonces[nonce]; ok {
+		return errors.Wrapf(ErrRevocationNonceUsed, "%v", nonce)
+	}
+	revoked, err := t.isRevoked(ctx, nonce)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return errors.Wrapf(ErrRevocationNonceUsed, "%v", nonce)
+	}
+	t.usedNonces[nonce] = struct{}{}
+	return nil
+}
+
+// Revoke adds nonces to the revocation tree. Already revoked nonces are
+// skipped.
+func (t *IssuerRevocationTree) Revoke(ctx context.Context,
+	nonces ...uint64) error {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, n := range nonces {
+		err := t.mt.Add(ctx, new(big.Int).SetUint64(n), big.NewInt(0))
+		if errors.Is(err, merkletree.ErrEntryIndexAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to revoke nonce %v", n)
+		}
+	}
+	return nil
+}
+
+// IsRevoked returns true if the nonce is in the revocation tree
+func (t *IssuerRevocationTree) IsRevoked(ctx context.Context,
+	nonce uint64) (bool, error) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.isRevoked(ctx, nonce)
+}
+
+func (t *IssuerRevocationTree) isRevoked(ctx context.Context,
+	nonce uint64) (bool, error) {
+
+	proof, _, err := t.mt.GenerateProof(ctx, new(big.Int).SetUint64(nonce),
+		nil)
+	if err != nil {
+		return false, err
+	}
+	return proof.Existence, nil
+}
+
+// Root returns the root of the revocation tree
+func (t *IssuerRevocationTree) Root() *merkletree.Hash {
+	return t.mt.Root()
+}
+
+// TreeState returns the issuer's state calculated from the claims tree
+// root, the revocation tree root and the root of roots
+func (t *IssuerRevocationTree) TreeState() (TreeState, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.treeState()
+}
+
+func (t *IssuerRevocationTree) treeState() (TreeState, error) {
+	revocationTreeRoot := t.mt.Root()
+	state, err := merkletree.HashElems(t.claimsTreeRoot.BigInt(),
+		revocationTreeRoot.BigInt(), t.rootOfRoots.BigInt())
+	if err != nil {
+		return TreeState{}, err
+	}
+
+	stateHex := state.Hex()
+	claimsTreeRootHex := t.claimsTreeRoot.Hex()
+	revocationTreeRootHex := revocationTreeRoot.Hex()
+	rootOfRootsHex := t.rootOfRoots.Hex()
+	return TreeState{
+		State:              &stateHex,
+		ClaimsTreeRoot:     &claimsTreeRootHex,
+		RevocationTreeRoot: &revocationTreeRootHex,
+		RootOfRoots:        &rootOfRootsHex,
+	}, nil
+}
+
+// RevocationStatus returns the issuer's tree state with the proof of
+// existence (revoked) or non-existence (not revoked) of the nonce in the
+// revocation tree
+func (t *IssuerRevocationTree) RevocationStatus(ctx context.Context,
+	nonce uint64) (RevocationStatus, error) {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	proof, _, err := t.mt.GenerateProof(ctx, new(big.Int).SetUint64(nonce),
+		nil)
+	if err != nil {
+		return RevocationStatus{}, err
+	}
+	treeState, err := t.treeState()
+	if err != nil {
+		return RevocationStatus{}, err
+	}
+	return RevocationStatus{Issuer: treeState, MTP: *proof}, nil
+}
+
+// Resolve implements CredentialStatusResolver with the revocation status of
+// the credential status nonce
+func (t *IssuerRevocationTree) Resolve(ctx context.Context,
+	credentialStatus CredentialStatus) (RevocationStatus, error) {
+
+	return t.RevocationStatus(ctx, credentialStatus.RevocationNonce)
+}
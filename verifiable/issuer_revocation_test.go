@@ -0,0 +1,72 @@
+package verifiable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssuerRevocationTree(t *testing.T) {
+	ctx := context.Background()
+	claimsTreeRoot, err := merkletree.NewHashFromString("1234")
+	require.NoError(t, err)
+	tree, err := NewIssuerRevocationTree(ctx, memory.NewMemoryStorage(),
+		claimsTreeRoot, nil)
+	require.NoError(t, err)
+
+	nonce1, err := tree.NewNonce(ctx)
+	require.NoError(t, err)
+	nonce2, err := tree.NewNonce(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, nonce1, nonce2)
+
+	err = tree.ReserveNonce(ctx, nonce1)
+	require.ErrorIs(t, err, ErrRevocationNonceUsed)
+	require.NoError(t, tree.ReserveNonce(ctx, 10))
+	require.ErrorIs(t, tree.ReserveNonce(ctx, 10), ErrRevocationNonceUsed)
+	tree.MarkNoncesUsed(11)
+	require.ErrorIs(t, tree.ReserveNonce(ctx, 11), ErrRevocationNonceUsed)
+
+	registry := &CredentialStatusResolverRegistry{}
+	registry.Register(SparseMerkleTreeProof, tree)
+	statusOpts := []CredentialStatusValidationOption{
+		WithValidationStatusResolverRegistry(registry)}
+	status := func(nonce uint64) CredentialStatus {
+		return CredentialStatus{ID: "https://issuer.example.com/status",
+			Type: SparseMerkleTreeProof, RevocationNonce: nonce}
+	}
+
+	_, err = ValidateCredentialStatus(ctx, status(nonce1), statusOpts...)
+	require.NoError(t, err)
+
+	require.NoError(t, tree.Revoke(ctx, nonce1, 10))
+	// revoking twice is not an error
+	require.NoError(t, tree.Revoke(ctx, nonce1))
+	revoked, err := tree.IsRevoked(ctx, nonce1)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	_, err = ValidateCredentialStatus(ctx, status(nonce1), statusOpts...)
+	require.ErrorIs(t, err, ErrCredentialIsRevoked)
+	_, err = ValidateCredentialStatus(ctx, status(nonce2), statusOpts...)
+	require.NoError(t, err)
+
+	revStatus, err := tree.RevocationStatus(ctx, nonce2)
+	require.NoError(t, err)
+	require.False(t, revStatus.MTP.Existence)
+	require.Equal(t, tree.Root().Hex(), *revStatus.Issuer.RevocationTreeRoot)
+	require.Equal(t, claimsTreeRoot.Hex(), *revStatus.Issuer.ClaimsTreeRoot)
+	require.Equal(t, merkletree.HashZero.Hex(), *revStatus.Issuer.RootOfRoots)
+
+	// the state changes with the claims tree root
+	tree.SetRoots(&merkletree.HashZero, nil)
+	treeState, err := tree.TreeState()
+	require.NoError(t, err)
+	require.NotEqual(t, *revStatus.Issuer.State, *treeState.State)
+	ok, err := validateTreeState(treeState)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,146 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/pkg/errors"
+)
+
+// WithIssuerStateUpdate enables fetching of the latest issuer state for
+// BJJSignature2021 proofs. If the issuer state of the proof is neither
+// published nor genesis (e.g. the issuer has rotated the state and has not
+// published it yet when the credential was issued) and the issuer data has
+// UpdateURL, the updated issuer data is downloaded from it with the
+// httpClient (http.DefaultClient if nil). The updated data must be of the
+// same issuer, must prove the inclusion of the same auth claim into the
+// claims tree of the updated state, and the updated state must be published
+// or genesis.
+func WithIssuerStateUpdate(httpClient *http.Client) W3CProofVerificationOpt {
+	return func(opts *w3CProofVerificationConfig) {
+		opts.issuerStateUpdate = true
+		opts.httpClient = httpClient
+	}
+}
+
+func verifyUpdatedIssuerState(ctx context.Context, issuerData IssuerData,
+	authClaim *core.Claim, didResolver DIDResolver,
+	verifyConfig w3CProofVerificationConfig) error {
+
+	start := time.Now()
+	updatedData, err := fetchIssuerData(ctx, verifyConfig.httpClient,
+		issuerData.UpdateURL)
+	if err == nil {
+		err = verifyIssuerDataUpdate(issuerData, updatedData, authClaim)
+	}
+	logVerificationStep(ctx, verifyConfig.logger,
+		VerificationStepIssuerStateUpdate, start, err,
+		map[string]any{"issuer": issuerData.ID, "url": issuerData.UpdateURL})
+	if err != nil {
+		return errors.Wrap(err, "failed to update issuer state")
+	}
+
+	return verifyIssuerState(ctx, updatedData, didResolver,
+		verifyConfig.logger)
+}
+
+// verifyIssuerDataUpdate checks that the updated issuer data belongs to the
+// same issuer and contains the auth claim in its claims tree
+func verifyIssuerDataUpdate(issuerData, updatedData IssuerData,
+	authClaim *core.Claim) error {
+
+	if updatedData.ID != issuerData.ID {
+		return errors.Errorf("issuer mismatch: %v != %v", updatedData.ID,
+			issuerData.ID)
+	}
+	if updatedData.AuthCoreClaim != "" &&
+		updatedData.AuthCoreClaim != issuerData.AuthCoreClaim {
+
+		return errors.New("auth core claim mismatch")
+	}
+
+	state := updatedData.State
+	if state.Value == nil || state.ClaimsTreeRoot == nil {
+		return errors.New("updated issuer state is empty")
+	}
+	treeStateOk, err := validateTreeState(TreeState{
+		State:              state.Value,
+		ClaimsTreeRoot:     state.ClaimsTreeRoot,
+		RevocationTreeRoot: state.RevocationTreeRoot,
+		RootOfRoots:        state.RootOfRoots,
+	})
+	if err != nil {
+		return err
+	}
+	if !treeStateOk {
+		return errors.New("invalid tree state of the updated issuer state")
+	}
+
+	if updatedData.MTP == nil {
+		return errors.New("auth claim proof is empty")
+	}
+	claimsTreeRoot, err := merkletree.NewHashFromHex(*state.ClaimsTreeRoot)
+	if err != nil {
+		return err
+	}
+	hi, hv, err := authClaim.HiHv()
+	if err != nil {
+		return err
+	}
+	if !updatedData.MTP.Existence ||
+		!merkletree.VerifyProof(claimsTreeRoot, updatedData.MTP, hi, hv) {
+
+		return errors.New("auth claim is not in the updated issuer state")
+	}
+
+	return nil
+}
+
+func fetchIssuerData(ctx context.Context, httpClient *http.Client,
+	url string) (out IssuerData, err error) {
+
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url,
+		http.NoBody)
+	if err != nil {
+		return out, err
+	}
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return out, err
+	}
+	defer func() {
+		err2 := httpResp.Body.Close()
+		if err2 != nil && err == nil {
+			err = err2
+		}
+	}()
+
+	statusOK := httpResp.StatusCode >= 200 && httpResp.StatusCode < 300
+	if !statusOK {
+		return out, fmt.Errorf("unexpected status code: %d",
+			httpResp.StatusCode)
+	}
+
+	limitReader := &io.LimitedReader{R: httpResp.Body, N: limitReaderBytes}
+	respData, err := io.ReadAll(limitReader)
+	if err != nil {
+		return out, err
+	}
+	if limitReader.N <= 0 {
+		return out, fmt.Errorf("response body size exceeds the limit of %d",
+			limitReaderBytes)
+	}
+
+	err = json.Unmarshal(respData, &out)
+	return out, err
+}
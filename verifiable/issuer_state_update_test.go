@@ -0,0 +1,138 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func newRandAuthClaim(t testing.TB) *core.Claim {
+	privKey := babyjub.NewRandPrivKey()
+	authClaim, err := NewAuthBJJCoreClaim(privKey.Public(), 0)
+	require.NoError(t, err)
+	return authClaim
+}
+
+// issuerDataWithAuthClaim returns the issuer data with the state of the
+// claims tree containing the auth claim
+func issuerDataWithAuthClaim(t testing.TB, issuerID string,
+	authClaim *core.Claim) IssuerData {
+
+	ctx := context.Background()
+	claimsTree, err := merkletree.NewMerkleTree(ctx,
+		memory.NewMemoryStorage(), 40)
+	require.NoError(t, err)
+	hi, hv, err := authClaim.HiHv()
+	require.NoError(t, err)
+	require.NoError(t, claimsTree.Add(ctx, hi, hv))
+	proof, _, err := claimsTree.GenerateProof(ctx, hi, nil)
+	require.NoError(t, err)
+
+	state, err := poseidon.Hash([]*big.Int{claimsTree.Root().BigInt(),
+		big.NewInt(0), big.NewInt(0)})
+	require.NoError(t, err)
+	stateHash, err := merkletree.NewHashFromBigInt(state)
+	require.NoError(t, err)
+
+	authClaimHex, err := authClaim.Hex()
+	require.NoError(t, err)
+	stateHex := stateHash.Hex()
+	claimsTreeRootHex := claimsTree.Root().Hex()
+	zeroHex := merkletree.HashZero.Hex()
+	return IssuerData{
+		ID: issuerID,
+		State: State{
+			Value:              &stateHex,
+			ClaimsTreeRoot:     &claimsTreeRootHex,
+			RevocationTreeRoot: &zeroHex,
+			RootOfRoots:        &zeroHex,
+		},
+		AuthCoreClaim: authClaimHex,
+		MTP:           proof,
+	}
+}
+
+func TestVerifyIssuerDataUpdate(t *testing.T) {
+	issuerID := "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf"
+	authClaim := newRandAuthClaim(t)
+	authClaimHex, err := authClaim.Hex()
+	require.NoError(t, err)
+	issuerData := IssuerData{ID: issuerID, AuthCoreClaim: authClaimHex}
+
+	updatedData := issuerDataWithAuthClaim(t, issuerID, authClaim)
+	require.NoError(t,
+		verifyIssuerDataUpdate(issuerData, updatedData, authClaim))
+
+	t.Run("issuer mismatch", func(t *testing.T) {
+		otherData := updatedData
+		otherData.ID = "did:polygonid:polygon:mumbai:2qFXWZVHPy8R8AWmPpdPd5vfwgWsBgaUJp4ACzpdq1"
+		err := verifyIssuerDataUpdate(issuerData, otherData, authClaim)
+		require.ErrorContains(t, err, "issuer mismatch")
+	})
+
+	t.Run("other auth claim", func(t *testing.T) {
+		otherData := issuerDataWithAuthClaim(t, issuerID,
+			newRandAuthClaim(t))
+		err := verifyIssuerDataUpdate(issuerData, otherData, authClaim)
+		require.EqualError(t, err, "auth core claim mismatch")
+
+		otherData.AuthCoreClaim = ""
+		err = verifyIssuerDataUpdate(issuerData, otherData, authClaim)
+		require.EqualError(t, err,
+			"auth claim is not in the updated issuer state")
+	})
+
+	t.Run("no proof", func(t *testing.T) {
+		otherData := updatedData
+		otherData.MTP = nil
+		err := verifyIssuerDataUpdate(issuerData, otherData, authClaim)
+		require.EqualError(t, err, "auth claim proof is empty")
+	})
+
+	t.Run("invalid state", func(t *testing.T) {
+		otherData := updatedData
+		zeroHex := merkletree.HashZero.Hex()
+		otherData.State.Value = &zeroHex
+		err := verifyIssuerDataUpdate(issuerData, otherData, authClaim)
+		require.EqualError(t, err,
+			"invalid tree state of the updated issuer state")
+	})
+}
+
+func TestFetchIssuerData(t *testing.T) {
+	issuerID := "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf"
+	authClaim := newRandAuthClaim(t)
+	updatedData := issuerDataWithAuthClaim(t, issuerID, authClaim)
+	updatedData.UpdateURL = "https://issuer.example.com/update"
+
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/issuer" {
+				http.NotFound(w, r)
+				return
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(updatedData))
+		}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	gotData, err := fetchIssuerData(ctx, srv.Client(), srv.URL+"/issuer")
+	require.NoError(t, err)
+	require.Equal(t, updatedData.ID, gotData.ID)
+	require.Equal(t, updatedData.State, gotData.State)
+	require.Equal(t, updatedData.UpdateURL, gotData.UpdateURL)
+	require.NoError(t, verifyIssuerDataUpdate(updatedData, gotData, authClaim))
+
+	_, err = fetchIssuerData(ctx, srv.Client(), srv.URL+"/other")
+	require.EqualError(t, err, "unexpected status code: 404")
+}
@@ -0,0 +1,230 @@
+package verifiable
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/curve25519"
+)
+
+// JWE algorithms supported by EncryptJWE and DecryptJWE: ECDH-ES direct key
+// agreement with X25519 ephemeral key (RFC 8037) and AES-256-GCM content
+// encryption (RFC 7518)
+const (
+	JWEAlgorithmECDHES   = "ECDH-ES"
+	JWEEncryptionA256GCM = "A256GCM"
+)
+
+// Content types of the encrypted payload
+const (
+	MediaTypeVerifiableCredential   = "application/vc+ld+json"
+	MediaTypeVerifiablePresentation = "application/vp+ld+json"
+)
+
+const jweKeySize = 32
+
+// JWEHeader is the protected header of the JWE
+type JWEHeader struct {
+	Algorithm          string         `json:"alg"`
+	Encryption         string         `json:"enc"`
+	KeyID              string         `json:"kid,omitempty"`
+	ContentType        string         `json:"cty,omitempty"`
+	EphemeralPublicKey map[string]any `json:"epk"`
+}
+
+// EncryptJWE encrypts the payload to the recipient's X25519 key and returns
+// the JWE in compact serialization. The recipient key may be taken from the
+// keyAgreement of the recipient's DID document with X25519KeyFromDIDDocument.
+func EncryptJWE(payload []byte, contentType string,
+	recipient X25519PublicKey) (string, error) {
+
+	ephemeralPub, sharedSecret, err := x25519EphemeralAgreement(
+		recipient.Key)
+	if err != nil {
+		return "", err
+	}
+
+	header := JWEHeader{
+		Algorithm:   JWEAlgorithmECDHES,
+		Encryption:  JWEEncryptionA256GCM,
+		KeyID:       recipient.KeyID,
+		ContentType: contentType,
+		EphemeralPublicKey: map[string]any{
+			"kty": "OKP",
+			"crv": "X25519",
+			"x":   base64.RawURLEncoding.EncodeToString(ephemeralPub),
+		},
+	}
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedHeader := base64.RawURLEncoding.EncodeToString(headerBytes)
+
+	aead, err := jweAEAD(sharedSecret)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nil, iv, payload, []byte(encodedHeader))
+	ciphertext := sealed[:len(sealed)-aead.Overhead()]
+	tag := sealed[len(sealed)-aead.Overhead():]
+
+	return strings.Join([]string{
+		encodedHeader,
+		"", // no encrypted key for direct key agreement
+		base64.RawURLEncoding.EncodeToString(iv),
+		base64.RawURLEncoding.EncodeToString(ciphertext),
+		base64.RawURLEncoding.EncodeToString(tag),
+	}, "."), nil
+}
+
+// DecryptJWE decrypts the JWE in compact serialization created by
+// EncryptJWE with the recipient's X25519 private key
+func DecryptJWE(jwe string, privateKey [32]byte) ([]byte, JWEHeader, error) {
+	var header JWEHeader
+	parts := strings.Split(jwe, ".")
+	if len(parts) != 5 {
+		return nil, header, errors.New("invalid JWE compact serialization")
+	}
+	if parts[1] != "" {
+		return nil, header, errors.New("unexpected JWE encrypted key")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, header, errors.Wrap(err, "invalid JWE header")
+	}
+	err = json.Unmarshal(headerBytes, &header)
+	if err != nil {
+		return nil, header, errors.Wrap(err, "invalid JWE header")
+	}
+	if header.Algorithm != JWEAlgorithmECDHES {
+		return nil, header, errors.Errorf("unsupported JWE algorithm: %v",
+			header.Algorithm)
+	}
+	if header.Encryption != JWEEncryptionA256GCM {
+		return nil, header, errors.Errorf("unsupported JWE encryption: %v",
+			header.Encryption)
+	}
+
+	ephemeralPub, err := jweEphemeralPublicKey(header.EphemeralPublicKey)
+	if err != nil {
+		return nil, header, err
+	}
+
+	var encoded [3][]byte
+	for i := range encoded {
+		encoded[i], err = base64.RawURLEncoding.DecodeString(parts[i+2])
+		if err != nil {
+			return nil, header, errors.Wrap(err, "invalid JWE encoding")
+		}
+	}
+	iv, ciphertext, tag := encoded[0], encoded[1], encoded[2]
+
+	sharedSecret, err := x25519Agreement(privateKey, ephemeralPub)
+	if err != nil {
+		return nil, header, err
+	}
+	aead, err := jweAEAD(sharedSecret)
+	if err != nil {
+		return nil, header, err
+	}
+	if len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+		return nil, header, errors.New("invalid JWE initialization vector " +
+			"or authentication tag")
+	}
+
+	payload, err := aead.Open(nil, iv, append(ciphertext, tag...),
+		[]byte(parts[0]))
+	if err != nil {
+		return nil, header, errors.Wrap(err, "can't decrypt JWE")
+	}
+	return payload, header, nil
+}
+
+// EncryptJWE encrypts the credential to the recipient's X25519 key
+func (vc *W3CCredential) EncryptJWE(
+	recipient X25519PublicKey) (string, error) {
+
+	payload, err := json.Marshal(vc)
+	if err != nil {
+		return "", err
+	}
+	return EncryptJWE(payload, MediaTypeVerifiableCredential, recipient)
+}
+
+// DecryptCredentialJWE decrypts the credential encrypted with EncryptJWE and
+// checks that the payload is a verifiable credential. The proof of the
+// credential is not verified.
+func DecryptCredentialJWE(jwe string,
+	privateKey [32]byte) (*W3CCredential, error) {
+
+	payload, header, err := DecryptJWE(jwe, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if header.ContentType != MediaTypeVerifiableCredential {
+		return nil, errors.Errorf("unexpected JWE content type: %v",
+			header.ContentType)
+	}
+
+	var vc W3CCredential
+	err = json.Unmarshal(payload, &vc)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(vc.Type, TypeW3CVerifiableCredential) {
+		return nil, errors.New("JWE payload is not a verifiable credential")
+	}
+	return &vc, nil
+}
+
+func jweEphemeralPublicKey(epk map[string]any) ([]byte, error) {
+	if epk["kty"] != "OKP" || epk["crv"] != "X25519" {
+		return nil, errors.New("unsupported JWE ephemeral key")
+	}
+	x, _ := epk["x"].(string)
+	key, err := base64.RawURLEncoding.DecodeString(x)
+	if err != nil || len(key) != curve25519.PointSize {
+		return nil, errors.New("invalid JWE ephemeral key")
+	}
+	return key, nil
+}
+
+// jweAEAD derives the content encryption key from the shared secret with
+// Concat KDF (RFC 7518, section 4.6.2) and returns AES-GCM cipher. PartyUInfo
+// and PartyVInfo are empty.
+func jweAEAD(sharedSecret []byte) (cipher.AEAD, error) {
+	lenPrefixed := func(b []byte) []byte {
+		out := make([]byte, 4, 4+len(b))
+		binary.BigEndian.PutUint32(out, uint32(len(b)))
+		return append(out, b...)
+	}
+
+	h := sha256.New()
+	_ = binary.Write(h, binary.BigEndian, uint32(1)) // round number
+	h.Write(sharedSecret)
+	h.Write(lenPrefixed([]byte(JWEEncryptionA256GCM))) // AlgorithmID
+	h.Write(lenPrefixed(nil))                          // PartyUInfo
+	h.Write(lenPrefixed(nil))                          // PartyVInfo
+	_ = binary.Write(h, binary.BigEndian, uint32(jweKeySize*8))
+	key := h.Sum(nil)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
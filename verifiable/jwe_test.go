@@ -0,0 +1,99 @@
+package verifiable
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestW3CCredential_EncryptJWE(t *testing.T) {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	var privKey [32]byte
+	_, err = rand.Read(privKey[:])
+	require.NoError(t, err)
+	pubKey, err := curve25519.X25519(privKey[:], curve25519.Basepoint)
+	require.NoError(t, err)
+
+	didDoc := DIDDocument{
+		ID: "did:example:123",
+		VerificationMethod: []CommonVerificationMethod{{
+			ID:   "did:example:123#key-x25519",
+			Type: "JsonWebKey2020",
+			PublicKeyJwk: map[string]interface{}{
+				"kty": "OKP",
+				"crv": "X25519",
+				"x":   base64.RawURLEncoding.EncodeToString(pubKey),
+			},
+		}},
+		KeyAgreement: []interface{}{"did:example:123#key-x25519"},
+	}
+	recipient, err := X25519KeyFromDIDDocument(didDoc, "")
+	require.NoError(t, err)
+
+	jwe, err := vc.EncryptJWE(recipient)
+	require.NoError(t, err)
+	require.Len(t, strings.Split(jwe, "."), 5)
+
+	vc2, err := DecryptCredentialJWE(jwe, privKey)
+	require.NoError(t, err)
+	require.Equal(t, vc, *vc2)
+
+	_, header, err := DecryptJWE(jwe, privKey)
+	require.NoError(t, err)
+	require.Equal(t, JWEAlgorithmECDHES, header.Algorithm)
+	require.Equal(t, JWEEncryptionA256GCM, header.Encryption)
+	require.Equal(t, "did:example:123#key-x25519", header.KeyID)
+	require.Equal(t, MediaTypeVerifiableCredential, header.ContentType)
+
+	t.Run("wrong key", func(t *testing.T) {
+		var wrongKey [32]byte
+		_, err := rand.Read(wrongKey[:])
+		require.NoError(t, err)
+		_, err = DecryptCredentialJWE(jwe, wrongKey)
+		require.ErrorContains(t, err, "can't decrypt JWE")
+	})
+
+	t.Run("tampered header", func(t *testing.T) {
+		parts := strings.Split(jwe, ".")
+		headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+		require.NoError(t, err)
+		var header map[string]any
+		require.NoError(t, json.Unmarshal(headerBytes, &header))
+		header["kid"] = "did:example:123#other"
+		headerBytes, err = json.Marshal(header)
+		require.NoError(t, err)
+		parts[0] = base64.RawURLEncoding.EncodeToString(headerBytes)
+
+		_, err = DecryptCredentialJWE(strings.Join(parts, "."), privKey)
+		require.ErrorContains(t, err, "can't decrypt JWE")
+	})
+
+	t.Run("presentation", func(t *testing.T) {
+		payload := []byte(`{"type":["VerifiablePresentation"]}`)
+		jwe, err := EncryptJWE(payload, MediaTypeVerifiablePresentation,
+			recipient)
+		require.NoError(t, err)
+
+		payload2, header, err := DecryptJWE(jwe, privKey)
+		require.NoError(t, err)
+		require.Equal(t, payload, payload2)
+		require.Equal(t, MediaTypeVerifiablePresentation, header.ContentType)
+
+		_, err = DecryptCredentialJWE(jwe, privKey)
+		require.EqualError(t, err,
+			"unexpected JWE content type: application/vp+ld+json")
+	})
+
+	t.Run("invalid serialization", func(t *testing.T) {
+		_, _, err := DecryptJWE("a.b.c", privKey)
+		require.EqualError(t, err, "invalid JWE compact serialization")
+	})
+}
@@ -0,0 +1,64 @@
+package verifiable
+
+import (
+	"context"
+	"time"
+)
+
+// Verification steps reported to Logger
+const (
+	VerificationStepValidityPeriod    = "validity_period"
+	VerificationStepMerklization      = "merklization"
+	VerificationStepCoreClaim         = "core_claim"
+	VerificationStepDIDResolution     = "did_resolution"
+	VerificationStepSignature         = "signature"
+	VerificationStepMTProof           = "mt_proof"
+	VerificationStepCredentialStatus  = "credential_status"
+	VerificationStepIssuerStateUpdate = "issuer_state_update"
+	VerificationStepCredentialSchema  = "credential_schema"
+)
+
+// VerificationEvent is a structured event emitted after each verification
+// step.
+type VerificationEvent struct {
+	// Step is one of VerificationStep* constants
+	Step string
+	// Duration is the time spent on the step
+	Duration time.Duration
+	// Err is an error returned by the step, nil if the step succeeded
+	Err error
+	// Attrs contains step specific attributes, like issuer DID or
+	// credential status type
+	Attrs map[string]any
+}
+
+// Logger receives verification events. Implementations should be safe for
+// concurrent use and should not block for a long time.
+type Logger interface {
+	LogVerificationEvent(ctx context.Context, event VerificationEvent)
+}
+
+// LoggerFunc is an adapter to use ordinary functions as Logger
+type LoggerFunc func(ctx context.Context, event VerificationEvent)
+
+// LogVerificationEvent calls f(ctx, event)
+func (f LoggerFunc) LogVerificationEvent(ctx context.Context,
+	event VerificationEvent) {
+
+	f(ctx, event)
+}
+
+// logVerificationStep reports the step to the logger if it is not nil
+func logVerificationStep(ctx context.Context, logger Logger, step string,
+	start time.Time, err error, attrs map[string]any) {
+
+	if logger == nil {
+		return
+	}
+	logger.LogVerificationEvent(ctx, VerificationEvent{
+		Step:     step,
+		Duration: time.Since(start),
+		Err:      err,
+		Attrs:    attrs,
+	})
+}
@@ -0,0 +1,71 @@
+package verifiable
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+type w3CCredentialParseConfig struct {
+	strictTypes bool
+}
+
+// W3CCredentialParseOpt is an option for ParseW3CCredential
+type W3CCredentialParseOpt func(cfg *w3CCredentialParseConfig)
+
+// WithStrictTypes makes ParseW3CCredential return an error if the type of
+// credentialSchema, refreshService or displayMethod is not one of the known
+// types. Without this option any type is accepted, as with json.Unmarshal.
+func WithStrictTypes() W3CCredentialParseOpt {
+	return func(cfg *w3CCredentialParseConfig) {
+		cfg.strictTypes = true
+	}
+}
+
+// ParseW3CCredential unmarshals the credential from JSON. With no options it
+// is equivalent to json.Unmarshal.
+func ParseW3CCredential(data []byte,
+	opts ...W3CCredentialParseOpt) (*W3CCredential, error) {
+
+	cfg := w3CCredentialParseConfig{}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	var vc W3CCredential
+	err := json.Unmarshal(data, &vc)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.strictTypes {
+		err = vc.validateTypes()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &vc, nil
+}
+
+func (vc *W3CCredential) validateTypes() error {
+	if vc.CredentialSchema != (CredentialSchema{}) {
+		err := vc.CredentialSchema.Type.Validate()
+		if err != nil {
+			return errors.WithMessage(err, "credentialSchema")
+		}
+	}
+	if vc.RefreshService != nil {
+		err := vc.RefreshService.Type.Validate()
+		if err != nil {
+			return errors.WithMessage(err, "refreshService")
+		}
+	}
+	if vc.DisplayMethod != nil {
+		err := vc.DisplayMethod.Type.Validate()
+		if err != nil {
+			return errors.WithMessage(err, "displayMethod")
+		}
+	}
+	return nil
+}
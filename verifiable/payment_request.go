@@ -0,0 +1,212 @@
+package verifiable
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedPaymentRequestType is returned when payment request type is
+// not one of the known types
+var ErrUnsupportedPaymentRequestType = errors.New(
+	"unsupported payment request type")
+
+// PaymentRequestType represent payment request types
+type PaymentRequestType string
+
+// Validate returns ErrUnsupportedPaymentRequestType if payment request type
+// is not known
+func (t PaymentRequestType) Validate() error {
+	switch t {
+	case Iden3PaymentRequestCryptoV1, Iden3PaymentRailsRequestV1,
+		Iden3PaymentRailsERC20RequestV1:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnsupportedPaymentRequestType, "%q",
+			string(t))
+	}
+}
+
+// PaymentType represent payment types
+type PaymentType string
+
+// PaymentRequestBody is the body of iden3comm payment request message: the
+// issuer asks the holder to pay for the credentials
+type PaymentRequestBody struct {
+	Agent    string               `json:"agent"`
+	Payments []PaymentRequestInfo `json:"payments"`
+}
+
+// PaymentRequestInfo is the payment request for the credentials
+type PaymentRequestInfo struct {
+	Type        string                 `json:"type,omitempty"`
+	Credentials []CredentialInfo       `json:"credentials"`
+	Description string                 `json:"description"`
+	Data        PaymentRequestInfoData `json:"data"`
+}
+
+// PaymentRequestInfoData is the data of the payment request: either a
+// single Iden3PaymentRequestCryptoV1 request (a JSON object) or the list of
+// payment rails requests, one of them is to be paid (a JSON array)
+type PaymentRequestInfoData struct {
+	Crypto *PaymentRequestCrypto
+	Rails  []PaymentRailsRequest
+}
+
+// MarshalJSON encodes Crypto as an object or Rails as an array
+func (d PaymentRequestInfoData) MarshalJSON() ([]byte, error) {
+	if d.Crypto != nil {
+		if len(d.Rails) != 0 {
+			return nil, errors.New(
+				"payment request data has both crypto and rails requests")
+		}
+		return json.Marshal(d.Crypto)
+	}
+	return json.Marshal(d.Rails)
+}
+
+// UnmarshalJSON decodes the object into Crypto and the array into Rails,
+// checking types of requests
+func (d *PaymentRequestInfoData) UnmarshalJSON(in []byte) error {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 {
+		return errors.New("payment request data is empty")
+	}
+
+	switch in[0] {
+	case '{':
+		var crypto PaymentRequestCrypto
+		err := json.Unmarshal(in, &crypto)
+		if err != nil {
+			return err
+		}
+		if crypto.Type != Iden3PaymentRequestCryptoV1 {
+			return errors.Wrapf(ErrUnsupportedPaymentRequestType,
+				"%q is not a crypto payment request", string(crypto.Type))
+		}
+		*d = PaymentRequestInfoData{Crypto: &crypto}
+	case '[':
+		var rails []PaymentRailsRequest
+		err := json.Unmarshal(in, &rails)
+		if err != nil {
+			return err
+		}
+		for _, r := range rails {
+			if r.Type != Iden3PaymentRailsRequestV1 &&
+				r.Type != Iden3PaymentRailsERC20RequestV1 {
+
+				return errors.Wrapf(ErrUnsupportedPaymentRequestType,
+					"%q is not a payment rails request", string(r.Type))
+			}
+		}
+		*d = PaymentRequestInfoData{Rails: rails}
+	default:
+		return errors.New("payment request data is neither object nor array")
+	}
+	return nil
+}
+
+// PaymentRequestCrypto is Iden3PaymentRequestCryptoV1 payment request:
+// transfer of the amount of the currency to the address
+type PaymentRequestCrypto struct {
+	Type       PaymentRequestType `json:"type"`
+	ID         string             `json:"id"`
+	Context    string             `json:"@context,omitempty"`
+	ChainID    string             `json:"chainId"`
+	Address    string             `json:"address"`
+	Amount     string             `json:"amount"`
+	Currency   string             `json:"currency"`
+	Expiration string             `json:"expiration,omitempty"`
+}
+
+// PaymentRailsRequest is Iden3PaymentRailsRequestV1 or
+// Iden3PaymentRailsERC20RequestV1 payment request signed by the recipient.
+// TokenAddress and Features are set for ERC20 requests only.
+type PaymentRailsRequest struct {
+	Context        []string           `json:"@context"`
+	Type           PaymentRequestType `json:"type"`
+	Recipient      string             `json:"recipient"`
+	Amount         string             `json:"amount"`
+	ExpirationDate *time.Time         `json:"expirationDate"`
+	Nonce          string             `json:"nonce"`
+	Metadata       string             `json:"metadata"`
+	Proof          []EIP712Proof      `json:"proof"`
+	TokenAddress   string             `json:"tokenAddress,omitempty"`
+	Features       []string           `json:"features,omitempty"`
+}
+
+// EIP712Proof is EthereumEip712Signature2021 proof of the payment rails
+// request
+type EIP712Proof struct {
+	Type               string     `json:"type"`
+	ProofPurpose       string     `json:"proofPurpose"`
+	ProofValue         string     `json:"proofValue"`
+	VerificationMethod string     `json:"verificationMethod"`
+	Created            string     `json:"created"`
+	EIP712             EIP712Data `json:"eip712"`
+}
+
+// EIP712Data is the typed data description of EIP712Proof
+type EIP712Data struct {
+	Types       string       `json:"types"`
+	PrimaryType string       `json:"primaryType"`
+	Domain      EIP712Domain `json:"domain"`
+}
+
+// EIP712Domain is the domain of EIP712 typed data
+type EIP712Domain struct {
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	ChainID           string `json:"chainId"`
+	VerifyingContract string `json:"verifyingContract"`
+	Salt              string `json:"salt,omitempty"`
+}
+
+// Validate checks that the body has the agent URL and payments for
+// credentials
+func (b PaymentRequestBody) Validate() error {
+	if err := validateServiceURL(b.Agent); err != nil {
+		return errors.Wrap(err, "invalid payment request agent")
+	}
+	if len(b.Payments) == 0 {
+		return errors.New("payment request has no payments")
+	}
+	for i, p := range b.Payments {
+		if len(p.Credentials) == 0 {
+			return errors.Errorf("payment request %d has no credentials", i)
+		}
+		for _, c := range p.Credentials {
+			if err := c.validate(); err != nil {
+				return errors.Wrapf(err, "payment request %d", i)
+			}
+		}
+		if p.Data.Crypto == nil && len(p.Data.Rails) == 0 {
+			return errors.Errorf("payment request %d has no data", i)
+		}
+	}
+	return nil
+}
+
+// PaymentBody is the body of iden3comm payment message: the holder reports
+// payments of the requests
+type PaymentBody struct {
+	Payments []Payment `json:"payments"`
+}
+
+// Payment is the payment of the request with ID (Iden3PaymentCryptoV1) or
+// nonce (payment rails)
+type Payment struct {
+	ID          string      `json:"id,omitempty"`
+	Nonce       string      `json:"nonce,omitempty"`
+	Type        PaymentType `json:"type"`
+	Context     string      `json:"@context,omitempty"`
+	PaymentData PaymentData `json:"paymentData"`
+}
+
+// PaymentData is the transaction of the payment
+type PaymentData struct {
+	TxID    string `json:"txId"`
+	ChainID string `json:"chainId,omitempty"`
+}
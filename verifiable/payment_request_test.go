@@ -0,0 +1,172 @@
+package verifiable
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const paymentRequestCryptoBody = `{
+  "agent": "https://issuer.example.com/api/v1/agent",
+  "payments": [
+    {
+      "credentials": [
+        {
+          "type": "AML",
+          "context": "http://test.com"
+        }
+      ],
+      "description": "payment for AML credential",
+      "data": {
+        "type": "Iden3PaymentRequestCryptoV1",
+        "id": "123",
+        "chainId": "80002",
+        "address": "0x2C2007d72f533FfD409F0D9f515983e95bF14992",
+        "amount": "10",
+        "currency": "ETH",
+        "expiration": "2025-04-17T12:00:00Z"
+      }
+    }
+  ]
+}`
+
+const paymentRequestRailsBody = `{
+  "agent": "https://issuer.example.com/api/v1/agent",
+  "payments": [
+    {
+      "credentials": [
+        {
+          "type": "AML",
+          "context": "http://test.com"
+        }
+      ],
+      "description": "payment for AML credential",
+      "data": [
+        {
+          "@context": [
+            "https://schema.iden3.io/core/jsonld/payment.jsonld#Iden3PaymentRailsRequestV1",
+            "https://w3id.org/security/suites/eip712sig-2021/v1"
+          ],
+          "type": "Iden3PaymentRailsRequestV1",
+          "recipient": "0xaddress",
+          "amount": "100",
+          "expirationDate": "2025-04-17T12:00:00Z",
+          "nonce": "25",
+          "metadata": "0x",
+          "proof": [
+            {
+              "type": "EthereumEip712Signature2021",
+              "proofPurpose": "assertionMethod",
+              "proofValue": "0xa05292e9",
+              "verificationMethod": "did:pkh:eip155:80002:0xE9D7fCDf32dF4772A7EF7C24c76aB40E4A42274a#blockchainAccountId",
+              "created": "2024-09-26T12:28:19.702580067Z",
+              "eip712": {
+                "types": "https://schema.iden3.io/core/json/Iden3PaymentRailsRequestV1.json",
+                "primaryType": "Iden3PaymentRailsRequestV1",
+                "domain": {
+                  "name": "MCPayment",
+                  "version": "1.0.0",
+                  "chainId": "80002",
+                  "verifyingContract": "0x380dd90852d3Fe75B4f08D0c47416D6c4E0dC774"
+                }
+              }
+            }
+          ]
+        },
+        {
+          "@context": [
+            "https://schema.iden3.io/core/jsonld/payment.jsonld#Iden3PaymentRailsERC20RequestV1",
+            "https://w3id.org/security/suites/eip712sig-2021/v1"
+          ],
+          "type": "Iden3PaymentRailsERC20RequestV1",
+          "recipient": "0xaddress",
+          "amount": "100",
+          "expirationDate": "2025-04-17T12:00:00Z",
+          "nonce": "25",
+          "metadata": "0x",
+          "proof": [],
+          "tokenAddress": "0x2FE40749812FAC39a0F380649eF59E01bccf3a1A",
+          "features": ["EIP-2612"]
+        }
+      ]
+    }
+  ]
+}`
+
+func TestPaymentRequestBody_JSON(t *testing.T) {
+	t.Run("crypto", func(t *testing.T) {
+		var body PaymentRequestBody
+		err := json.Unmarshal([]byte(paymentRequestCryptoBody), &body)
+		require.NoError(t, err)
+		require.NoError(t, body.Validate())
+
+		require.Len(t, body.Payments, 1)
+		data := body.Payments[0].Data
+		require.Empty(t, data.Rails)
+		require.NotNil(t, data.Crypto)
+		require.Equal(t, Iden3PaymentRequestCryptoV1, data.Crypto.Type)
+		require.Equal(t, "10", data.Crypto.Amount)
+
+		bodyBytes, err := json.Marshal(body)
+		require.NoError(t, err)
+		require.JSONEq(t, paymentRequestCryptoBody, string(bodyBytes))
+	})
+
+	t.Run("rails", func(t *testing.T) {
+		var body PaymentRequestBody
+		err := json.Unmarshal([]byte(paymentRequestRailsBody), &body)
+		require.NoError(t, err)
+		require.NoError(t, body.Validate())
+
+		data := body.Payments[0].Data
+		require.Nil(t, data.Crypto)
+		require.Len(t, data.Rails, 2)
+		require.Equal(t, Iden3PaymentRailsRequestV1, data.Rails[0].Type)
+		require.Equal(t, "MCPayment", data.Rails[0].Proof[0].EIP712.Domain.Name)
+		require.Equal(t, Iden3PaymentRailsERC20RequestV1, data.Rails[1].Type)
+		require.Equal(t, []string{"EIP-2612"}, data.Rails[1].Features)
+
+		bodyBytes, err := json.Marshal(body)
+		require.NoError(t, err)
+		require.JSONEq(t, paymentRequestRailsBody, string(bodyBytes))
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		var data PaymentRequestInfoData
+		err := json.Unmarshal(
+			[]byte(`{"type":"Iden3PaymentRailsRequestV1"}`), &data)
+		require.ErrorIs(t, err, ErrUnsupportedPaymentRequestType)
+		err = json.Unmarshal(
+			[]byte(`[{"type":"Iden3PaymentRequestCryptoV1"}]`), &data)
+		require.ErrorIs(t, err, ErrUnsupportedPaymentRequestType)
+		err = json.Unmarshal([]byte(`"data"`), &data)
+		require.EqualError(t, err,
+			"payment request data is neither object nor array")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var body PaymentRequestBody
+		err := json.Unmarshal([]byte(paymentRequestCryptoBody), &body)
+		require.NoError(t, err)
+		body.Payments[0].Credentials[0].Context = ""
+		require.EqualError(t, body.Validate(),
+			"payment request 0: context of credential AML is empty")
+	})
+}
+
+func TestPaymentBody_JSON(t *testing.T) {
+	in := `{"payments":[{"nonce":"25","type":"Iden3PaymentRailsV1","paymentData":{"txId":"0x123","chainId":"80002"}}]}`
+	var body PaymentBody
+	err := json.Unmarshal([]byte(in), &body)
+	require.NoError(t, err)
+	require.Equal(t, PaymentBody{Payments: []Payment{{
+		Nonce:       "25",
+		Type:        Iden3PaymentRailsV1,
+		PaymentData: PaymentData{TxID: "0x123", ChainID: "80002"},
+	}}}, body)
+
+	out, err := json.Marshal(body)
+	require.NoError(t, err)
+	require.JSONEq(t, in, string(out))
+}
@@ -0,0 +1,221 @@
+package verifiable
+
+import (
+	"context"
+	"time"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/pkg/errors"
+)
+
+// ErrPolicyViolation is returned by VerifyWithPolicy when the credential is
+// not accepted by the verification policy
+var ErrPolicyViolation = errors.New("credential violates verification policy")
+
+// Policy is the set of rules a verifier applies to credentials on top of
+// proof verification. Empty lists do not restrict the corresponding
+// property.
+type Policy struct {
+	// TrustedIssuers are DIDs of issuers whose credentials are accepted
+	TrustedIssuers []*w3c.DID
+	// TrustRegistry must trust the issuer for any of the credential types
+	// other than VerifiableCredential. It is consulted in addition to
+	// TrustedIssuers.
+	TrustRegistry TrustRegistry
+	// CredentialTypes are accepted credential types. The credential is
+	// accepted if any of its types is in the list.
+	CredentialTypes []string
+	// CredentialSchemas are accepted credentialSchema IDs
+	CredentialSchemas []string
+	// ProofTypes are accepted proof types in the order of preference. The
+	// first proof of the credential of the most preferred type is verified.
+	// If empty, the first proof of the credential is verified.
+	ProofTypes []ProofType
+	// StatusTypes are accepted credential status types
+	StatusTypes []CredentialStatusType
+	// RequireStatus rejects credentials without credential status
+	RequireStatus bool
+	// MaxAge rejects credentials issued earlier than MaxAge ago and
+	// credentials without issuanceDate. Zero means no limit.
+	MaxAge time.Duration
+	// ClockSkew is the leeway of expirationDate and issuanceDate checks,
+	// which are always enabled
+	ClockSkew time.Duration
+
+	// ProofVerificationOpts are options used to verify the proof
+	ProofVerificationOpts []W3CProofVerificationOpt
+	// StatusValidationOpts are options used to validate the credential
+	// status
+	StatusValidationOpts []CredentialStatusValidationOption
+}
+
+// VerifyWithPolicy checks the credential against the policy, then verifies
+// its proof, validity period and credential status. Policy violations are
+// returned wrapping ErrPolicyViolation.
+func (vc *W3CCredential) VerifyWithPolicy(ctx context.Context,
+	policy Policy, didResolver DIDResolver) error {
+
+	proofType, err := vc.checkPolicy(ctx, policy, time.Now())
+	if err != nil {
+		return err
+	}
+
+	proofOpts := append([]W3CProofVerificationOpt{
+		WithExpirationCheck(policy.ClockSkew),
+		WithIssuanceNotInFuture(policy.ClockSkew),
+	}, policy.ProofVerificationOpts...)
+	return vc.verifyProofAndStatus(ctx, proofType, didResolver, proofOpts,
+		policy.StatusValidationOpts)
+}
+
+// checkPolicy checks the credential against the policy and returns the type
+// of the proof to verify
+func (vc *W3CCredential) checkPolicy(ctx context.Context, policy Policy,
+	now time.Time) (ProofType, error) {
+
+	if len(policy.TrustedIssuers) != 0 && !policyTrustsIssuer(policy,
+		vc.Issuer) {
+
+		return "", errors.Wrapf(ErrPolicyViolation, "issuer %v is not trusted",
+			vc.Issuer)
+	}
+
+	if policy.TrustRegistry != nil {
+		err := vc.checkTrustRegistry(ctx, policy.TrustRegistry)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(policy.CredentialTypes) != 0 &&
+		!containsAny(policy.CredentialTypes, vc.Type) {
+
+		return "", errors.Wrapf(ErrPolicyViolation,
+			"credential type %v is not allowed", vc.Type)
+	}
+
+	if len(policy.CredentialSchemas) != 0 &&
+		!containsAny(policy.CredentialSchemas,
+			[]string{vc.CredentialSchema.ID}) {
+
+		return "", errors.Wrapf(ErrPolicyViolation,
+			"credential schema %v is not allowed", vc.CredentialSchema.ID)
+	}
+
+	if policy.MaxAge > 0 {
+		if vc.IssuanceDate == nil {
+			return "", errors.Wrap(ErrPolicyViolation,
+				"credential has no issuance date")
+		}
+		if now.Sub(*vc.IssuanceDate) > policy.MaxAge+policy.ClockSkew {
+			return "", errors.Wrapf(ErrPolicyViolation,
+				"credential issued at %v is older than %v",
+				vc.IssuanceDate.Format(time.RFC3339), policy.MaxAge)
+		}
+	}
+
+	err := vc.checkStatusPolicy(policy)
+	if err != nil {
+		return "", err
+	}
+
+	return vc.policyProofType(policy)
+}
+
+// checkTrustRegistry checks that the registry trusts the issuer for any of
+// the credential types
+func (vc *W3CCredential) checkTrustRegistry(ctx context.Context,
+	registry TrustRegistry) error {
+
+	issuerDID, err := w3c.ParseDID(vc.Issuer)
+	if err != nil {
+		return errors.Wrapf(ErrPolicyViolation, "invalid issuer DID %v: %v",
+			vc.Issuer, err)
+	}
+
+	var types []string
+	for _, t := range vc.Type {
+		if t != TypeW3CVerifiableCredential {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		types = vc.Type
+	}
+
+	for _, t := range types {
+		trusted, err := registry.IsTrustedIssuer(ctx, issuerDID, t)
+		if err != nil {
+			return errors.WithMessage(err, "trust registry")
+		}
+		if trusted {
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrPolicyViolation,
+		"issuer %v is not trusted for credential type %v", vc.Issuer, types)
+}
+
+func (vc *W3CCredential) checkStatusPolicy(policy Policy) error {
+	if vc.CredentialStatus == nil {
+		if policy.RequireStatus {
+			return errors.Wrap(ErrPolicyViolation,
+				"credential has no credential status")
+		}
+		return nil
+	}
+
+	if len(policy.StatusTypes) == 0 {
+		return nil
+	}
+	credStatus, err := coerceCredentialStatus(vc.CredentialStatus)
+	if err != nil {
+		return err
+	}
+	for _, statusType := range policy.StatusTypes {
+		if credStatus.Type == statusType {
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrPolicyViolation,
+		"credential status type %v is not allowed", credStatus.Type)
+}
+
+// policyProofType selects the most preferred proof type of the policy the
+// credential has a proof of
+func (vc *W3CCredential) policyProofType(policy Policy) (ProofType, error) {
+	if len(vc.Proof) == 0 {
+		return "", ErrProofNotFound
+	}
+	if len(policy.ProofTypes) == 0 {
+		return vc.Proof[0].ProofType(), nil
+	}
+	for _, proofType := range policy.ProofTypes {
+		if _, ok := vc.Proof.ByType(proofType); ok {
+			return proofType, nil
+		}
+	}
+	return "", errors.Wrapf(ErrPolicyViolation,
+		"credential has no proof of accepted types %v", policy.ProofTypes)
+}
+
+func policyTrustsIssuer(policy Policy, issuer string) bool {
+	for _, did := range policy.TrustedIssuers {
+		if did != nil && did.String() == issuer {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny checks if any of values is in the list
+func containsAny(list, values []string) bool {
+	for _, v := range values {
+		for _, item := range list {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestW3CCredential_VerifyWithPolicy(t *testing.T) {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	issuerDID, err := w3c.ParseDID(vc.Issuer)
+	require.NoError(t, err)
+	otherDID, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qH2mPVRN7ZDCnEofjeh8Qd2Uo3YsEhTVhKhjB8xs4")
+	require.NoError(t, err)
+
+	resolverURL := "http://my-universal-resolver/1.0/identifiers"
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+			"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			"http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e": `./testdata/verifycred//my-universal-resolver-1.json`,
+		}, tst.IgnoreUntouchedURLs())()
+	resolverRegistry := &CredentialStatusResolverRegistry{}
+	resolverRegistry.Register(Iden3ReverseSparseMerkleTreeProof,
+		test1Resolver{})
+	didResolver := HTTPDIDResolver{resolverURL: resolverURL}
+	ctx := context.Background()
+
+	policy := Policy{
+		TrustedIssuers:    []*w3c.DID{issuerDID},
+		CredentialTypes:   []string{"KYCAgeCredential"},
+		CredentialSchemas: []string{vc.CredentialSchema.ID},
+		ProofTypes: []ProofType{Iden3SparseMerkleTreeProofType,
+			BJJSignatureProofType},
+		StatusTypes:   []CredentialStatusType{Iden3ReverseSparseMerkleTreeProof},
+		RequireStatus: true,
+		ProofVerificationOpts: []W3CProofVerificationOpt{
+			WithStatusResolverRegistry(resolverRegistry)},
+		StatusValidationOpts: []CredentialStatusValidationOption{
+			WithValidationStatusResolverRegistry(resolverRegistry)},
+	}
+	err = vc.VerifyWithPolicy(ctx, policy, didResolver)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name    string
+		modify  func(p *Policy)
+		wantErr string
+	}{
+		{
+			name:    "untrusted issuer",
+			modify:  func(p *Policy) { p.TrustedIssuers = []*w3c.DID{otherDID} },
+			wantErr: "issuer " + vc.Issuer + " is not trusted",
+		},
+		{
+			name:    "credential type",
+			modify:  func(p *Policy) { p.CredentialTypes = []string{"Other"} },
+			wantErr: "credential type [VerifiableCredential KYCAgeCredential] is not allowed",
+		},
+		{
+			name:    "credential schema",
+			modify:  func(p *Policy) { p.CredentialSchemas = []string{"other"} },
+			wantErr: "credential schema " + vc.CredentialSchema.ID + " is not allowed",
+		},
+		{
+			name: "proof type",
+			modify: func(p *Policy) {
+				p.ProofTypes = []ProofType{Iden3SparseMerkleTreeProofType}
+			},
+			wantErr: "credential has no proof of accepted types [Iden3SparseMerkleTreeProof]",
+		},
+		{
+			name: "status type",
+			modify: func(p *Policy) {
+				p.StatusTypes = []CredentialStatusType{StatusList2021Entry}
+			},
+			wantErr: "credential status type Iden3ReverseSparseMerkleTreeProof is not allowed",
+		},
+		{
+			name:    "max age",
+			modify:  func(p *Policy) { p.MaxAge = 24 * time.Hour },
+			wantErr: "credential issued at 2023-12-21T16:35:46+02:00 is older than 24h0m0s",
+		},
+		{
+			name: "trust registry",
+			modify: func(p *Policy) {
+				p.TrustRegistry = NewStaticTrustRegistry(TrustedIssuer{
+					DID: issuerDID, CredentialTypes: []string{"Other"}})
+			},
+			wantErr: "issuer " + vc.Issuer +
+				" is not trusted for credential type [KYCAgeCredential]",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := policy
+			tc.modify(&p)
+			err := vc.VerifyWithPolicy(ctx, p, didResolver)
+			require.ErrorIs(t, err, ErrPolicyViolation)
+			require.EqualError(t, err,
+				tc.wantErr+": "+ErrPolicyViolation.Error())
+		})
+	}
+
+	t.Run("trusted by registry", func(t *testing.T) {
+		p := policy
+		p.TrustRegistry = NewStaticTrustRegistry(TrustedIssuer{
+			DID: issuerDID, CredentialTypes: []string{"KYCAgeCredential"}})
+		err := vc.VerifyWithPolicy(ctx, p, didResolver)
+		require.NoError(t, err)
+	})
+
+	t.Run("trust registry error", func(t *testing.T) {
+		p := policy
+		p.TrustRegistry = TrustRegistryFunc(func(context.Context, *w3c.DID,
+			string) (bool, error) {
+
+			return false, errors.New("registry is unavailable")
+		})
+		err := vc.VerifyWithPolicy(ctx, p, didResolver)
+		require.EqualError(t, err,
+			"trust registry: registry is unavailable")
+	})
+
+	t.Run("status required", func(t *testing.T) {
+		vc2 := vc
+		vc2.CredentialStatus = nil
+		err := vc2.VerifyWithPolicy(ctx, policy, didResolver)
+		require.ErrorIs(t, err, ErrPolicyViolation)
+		require.ErrorContains(t, err, "credential has no credential status")
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		vc2 := vc
+		expiration := time.Now().Add(-time.Hour)
+		vc2.Expiration = &expiration
+		err := vc2.VerifyWithPolicy(ctx, policy, didResolver)
+		require.ErrorIs(t, err, ErrCredentialExpired)
+	})
+}
@@ -0,0 +1,246 @@
+package verifiable
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/pkg/errors"
+)
+
+// TypeVerifiablePresentation is the type of W3C verifiable presentations
+const TypeVerifiablePresentation = "VerifiablePresentation"
+
+// ErrPresentationProofFailed is returned by VerifyProof when the
+// presentation proof is not valid
+var ErrPresentationProofFailed = errors.New(
+	"presentation proof verification failed")
+
+// W3CPresentation is the W3C verifiable presentation of credentials by the
+// holder
+type W3CPresentation struct {
+	Context              []string           `json:"@context"`
+	ID                   string             `json:"id,omitempty"`
+	Type                 []string           `json:"type"`
+	Holder               string             `json:"holder"`
+	VerifiableCredential []W3CCredential    `json:"verifiableCredential,omitempty"`
+	Proof                *PresentationProof `json:"proof,omitempty"`
+}
+
+// NewW3CPresentation creates the presentation of the credentials by the
+// holder
+func NewW3CPresentation(holder string,
+	credentials ...W3CCredential) *W3CPresentation {
+
+	return &W3CPresentation{
+		Context:              []string{JSONLDSchemaW3CCredential2018},
+		Type:                 []string{TypeVerifiablePresentation},
+		Holder:               holder,
+		VerifiableCredential: credentials,
+	}
+}
+
+// PresentationProof is the holder's signature of the presentation bound to
+// the challenge and the domain of the relying party.
+//
+// For BJJSignature2021 proofs HolderData is the auth BJJ claim of the holder
+// with the MTP of the claim in the claims tree of the holder's state. For
+// Ed25519Signature2020 proofs VerificationMethod is the DID URL of the key in
+// the authentication relationship of the holder's DID document.
+type PresentationProof struct {
+	Type               ProofType    `json:"type"`
+	Created            time.Time    `json:"created"`
+	ProofPurpose       ProofPurpose `json:"proofPurpose"`
+	Challenge          string       `json:"challenge"`
+	Domain             string       `json:"domain,omitempty"`
+	HolderData         *IssuerData  `json:"holderData,omitempty"`
+	VerificationMethod string       `json:"verificationMethod,omitempty"`
+	// Signature is hex encoded compressed BJJ signature or Ed25519
+	// signature
+	Signature string `json:"signature"`
+}
+
+// presentationSigningInput is the message signed by the presentation proof.
+// Credentials are represented by the roots of their merklized documents
+// without proofs, so the signature commits to the canonical form of the
+// credentials data.
+type presentationSigningInput struct {
+	Holder          string    `json:"holder"`
+	Challenge       string    `json:"challenge"`
+	Domain          string    `json:"domain"`
+	Created         time.Time `json:"created"`
+	CredentialRoots []string  `json:"credentialRoots"`
+}
+
+func (vp *W3CPresentation) signingInput(ctx context.Context,
+	challenge, domain string, created time.Time,
+	opts []merklize.MerklizeOption) ([]byte, error) {
+
+	if challenge == "" {
+		return nil, errors.New("challenge is empty")
+	}
+	if vp.Holder == "" {
+		return nil, errors.New("presentation holder is empty")
+	}
+
+	in := presentationSigningInput{
+		Holder:          vp.Holder,
+		Challenge:       challenge,
+		Domain:          domain,
+		Created:         created.UTC(),
+		CredentialRoots: make([]string, 0, len(vp.VerifiableCredential)),
+	}
+	for i := range vp.VerifiableCredential {
+		mz, err := vp.VerifiableCredential[i].Merklize(ctx, opts...)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "credential %d", i)
+		}
+		in.CredentialRoots = append(in.CredentialRoots, mz.Root().Hex())
+	}
+	return json.Marshal(in)
+}
+
+func bjjPresentationMessage(signingInput []byte) (*big.Int, error) {
+	return poseidon.HashBytes(signingInput)
+}
+
+// SignBJJ adds the proof signed with the BJJ key of the auth claim in
+// holderData. The challenge and the domain are chosen by the relying party
+// to prevent replays. Options are used to merklize the credentials.
+func (vp *W3CPresentation) SignBJJ(ctx context.Context, challenge,
+	domain string, key babyjub.PrivateKey, holderData IssuerData,
+	opts ...merklize.MerklizeOption) error {
+
+	if holderData.ID != vp.Holder {
+		return errors.Errorf("holder data %v is not of the holder %v",
+			holderData.ID, vp.Holder)
+	}
+
+	created := time.Now().Truncate(time.Second)
+	in, err := vp.signingInput(ctx, challenge, domain, created, opts)
+	if err != nil {
+		return err
+	}
+	msg, err := bjjPresentationMessage(in)
+	if err != nil {
+		return err
+	}
+	sig := key.SignPoseidon(msg).Compress()
+	vp.Proof = &PresentationProof{
+		Type:         BJJSignatureProofType,
+		Created:      created,
+		ProofPurpose: ProofPurposeAuthentication,
+		Challenge:    challenge,
+		Domain:       domain,
+		HolderData:   &holderData,
+		Signature:    hex.EncodeToString(sig[:]),
+	}
+	return nil
+}
+
+// SignEd25519 adds the proof signed with the Ed25519 key of the verification
+// method of the holder's DID document. The challenge and the domain are
+// chosen by the relying party to prevent replays. Options are used to
+// merklize the credentials.
+func (vp *W3CPresentation) SignEd25519(ctx context.Context, challenge,
+	domain string, key ed25519.PrivateKey, verificationMethod string,
+	opts ...merklize.MerklizeOption) error {
+
+	created := time.Now().Truncate(time.Second)
+	in, err := vp.signingInput(ctx, challenge, domain, created, opts)
+	if err != nil {
+		return err
+	}
+	vp.Proof = &PresentationProof{
+		Type:               Ed25519SignatureProofType,
+		Created:            created,
+		ProofPurpose:       ProofPurposeAuthentication,
+		Challenge:          challenge,
+		Domain:             domain,
+		VerificationMethod: verificationMethod,
+		Signature:          hex.EncodeToString(ed25519.Sign(key, in)),
+	}
+	return nil
+}
+
+// VerifyProof checks that the presentation proof is signed by the holder for
+// the expected challenge and domain, and that the signing key belongs to the
+// holder's DID the same way as VerifyHolderBinding does. Proofs of the
+// presented credentials are not verified. WithMerklizeOptions options are
+// used to merklize the credentials, WithStatusResolverRegistry is used to
+// validate the revocation status of the holder's auth claim. Mismatches of
+// the challenge, the domain, the holder, the key and the signature are
+// returned wrapping ErrPresentationProofFailed.
+func (vp *W3CPresentation) VerifyProof(ctx context.Context, challenge,
+	domain string, didResolver DIDResolver,
+	opts ...W3CProofVerificationOpt) (HolderBindingResult, error) {
+
+	verifyConfig, err := newProofVerificationConfig(didResolver, opts)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+
+	proof := vp.Proof
+	if proof == nil {
+		return HolderBindingResult{}, ErrProofNotFound
+	}
+	if challenge == "" || proof.Challenge != challenge {
+		return HolderBindingResult{}, errors.Wrap(ErrPresentationProofFailed,
+			"challenge does not match")
+	}
+	if proof.Domain != domain {
+		return HolderBindingResult{}, errors.Wrapf(ErrPresentationProofFailed,
+			"domain %v does not match", proof.Domain)
+	}
+	if proof.ProofPurpose != ProofPurposeAuthentication {
+		return HolderBindingResult{}, errors.Wrapf(ErrPresentationProofFailed,
+			"unexpected proof purpose %v", proof.ProofPurpose)
+	}
+
+	in, err := vp.signingInput(ctx, proof.Challenge, proof.Domain,
+		proof.Created, verifyConfig.merklizeOptions)
+	if err != nil {
+		return HolderBindingResult{}, err
+	}
+
+	switch proof.Type {
+	case BJJSignatureProofType:
+		if proof.HolderData == nil {
+			return HolderBindingResult{}, errors.New("holder data is empty")
+		}
+		if proof.HolderData.ID != vp.Holder {
+			return HolderBindingResult{}, errors.Wrapf(
+				ErrPresentationProofFailed,
+				"holder data %v is not of the presentation holder",
+				proof.HolderData.ID)
+		}
+		var msg *big.Int
+		msg, err = bjjPresentationMessage(in)
+		if err != nil {
+			return HolderBindingResult{}, err
+		}
+		return verifyHolderSignatureBJJ(ctx, *proof.HolderData, msg,
+			proof.Signature, didResolver,
+			verifyConfig.credStatusValidationOpts, ErrPresentationProofFailed)
+	case Ed25519SignatureProofType:
+		vmDID, _, _ := strings.Cut(proof.VerificationMethod, "#")
+		if vmDID != "" && vmDID != vp.Holder {
+			return HolderBindingResult{}, errors.Wrapf(
+				ErrPresentationProofFailed,
+				"verification method %v is not of the presentation holder",
+				proof.VerificationMethod)
+		}
+		return verifyHolderSignatureEd25519(ctx, vp.Holder,
+			proof.VerificationMethod, in, proof.Signature, didResolver,
+			ErrPresentationProofFailed)
+	default:
+		return HolderBindingResult{}, ErrProofNotSupported
+	}
+}
@@ -0,0 +1,180 @@
+package verifiable
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func readPresentedCredential(t *testing.T) W3CCredential {
+	credentialBytes, err := os.ReadFile(
+		"../json/testdata/non-merklized-1.json-ld")
+	require.NoError(t, err)
+	var credential W3CCredential
+	require.NoError(t, json.Unmarshal(credentialBytes, &credential))
+	return credential
+}
+
+func TestW3CPresentation_BJJ(t *testing.T) {
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://www.w3.org/2018/credentials/v1":              "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			"https://example.com/schema-delivery-address.json-ld": "../json/testdata/schema-delivery-address.json-ld",
+		},
+		tst.IgnoreUntouchedURLs())()
+
+	ctx := context.Background()
+	key := babyjub.NewRandPrivKey()
+	holderData, treeState := newGenesisHolder(t, key)
+
+	published := false
+	didResolver := &fixedDIDResolver{doc: DIDDocument{
+		ID: holderData.ID,
+		VerificationMethod: []CommonVerificationMethod{{
+			ID:            holderData.ID + "#state-info",
+			Type:          "Iden3StateInfo2023",
+			IdentityState: IdentityState{Published: &published},
+		}},
+	}}
+	registry := &CredentialStatusResolverRegistry{}
+	registry.Register(SparseMerkleTreeProof,
+		holderBindingStatusResolver{state: treeState})
+	verifyOpts := []W3CProofVerificationOpt{
+		WithStatusResolverRegistry(registry)}
+
+	vp := NewW3CPresentation(holderData.ID, readPresentedCredential(t))
+	err := vp.SignBJJ(ctx, "challenge-1", "verifier.example.com", key,
+		holderData)
+	require.NoError(t, err)
+
+	// the proof survives serialization
+	vpBytes, err := json.Marshal(vp)
+	require.NoError(t, err)
+	var vp2 W3CPresentation
+	require.NoError(t, json.Unmarshal(vpBytes, &vp2))
+
+	res, err := vp2.VerifyProof(ctx, "challenge-1", "verifier.example.com",
+		didResolver, verifyOpts...)
+	require.NoError(t, err)
+	require.Equal(t, HolderBindingResult{SubjectID: holderData.ID,
+		ProofType: BJJSignatureProofType,
+		KeyID:     holderData.AuthCoreClaim}, res)
+
+	t.Run("other challenge", func(t *testing.T) {
+		_, err := vp2.VerifyProof(ctx, "challenge-2", "verifier.example.com",
+			didResolver, verifyOpts...)
+		require.ErrorIs(t, err, ErrPresentationProofFailed)
+	})
+
+	t.Run("other domain", func(t *testing.T) {
+		_, err := vp2.VerifyProof(ctx, "challenge-1", "other.example.com",
+			didResolver, verifyOpts...)
+		require.ErrorIs(t, err, ErrPresentationProofFailed)
+	})
+
+	t.Run("replayed proof", func(t *testing.T) {
+		vp3 := vp2
+		proof := *vp2.Proof
+		proof.Challenge = "challenge-2"
+		vp3.Proof = &proof
+		_, err := vp3.VerifyProof(ctx, "challenge-2", "verifier.example.com",
+			didResolver, verifyOpts...)
+		require.ErrorIs(t, err, ErrPresentationProofFailed)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("tampered credential", func(t *testing.T) {
+		var vp3 W3CPresentation
+		require.NoError(t, json.Unmarshal(vpBytes, &vp3))
+		vp3.VerifiableCredential[0].CredentialSubject["price"] = "123.53"
+		_, err := vp3.VerifyProof(ctx, "challenge-1", "verifier.example.com",
+			didResolver, verifyOpts...)
+		require.ErrorIs(t, err, ErrPresentationProofFailed)
+		require.ErrorContains(t, err, "invalid signature")
+	})
+
+	t.Run("other holder", func(t *testing.T) {
+		otherKey := babyjub.NewRandPrivKey()
+		otherHolder, _ := newGenesisHolder(t, otherKey)
+		vp3 := *NewW3CPresentation(holderData.ID)
+		err := vp3.SignBJJ(ctx, "challenge-1", "", otherKey, otherHolder)
+		require.Error(t, err)
+
+		vp3.Holder = otherHolder.ID
+		require.NoError(t, vp3.SignBJJ(ctx, "challenge-1", "", otherKey,
+			otherHolder))
+		vp3.Holder = holderData.ID
+		_, err = vp3.VerifyProof(ctx, "challenge-1", "", didResolver,
+			verifyOpts...)
+		require.ErrorIs(t, err, ErrPresentationProofFailed)
+	})
+
+	t.Run("no proof", func(t *testing.T) {
+		_, err := NewW3CPresentation(holderData.ID).VerifyProof(ctx,
+			"challenge-1", "", didResolver, verifyOpts...)
+		require.ErrorIs(t, err, ErrProofNotFound)
+	})
+}
+
+func TestW3CPresentation_Ed25519(t *testing.T) {
+	ctx := context.Background()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	holder := "did:example:holder"
+	keyID := holder + "#key-1"
+	didResolver := &fixedDIDResolver{doc: DIDDocument{
+		ID: holder,
+		VerificationMethod: []CommonVerificationMethod{{
+			ID:         keyID,
+			Type:       "JsonWebKey2020",
+			Controller: holder,
+			PublicKeyJwk: map[string]any{"kty": "OKP", "crv": "Ed25519",
+				"x": base64.RawURLEncoding.EncodeToString(pub)},
+		}},
+		Authentication: []Authentication{{did: keyID}},
+	}}
+
+	vp := NewW3CPresentation(holder)
+	err = vp.SignEd25519(ctx, "challenge-1", "verifier.example.com", priv,
+		keyID)
+	require.NoError(t, err)
+
+	res, err := vp.VerifyProof(ctx, "challenge-1", "verifier.example.com",
+		didResolver)
+	require.NoError(t, err)
+	require.Equal(t, HolderBindingResult{SubjectID: holder,
+		ProofType: Ed25519SignatureProofType, KeyID: keyID}, res)
+
+	t.Run("other domain", func(t *testing.T) {
+		_, err := vp.VerifyProof(ctx, "challenge-1", "", didResolver)
+		require.ErrorIs(t, err, ErrPresentationProofFailed)
+	})
+
+	t.Run("key of other DID", func(t *testing.T) {
+		vp2 := *vp
+		proof := *vp.Proof
+		proof.VerificationMethod = "did:example:other#key-1"
+		vp2.Proof = &proof
+		_, err := vp2.VerifyProof(ctx, "challenge-1", "verifier.example.com",
+			didResolver)
+		require.ErrorIs(t, err, ErrPresentationProofFailed)
+	})
+
+	t.Run("not authentication key", func(t *testing.T) {
+		vp2 := *vp
+		proof := *vp.Proof
+		proof.VerificationMethod = holder + "#key-2"
+		vp2.Proof = &proof
+		_, err := vp2.VerifyProof(ctx, "challenge-1", "verifier.example.com",
+			didResolver)
+		require.ErrorIs(t, err, ErrPresentationProofFailed)
+	})
+}
@@ -20,6 +20,9 @@ type IssuerData struct {
 	AuthCoreClaim    string      `json:"authCoreClaim,omitempty"`
 	MTP              *mt.Proof   `json:"mtp,omitempty"`
 	CredentialStatus interface{} `json:"credentialStatus,omitempty"`
+	// UpdateURL is the URL to fetch the issuer data with the latest state of
+	// the issuer, see WithIssuerStateUpdate
+	UpdateURL string `json:"updateUrl,omitempty"`
 }
 
 func (id *IssuerData) authClaim() (*core.Claim, error) {
@@ -260,8 +263,94 @@ type CredentialProof interface {
 	GetCoreClaim() (*core.Claim, error)
 }
 
+// CredentialProofs is the list of credential proofs. It is unmarshalled from
+// a single proof object or an array of proofs and is always marshaled as an
+// array keeping the order of proofs.
 type CredentialProofs []CredentialProof
 
+// MarshalJSON encodes proofs as a JSON array
+func (cps CredentialProofs) MarshalJSON() ([]byte, error) {
+	if cps == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal([]CredentialProof(cps))
+}
+
+// ByType returns the first proof of the type
+func (cps CredentialProofs) ByType(proofType ProofType) (CredentialProof,
+	bool) {
+
+	for _, p := range cps {
+		if p != nil && p.ProofType() == proofType {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Types returns types of all proofs in order
+func (cps CredentialProofs) Types() []ProofType {
+	types := make([]ProofType, 0, len(cps))
+	for _, p := range cps {
+		if p != nil {
+			types = append(types, p.ProofType())
+		}
+	}
+	return types
+}
+
+// BJJSignature returns the first BJJSignature2021 proof
+func (cps CredentialProofs) BJJSignature() (*BJJSignatureProof2021, bool) {
+	p, ok := cps.ByType(BJJSignatureProofType)
+	if !ok {
+		return nil, false
+	}
+	proof, err := asBJJSignatureProof(p)
+	return proof, err == nil
+}
+
+// SMT returns the first Iden3SparseMerkleTreeProof proof
+func (cps CredentialProofs) SMT() (*Iden3SparseMerkleTreeProof, bool) {
+	p, ok := cps.ByType(Iden3SparseMerkleTreeProofType)
+	if !ok {
+		return nil, false
+	}
+	proof, err := asIden3SparseMerkleTreeProof(p)
+	return proof, err == nil
+}
+
+// asBJJSignatureProof converts the proof of BJJSignature2021 type to
+// *BJJSignatureProof2021. Proofs of other Go types (e.g. CommonProof) are
+// converted by re-marshaling.
+func asBJJSignatureProof(p CredentialProof) (*BJJSignatureProof2021, error) {
+	if proof, ok := p.(*BJJSignatureProof2021); ok {
+		return proof, nil
+	}
+	var proof BJJSignatureProof2021
+	err := remarshalObj(&proof, p)
+	if err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// asIden3SparseMerkleTreeProof converts the proof of
+// Iden3SparseMerkleTreeProof type to *Iden3SparseMerkleTreeProof like
+// asBJJSignatureProof
+func asIden3SparseMerkleTreeProof(
+	p CredentialProof) (*Iden3SparseMerkleTreeProof, error) {
+
+	if proof, ok := p.(*Iden3SparseMerkleTreeProof); ok {
+		return proof, nil
+	}
+	var proof Iden3SparseMerkleTreeProof
+	err := remarshalObj(&proof, p)
+	if err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
 func reUnmarshalFromObj(obj jsonObj, v interface{}) error {
 	objBytes, err := json.Marshal(obj)
 	if err != nil {
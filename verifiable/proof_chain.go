@@ -0,0 +1,180 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrBrokenProofChain is returned when a proof of the proof chain does not
+// reference the previous proof
+var ErrBrokenProofChain = errors.New(
+	"proof does not reference the previous proof of the chain")
+
+// ProofChainLink is a proof of the proof chain with its chain metadata
+type ProofChainLink struct {
+	// ID of the proof
+	ID string
+	// PreviousProof is the ID of the previous proof of the chain. It is
+	// empty for the first proof.
+	PreviousProof string
+	Proof         CredentialProof
+}
+
+// ProofChain is the ordered list of proofs of the proofChain credential
+// property. Every proof except the first one references the previous proof
+// with previousProof.
+type ProofChain []ProofChainLink
+
+// MarshalJSON encodes the chain as an array of proofs with id and
+// previousProof properties
+func (pc ProofChain) MarshalJSON() ([]byte, error) {
+	if pc == nil {
+		return []byte("null"), nil
+	}
+	proofs := make([]jsonObj, 0, len(pc))
+	for i, l := range pc {
+		var proofObj jsonObj
+		err := remarshalObj(&proofObj, l.Proof)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "proof chain link %d", i)
+		}
+		if l.ID != "" {
+			proofObj["id"] = l.ID
+		}
+		if l.PreviousProof != "" {
+			proofObj["previousProof"] = l.PreviousProof
+		}
+		proofs = append(proofs, proofObj)
+	}
+	return json.Marshal(proofs)
+}
+
+// UnmarshalJSON decodes the array of proofs keeping their order
+func (pc *ProofChain) UnmarshalJSON(bs []byte) error {
+	var proofs []any
+	err := json.Unmarshal(bs, &proofs)
+	if err != nil {
+		return errors.WithMessage(err, "proof chain is not an array")
+	}
+	if proofs == nil {
+		*pc = nil
+		return nil
+	}
+
+	chain := make(ProofChain, 0, len(proofs))
+	for i, p := range proofs {
+		proofObj, ok := p.(jsonObj)
+		if !ok {
+			return errors.Errorf("proof chain link %d is not an object", i)
+		}
+		var link ProofChainLink
+		link.ID, err = optionalString(proofObj, "id")
+		if err != nil {
+			return errors.WithMessagef(err, "proof chain link %d", i)
+		}
+		link.PreviousProof, err = optionalString(proofObj, "previousProof")
+		if err != nil {
+			return errors.WithMessagef(err, "proof chain link %d", i)
+		}
+		link.Proof, err = extractProof(proofObj)
+		if err != nil {
+			return errors.WithMessagef(err, "proof chain link %d", i)
+		}
+		chain = append(chain, link)
+	}
+	*pc = chain
+	return nil
+}
+
+func optionalString(obj jsonObj, key string) (string, error) {
+	v, ok := obj[key]
+	if !ok {
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", errors.Errorf("%v is not a string", key)
+	}
+	return s, nil
+}
+
+// Validate checks the order of the chain: the first proof does not have
+// previousProof and every next proof references the ID of the previous one
+func (pc ProofChain) Validate() error {
+	for i, l := range pc {
+		if l.Proof == nil {
+			return errors.Errorf("proof chain link %d has no proof", i)
+		}
+		if i == 0 {
+			if l.PreviousProof != "" {
+				return errors.Wrapf(ErrBrokenProofChain,
+					"first proof references %v", l.PreviousProof)
+			}
+			continue
+		}
+		if pc[i-1].ID == "" || l.PreviousProof != pc[i-1].ID {
+			return errors.Wrapf(ErrBrokenProofChain, "proof chain link %d", i)
+		}
+	}
+	return nil
+}
+
+// Types returns types of all proofs in order
+func (pc ProofChain) Types() []ProofType {
+	types := make([]ProofType, 0, len(pc))
+	for _, l := range pc {
+		if l.Proof != nil {
+			types = append(types, l.Proof.ProofType())
+		}
+	}
+	return types
+}
+
+// ProofChainError is returned by VerifyProofChain when the verification of
+// a proof of the chain fails
+type ProofChainError struct {
+	// Index of the failed proof in the chain
+	Index int
+	Err   error
+}
+
+func (e *ProofChainError) Error() string {
+	return fmt.Sprintf("proof chain link %d: %v", e.Index, e.Err)
+}
+
+func (e *ProofChainError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyProofChain validates the order of the proofChain of the credential
+// and verifies its proofs sequentially. Verification stops at the first
+// failed proof, which is returned as ProofChainError. Returns
+// ErrProofNotFound if the credential has no proof chain.
+func (vc *W3CCredential) VerifyProofChain(ctx context.Context,
+	didResolver DIDResolver, opts ...W3CProofVerificationOpt) error {
+
+	if len(vc.ProofChain) == 0 {
+		return ErrProofNotFound
+	}
+
+	verifyConfig, err := vc.prepareProofVerification(ctx, didResolver, opts)
+	if err != nil {
+		return err
+	}
+
+	err = vc.ProofChain.Validate()
+	if err != nil {
+		return err
+	}
+
+	for i, l := range vc.ProofChain {
+		err = vc.verifyProof(ctx, l.Proof, didResolver, verifyConfig)
+		if err != nil {
+			return &ProofChainError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func proofChainTestCredential(t testing.TB) *W3CCredential {
+	var vc W3CCredential
+	err := json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	proof2, err := cloneProof(vc.Proof[0])
+	require.NoError(t, err)
+	vc.ProofChain = ProofChain{
+		{ID: "urn:uuid:proof-1", Proof: vc.Proof[0]},
+		{ID: "urn:uuid:proof-2", PreviousProof: "urn:uuid:proof-1",
+			Proof: proof2},
+	}
+	vc.Proof = nil
+	return &vc
+}
+
+func TestProofChain_JSON(t *testing.T) {
+	vc := proofChainTestCredential(t)
+	vcBytes, err := json.Marshal(vc)
+	require.NoError(t, err)
+
+	var vc2 W3CCredential
+	require.NoError(t, json.Unmarshal(vcBytes, &vc2))
+	require.Len(t, vc2.ProofChain, 2)
+	require.Equal(t, "urn:uuid:proof-1", vc2.ProofChain[0].ID)
+	require.Empty(t, vc2.ProofChain[0].PreviousProof)
+	require.Equal(t, "urn:uuid:proof-2", vc2.ProofChain[1].ID)
+	require.Equal(t, "urn:uuid:proof-1", vc2.ProofChain[1].PreviousProof)
+	require.IsType(t, &BJJSignatureProof2021{}, vc2.ProofChain[1].Proof)
+	require.Equal(t, []ProofType{BJJSignatureProofType, BJJSignatureProofType},
+		vc2.ProofChain.Types())
+	require.NoError(t, vc2.ProofChain.Validate())
+
+	// the proof chain is not merklized
+	require.Nil(t, vc2.WithoutProofs().ProofChain)
+	vc3, err := vc2.Clone()
+	require.NoError(t, err)
+	require.Equal(t, vc2.ProofChain, vc3.ProofChain)
+
+	err = json.Unmarshal([]byte(`{"proofChain":[{"id":1}]}`), &vc2)
+	require.EqualError(t, err, "proof chain link 0: id is not a string")
+}
+
+func TestProofChain_Validate(t *testing.T) {
+	vc := proofChainTestCredential(t)
+	require.NoError(t, vc.ProofChain.Validate())
+
+	chain := append(ProofChain(nil), vc.ProofChain...)
+	chain[1].PreviousProof = "urn:uuid:other"
+	require.ErrorIs(t, chain.Validate(), ErrBrokenProofChain)
+
+	chain = append(ProofChain(nil), vc.ProofChain...)
+	chain[0].PreviousProof = "urn:uuid:proof-0"
+	require.ErrorIs(t, chain.Validate(), ErrBrokenProofChain)
+
+	// the order matters
+	chain = ProofChain{vc.ProofChain[1], vc.ProofChain[0]}
+	require.ErrorIs(t, chain.Validate(), ErrBrokenProofChain)
+}
+
+func TestW3CCredential_VerifyProofChain(t *testing.T) {
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+			"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld":                                         "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+			"http://my-universal-resolver/1.0/identifiers/did%3Apolygonid%3Apolygon%3Amumbai%3A2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf?state=f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e": `./testdata/verifycred//my-universal-resolver-1.json`,
+		}, tst.IgnoreUntouchedURLs())()
+	resolverRegistry := &CredentialStatusResolverRegistry{}
+	resolverRegistry.Register(Iden3ReverseSparseMerkleTreeProof,
+		test1Resolver{})
+	didResolver := HTTPDIDResolver{
+		resolverURL: "http://my-universal-resolver/1.0/identifiers"}
+	ctx := context.Background()
+
+	vc := proofChainTestCredential(t)
+	err := vc.VerifyProofChain(ctx, didResolver,
+		WithStatusResolverRegistry(resolverRegistry))
+	require.NoError(t, err)
+
+	t.Run("invalid second proof", func(t *testing.T) {
+		vc := proofChainTestCredential(t)
+		proof := *vc.ProofChain[1].Proof.(*BJJSignatureProof2021)
+		proof.Signature = proof.Signature[:len(proof.Signature)-2] + "00"
+		vc.ProofChain[1].Proof = &proof
+
+		err := vc.VerifyProofChain(ctx, didResolver,
+			WithStatusResolverRegistry(resolverRegistry))
+		var chainErr *ProofChainError
+		require.True(t, errors.As(err, &chainErr))
+		require.Equal(t, 1, chainErr.Index)
+	})
+
+	t.Run("broken chain", func(t *testing.T) {
+		vc := proofChainTestCredential(t)
+		vc.ProofChain[1].PreviousProof = ""
+		err := vc.VerifyProofChain(ctx, didResolver,
+			WithStatusResolverRegistry(resolverRegistry))
+		require.ErrorIs(t, err, ErrBrokenProofChain)
+	})
+
+	t.Run("no proof chain", func(t *testing.T) {
+		vc := proofChainTestCredential(t)
+		vc.ProofChain = nil
+		err := vc.VerifyProofChain(ctx, didResolver)
+		require.ErrorIs(t, err, ErrProofNotFound)
+	})
+}
@@ -329,6 +329,68 @@ func TestCredentialProofs_UnmarshalJSON(t *testing.T) {
 		},
 	}
 	require.Equal(t, want, p)
+
+	require.Equal(t, []ProofType{BJJSignatureProofType,
+		Iden3SparseMerkleProofType, "Ed25519Signature2020"}, p.Types())
+	bjjProof, ok := p.BJJSignature()
+	require.True(t, ok)
+	require.Same(t, p[0], bjjProof)
+	_, ok = p.SMT()
+	require.False(t, ok)
+	commonProof, ok := p.ByType("Ed25519Signature2020")
+	require.True(t, ok)
+	require.Same(t, p[2], commonProof)
+
+	// proofs are marshaled as an array in the same order
+	pBytes, err := json.Marshal(p)
+	require.NoError(t, err)
+	var p2 CredentialProofs
+	err = json.Unmarshal(pBytes, &p2)
+	require.NoError(t, err)
+	require.Equal(t, p, p2)
+}
+
+func TestCredentialProofs_Accessors(t *testing.T) {
+	// single proof object is unmarshalled as one element array
+	in := `{
+  "type": "Iden3SparseMerkleTreeProof",
+  "issuerData": {"id": "did:iden3:polygon:mumbai:wvEkzpApgwGHrSTxEFG6V6HrTCa5R2rwQ3XWAkrnG"},
+  "coreClaim": "c9b2370371b7fa8b3dab2a5ba81b68382a0000000000000000000000000000000112b4f1183b6a0708a8addd31c093004ac2e40ab1b291ad6d208244032b0c006947c37450a6a4c50a586e8a253dc8385d8d1ee77b37f464fe5052dc2f0dd8020000000000000000000000000000000000000000000000000000000000000000e29d235b00000000281cdcdf0200000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000",
+  "mtp": {"existence": true, "siblings": []}
+}`
+	var p CredentialProofs
+	err := json.Unmarshal([]byte(in), &p)
+	require.NoError(t, err)
+	smtProof, ok := p.SMT()
+	require.True(t, ok)
+	require.Same(t, p[0], smtProof)
+	_, ok = p.BJJSignature()
+	require.False(t, ok)
+
+	pBytes, err := json.Marshal(p)
+	require.NoError(t, err)
+	require.Equal(t, byte('['), pBytes[0])
+
+	// proof of the known type stored as CommonProof is converted
+	const signature = "b36ed82e13d2868d6b5c5dff0f461e309e1af4cf3fdc9822fd0f86b76c820f19cd728d06ff22c259d4aeef3406c3d44577014fbd0e8fb14330022de77bda8302"
+	p = CredentialProofs{&CommonProof{
+		"type":       "BJJSignature2021",
+		"issuerData": map[string]any{"id": "did:iden3:polygon:mumbai:wvEkzpApgwGHrSTxEFG6V6HrTCa5R2rwQ3XWAkrnG"},
+		"coreClaim":  smtProof.CoreClaim,
+		"signature":  signature,
+	}}
+	bjjProof, ok := p.BJJSignature()
+	require.True(t, ok)
+	require.Equal(t, signature, bjjProof.Signature)
+
+	// invalid proof can't be converted
+	p = CredentialProofs{&CommonProof{"type": "BJJSignature2021"}}
+	_, ok = p.BJJSignature()
+	require.False(t, ok)
+
+	pBytes, err = json.Marshal(CredentialProofs(nil))
+	require.NoError(t, err)
+	require.Equal(t, "null", string(pBytes))
 }
 
 func TestIden3SparseMerkleTreeProofType_is_CredentialProof(t *testing.T) {
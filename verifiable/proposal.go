@@ -0,0 +1,121 @@
+package verifiable
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedProposalType is returned when proposal type is not one of
+// the known types
+var ErrUnsupportedProposalType = errors.New("unsupported proposal type")
+
+// ProposalType represent credential proposal types
+type ProposalType string
+
+// Validate returns ErrUnsupportedProposalType if proposal type is not known
+func (t ProposalType) Validate() error {
+	switch t {
+	case WebVerificationFormV1:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnsupportedProposalType, "%q", string(t))
+	}
+}
+
+// CredentialInfo is the type of a credential with the JSON-LD context
+// defining it, as referenced by proposals and payment requests
+type CredentialInfo struct {
+	Type    string `json:"type"`
+	Context string `json:"context"`
+}
+
+func (c CredentialInfo) validate() error {
+	if c.Type == "" {
+		return errors.New("credential type is empty")
+	}
+	if c.Context == "" {
+		return errors.Errorf("context of credential %v is empty", c.Type)
+	}
+	return nil
+}
+
+// ProposalRequestBody is the body of iden3comm credential proposal request
+// message: the holder asks the issuer how to get the credentials
+type ProposalRequestBody struct {
+	Credentials []CredentialInfo         `json:"credentials"`
+	Metadata    *ProposalRequestMetadata `json:"metadata,omitempty"`
+	DIDDoc      json.RawMessage          `json:"did_doc,omitempty"`
+}
+
+// ProposalRequestMetadata is the metadata of credential proposal request
+type ProposalRequestMetadata struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Validate checks that at least one credential is requested and every
+// credential has type and context
+func (b ProposalRequestBody) Validate() error {
+	if len(b.Credentials) == 0 {
+		return errors.New("proposal request has no credentials")
+	}
+	for _, c := range b.Credentials {
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProposalBody is the body of iden3comm credential proposal message: the
+// issuer tells the holder how to get the credentials
+type ProposalBody struct {
+	Proposals []Proposal `json:"proposals"`
+}
+
+// Proposal describes the way to get the credentials
+type Proposal struct {
+	Credentials []CredentialInfo `json:"credentials"`
+	Type        ProposalType     `json:"type"`
+	URL         string           `json:"url,omitempty"`
+	Expiration  *time.Time       `json:"expiration,omitempty"`
+	Description string           `json:"description,omitempty"`
+}
+
+// Validate checks the proposal type, the credentials and the URL of
+// WebVerificationFormV1 proposals
+func (p Proposal) Validate() error {
+	err := p.Type.Validate()
+	if err != nil {
+		return err
+	}
+	if len(p.Credentials) == 0 {
+		return errors.New("proposal has no credentials")
+	}
+	for _, c := range p.Credentials {
+		if err := c.validate(); err != nil {
+			return err
+		}
+	}
+	if p.Type == WebVerificationFormV1 {
+		if err := validateServiceURL(p.URL); err != nil {
+			return errors.Wrap(err, "invalid proposal url")
+		}
+	}
+	return nil
+}
+
+// Validate checks every proposal of the body
+func (b ProposalBody) Validate() error {
+	if len(b.Proposals) == 0 {
+		return errors.New("proposal message has no proposals")
+	}
+	for i, p := range b.Proposals {
+		if err := p.Validate(); err != nil {
+			return errors.Wrapf(err, "proposal %d", i)
+		}
+	}
+	return nil
+}
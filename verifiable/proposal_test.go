@@ -0,0 +1,75 @@
+package verifiable
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposalRequestBody_JSON(t *testing.T) {
+	in := `{
+  "credentials": [
+    {
+      "type": "KYCAgeCredential",
+      "context": "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld"
+    }
+  ],
+  "metadata": {"type": "SomeMetadataType", "data": {"a": 1}},
+  "did_doc": {"id": "did:example:123"}
+}`
+	var body ProposalRequestBody
+	err := json.Unmarshal([]byte(in), &body)
+	require.NoError(t, err)
+	require.NoError(t, body.Validate())
+	require.Equal(t, "KYCAgeCredential", body.Credentials[0].Type)
+	require.Equal(t, "SomeMetadataType", body.Metadata.Type)
+
+	out, err := json.Marshal(body)
+	require.NoError(t, err)
+	require.JSONEq(t, in, string(out))
+
+	require.EqualError(t, ProposalRequestBody{}.Validate(),
+		"proposal request has no credentials")
+}
+
+func TestProposalBody_JSON(t *testing.T) {
+	in := `{
+  "proposals": [
+    {
+      "credentials": [
+        {
+          "type": "KYCAgeCredential",
+          "context": "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld"
+        }
+      ],
+      "type": "WebVerificationFormV1.0",
+      "url": "https://issuer.example.com/verify",
+      "expiration": "2025-04-17T12:00:00Z",
+      "description": "fill the form to get the credential"
+    }
+  ]
+}`
+	var body ProposalBody
+	err := json.Unmarshal([]byte(in), &body)
+	require.NoError(t, err)
+	require.NoError(t, body.Validate())
+	require.Equal(t, WebVerificationFormV1, body.Proposals[0].Type)
+	require.Equal(t, "2025-04-17T12:00:00Z",
+		body.Proposals[0].Expiration.Format("2006-01-02T15:04:05Z07:00"))
+
+	out, err := json.Marshal(body)
+	require.NoError(t, err)
+	require.JSONEq(t, in, string(out))
+
+	body.Proposals[0].Type = "Other"
+	err = body.Validate()
+	require.ErrorIs(t, err, ErrUnsupportedProposalType)
+	require.EqualError(t, err,
+		`proposal 0: "Other": unsupported proposal type`)
+
+	body.Proposals[0].Type = WebVerificationFormV1
+	body.Proposals[0].URL = "/verify"
+	require.EqualError(t, body.Validate(),
+		"proposal 0: invalid proposal url: service id is not an absolute URL")
+}
@@ -1,10 +1,53 @@
 package verifiable
 
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedRefreshServiceType is returned when refresh service type is
+// not one of the known types
+var ErrUnsupportedRefreshServiceType = errors.New(
+	"unsupported refresh service type")
+
 // RefreshServiceType represent refresh service types
 type RefreshServiceType string
 
+// Validate returns ErrUnsupportedRefreshServiceType if refresh service type
+// is not known
+func (t RefreshServiceType) Validate() error {
+	switch t {
+	case Iden3RefreshService2023:
+		return nil
+	default:
+		return errors.Wrapf(ErrUnsupportedRefreshServiceType, "%q", string(t))
+	}
+}
+
 // RefreshService is struct that represents refresh service json-ld document
 type RefreshService struct {
 	ID   string             `json:"id"`
 	Type RefreshServiceType `json:"type"`
 }
+
+// NewIden3RefreshService creates a new refresh service of
+// Iden3RefreshService2023 type. id should be an absolute URL.
+func NewIden3RefreshService(id string) (*RefreshService, error) {
+	err := validateServiceURL(id)
+	if err != nil {
+		return nil, err
+	}
+	return &RefreshService{ID: id, Type: Iden3RefreshService2023}, nil
+}
+
+func validateServiceURL(id string) error {
+	u, err := url.Parse(id)
+	if err != nil {
+		return err
+	}
+	if !u.IsAbs() {
+		return errors.New("service id is not an absolute URL")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package verifiable
+
+import (
+	"context"
+
+	"github.com/iden3/go-schema-processor/v2/loaders"
+)
+
+// RelatedResource is the integrity metadata of a resource used by the
+// credential, like its JSON-LD context or JSON schema
+// (https://www.w3.org/TR/vc-data-model-2.0/#integrity-of-related-resources)
+type RelatedResource struct {
+	ID string `json:"id"`
+	// DigestSRI is the Subresource Integrity digest of the resource bytes
+	DigestSRI string `json:"digestSRI"`
+	MediaType string `json:"mediaType,omitempty"`
+}
+
+// NewRelatedResource returns the integrity metadata of the resource content
+// with sha384 digest
+func NewRelatedResource(id string, content []byte) RelatedResource {
+	return RelatedResource{ID: id, DigestSRI: loaders.DigestSRI(content)}
+}
+
+// AddRelatedResource adds the integrity metadata of the resource content to
+// the credential, replacing the metadata of the resource with the same ID.
+// It is intended for issuers, e.g. to pin the credentialSchema and the
+// contexts of the credential.
+func (vc *W3CCredential) AddRelatedResource(id string, content []byte) {
+	r := NewRelatedResource(id, content)
+	for i := range vc.RelatedResource {
+		if vc.RelatedResource[i].ID == id {
+			vc.RelatedResource[i] = r
+			return
+		}
+	}
+	vc.RelatedResource = append(vc.RelatedResource, r)
+}
+
+// IntegrityContext returns the context with the digests of RelatedResource
+// set with loaders.WithIntegrity, so the document loaders created by
+// loaders.NewDocumentLoader verify the fetched resources. Merklize and
+// credential schema validation use it.
+func (vc *W3CCredential) IntegrityContext(ctx context.Context) context.Context {
+	if len(vc.RelatedResource) == 0 {
+		return ctx
+	}
+	digests := make(map[string]string, len(vc.RelatedResource))
+	for _, r := range vc.RelatedResource {
+		digests[r.ID] = r.DigestSRI
+	}
+	return loaders.WithIntegrity(ctx, digests)
+}
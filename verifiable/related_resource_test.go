@@ -0,0 +1,55 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/iden3/go-schema-processor/v2/loaders"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/stretchr/testify/require"
+)
+
+func TestW3CCredential_RelatedResource(t *testing.T) {
+	const kycContextURL = "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld"
+	defer tst.MockHTTPClient(t,
+		map[string]string{
+			kycContextURL: "../merklize/testdata/httpresp/kyc-v3.json-ld",
+			"https://schema.iden3.io/core/jsonld/iden3proofs.jsonld": "../merklize/testdata/httpresp/iden3proofs.json-ld",
+			"https://www.w3.org/2018/credentials/v1":                 "../merklize/testdata/httpresp/credentials-v1.jsonld",
+		}, tst.IgnoreUntouchedURLs())()
+
+	kycContext, err := os.ReadFile("../merklize/testdata/httpresp/kyc-v3.json-ld")
+	require.NoError(t, err)
+
+	var vc W3CCredential
+	err = json.Unmarshal([]byte(bjjSignatureProofCredential), &vc)
+	require.NoError(t, err)
+
+	vc.AddRelatedResource(kycContextURL, []byte(`{}`))
+	vc.AddRelatedResource(kycContextURL, kycContext)
+	require.Equal(t, []RelatedResource{{
+		ID:        kycContextURL,
+		DigestSRI: loaders.DigestSRI(kycContext),
+	}}, vc.RelatedResource)
+
+	vcBytes, err := json.Marshal(vc)
+	require.NoError(t, err)
+	var vc2 W3CCredential
+	err = json.Unmarshal(vcBytes, &vc2)
+	require.NoError(t, err)
+	require.Equal(t, vc.RelatedResource, vc2.RelatedResource)
+
+	ctx := vc.IntegrityContext(context.Background())
+	_, err = loaders.LoadDocument(ctx, loaders.NewDocumentLoader(nil, ""),
+		kycContextURL)
+	require.NoError(t, err)
+
+	// the context does not match the digest
+	vc.RelatedResource[0] = NewRelatedResource(kycContextURL, []byte(`{}`))
+	_, err = vc.Merklize(context.Background(),
+		merklize.WithDocumentLoader(loaders.NewDocumentLoader(nil, "")))
+	require.ErrorIs(t, err, loaders.ErrIntegrityMismatch)
+}
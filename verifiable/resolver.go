@@ -74,3 +74,21 @@ func GetStatusResolver(
 func DeleteStatusResolver(resolverType CredentialStatusType) {
 	DefaultCredentialStatusResolverRegistry.Delete(resolverType)
 }
+
+type ctxKeyUserDID struct{}
+
+// WithUserDID puts the DID of the user (the holder or the verifier) in the
+// context. It is the sender of messages to agents.
+func WithUserDID(ctx context.Context, userDID *w3c.DID) context.Context {
+	return context.WithValue(ctx, ctxKeyUserDID{}, userDID)
+}
+
+// GetUserDID extract the user DID from the context.
+// Or nil if nothing is found.
+func GetUserDID(ctx context.Context) *w3c.DID {
+	v := ctx.Value(ctxKeyUserDID{})
+	if v == nil {
+		return nil
+	}
+	return v.(*w3c.DID)
+}
@@ -0,0 +1,64 @@
+package verifiable
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// NonRevocationSnapshot is the revocation status of the credential fetched at
+// some moment of time. It may be stored alongside the credential and verified
+// later without network access to prove that the credential was not revoked
+// at the moment of the check.
+type NonRevocationSnapshot struct {
+	CredentialStatus CredentialStatus `json:"credentialStatus"`
+	RevocationStatus RevocationStatus `json:"revocationStatus"`
+	CheckedAt        time.Time        `json:"checkedAt"`
+}
+
+// SnapshotNonRevocation resolves and validates the credential status and
+// returns its snapshot. Returns ErrCredentialIsRevoked if the credential was
+// revoked.
+func (vc *W3CCredential) SnapshotNonRevocation(ctx context.Context,
+	opts ...CredentialStatusValidationOption) (*NonRevocationSnapshot, error) {
+
+	credStatus, err := coerceCredentialStatus(vc.CredentialStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	revocationStatus, err := ValidateCredentialStatus(ctx, *credStatus,
+		opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NonRevocationSnapshot{
+		CredentialStatus: *credStatus,
+		RevocationStatus: revocationStatus,
+		CheckedAt:        time.Now().UTC(),
+	}, nil
+}
+
+// Verify validates the issuer's tree state and the non-revocation proof
+// stored in the snapshot. It does not make any network requests.
+func (s *NonRevocationSnapshot) Verify() error {
+	return verifyRevocationStatus(s.RevocationStatus,
+		s.CredentialStatus.RevocationNonce)
+}
+
+// VerifyForCredential checks that the snapshot was made for the given
+// credential status and verifies it.
+func (s *NonRevocationSnapshot) VerifyForCredential(vc *W3CCredential) error {
+	credStatus, err := coerceCredentialStatus(vc.CredentialStatus)
+	if err != nil {
+		return err
+	}
+
+	if !sameCredentialStatus(*credStatus, s.CredentialStatus) {
+		return errors.New("snapshot was made for another credential status")
+	}
+
+	return s.Verify()
+}
@@ -0,0 +1,75 @@
+package verifiable
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// SchemaCache keeps the results of JSON-LD context processing, such as the
+// iden3 serialization attribute of a credential type, to share them between
+// credentials with the same @context. It is intended for bulk issuance where
+// many credentials of the same type are converted to core claims. It is safe
+// for concurrent use. The cache does not track changes of remote contexts,
+// so it should not be kept for a long time.
+type SchemaCache struct {
+	m        sync.Mutex
+	serAttrs map[schemaCacheKey]*schemaCacheEntry
+}
+
+type schemaCacheKey struct {
+	contexts       string
+	credentialType string
+}
+
+type schemaCacheEntry struct {
+	done    chan struct{}
+	serAttr string
+	err     error
+}
+
+// NewSchemaCache creates new empty SchemaCache
+func NewSchemaCache() *SchemaCache {
+	return &SchemaCache{serAttrs: make(map[schemaCacheKey]*schemaCacheEntry)}
+}
+
+// serializationAttr returns the serialization attribute of the credential
+// type. The context is parsed once for all concurrent callers. If c is nil,
+// the context is parsed on every call.
+func (c *SchemaCache) serializationAttr(credential W3CCredential,
+	opts *ld.JsonLdOptions, tp string) (string, error) {
+
+	if c == nil {
+		return getSerializationAttr(credential, opts, tp)
+	}
+
+	contexts, err := json.Marshal(credential.Context)
+	if err != nil {
+		return "", err
+	}
+	key := schemaCacheKey{contexts: string(contexts), credentialType: tp}
+
+	c.m.Lock()
+	e, ok := c.serAttrs[key]
+	if !ok {
+		e = &schemaCacheEntry{done: make(chan struct{})}
+		c.serAttrs[key] = e
+	}
+	c.m.Unlock()
+
+	if ok {
+		<-e.done
+		return e.serAttr, e.err
+	}
+
+	e.serAttr, e.err = getSerializationAttr(credential, opts, tp)
+	close(e.done)
+	if e.err != nil {
+		// do not cache errors, they may be caused by the network
+		c.m.Lock()
+		delete(c.serAttrs, key)
+		c.m.Unlock()
+	}
+	return e.serAttr, e.err
+}
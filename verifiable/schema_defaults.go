@@ -0,0 +1,216 @@
+package verifiable
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrSchemaConstMismatch is returned when the credentialSubject field does
+// not have the value fixed by the const keyword of the credential schema
+var ErrSchemaConstMismatch = errors.New(
+	"credential subject field does not match schema const")
+
+// SubjectFieldRules are the values of credentialSubject fields fixed or
+// suggested by the JSON schema of the credential. Fields are keyed by their
+// path in the document notation, e.g. "address.country" for nested objects.
+type SubjectFieldRules struct {
+	// Const are the values of the fields with the const keyword. The field
+	// must have this value.
+	Const map[string]any
+	// Default are the values of the fields with the default keyword. The
+	// value is used if the field is missing.
+	Default map[string]any
+}
+
+type subjectPropertySchema struct {
+	Const      json.RawMessage                  `json:"const"`
+	Default    json.RawMessage                  `json:"default"`
+	Properties map[string]subjectPropertySchema `json:"properties"`
+}
+
+// SubjectFieldRulesFromSchema returns the const and default values of
+// credentialSubject fields of the JSON schema, including the fields of
+// nested objects.
+func SubjectFieldRulesFromSchema(schemaBytes []byte) (SubjectFieldRules,
+	error) {
+
+	var schema struct {
+		Properties struct {
+			CredentialSubject subjectPropertySchema `json:"credentialSubject"`
+		} `json:"properties"`
+	}
+	err := json.Unmarshal(schemaBytes, &schema)
+	if err != nil {
+		return SubjectFieldRules{}, errors.WithMessage(err,
+			"invalid credential schema")
+	}
+
+	rules := SubjectFieldRules{
+		Const:   map[string]any{},
+		Default: map[string]any{},
+	}
+	err = rules.collect(schema.Properties.CredentialSubject.Properties, "")
+	return rules, err
+}
+
+func (r SubjectFieldRules) collect(props map[string]subjectPropertySchema,
+	prefix string) error {
+
+	for name, prop := range props {
+		field := prefix + name
+		if prop.Const != nil {
+			var v any
+			if err := json.Unmarshal(prop.Const, &v); err != nil {
+				return errors.WithMessagef(err, "invalid const of %v", field)
+			}
+			r.Const[field] = v
+		}
+		if prop.Default != nil {
+			var v any
+			if err := json.Unmarshal(prop.Default, &v); err != nil {
+				return errors.WithMessagef(err, "invalid default of %v",
+					field)
+			}
+			r.Default[field] = v
+		}
+		if err := r.collect(prop.Properties, field+"."); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplySchemaDefaults sets the credentialSubject fields with const or
+// default values in the JSON schema if they are missing. Objects are created
+// for missing parents of const fields only. ErrSchemaConstMismatch is
+// returned if the field has the value other than the const.
+func (vc *W3CCredential) ApplySchemaDefaults(schemaBytes []byte) error {
+	rules, err := SubjectFieldRulesFromSchema(schemaBytes)
+	if err != nil {
+		return err
+	}
+
+	if vc.CredentialSubject == nil {
+		vc.CredentialSubject = map[string]any{}
+	}
+	for _, field := range sortedKeys(rules.Const) {
+		err = applySubjectFieldRule(vc.CredentialSubject, field,
+			rules.Const[field], true)
+		if err != nil {
+			return err
+		}
+	}
+	for _, field := range sortedKeys(rules.Default) {
+		if _, isConst := rules.Const[field]; isConst {
+			continue
+		}
+		err = applySubjectFieldRule(vc.CredentialSubject, field,
+			rules.Default[field], false)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckSchemaConstants checks that all credentialSubject fields with const
+// values in the JSON schema are present and have these values. Unlike the
+// validation with the JSON schema, missing fields are reported too, as they
+// are injected by ApplySchemaDefaults on issuance.
+func (vc *W3CCredential) CheckSchemaConstants(schemaBytes []byte) error {
+	rules, err := SubjectFieldRulesFromSchema(schemaBytes)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range sortedKeys(rules.Const) {
+		v, ok := getSubjectField(vc.CredentialSubject, field)
+		if !ok {
+			return errors.Wrapf(ErrSchemaConstMismatch, "%v is missing",
+				field)
+		}
+		if !sameJSONValue(v, rules.Const[field]) {
+			return errors.Wrapf(ErrSchemaConstMismatch, "%v is %v, not %v",
+				field, v, rules.Const[field])
+		}
+	}
+	return nil
+}
+
+func applySubjectFieldRule(subject map[string]any, field string, value any,
+	isConst bool) error {
+
+	parts := strings.Split(field, ".")
+	obj := subject
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := obj[p]
+		if !ok {
+			if !isConst {
+				return nil
+			}
+			next = map[string]any{}
+			obj[p] = next
+		}
+		nextObj, ok := next.(map[string]any)
+		if !ok {
+			return errors.Errorf("%v: %v is not an object", field, p)
+		}
+		obj = nextObj
+	}
+
+	name := parts[len(parts)-1]
+	current, ok := obj[name]
+	switch {
+	case !ok:
+		obj[name] = value
+	case isConst && !sameJSONValue(current, value):
+		return errors.Wrapf(ErrSchemaConstMismatch, "%v is %v, not %v",
+			field, current, value)
+	}
+	return nil
+}
+
+func getSubjectField(subject map[string]any, field string) (any, bool) {
+	var v any = subject
+	for _, p := range strings.Split(field, ".") {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		v, ok = obj[p]
+		if !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// sameJSONValue compares values by their JSON encoding, so numbers of
+// different Go types are equal
+func sameJSONValue(a, b any) bool {
+	aBytes, errA := json.Marshal(a)
+	bBytes, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	var aVal, bVal any
+	if json.Unmarshal(aBytes, &aVal) != nil ||
+		json.Unmarshal(bBytes, &bVal) != nil {
+
+		return false
+	}
+	return reflect.DeepEqual(aVal, bVal)
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,103 @@
+package verifiable
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const schemaWithDefaults = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "credentialSubject": {
+      "type": "object",
+      "properties": {
+        "id": {"type": "string"},
+        "category": {"type": "integer", "const": 7},
+        "level": {"type": "string", "default": "basic"},
+        "address": {
+          "type": "object",
+          "properties": {
+            "country": {"type": "string", "const": "UA"},
+            "city": {"type": "string", "default": "Kyiv"}
+          }
+        }
+      }
+    }
+  }
+}`
+
+func TestSubjectFieldRulesFromSchema(t *testing.T) {
+	rules, err := SubjectFieldRulesFromSchema([]byte(schemaWithDefaults))
+	require.NoError(t, err)
+	require.Equal(t, SubjectFieldRules{
+		Const: map[string]any{
+			"category":        float64(7),
+			"address.country": "UA",
+		},
+		Default: map[string]any{
+			"level":        "basic",
+			"address.city": "Kyiv",
+		},
+	}, rules)
+
+	_, err = SubjectFieldRulesFromSchema([]byte(`[]`))
+	require.ErrorContains(t, err, "invalid credential schema")
+}
+
+func TestW3CCredential_ApplySchemaDefaults(t *testing.T) {
+	t.Run("missing fields", func(t *testing.T) {
+		vc := W3CCredential{CredentialSubject: map[string]any{
+			"id": "did:iden3:polygon:mumbai:wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ",
+		}}
+		require.Error(t, vc.CheckSchemaConstants([]byte(schemaWithDefaults)))
+
+		err := vc.ApplySchemaDefaults([]byte(schemaWithDefaults))
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"id":       "did:iden3:polygon:mumbai:wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ",
+			"category": float64(7),
+			"level":    "basic",
+			"address":  map[string]any{"country": "UA", "city": "Kyiv"},
+		}, vc.CredentialSubject)
+		require.NoError(t, vc.CheckSchemaConstants([]byte(schemaWithDefaults)))
+	})
+
+	t.Run("present fields are kept", func(t *testing.T) {
+		vc := W3CCredential{CredentialSubject: map[string]any{
+			"category": 7,
+			"level":    "advanced",
+			"address":  map[string]any{},
+		}}
+		err := vc.ApplySchemaDefaults([]byte(schemaWithDefaults))
+		require.NoError(t, err)
+		require.Equal(t, map[string]any{
+			"category": 7,
+			"level":    "advanced",
+			"address":  map[string]any{"country": "UA", "city": "Kyiv"},
+		}, vc.CredentialSubject)
+	})
+
+	t.Run("const mismatch", func(t *testing.T) {
+		vc := W3CCredential{CredentialSubject: map[string]any{
+			"category": 8,
+		}}
+		err := vc.ApplySchemaDefaults([]byte(schemaWithDefaults))
+		require.ErrorIs(t, err, ErrSchemaConstMismatch)
+		require.EqualError(t, err, "category is 8, not 7: credential "+
+			"subject field does not match schema const")
+
+		err = vc.CheckSchemaConstants([]byte(schemaWithDefaults))
+		require.ErrorIs(t, err, ErrSchemaConstMismatch)
+	})
+
+	t.Run("parent is not an object", func(t *testing.T) {
+		vc := W3CCredential{CredentialSubject: map[string]any{
+			"address": "Kyiv",
+		}}
+		err := vc.ApplySchemaDefaults([]byte(schemaWithDefaults))
+		require.EqualError(t, err,
+			"address.country: address is not an object")
+	})
+}
@@ -0,0 +1,144 @@
+package verifiable
+
+import (
+	"bytes"
+	"sort"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/iden3/go-schema-processor/v2/utils"
+	"github.com/piprate/json-gold/ld"
+	"github.com/pkg/errors"
+)
+
+// SchemaType is a credential type as it is used in credentials: the type
+// name (or IRI) from credentialSubject.type and the credential @context.
+type SchemaType struct {
+	Context []string
+	Type    string
+}
+
+// SchemaHashInfo is the schema hash of the core claim for the credential
+// type
+type SchemaHashInfo struct {
+	SchemaType
+	// TypeIRI is the expanded IRI of the type the schema hash is calculated
+	// from
+	TypeIRI string
+	Hash    core.SchemaHash
+}
+
+// SchemaHashCollision lists different type IRIs with the same schema hash
+type SchemaHashCollision struct {
+	Hash     core.SchemaHash
+	TypeIRIs []string
+}
+
+// SchemaHashReport is the result of CheckSchemaHashes
+type SchemaHashReport struct {
+	// Hashes are schema hashes of all types ordered by hash and then by
+	// type IRI
+	Hashes []SchemaHashInfo
+	// Collisions are schema hashes shared by different type IRIs, ordered by
+	// hash
+	Collisions []SchemaHashCollision
+	// Reserved are types with the schema hash that can't be used for
+	// credentials: the zero hash and the hash of the auth claim
+	Reserved []SchemaHashInfo
+}
+
+// OK returns true if there are no collisions and reserved hashes
+func (r SchemaHashReport) OK() bool {
+	return len(r.Collisions) == 0 && len(r.Reserved) == 0
+}
+
+// CheckSchemaHashes calculates core claim schema hashes of all types the
+// same way as ToCoreClaim does and reports collisions between different
+// type IRIs and hashes that are reserved. The same type IRI used with
+// different contexts is reported once. Remote contexts are loaded with the
+// documentLoader, or with the default loader of the merklize package if it
+// is nil.
+func CheckSchemaHashes(types []SchemaType,
+	documentLoader ld.DocumentLoader) (SchemaHashReport, error) {
+
+	jsonLDOpts := merklize.Options{DocumentLoader: documentLoader}.
+		JSONLDOptions()
+
+	var report SchemaHashReport
+	seenIRIs := make(map[string]bool)
+	for _, tp := range types {
+		typeIRI, err := expandTypeIRI(tp, jsonLDOpts)
+		if err != nil {
+			return SchemaHashReport{}, err
+		}
+		if seenIRIs[typeIRI] {
+			continue
+		}
+		seenIRIs[typeIRI] = true
+
+		report.Hashes = append(report.Hashes, SchemaHashInfo{
+			SchemaType: tp,
+			TypeIRI:    typeIRI,
+			Hash:       utils.CreateSchemaHash([]byte(typeIRI)),
+		})
+	}
+
+	sortSchemaHashes(report.Hashes)
+	report.Collisions = schemaHashCollisions(report.Hashes)
+	for _, h := range report.Hashes {
+		if h.Hash == (core.SchemaHash{}) || h.Hash == core.AuthSchemaHash {
+			report.Reserved = append(report.Reserved, h)
+		}
+	}
+
+	return report, nil
+}
+
+func sortSchemaHashes(hashes []SchemaHashInfo) {
+	sort.Slice(hashes, func(i, j int) bool {
+		c := bytes.Compare(hashes[i].Hash[:], hashes[j].Hash[:])
+		if c != 0 {
+			return c < 0
+		}
+		return hashes[i].TypeIRI < hashes[j].TypeIRI
+	})
+}
+
+// schemaHashCollisions expects hashes sorted with sortSchemaHashes
+func schemaHashCollisions(hashes []SchemaHashInfo) []SchemaHashCollision {
+	var collisions []SchemaHashCollision
+	for i := 0; i < len(hashes); {
+		j := i + 1
+		for j < len(hashes) && hashes[j].Hash == hashes[i].Hash {
+			j++
+		}
+		if j-i > 1 {
+			c := SchemaHashCollision{Hash: hashes[i].Hash}
+			for _, h := range hashes[i:j] {
+				c.TypeIRIs = append(c.TypeIRIs, h.TypeIRI)
+			}
+			collisions = append(collisions, c)
+		}
+		i = j
+	}
+	return collisions
+}
+
+func expandTypeIRI(tp SchemaType, opts *ld.JsonLdOptions) (string, error) {
+	if tp.Type == "" {
+		return "", errors.New("type is empty")
+	}
+	ldCtx, err := ld.NewContext(nil, opts).Parse(anySlice(tp.Context))
+	if err != nil {
+		return "", err
+	}
+	typeIRI, err := ldCtx.ExpandIri(tp.Type, false, true, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	if !ld.IsAbsoluteIri(typeIRI) {
+		return "", errors.Errorf("type %v is not defined in the context",
+			tp.Type)
+	}
+	return typeIRI, nil
+}
@@ -0,0 +1,66 @@
+package verifiable
+
+import (
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	tst "github.com/iden3/go-schema-processor/v2/testing"
+	"github.com/iden3/go-schema-processor/v2/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSchemaHashes(t *testing.T) {
+	defer tst.MockHTTPClient(t, map[string]string{
+		"https://www.w3.org/2018/credentials/v1":                                                         "../merklize/testdata/httpresp/credentials-v1.jsonld",
+		"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld": "../merklize/testdata/httpresp/kyc-v3.json-ld",
+	}, tst.IgnoreUntouchedURLs())()
+
+	kycCtx := []string{
+		"https://www.w3.org/2018/credentials/v1",
+		"https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld",
+	}
+	const kycPrefix = "https://raw.githubusercontent.com/iden3/claim-schema-vocab/main/schemas/json-ld/kyc-v3.json-ld#"
+
+	report, err := CheckSchemaHashes([]SchemaType{
+		{Context: kycCtx, Type: "KYCAgeCredential"},
+		{Context: kycCtx, Type: "KYCCountryOfResidenceCredential"},
+		// the same type IRI is reported once
+		{Context: kycCtx, Type: kycPrefix + "KYCAgeCredential"},
+	}, nil)
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	require.Len(t, report.Hashes, 2)
+
+	byIRI := make(map[string]core.SchemaHash)
+	for _, h := range report.Hashes {
+		byIRI[h.TypeIRI] = h.Hash
+	}
+	require.Equal(t, utils.CreateSchemaHash([]byte(kycPrefix+"KYCAgeCredential")),
+		byIRI[kycPrefix+"KYCAgeCredential"])
+	require.Contains(t, byIRI, kycPrefix+"KYCCountryOfResidenceCredential")
+	require.True(t, string(report.Hashes[0].Hash[:]) <
+		string(report.Hashes[1].Hash[:]))
+
+	_, err = CheckSchemaHashes([]SchemaType{
+		{Context: kycCtx, Type: "UnknownCredential"}}, nil)
+	require.EqualError(t, err,
+		"type UnknownCredential is not defined in the context")
+}
+
+func TestSchemaHashCollisions(t *testing.T) {
+	h1 := core.SchemaHash{1}
+	h2 := core.SchemaHash{2}
+	hashes := []SchemaHashInfo{
+		{TypeIRI: "urn:c", Hash: h2},
+		{TypeIRI: "urn:b", Hash: h1},
+		{TypeIRI: "urn:d", Hash: core.SchemaHash{3}},
+		{TypeIRI: "urn:a", Hash: h2},
+		{TypeIRI: "urn:e", Hash: h1},
+	}
+	sortSchemaHashes(hashes)
+	require.Equal(t, []SchemaHashCollision{
+		{Hash: h1, TypeIRIs: []string{"urn:b", "urn:e"}},
+		{Hash: h2, TypeIRIs: []string{"urn:a", "urn:c"}},
+	}, schemaHashCollisions(hashes))
+	require.Nil(t, schemaHashCollisions(hashes[4:]))
+}
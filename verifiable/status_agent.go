@@ -0,0 +1,291 @@
+package verifiable
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// RevocationStatusRequestMessageType is the iden3comm message type of
+	// the request of the revocation status to the agent
+	RevocationStatusRequestMessageType = "https://iden3-communication.io/revocation/1.0/request-status"
+	// RevocationStatusResponseMessageType is the iden3comm message type of
+	// the revocation status response of the agent
+	RevocationStatusResponseMessageType = "https://iden3-communication.io/revocation/1.0/status"
+
+	// MediaTypePlainMessage is the iden3comm media type of unpacked messages
+	MediaTypePlainMessage = "application/iden3comm-plain-json"
+
+	defaultAgentMessageTTL   = 5 * time.Minute
+	defaultAgentMaxClockSkew = time.Minute
+)
+
+// ErrInvalidAgentResponse is returned by AgentResolver and
+// ValidateRevocationStatusResponse when the response of the agent does not
+// correspond to the request, is stale or expired
+var ErrInvalidAgentResponse = errors.New("invalid agent response")
+
+// AgentMessage is the iden3comm message exchanged with the agent
+type AgentMessage struct {
+	ID          string          `json:"id"`
+	Typ         string          `json:"typ,omitempty"`
+	Type        string          `json:"type"`
+	ThreadID    string          `json:"thid,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	From        string          `json:"from,omitempty"`
+	To          string          `json:"to,omitempty"`
+	CreatedTime *int64          `json:"created_time,omitempty"`
+	ExpiresTime *int64          `json:"expires_time,omitempty"`
+}
+
+// RevocationStatusRequestBody is the body of the revocation status request
+type RevocationStatusRequestBody struct {
+	RevocationNonce uint64 `json:"revocation_nonce"`
+}
+
+// AgentPacker packs requests to the agent and unpacks its responses, e.g.
+// with the JWS or anoncrypt envelopes of iden3comm. Pack returns the media
+// type of the packed message, which is sent as the Content-Type.
+type AgentPacker interface {
+	Pack(ctx context.Context, msg []byte) ([]byte, string, error)
+	Unpack(ctx context.Context, envelope []byte) ([]byte, error)
+}
+
+// NewRevocationStatusRequest returns the revocation status request from the
+// user to the issuer with a random ID, used as the thread ID, and the
+// expiration after ttl (5 minutes if zero).
+func NewRevocationStatusRequest(from, to string, revocationNonce uint64,
+	ttl time.Duration, now time.Time) (AgentMessage, error) {
+
+	if ttl <= 0 {
+		ttl = defaultAgentMessageTTL
+	}
+	id, err := newMessageID()
+	if err != nil {
+		return AgentMessage{}, err
+	}
+	body, err := json.Marshal(RevocationStatusRequestBody{
+		RevocationNonce: revocationNonce,
+	})
+	if err != nil {
+		return AgentMessage{}, errors.WithStack(err)
+	}
+
+	createdTime := now.Unix()
+	expiresTime := now.Add(ttl).Unix()
+	return AgentMessage{
+		ID:          id,
+		Typ:         MediaTypePlainMessage,
+		Type:        RevocationStatusRequestMessageType,
+		ThreadID:    id,
+		Body:        body,
+		From:        from,
+		To:          to,
+		CreatedTime: &createdTime,
+		ExpiresTime: &expiresTime,
+	}, nil
+}
+
+// ValidateRevocationStatusResponse checks that the response belongs to the
+// thread of the request, is exchanged between the same parties and is
+// neither expired nor stale: it should be received before the request
+// expires and created not earlier than the request, with maxClockSkew
+// allowed (1 minute if zero). Returns the revocation status of the response
+// body.
+func ValidateRevocationStatusResponse(req, resp AgentMessage,
+	maxClockSkew time.Duration, now time.Time) (RevocationStatus, error) {
+
+	if maxClockSkew <= 0 {
+		maxClockSkew = defaultAgentMaxClockSkew
+	}
+
+	var out RevocationStatus
+	if resp.Type != RevocationStatusResponseMessageType {
+		return out, errors.Wrapf(ErrInvalidAgentResponse,
+			"unexpected message type %q", resp.Type)
+	}
+	if resp.ID == "" || resp.ID == req.ID {
+		return out, errors.Wrap(ErrInvalidAgentResponse,
+			"response reuses the request ID")
+	}
+	threadID := req.ThreadID
+	if threadID == "" {
+		threadID = req.ID
+	}
+	if resp.ThreadID != threadID {
+		return out, errors.Wrapf(ErrInvalidAgentResponse,
+			"thread ID %q does not match the request thread ID %q",
+			resp.ThreadID, threadID)
+	}
+	if req.To != "" && resp.From != req.To {
+		return out, errors.Wrapf(ErrInvalidAgentResponse,
+			"response is from %q, not %q", resp.From, req.To)
+	}
+	if req.From != "" && resp.To != "" && resp.To != req.From {
+		return out, errors.Wrapf(ErrInvalidAgentResponse,
+			"response is to %q, not %q", resp.To, req.From)
+	}
+
+	skew := int64(maxClockSkew / time.Second)
+	nowUnix := now.Unix()
+	if req.ExpiresTime != nil && nowUnix > *req.ExpiresTime+skew {
+		return out, errors.Wrap(ErrInvalidAgentResponse, "request expired")
+	}
+	if resp.ExpiresTime != nil && nowUnix > *resp.ExpiresTime+skew {
+		return out, errors.Wrap(ErrInvalidAgentResponse, "response expired")
+	}
+	if resp.CreatedTime != nil {
+		if req.CreatedTime != nil && *resp.CreatedTime < *req.CreatedTime-skew {
+			return out, errors.Wrap(ErrInvalidAgentResponse,
+				"response is created before the request")
+		}
+		if *resp.CreatedTime > nowUnix+skew {
+			return out, errors.Wrap(ErrInvalidAgentResponse,
+				"response is created in the future")
+		}
+	}
+
+	if len(resp.Body) == 0 {
+		return out, errors.Wrap(ErrInvalidAgentResponse, "empty body")
+	}
+	err := json.Unmarshal(resp.Body, &out)
+	if err != nil {
+		return out, errors.Wrap(ErrInvalidAgentResponse, err.Error())
+	}
+	return out, nil
+}
+
+// AgentResolver resolves Iden3commRevocationStatusV1 statuses by sending
+// the revocation status request to the agent of the issuer. The sender is
+// the DID put in the context with WithUserDID and the recipient is the
+// issuer DID put with WithIssuerDID. The zero value sends plain messages
+// with http.DefaultClient.
+type AgentResolver struct {
+	// HTTPClient is used to send requests. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+	// Packer packs requests and unpacks responses. If nil, messages are sent
+	// and received unpacked.
+	Packer AgentPacker
+	// MessageTTL is the expiration of requests. If zero, it is 5 minutes.
+	MessageTTL time.Duration
+	// MaxClockSkew is the allowed difference between the clocks of the user
+	// and the agent. If zero, it is 1 minute.
+	MaxClockSkew time.Duration
+	// MaxResponseBytes limits the size of the response body. If zero, the
+	// limit is 16 KiB.
+	MaxResponseBytes int64
+
+	now func() time.Time
+}
+
+func (r AgentResolver) Resolve(ctx context.Context,
+	credentialStatus CredentialStatus) (out RevocationStatus, err error) {
+
+	now := time.Now
+	if r.now != nil {
+		now = r.now
+	}
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	maxBytes := r.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = limitReaderBytes
+	}
+
+	var from, to string
+	if userDID := GetUserDID(ctx); userDID != nil {
+		from = userDID.String()
+	}
+	if issuerDID := GetIssuerDID(ctx); issuerDID != nil {
+		to = issuerDID.String()
+	}
+	if from == "" || to == "" {
+		return out, errors.New(
+			"user and issuer DIDs are required in the context")
+	}
+
+	req, err := NewRevocationStatusRequest(from, to,
+		credentialStatus.RevocationNonce, r.MessageTTL, now())
+	if err != nil {
+		return out, err
+	}
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return out, errors.WithStack(err)
+	}
+	mediaType := MediaTypePlainMessage
+	if r.Packer != nil {
+		reqBytes, mediaType, err = r.Packer.Pack(ctx, reqBytes)
+		if err != nil {
+			return out, errors.WithMessage(err, "failed to pack request")
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		credentialStatus.ID, bytes.NewReader(reqBytes))
+	if err != nil {
+		return out, err
+	}
+	httpReq.Header.Set("Content-Type", mediaType)
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return out, errors.Wrap(ErrIssuerUnreachable, err.Error())
+	}
+	defer func() {
+		err2 := httpResp.Body.Close()
+		if err2 != nil && err == nil {
+			err = err2
+		}
+	}()
+
+	statusOK := httpResp.StatusCode >= 200 && httpResp.StatusCode < 300
+	if !statusOK {
+		return out, errors.Wrapf(ErrIssuerUnreachable,
+			"unexpected status code: %d", httpResp.StatusCode)
+	}
+
+	limitReader := &io.LimitedReader{R: httpResp.Body, N: maxBytes + 1}
+	respData, err := io.ReadAll(limitReader)
+	if err != nil {
+		return out, errors.Wrap(ErrIssuerUnreachable, err.Error())
+	}
+	if int64(len(respData)) > maxBytes {
+		return out, errors.Wrapf(ErrInvalidAgentResponse,
+			"response body size exceeds the limit of %d", maxBytes)
+	}
+	if r.Packer != nil {
+		respData, err = r.Packer.Unpack(ctx, respData)
+		if err != nil {
+			return out, errors.Wrap(ErrInvalidAgentResponse, err.Error())
+		}
+	}
+
+	var resp AgentMessage
+	err = json.Unmarshal(respData, &resp)
+	if err != nil {
+		return out, errors.Wrap(ErrInvalidAgentResponse, err.Error())
+	}
+	return ValidateRevocationStatusResponse(req, resp, r.MaxClockSkew, now())
+}
+
+// newMessageID returns a random UUID v4
+func newMessageID() (string, error) {
+	var b [16]byte
+	_, err := rand.Read(b[:])
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return formatUUID(b), nil
+}
@@ -0,0 +1,214 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+// base64Packer is the test packer wrapping messages in base64
+type base64Packer struct{}
+
+func (base64Packer) Pack(_ context.Context, msg []byte) ([]byte, string,
+	error) {
+
+	return []byte(base64.StdEncoding.EncodeToString(msg)),
+		"application/test-base64", nil
+}
+
+func (base64Packer) Unpack(_ context.Context, envelope []byte) ([]byte,
+	error) {
+
+	return base64.StdEncoding.DecodeString(string(envelope))
+}
+
+func TestAgentResolver(t *testing.T) {
+	revStatusBytes, err := os.ReadFile(
+		"testdata/verifycred/issuer-state-response.json")
+	require.NoError(t, err)
+
+	userDID, err := w3c.ParseDID(
+		"did:iden3:polygon:mumbai:wyFiV4w71QgWPn6bYLsZoysFay66gKtVa9kfu6yMZ")
+	require.NoError(t, err)
+	issuerDID, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qJp131YoXVu8iLNGfL3TkQAWEr3pqimh2iaPgH3BJ")
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	packed := false
+	// respond modifies the response built for the request
+	var respond func(req AgentMessage, resp *AgentMessage)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			var reqBytes []byte
+			var err error
+			if packed {
+				require.Equal(t, "application/test-base64",
+					r.Header.Get("Content-Type"))
+				var envelope []byte
+				envelope, err = io.ReadAll(r.Body)
+				require.NoError(t, err)
+				reqBytes, err = base64Packer{}.Unpack(r.Context(), envelope)
+			} else {
+				require.Equal(t, MediaTypePlainMessage,
+					r.Header.Get("Content-Type"))
+				reqBytes, err = io.ReadAll(r.Body)
+			}
+			require.NoError(t, err)
+
+			var req AgentMessage
+			require.NoError(t, json.Unmarshal(reqBytes, &req))
+			require.Equal(t, RevocationStatusRequestMessageType, req.Type)
+			require.Equal(t, req.ID, req.ThreadID)
+			require.JSONEq(t, `{"revocation_nonce":3972757}`,
+				string(req.Body))
+
+			created := now.Unix()
+			resp := AgentMessage{
+				ID:          "resp-" + req.ID,
+				Typ:         MediaTypePlainMessage,
+				Type:        RevocationStatusResponseMessageType,
+				ThreadID:    req.ThreadID,
+				Body:        revStatusBytes,
+				From:        req.To,
+				To:          req.From,
+				CreatedTime: &created,
+			}
+			if respond != nil {
+				respond(req, &resp)
+			}
+			respBytes, err := json.Marshal(resp)
+			require.NoError(t, err)
+			if packed {
+				respBytes, _, err = base64Packer{}.Pack(r.Context(),
+					respBytes)
+				require.NoError(t, err)
+			}
+			_, _ = w.Write(respBytes)
+		}))
+	defer srv.Close()
+
+	ctx := WithIssuerDID(WithUserDID(context.Background(), userDID),
+		issuerDID)
+	credStatus := CredentialStatus{ID: srv.URL + "/agent",
+		Type: Iden3commRevocationStatusV1, RevocationNonce: 3972757}
+	resolver := AgentResolver{
+		HTTPClient: srv.Client(),
+		now:        func() time.Time { return now },
+	}
+
+	revStatus, err := resolver.Resolve(ctx, credStatus)
+	require.NoError(t, err)
+	require.Equal(t,
+		"95e4f8437be5d50a569bb532713110e4f5d2ac97765fae54041dddae9638a119",
+		*revStatus.Issuer.State)
+
+	t.Run("packer", func(t *testing.T) {
+		packed = true
+		defer func() { packed = false }()
+		resolver := resolver
+		resolver.Packer = base64Packer{}
+		_, err := resolver.Resolve(ctx, credStatus)
+		require.NoError(t, err)
+	})
+
+	t.Run("no DIDs in context", func(t *testing.T) {
+		_, err := resolver.Resolve(context.Background(), credStatus)
+		require.EqualError(t, err,
+			"user and issuer DIDs are required in the context")
+	})
+
+	invalidResponses := []struct {
+		name    string
+		modify  func(req AgentMessage, resp *AgentMessage)
+		wantErr string
+	}{
+		{
+			name: "wrong thread",
+			modify: func(_ AgentMessage, resp *AgentMessage) {
+				resp.ThreadID = "other"
+			},
+			wantErr: "does not match the request thread ID",
+		},
+		{
+			name: "replayed request ID",
+			modify: func(req AgentMessage, resp *AgentMessage) {
+				resp.ID = req.ID
+			},
+			wantErr: "response reuses the request ID",
+		},
+		{
+			name: "wrong type",
+			modify: func(_ AgentMessage, resp *AgentMessage) {
+				resp.Type = RevocationStatusRequestMessageType
+			},
+			wantErr: "unexpected message type",
+		},
+		{
+			name: "wrong sender",
+			modify: func(req AgentMessage, resp *AgentMessage) {
+				resp.From = req.From
+			},
+			wantErr: "response is from",
+		},
+		{
+			name: "stale response",
+			modify: func(_ AgentMessage, resp *AgentMessage) {
+				created := now.Add(-time.Hour).Unix()
+				resp.CreatedTime = &created
+			},
+			wantErr: "response is created before the request",
+		},
+		{
+			name: "expired response",
+			modify: func(_ AgentMessage, resp *AgentMessage) {
+				expires := now.Add(-time.Hour).Unix()
+				resp.ExpiresTime = &expires
+			},
+			wantErr: "response expired",
+		},
+	}
+	for _, tc := range invalidResponses {
+		t.Run(tc.name, func(t *testing.T) {
+			respond = tc.modify
+			defer func() { respond = nil }()
+			_, err := resolver.Resolve(ctx, credStatus)
+			require.ErrorIs(t, err, ErrInvalidAgentResponse)
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}
+
+func TestValidateRevocationStatusResponse_RequestExpired(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	req, err := NewRevocationStatusRequest("did:example:user",
+		"did:example:issuer", 1, time.Minute, now)
+	require.NoError(t, err)
+	require.Len(t, req.ID, 36)
+	require.Equal(t, now.Add(time.Minute).Unix(), *req.ExpiresTime)
+
+	resp := AgentMessage{
+		ID:       "resp",
+		Type:     RevocationStatusResponseMessageType,
+		ThreadID: req.ID,
+		From:     "did:example:issuer",
+		Body:     json.RawMessage(`{"issuer":{},"mtp":{"existence":false}}`),
+	}
+	_, err = ValidateRevocationStatusResponse(req, resp, time.Second,
+		now.Add(30*time.Second))
+	require.NoError(t, err)
+
+	_, err = ValidateRevocationStatusResponse(req, resp, time.Second,
+		now.Add(2*time.Minute))
+	require.ErrorIs(t, err, ErrInvalidAgentResponse)
+	require.ErrorContains(t, err, "request expired")
+}
@@ -3,27 +3,69 @@ package verifiable
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/pkg/errors"
 )
 
+// ErrIssuerUnreachable is returned by IssuerResolver when the status can't be
+// fetched from the issuer: the request failed or the issuer responded with a
+// non-2xx status code
+var ErrIssuerUnreachable = errors.New("issuer is unreachable")
+
+// ErrInvalidIssuerResponse is returned by IssuerResolver when the issuer
+// responded with a malformed or too large revocation status
+var ErrInvalidIssuerResponse = errors.New("invalid issuer response")
+
+// IssuerResolver resolves SparseMerkleTreeProof statuses by fetching the
+// revocation status from the issuer node by the status ID. The zero value
+// uses http.DefaultClient and the default size limit without validation of
+// the response.
 type IssuerResolver struct {
+	// HTTPClient is used to fetch the status. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+	// Header is added to requests, e.g. the Authorization header of the
+	// issuer node API
+	Header http.Header
+	// MaxResponseBytes limits the size of the response body. If zero, the
+	// limit is 16 KiB.
+	MaxResponseBytes int64
+	// ValidateResponse enables validation of the response: the state and the
+	// roots of the issuer should be hex encoded hashes and the MTP should be
+	// present.
+	ValidateResponse bool
 }
 
 const limitReaderBytes = 16 * 1024
 
-func (IssuerResolver) Resolve(ctx context.Context,
+func (r IssuerResolver) Resolve(ctx context.Context,
 	credentialStatus CredentialStatus) (out RevocationStatus, err error) {
 
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	maxBytes := r.MaxResponseBytes
+	if maxBytes <= 0 {
+		maxBytes = limitReaderBytes
+	}
+
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet,
 		credentialStatus.ID, http.NoBody)
 	if err != nil {
 		return out, err
 	}
-	httpResp, err := http.DefaultClient.Do(httpReq)
+	for k, vs := range r.Header {
+		for _, v := range vs {
+			httpReq.Header.Add(k, v)
+		}
+	}
+	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
-		return out, err
+		return out, errors.Wrap(ErrIssuerUnreachable, err.Error())
 	}
 	defer func() {
 		err2 := httpResp.Body.Close()
@@ -34,26 +76,80 @@ func (IssuerResolver) Resolve(ctx context.Context,
 
 	statusOK := httpResp.StatusCode >= 200 && httpResp.StatusCode < 300
 	if !statusOK {
-		return out, fmt.Errorf("unexpected status code: %d",
-			httpResp.StatusCode)
+		return out, errors.Wrapf(ErrIssuerUnreachable,
+			"unexpected status code: %d", httpResp.StatusCode)
 	}
 
-	limitReader := &io.LimitedReader{R: httpResp.Body, N: limitReaderBytes}
-
+	limitReader := &io.LimitedReader{R: httpResp.Body, N: maxBytes + 1}
 	respData, err := io.ReadAll(limitReader)
 	if err != nil {
-		return out, err
+		return out, errors.Wrap(ErrIssuerUnreachable, err.Error())
 	}
 
 	// Check if the body size exceeds the limit
-	if limitReader.N <= 0 {
-		return out, fmt.Errorf("response body size exceeds the limit of %d",
-			limitReaderBytes)
+	if int64(len(respData)) > maxBytes {
+		return out, errors.Wrapf(ErrInvalidIssuerResponse,
+			"response body size exceeds the limit of %d", maxBytes)
+	}
+
+	if r.ValidateResponse {
+		err = validateRevocationStatusResponse(respData)
+		if err != nil {
+			return out, errors.Wrap(ErrInvalidIssuerResponse, err.Error())
+		}
 	}
 
 	err = json.Unmarshal(respData, &out)
 	if err != nil {
-		return out, err
+		return out, errors.Wrap(ErrInvalidIssuerResponse, err.Error())
 	}
 	return out, nil
 }
+
+// validateRevocationStatusResponse checks the format of the revocation status
+// of the issuer node. The consistency of the state and the proof is checked
+// by ValidateCredentialStatus.
+func validateRevocationStatusResponse(respData []byte) error {
+	var resp struct {
+		Issuer *TreeState       `json:"issuer"`
+		MTP    *json.RawMessage `json:"mtp"`
+	}
+	err := json.Unmarshal(respData, &resp)
+	if err != nil {
+		return err
+	}
+	if resp.Issuer == nil {
+		return errors.New("issuer state is missing")
+	}
+	if resp.MTP == nil {
+		return errors.New("mtp is missing")
+	}
+	var mtp merkletree.Proof
+	err = json.Unmarshal(*resp.MTP, &mtp)
+	if err != nil {
+		return errors.Wrap(err, "invalid mtp")
+	}
+
+	if resp.Issuer.State == nil {
+		return errors.New("issuer state is missing")
+	}
+	roots := []struct {
+		name  string
+		value *string
+	}{
+		{"state", resp.Issuer.State},
+		{"claimsTreeRoot", resp.Issuer.ClaimsTreeRoot},
+		{"revocationTreeRoot", resp.Issuer.RevocationTreeRoot},
+		{"rootOfRoots", resp.Issuer.RootOfRoots},
+	}
+	for _, root := range roots {
+		if root.value == nil {
+			continue
+		}
+		_, err = merkletree.NewHashFromHex(*root.value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %v", root.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package verifiable
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIssuerResolver(t *testing.T) {
+	validResp, err := os.ReadFile(
+		"testdata/verifycred/issuer-state-response.json")
+	require.NoError(t, err)
+
+	var respBody []byte
+	respCode := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(respCode)
+			_, _ = w.Write(respBody)
+		}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	credStatus := CredentialStatus{ID: srv.URL + "/status/0",
+		Type: SparseMerkleTreeProof}
+	resolver := IssuerResolver{
+		HTTPClient:       srv.Client(),
+		Header:           http.Header{"Authorization": {"Bearer token"}},
+		ValidateResponse: true,
+	}
+
+	respBody = validResp
+	revStatus, err := resolver.Resolve(ctx, credStatus)
+	require.NoError(t, err)
+	require.Equal(t,
+		"95e4f8437be5d50a569bb532713110e4f5d2ac97765fae54041dddae9638a119",
+		*revStatus.Issuer.State)
+	require.False(t, revStatus.MTP.Existence)
+
+	t.Run("unauthorized", func(t *testing.T) {
+		resolver := resolver
+		resolver.Header = nil
+		_, err := resolver.Resolve(ctx, credStatus)
+		require.ErrorIs(t, err, ErrIssuerUnreachable)
+		require.ErrorContains(t, err, "unexpected status code: 401")
+	})
+
+	t.Run("network failure", func(t *testing.T) {
+		credStatus := credStatus
+		credStatus.ID = "http://127.0.0.1:0/status/0"
+		_, err := resolver.Resolve(ctx, credStatus)
+		require.ErrorIs(t, err, ErrIssuerUnreachable)
+	})
+
+	t.Run("size limit", func(t *testing.T) {
+		resolver := resolver
+		resolver.MaxResponseBytes = int64(len(validResp) - 1)
+		_, err := resolver.Resolve(ctx, credStatus)
+		require.ErrorIs(t, err, ErrInvalidIssuerResponse)
+		require.ErrorContains(t, err, "response body size exceeds the limit")
+	})
+
+	invalidResponses := map[string]string{
+		"not json":       `<html></html>`,
+		"missing mtp":    `{"issuer":{"state":"95e4f8437be5d50a569bb532713110e4f5d2ac97765fae54041dddae9638a119"}}`,
+		"missing state":  `{"issuer":{},"mtp":{"existence":false,"siblings":[]}}`,
+		"invalid root":   `{"issuer":{"state":"95e4f8437be5d50a569bb532713110e4f5d2ac97765fae54041dddae9638a119","claimsTreeRoot":"xyz"},"mtp":{"existence":false,"siblings":[]}}`,
+		"invalid proof":  `{"issuer":{"state":"95e4f8437be5d50a569bb532713110e4f5d2ac97765fae54041dddae9638a119"},"mtp":{"existence":false,"siblings":["xyz"]}}`,
+		"missing issuer": `{"mtp":{"existence":false,"siblings":[]}}`,
+	}
+	for name, body := range invalidResponses {
+		t.Run(name, func(t *testing.T) {
+			respBody = []byte(body)
+			defer func() { respBody = validResp }()
+			_, err := resolver.Resolve(ctx, credStatus)
+			require.ErrorIs(t, err, ErrInvalidIssuerResponse)
+		})
+	}
+
+	t.Run("no validation", func(t *testing.T) {
+		respBody = []byte(strings.Replace(string(validResp),
+			`"9af7b27d`, `"xyz`, 1))
+		defer func() { respBody = validResp }()
+		resolver := resolver
+		resolver.ValidateResponse = false
+		_, err := resolver.Resolve(ctx, credStatus)
+		require.NoError(t, err)
+	})
+
+	t.Run("server error", func(t *testing.T) {
+		respCode = http.StatusInternalServerError
+		defer func() { respCode = http.StatusOK }()
+		_, err := resolver.Resolve(ctx, credStatus)
+		require.ErrorIs(t, err, ErrIssuerUnreachable)
+	})
+}
@@ -0,0 +1,404 @@
+package verifiable
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"github.com/pkg/errors"
+)
+
+// Status purposes of StatusList2021
+const (
+	StatusPurposeRevocation = "revocation"
+	StatusPurposeSuspension = "suspension"
+)
+
+// StatusList2021MinSize is the minimal number of entries in the status list
+// required by the specification for the group privacy (16KB bitstring)
+const StatusList2021MinSize = 131072
+
+const (
+	statusList2021CredentialType = "StatusList2021Credential"
+	statusList2021Type           = "StatusList2021"
+
+	// limits protect from huge credentials and gzip bombs
+	limitStatusListCredentialBytes = 1024 * 1024
+	limitStatusListBytes           = 16 * 1024 * 1024
+)
+
+// StatusList2021 is the bitstring of the status list. Entry with index 0 is
+// the most significant bit of the first byte.
+type StatusList2021 struct {
+	bits []byte
+}
+
+// NewStatusList2021 creates the status list with all the entries unset. If
+// size is less than StatusList2021MinSize, the minimal size is used.
+func NewStatusList2021(size int) *StatusList2021 {
+	if size < StatusList2021MinSize {
+		size = StatusList2021MinSize
+	}
+	return &StatusList2021{bits: make([]byte, (size+7)/8)}
+}
+
+// DecodeStatusList2021 decodes the encodedList value of the status list
+// credential: base64url encoded GZIP compressed bitstring.
+func DecodeStatusList2021(encodedList string) (*StatusList2021, error) {
+	compressed, err := base64.RawURLEncoding.DecodeString(
+		strings.TrimRight(encodedList, "="))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid status list encoding")
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid status list compression")
+	}
+	limitReader := &io.LimitedReader{R: r, N: limitStatusListBytes + 1}
+	bits, err := io.ReadAll(limitReader)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid status list compression")
+	}
+	if len(bits) > limitStatusListBytes {
+		return nil, errors.Errorf("status list size exceeds the limit of %d "+
+			"bytes", limitStatusListBytes)
+	}
+
+	return &StatusList2021{bits: bits}, nil
+}
+
+// Len returns the number of entries in the status list
+func (l *StatusList2021) Len() int {
+	return len(l.bits) * 8
+}
+
+// Status returns true if the entry with the index is set
+func (l *StatusList2021) Status(index int) (bool, error) {
+	if index < 0 || index >= l.Len() {
+		return false, errors.Errorf("status list index %d is out of range",
+			index)
+	}
+	return l.bits[index/8]&(0x80>>(index%8)) != 0, nil
+}
+
+// SetStatus sets or unsets the entry with the index
+func (l *StatusList2021) SetStatus(index int, status bool) error {
+	if index < 0 || index >= l.Len() {
+		return errors.Errorf("status list index %d is out of range", index)
+	}
+	if status {
+		l.bits[index/8] |= 0x80 >> (index % 8)
+	} else {
+		l.bits[index/8] &^= 0x80 >> (index % 8)
+	}
+	return nil
+}
+
+// Encode compresses the status list with GZIP and encodes it with base64url
+// without padding to be used as encodedList of the status list credential.
+func (l *StatusList2021) Encode() (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(l.bits)
+	if err != nil {
+		return "", err
+	}
+	err = w.Close()
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// NewStatusList2021Credential creates the unsigned status list credential of
+// the issuer to be published at id URL.
+func NewStatusList2021Credential(id, issuer, statusPurpose string,
+	list *StatusList2021) (*W3CCredential, error) {
+
+	encodedList, err := list.Encode()
+	if err != nil {
+		return nil, err
+	}
+
+	issuanceDate := time.Now().UTC()
+	return &W3CCredential{
+		ID: id,
+		Context: []string{JSONLDSchemaW3CCredential2018,
+			JSONLDSchemaStatusList2021},
+		Type: []string{TypeW3CVerifiableCredential,
+			statusList2021CredentialType},
+		IssuanceDate: &issuanceDate,
+		Issuer:       issuer,
+		CredentialSubject: map[string]any{
+			"id":            id + "#list",
+			"type":          statusList2021Type,
+			"statusPurpose": statusPurpose,
+			"encodedList":   encodedList,
+		},
+	}, nil
+}
+
+// NewStatusList2021Entry creates the credential status pointing to the entry
+// with the index in the status list credential
+func NewStatusList2021Entry(statusListCredential, statusPurpose string,
+	index int) CredentialStatus {
+
+	return CredentialStatus{
+		ID:                   fmt.Sprintf("%v#%v", statusListCredential, index),
+		Type:                 StatusList2021Entry,
+		StatusPurpose:        statusPurpose,
+		StatusListIndex:      strconv.Itoa(index),
+		StatusListCredential: statusListCredential,
+	}
+}
+
+// StatusList2021Resolver resolves StatusList2021Entry credential status. It
+// downloads the status list credential, verifies its proof and checks the
+// entry. Only the proof types supported by VerifyProof (BJJSignature2021
+// and Iden3SparseMerkleTreeProof) are verified, status list credentials
+// without them are rejected unless SkipProofVerification is set. If the
+// issuer DID is put into the context with WithIssuerDID, it should be the
+// issuer of the status list credential.
+//
+// The result is a synthetic RevocationStatus of the revocation tree
+// containing the credential status revocation nonce only if the entry is
+// set, so it is validated by ValidateCredentialStatus as any other
+// revocation status. The credential is considered revoked for both
+// revocation and suspension purposes.
+type StatusList2021Resolver struct {
+	// HTTPClient is used to download status list credentials. If nil,
+	// http.DefaultClient is used.
+	HTTPClient *http.Client
+	// DIDResolver is used to verify the proof of the status list
+	// credential. It is required unless SkipProofVerification is set.
+	DIDResolver DIDResolver
+	// ProofVerificationOpts are passed to VerifyProof of the status list
+	// credential
+	ProofVerificationOpts []W3CProofVerificationOpt
+	// SkipProofVerification disables the verification of the status list
+	// credential proof. The status list credential is trusted as is, e.g.
+	// if it is downloaded from the trusted source or signed with the proof
+	// type the library does not support.
+	SkipProofVerification bool
+}
+
+func (r StatusList2021Resolver) Resolve(ctx context.Context,
+	credentialStatus CredentialStatus) (out RevocationStatus, err error) {
+
+	if credentialStatus.Type != StatusList2021Entry {
+		return out, errors.Errorf("unexpected credential status type: %v",
+			credentialStatus.Type)
+	}
+	index, err := strconv.Atoi(credentialStatus.StatusListIndex)
+	if err != nil || index < 0 {
+		return out, errors.Errorf("invalid status list index: %q",
+			credentialStatus.StatusListIndex)
+	}
+	if credentialStatus.StatusListCredential == "" {
+		return out, errors.New("status list credential is empty")
+	}
+
+	listVC, err := r.fetchStatusListCredential(ctx,
+		credentialStatus.StatusListCredential)
+	if err != nil {
+		return out, err
+	}
+
+	err = r.verifyStatusListProof(ctx, &listVC)
+	if err != nil {
+		return out, err
+	}
+
+	list, err := statusListFromCredential(ctx, listVC,
+		credentialStatus.StatusPurpose)
+	if err != nil {
+		return out, err
+	}
+
+	status, err := list.Status(index)
+	if err != nil {
+		return out, err
+	}
+
+	return statusListRevocationStatus(ctx, credentialStatus.RevocationNonce,
+		status)
+}
+
+func (r StatusList2021Resolver) fetchStatusListCredential(ctx context.Context,
+	url string) (vc W3CCredential, err error) {
+
+	httpClient := r.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url,
+		http.NoBody)
+	if err != nil {
+		return vc, err
+	}
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return vc, err
+	}
+	defer func() {
+		err2 := httpResp.Body.Close()
+		if err2 != nil && err == nil {
+			err = err2
+		}
+	}()
+
+	statusOK := httpResp.StatusCode >= 200 && httpResp.StatusCode < 300
+	if !statusOK {
+		return vc, fmt.Errorf("unexpected status code: %d",
+			httpResp.StatusCode)
+	}
+
+	limitReader := &io.LimitedReader{R: httpResp.Body,
+		N: limitStatusListCredentialBytes + 1}
+	respData, err := io.ReadAll(limitReader)
+	if err != nil {
+		return vc, err
+	}
+	if len(respData) > limitStatusListCredentialBytes {
+		return vc, fmt.Errorf("response body size exceeds the limit of %d",
+			limitStatusListCredentialBytes)
+	}
+
+	err = json.Unmarshal(respData, &vc)
+	return vc, err
+}
+
+// verifyStatusListProof verifies the first proof of the status list
+// credential of the type supported by VerifyProof
+func (r StatusList2021Resolver) verifyStatusListProof(ctx context.Context,
+	vc *W3CCredential) error {
+
+	if r.SkipProofVerification {
+		return nil
+	}
+	if len(vc.Proof) == 0 {
+		return errors.Wrap(ErrProofNotFound, "status list credential")
+	}
+
+	for _, p := range vc.Proof {
+		proofType := p.ProofType()
+		if proofType != BJJSignatureProofType &&
+			proofType != Iden3SparseMerkleTreeProofType {
+
+			continue
+		}
+		if r.DIDResolver == nil {
+			return errors.New("DID resolver is required to verify the " +
+				"status list credential proof")
+		}
+		err := vc.VerifyProof(ctx, proofType, r.DIDResolver,
+			r.ProofVerificationOpts...)
+		return errors.WithMessage(err, "status list credential")
+	}
+
+	return errors.Wrapf(ErrProofNotSupported,
+		"status list credential proof types %v", vc.Proof.Types())
+}
+
+func statusListFromCredential(ctx context.Context, vc W3CCredential,
+	statusPurpose string) (*StatusList2021, error) {
+
+	if !containsString(vc.Type, statusList2021CredentialType) {
+		return nil, errors.Errorf("credential is not %v",
+			statusList2021CredentialType)
+	}
+
+	if issuerDID := GetIssuerDID(ctx); issuerDID != nil &&
+		vc.Issuer != issuerDID.String() {
+
+		return nil, errors.Errorf("status list credential issuer %v is not "+
+			"the credential issuer %v", vc.Issuer, issuerDID.String())
+	}
+
+	if vc.Expiration != nil && time.Now().After(*vc.Expiration) {
+		return nil, errors.Wrap(ErrCredentialExpired,
+			"status list credential")
+	}
+
+	if tp, _ := vc.CredentialSubject["type"].(string); tp != statusList2021Type {
+		return nil, errors.Errorf("unexpected status list type: %v",
+			vc.CredentialSubject["type"])
+	}
+
+	listPurpose, _ := vc.CredentialSubject["statusPurpose"].(string)
+	if listPurpose != statusPurpose {
+		return nil, errors.Errorf("status purpose mismatch: credential "+
+			"status purpose %q != status list purpose %q", statusPurpose,
+			listPurpose)
+	}
+
+	encodedList, ok := vc.CredentialSubject["encodedList"].(string)
+	if !ok {
+		return nil, errors.New("status list encodedList is not a string")
+	}
+
+	return DecodeStatusList2021(encodedList)
+}
+
+// statusListRevocationStatus builds the revocation status of the issuer
+// with empty claims tree and roots tree, and revocation tree containing the
+// revocation nonce if revoked is true.
+func statusListRevocationStatus(ctx context.Context, revocationNonce uint64,
+	revoked bool) (out RevocationStatus, err error) {
+
+	revTree, err := merkletree.NewMerkleTree(ctx, memory.NewMemoryStorage(),
+		40)
+	if err != nil {
+		return out, err
+	}
+
+	revNonce := new(big.Int).SetUint64(revocationNonce)
+	if revoked {
+		err = revTree.Add(ctx, revNonce, big.NewInt(0))
+		if err != nil {
+			return out, err
+		}
+	}
+
+	proof, _, err := revTree.GenerateProof(ctx, revNonce, nil)
+	if err != nil {
+		return out, err
+	}
+
+	revRoot := revTree.Root()
+	state, err := poseidon.Hash([]*big.Int{big.NewInt(0), revRoot.BigInt(),
+		big.NewInt(0)})
+	if err != nil {
+		return out, err
+	}
+	stateHash, err := merkletree.NewHashFromBigInt(state)
+	if err != nil {
+		return out, err
+	}
+
+	stateHex := stateHash.Hex()
+	revRootHex := revRoot.Hex()
+	zeroHex := merkletree.HashZero.Hex()
+	out.Issuer = TreeState{
+		State:              &stateHex,
+		ClaimsTreeRoot:     &zeroHex,
+		RevocationTreeRoot: &revRootHex,
+		RootOfRoots:        &zeroHex,
+	}
+	out.MTP = *proof
+	return out, nil
+}
@@ -0,0 +1,162 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusList2021(t *testing.T) {
+	list := NewStatusList2021(10)
+	require.Equal(t, StatusList2021MinSize, list.Len())
+
+	require.NoError(t, list.SetStatus(0, true))
+	require.NoError(t, list.SetStatus(94567, true))
+	require.NoError(t, list.SetStatus(94568, true))
+	require.NoError(t, list.SetStatus(94568, false))
+	require.EqualError(t, list.SetStatus(StatusList2021MinSize, true),
+		"status list index 131072 is out of range")
+	require.Equal(t, byte(0x80), list.bits[0])
+
+	encoded, err := list.Encode()
+	require.NoError(t, err)
+	list2, err := DecodeStatusList2021(encoded)
+	require.NoError(t, err)
+	require.Equal(t, list, list2)
+
+	for idx, want := range map[int]bool{0: true, 1: false, 94567: true,
+		94568: false} {
+
+		status, err := list2.Status(idx)
+		require.NoError(t, err)
+		require.Equal(t, want, status, idx)
+	}
+
+	// example from the specification
+	list3, err := DecodeStatusList2021(
+		"H4sIAAAAAAAAA-3BMQEAAADCoPVPbQwfoAAAAAAAAAAAAAAAAAAAAIC3AYbSVKsAQAAA")
+	require.NoError(t, err)
+	require.Equal(t, StatusList2021MinSize, list3.Len())
+
+	_, err = DecodeStatusList2021("not a list")
+	require.ErrorContains(t, err, "invalid status list encoding")
+}
+
+func TestStatusList2021Resolver(t *testing.T) {
+	const issuer = "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf"
+
+	list := NewStatusList2021(0)
+	require.NoError(t, list.SetStatus(3, true))
+
+	var listVCBytes []byte
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(listVCBytes)
+		}))
+	defer srv.Close()
+
+	listVC, err := NewStatusList2021Credential(srv.URL, issuer,
+		StatusPurposeRevocation, list)
+	require.NoError(t, err)
+	listVCBytes, err = json.Marshal(listVC)
+	require.NoError(t, err)
+
+	registry := CredentialStatusResolverRegistry{}
+	registry.Register(StatusList2021Entry,
+		StatusList2021Resolver{HTTPClient: srv.Client(),
+			SkipProofVerification: true})
+
+	issuerDID, err := w3c.ParseDID(issuer)
+	require.NoError(t, err)
+	ctx := WithIssuerDID(context.Background(), issuerDID)
+
+	credStatus := NewStatusList2021Entry(srv.URL, StatusPurposeRevocation, 2)
+	credStatus.RevocationNonce = 100
+	_, err = ValidateCredentialStatus(ctx, credStatus,
+		WithValidationStatusResolverRegistry(&registry))
+	require.NoError(t, err)
+
+	credStatus = NewStatusList2021Entry(srv.URL, StatusPurposeRevocation, 3)
+	credStatus.RevocationNonce = 100
+	revStatus, err := ValidateCredentialStatus(ctx, credStatus,
+		WithValidationStatusResolverRegistry(&registry))
+	require.ErrorIs(t, err, ErrCredentialIsRevoked)
+	require.True(t, revStatus.MTP.Existence)
+
+	credStatus = NewStatusList2021Entry(srv.URL, StatusPurposeSuspension, 2)
+	_, err = ValidateCredentialStatus(ctx, credStatus,
+		WithValidationStatusResolverRegistry(&registry))
+	require.EqualError(t, err, `status purpose mismatch: credential status `+
+		`purpose "suspension" != status list purpose "revocation"`)
+
+	otherDID, err := w3c.ParseDID("did:iden3:polygon:mumbai:wuw5tydZ7AAd3efwEqPprnqjiNHR24jqruSPKmV1V")
+	require.NoError(t, err)
+	credStatus = NewStatusList2021Entry(srv.URL, StatusPurposeRevocation, 2)
+	_, err = ValidateCredentialStatus(
+		WithIssuerDID(context.Background(), otherDID), credStatus,
+		WithValidationStatusResolverRegistry(&registry))
+	require.ErrorContains(t, err, "is not the credential issuer")
+
+	credStatus.StatusListIndex = "-1"
+	_, err = ValidateCredentialStatus(ctx, credStatus,
+		WithValidationStatusResolverRegistry(&registry))
+	require.EqualError(t, err, `invalid status list index: "-1"`)
+}
+
+func TestStatusList2021Resolver_ProofVerification(t *testing.T) {
+	const issuer = "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf"
+
+	var listVCBytes []byte
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(listVCBytes)
+		}))
+	defer srv.Close()
+
+	listVC, err := NewStatusList2021Credential(srv.URL, issuer,
+		StatusPurposeRevocation, NewStatusList2021(0))
+	require.NoError(t, err)
+
+	resolve := func(t testing.TB, r StatusList2021Resolver,
+		proof CredentialProofs) error {
+
+		var err error
+		listVC.Proof = proof
+		listVCBytes, err = json.Marshal(listVC)
+		require.NoError(t, err)
+		r.HTTPClient = srv.Client()
+		credStatus := NewStatusList2021Entry(srv.URL,
+			StatusPurposeRevocation, 2)
+		_, err = r.Resolve(context.Background(), credStatus)
+		return err
+	}
+
+	// unsigned status list credential
+	err = resolve(t, StatusList2021Resolver{}, nil)
+	require.ErrorIs(t, err, ErrProofNotFound)
+	err = resolve(t, StatusList2021Resolver{SkipProofVerification: true}, nil)
+	require.NoError(t, err)
+
+	// proof type is not supported by VerifyProof
+	var unsupported CredentialProofs
+	require.NoError(t, json.Unmarshal(
+		[]byte(`[{"type":"Ed25519Signature2020"}]`), &unsupported))
+	err = resolve(t, StatusList2021Resolver{}, unsupported)
+	require.ErrorIs(t, err, ErrProofNotSupported)
+	err = resolve(t, StatusList2021Resolver{SkipProofVerification: true},
+		unsupported)
+	require.NoError(t, err)
+
+	// supported proof type requires the DID resolver
+	var bjjVC W3CCredential
+	require.NoError(t, json.Unmarshal([]byte(bjjSignatureProofCredential),
+		&bjjVC))
+	err = resolve(t, StatusList2021Resolver{}, bjjVC.Proof)
+	require.EqualError(t, err, "DID resolver is required to verify the "+
+		"status list credential proof")
+}
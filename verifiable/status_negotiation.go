@@ -0,0 +1,177 @@
+package verifiable
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoStatusMechanism is returned by NegotiateCredentialStatus when none of
+// the status mechanisms of the credential can be used
+var ErrNoStatusMechanism = errors.New(
+	"no credential status mechanism is available")
+
+// CredentialStatusAvailability may be implemented by CredentialStatusResolver
+// to report whether the status can be resolved, e.g. whether the network or
+// the contract the resolver depends on is reachable. Unavailable mechanisms
+// are skipped by NegotiateCredentialStatus without resolving.
+type CredentialStatusAvailability interface {
+	Available(ctx context.Context, credentialStatus CredentialStatus) error
+}
+
+// StatusMechanism is the credential status mechanism which may be used to
+// check the revocation of the credential
+type StatusMechanism struct {
+	// Source is the JSON path of the status in the credential, e.g.
+	// "credentialStatus" or "credentialStatus.statusIssuer"
+	Source string
+	Status CredentialStatus
+}
+
+// StatusAttempt is the result of the attempt to use the status mechanism
+type StatusAttempt struct {
+	Mechanism StatusMechanism
+	// Skipped is true if the mechanism is not tried because its resolver is
+	// not registered or is not available
+	Skipped bool
+	Err     error
+}
+
+// StatusNegotiationResult reports how the credential status was checked
+type StatusNegotiationResult struct {
+	// Used is the mechanism which status was verified. It is nil if none of
+	// mechanisms succeeded.
+	Used             *StatusMechanism
+	RevocationStatus RevocationStatus
+	// Attempts are all considered mechanisms in the order of negotiation
+	Attempts []StatusAttempt
+}
+
+// WithStatusTypePreference sets the order of status types in which
+// NegotiateCredentialStatus tries mechanisms. Mechanisms of other types are
+// tried after them in the order of the credential.
+func WithStatusTypePreference(
+	types ...CredentialStatusType) CredentialStatusValidationOption {
+
+	return func(opts *credentialStatusValidationOpts) error {
+		opts.statusTypePreference = types
+		return nil
+	}
+}
+
+// StatusMechanisms returns the status and its fallbacks (statusIssuer) in
+// the order of the credential
+func StatusMechanisms(credStatus CredentialStatus) []StatusMechanism {
+	var mechanisms []StatusMechanism
+	source := "credentialStatus"
+	status := &credStatus
+	for status != nil {
+		s := *status
+		s.StatusIssuer = nil
+		if s.RevocationNonce == 0 {
+			// statusIssuer usually omits the nonce of the primary status
+			s.RevocationNonce = credStatus.RevocationNonce
+		}
+		mechanisms = append(mechanisms, StatusMechanism{Source: source,
+			Status: s})
+		source += ".statusIssuer"
+		status = status.StatusIssuer
+	}
+	return mechanisms
+}
+
+// NegotiateCredentialStatus chooses the status mechanism of the credential
+// and validates the status with it. Mechanisms are tried in the order of
+// WithStatusTypePreference, or in the order of the credential by default.
+// Mechanisms without registered resolvers and unavailable ones (see
+// CredentialStatusAvailability) are skipped. If resolving fails, the next
+// mechanism is tried. ErrCredentialIsRevoked is returned immediately.
+//
+// The result reports the used mechanism and all attempts, even if the error
+// is returned. ErrNoStatusMechanism is returned if no mechanism succeeded.
+func NegotiateCredentialStatus(ctx context.Context,
+	credStatus CredentialStatus,
+	opts ...CredentialStatusValidationOption) (StatusNegotiationResult, error) {
+
+	var result StatusNegotiationResult
+	o := &credentialStatusValidationOpts{
+		statusResolverRegistry: DefaultCredentialStatusResolverRegistry,
+	}
+	for _, opt := range opts {
+		err := opt(o)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	mechanisms := orderStatusMechanisms(StatusMechanisms(credStatus),
+		o.statusTypePreference)
+	for i := range mechanisms {
+		m := mechanisms[i]
+		attempt := StatusAttempt{Mechanism: m}
+
+		resolver, err := o.statusResolverRegistry.Get(m.Status.Type)
+		if err == nil {
+			if a, ok := resolver.(CredentialStatusAvailability); ok {
+				err = a.Available(ctx, m.Status)
+			}
+		}
+		if err != nil {
+			attempt.Skipped = true
+			attempt.Err = err
+			result.Attempts = append(result.Attempts, attempt)
+			continue
+		}
+
+		start := time.Now()
+		var revStatus RevocationStatus
+		revStatus, err = resolver.Resolve(ctx, m.Status)
+		if err == nil {
+			err = verifyRevocationStatus(revStatus, m.Status.RevocationNonce)
+		}
+		logVerificationStep(ctx, o.logger, VerificationStepCredentialStatus,
+			start, err, map[string]any{
+				"type":            m.Status.Type,
+				"source":          m.Source,
+				"revocationNonce": m.Status.RevocationNonce,
+			})
+		attempt.Err = err
+		result.Attempts = append(result.Attempts, attempt)
+
+		if err == nil || errors.Is(err, ErrCredentialIsRevoked) {
+			result.Used = &m
+			result.RevocationStatus = revStatus
+			return result, err
+		}
+	}
+
+	return result, ErrNoStatusMechanism
+}
+
+// orderStatusMechanisms sorts mechanisms by the preference of their types
+// keeping the order of mechanisms of the same or not preferred types
+func orderStatusMechanisms(mechanisms []StatusMechanism,
+	preference []CredentialStatusType) []StatusMechanism {
+
+	if len(preference) == 0 {
+		return mechanisms
+	}
+
+	ordered := make([]StatusMechanism, 0, len(mechanisms))
+	used := make([]bool, len(mechanisms))
+	for _, tp := range preference {
+		for i, m := range mechanisms {
+			if !used[i] && m.Status.Type == tp {
+				ordered = append(ordered, m)
+				used[i] = true
+			}
+		}
+	}
+	for i, m := range mechanisms {
+		if !used[i] {
+			ordered = append(ordered, m)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,119 @@
+package verifiable
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStatusResolver struct {
+	resolveErr   error
+	availableErr error
+	calls        int
+}
+
+func (r *failingStatusResolver) Resolve(context.Context,
+	CredentialStatus) (RevocationStatus, error) {
+
+	r.calls++
+	return RevocationStatus{}, r.resolveErr
+}
+
+func (r *failingStatusResolver) Available(context.Context,
+	CredentialStatus) error {
+
+	return r.availableErr
+}
+
+func TestNegotiateCredentialStatus(t *testing.T) {
+	ctx := context.Background()
+	credStatus := CredentialStatus{
+		ID:              "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf/credentialStatus?contractAddress=80001:0x2fCE183c7Fbc4EbB5DB3B0F5a63e0e02AE9a85d2",
+		Type:            Iden3OnchainSparseMerkleTreeProof2023,
+		RevocationNonce: 10,
+		StatusIssuer: &CredentialStatus{
+			ID:   "https://rhs-staging.polygonid.me",
+			Type: Iden3ReverseSparseMerkleTreeProof,
+		},
+	}
+
+	mechanisms := StatusMechanisms(credStatus)
+	require.Len(t, mechanisms, 2)
+	require.Equal(t, "credentialStatus", mechanisms[0].Source)
+	require.Nil(t, mechanisms[0].Status.StatusIssuer)
+	require.Equal(t, "credentialStatus.statusIssuer", mechanisms[1].Source)
+	// the nonce of the primary status is used
+	require.Equal(t, uint64(10), mechanisms[1].Status.RevocationNonce)
+
+	t.Run("fallback on network error", func(t *testing.T) {
+		onchain := &failingStatusResolver{
+			resolveErr: errors.New("connection refused")}
+		registry := &CredentialStatusResolverRegistry{}
+		registry.Register(Iden3OnchainSparseMerkleTreeProof2023, onchain)
+		registry.Register(Iden3ReverseSparseMerkleTreeProof, test1Resolver{})
+
+		result, err := NegotiateCredentialStatus(ctx, credStatus,
+			WithValidationStatusResolverRegistry(registry))
+		require.NoError(t, err)
+		require.Equal(t, 1, onchain.calls)
+		require.Equal(t, "credentialStatus.statusIssuer", result.Used.Source)
+		require.Len(t, result.Attempts, 2)
+		require.EqualError(t, result.Attempts[0].Err, "connection refused")
+		require.False(t, result.Attempts[0].Skipped)
+		require.NoError(t, result.Attempts[1].Err)
+	})
+
+	t.Run("unavailable and unregistered", func(t *testing.T) {
+		onchain := &failingStatusResolver{
+			availableErr: errors.New("no RPC for chain 80001")}
+		registry := &CredentialStatusResolverRegistry{}
+		registry.Register(Iden3OnchainSparseMerkleTreeProof2023, onchain)
+
+		result, err := NegotiateCredentialStatus(ctx, credStatus,
+			WithValidationStatusResolverRegistry(registry))
+		require.ErrorIs(t, err, ErrNoStatusMechanism)
+		require.Nil(t, result.Used)
+		require.Equal(t, 0, onchain.calls)
+		require.Len(t, result.Attempts, 2)
+		require.True(t, result.Attempts[0].Skipped)
+		require.EqualError(t, result.Attempts[0].Err, "no RPC for chain 80001")
+		require.True(t, result.Attempts[1].Skipped)
+	})
+
+	t.Run("preference", func(t *testing.T) {
+		onchain := &failingStatusResolver{}
+		registry := &CredentialStatusResolverRegistry{}
+		registry.Register(Iden3OnchainSparseMerkleTreeProof2023, onchain)
+		registry.Register(Iden3ReverseSparseMerkleTreeProof, test1Resolver{})
+
+		result, err := NegotiateCredentialStatus(ctx, credStatus,
+			WithValidationStatusResolverRegistry(registry),
+			WithStatusTypePreference(Iden3ReverseSparseMerkleTreeProof))
+		require.NoError(t, err)
+		require.Equal(t, Iden3ReverseSparseMerkleTreeProof,
+			result.Used.Status.Type)
+		require.Len(t, result.Attempts, 1)
+		require.Equal(t, 0, onchain.calls)
+	})
+
+	t.Run("revoked", func(t *testing.T) {
+		tree, err := NewIssuerRevocationTree(ctx, memory.NewMemoryStorage(),
+			&merkletree.HashZero, nil)
+		require.NoError(t, err)
+		require.NoError(t, tree.Revoke(ctx, credStatus.RevocationNonce))
+		registry := &CredentialStatusResolverRegistry{}
+		registry.Register(Iden3OnchainSparseMerkleTreeProof2023, tree)
+		registry.Register(Iden3ReverseSparseMerkleTreeProof, test1Resolver{})
+
+		// revocation is not overridden by other mechanisms
+		result, err := NegotiateCredentialStatus(ctx, credStatus,
+			WithValidationStatusResolverRegistry(registry))
+		require.ErrorIs(t, err, ErrCredentialIsRevoked)
+		require.Equal(t, "credentialStatus", result.Used.Source)
+		require.Len(t, result.Attempts, 1)
+	})
+}
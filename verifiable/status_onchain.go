@@ -0,0 +1,174 @@
+package verifiable
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	onchainStatusIDPath        = "/credentialStatus"
+	onchainStatusParamContract = "contractAddress"
+	onchainStatusParamNonce    = "revocationNonce"
+	onchainStatusParamState    = "state"
+)
+
+var reContractAddress = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+
+// OnchainStatusID is the parsed ID of the credential status of
+// Iden3OnchainSparseMerkleTreeProof2023 type:
+//
+//	<issuer DID>/credentialStatus?contractAddress=<chain ID>:<address>&revocationNonce=<nonce>&state=<state>
+//
+// revocationNonce and state are optional.
+type OnchainStatusID struct {
+	IssuerDID *w3c.DID
+	ChainID   core.ChainID
+	// ContractAddress is the 0x-prefixed hex address of the state contract
+	ContractAddress string
+	RevocationNonce *uint64
+	State           *merkletree.Hash
+	// Params are other query parameters of the ID. They are kept by String.
+	Params url.Values
+}
+
+// ParseOnchainStatusID parses the ID of the credential status of
+// Iden3OnchainSparseMerkleTreeProof2023 type. Query parameters may be
+// escaped or not.
+func ParseOnchainStatusID(id string) (OnchainStatusID, error) {
+	var s OnchainStatusID
+
+	didPart, query, ok := strings.Cut(id, "?")
+	if !ok {
+		return s, errors.New("onchain status id has no query")
+	}
+	if !strings.HasSuffix(didPart, onchainStatusIDPath) {
+		return s, errors.Errorf("onchain status id path is not %v",
+			onchainStatusIDPath)
+	}
+	didStr := strings.TrimSuffix(didPart, onchainStatusIDPath)
+	var err error
+	s.IssuerDID, err = w3c.ParseDID(didStr)
+	if err != nil {
+		return s, errors.WithMessage(err, "invalid issuer DID")
+	}
+
+	s.Params, err = url.ParseQuery(query)
+	if err != nil {
+		return s, errors.WithMessage(err, "invalid onchain status id query")
+	}
+
+	contract, err := popSingleParam(s.Params, onchainStatusParamContract)
+	if err != nil {
+		return s, err
+	}
+	if contract == "" {
+		return s, errors.New("contractAddress is not set")
+	}
+	s.ChainID, s.ContractAddress, err = parseChainContract(contract)
+	if err != nil {
+		return s, err
+	}
+
+	nonce, err := popSingleParam(s.Params, onchainStatusParamNonce)
+	if err != nil {
+		return s, err
+	}
+	if nonce != "" {
+		n, err := strconv.ParseUint(nonce, 10, 64)
+		if err != nil {
+			return s, errors.Errorf("invalid revocationNonce: %v", nonce)
+		}
+		s.RevocationNonce = &n
+	}
+
+	state, err := popSingleParam(s.Params, onchainStatusParamState)
+	if err != nil {
+		return s, err
+	}
+	if state != "" {
+		s.State, err = merkletree.NewHashFromHex(state)
+		if err != nil {
+			return s, errors.Errorf("invalid state: %v", state)
+		}
+	}
+
+	if len(s.Params) == 0 {
+		s.Params = nil
+	}
+	return s, nil
+}
+
+// String builds the canonical ID: query parameters, including Params, are
+// sorted by key and escaped.
+func (s OnchainStatusID) String() string {
+	q := make(url.Values, len(s.Params)+3)
+	for k, v := range s.Params {
+		q[k] = v
+	}
+	q.Set(onchainStatusParamContract,
+		strconv.FormatInt(int64(s.ChainID), 10)+":"+s.ContractAddress)
+	if s.RevocationNonce != nil {
+		q.Set(onchainStatusParamNonce,
+			strconv.FormatUint(*s.RevocationNonce, 10))
+	}
+	if s.State != nil {
+		q.Set(onchainStatusParamState, s.State.Hex())
+	}
+
+	var did string
+	if s.IssuerDID != nil {
+		did = s.IssuerDID.String()
+	}
+	return did + onchainStatusIDPath + "?" + q.Encode()
+}
+
+// Validate checks that the issuer DID is set and the contract address is a
+// hex address
+func (s OnchainStatusID) Validate() error {
+	if s.IssuerDID == nil {
+		return errors.New("issuer DID is not set")
+	}
+	if !reContractAddress.MatchString(s.ContractAddress) {
+		return errors.Errorf("invalid contract address: %v",
+			s.ContractAddress)
+	}
+	return nil
+}
+
+func parseChainContract(v string) (core.ChainID, string, error) {
+	chainStr, address, ok := strings.Cut(v, ":")
+	if !ok {
+		return 0, "", errors.Errorf(
+			"contractAddress is not in <chain ID>:<address> format: %v", v)
+	}
+	chainID, err := strconv.ParseInt(chainStr, 10, 32)
+	if err != nil {
+		return 0, "", errors.Errorf("invalid chain ID: %v", chainStr)
+	}
+	if !reContractAddress.MatchString(address) {
+		return 0, "", errors.Errorf("invalid contract address: %v", address)
+	}
+	return core.ChainID(chainID), address, nil
+}
+
+// popSingleParam removes the parameter from q and returns its value. It
+// returns an error if the parameter is set more than once.
+func popSingleParam(q url.Values, key string) (string, error) {
+	values := q[key]
+	delete(q, key)
+	switch len(values) {
+	case 0:
+		return "", nil
+	case 1:
+		return values[0], nil
+	default:
+		return "", errors.Errorf("%v is set more than once", key)
+	}
+}
@@ -0,0 +1,101 @@
+package verifiable
+
+import (
+	"net/url"
+	"testing"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOnchainStatusID(t *testing.T) {
+	const (
+		issuerDID = "did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf"
+		contract  = "0x134B1BE34911E39A8397ec6289782989729807a4"
+		state     = "f9dd6aa4e1abef52b6c94ab7eb92faf1a283b371d263e25ac835c9c04894741e"
+	)
+
+	id := issuerDID + "/credentialStatus?revocationNonce=74881362&" +
+		"contractAddress=80001:" + contract + "&state=" + state
+	s, err := ParseOnchainStatusID(id)
+	require.NoError(t, err)
+	require.Equal(t, issuerDID, s.IssuerDID.String())
+	require.Equal(t, core.ChainID(80001), s.ChainID)
+	require.Equal(t, contract, s.ContractAddress)
+	require.NotNil(t, s.RevocationNonce)
+	require.Equal(t, uint64(74881362), *s.RevocationNonce)
+	require.Equal(t, state, s.State.Hex())
+	require.Nil(t, s.Params)
+	require.NoError(t, s.Validate())
+
+	wantID := issuerDID + "/credentialStatus?contractAddress=80001%3A" +
+		contract + "&revocationNonce=74881362&state=" + state
+	require.Equal(t, wantID, s.String())
+
+	// canonical ID is parsed to the same components
+	s2, err := ParseOnchainStatusID(s.String())
+	require.NoError(t, err)
+	require.Equal(t, s, s2)
+
+	t.Run("extra params", func(t *testing.T) {
+		s, err := ParseOnchainStatusID(issuerDID +
+			"/credentialStatus?contractAddress=80001%3A" + contract +
+			"&foo=bar")
+		require.NoError(t, err)
+		require.Nil(t, s.RevocationNonce)
+		require.Nil(t, s.State)
+		require.Equal(t, url.Values{"foo": {"bar"}}, s.Params)
+		require.Equal(t, issuerDID+"/credentialStatus?contractAddress=80001%3A"+
+			contract+"&foo=bar", s.String())
+	})
+
+	testCases := []struct {
+		name string
+		id   string
+		err  string
+	}{
+		{
+			name: "no query",
+			id:   issuerDID + "/credentialStatus",
+			err:  "onchain status id has no query",
+		},
+		{
+			name: "wrong path",
+			id:   issuerDID + "/status?contractAddress=80001:" + contract,
+			err:  "onchain status id path is not /credentialStatus",
+		},
+		{
+			name: "no contract",
+			id:   issuerDID + "/credentialStatus?revocationNonce=1",
+			err:  "contractAddress is not set",
+		},
+		{
+			name: "no chain ID",
+			id:   issuerDID + "/credentialStatus?contractAddress=" + contract,
+			err:  "contractAddress is not in <chain ID>:<address> format: " + contract,
+		},
+		{
+			name: "invalid address",
+			id:   issuerDID + "/credentialStatus?contractAddress=80001:0x1234",
+			err:  "invalid contract address: 0x1234",
+		},
+		{
+			name: "duplicate nonce",
+			id: issuerDID + "/credentialStatus?contractAddress=80001:" +
+				contract + "&revocationNonce=1&revocationNonce=2",
+			err: "revocationNonce is set more than once",
+		},
+		{
+			name: "invalid state",
+			id: issuerDID + "/credentialStatus?contractAddress=80001:" +
+				contract + "&state=123",
+			err: "invalid state: 123",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseOnchainStatusID(tc.id)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package verifiable
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// credentialStatusFields are JSON keys of the fields of CredentialStatus
+var credentialStatusFields = map[string]bool{
+	"id":                   true,
+	"type":                 true,
+	"revocationNonce":      true,
+	"statusIssuer":         true,
+	"statusPurpose":        true,
+	"statusListIndex":      true,
+	"statusListCredential": true,
+}
+
+type credentialStatusJSON CredentialStatus
+
+// Params returns fields of the status which are not fields of
+// CredentialStatus, e.g. parameters of status types unknown to this package.
+// They are kept on unmarshal and written back on marshal, so resolvers of new
+// status types may read them. Values are decoded with json.Unmarshal.
+func (cs CredentialStatus) Params() map[string]any {
+	params := make(map[string]any, len(cs.params))
+	for k, raw := range cs.params {
+		var v any
+		// params are valid JSON values, they are checked on unmarshal
+		_ = json.Unmarshal(raw, &v)
+		params[k] = v
+	}
+	return params
+}
+
+// SetParam sets the field of the status which is not a field of
+// CredentialStatus. The value is encoded with json.Marshal.
+func (cs *CredentialStatus) SetParam(key string, value any) error {
+	if credentialStatusFields[key] {
+		return errors.Errorf("%v is a field of credential status", key)
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if cs.params == nil {
+		cs.params = make(map[string]json.RawMessage)
+	}
+	cs.params[key] = raw
+	return nil
+}
+
+// MarshalJSON writes the fields of the status and its params
+func (cs CredentialStatus) MarshalJSON() ([]byte, error) {
+	fieldsBytes, err := json.Marshal(credentialStatusJSON(cs))
+	if err != nil {
+		return nil, err
+	}
+	if len(cs.params) == 0 {
+		return fieldsBytes, nil
+	}
+
+	obj := make(map[string]json.RawMessage, len(cs.params)+4)
+	err = json.Unmarshal(fieldsBytes, &obj)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cs.params {
+		if !credentialStatusFields[k] {
+			obj[k] = v
+		}
+	}
+	return json.Marshal(obj)
+}
+
+// UnmarshalJSON reads the fields of the status and keeps other keys as its
+// params
+func (cs *CredentialStatus) UnmarshalJSON(in []byte) error {
+	var fields credentialStatusJSON
+	err := json.Unmarshal(in, &fields)
+	if err != nil {
+		return err
+	}
+
+	var obj map[string]json.RawMessage
+	err = json.Unmarshal(in, &obj)
+	if err != nil {
+		return err
+	}
+	fields.params = nil
+	for k, v := range obj {
+		if credentialStatusFields[k] {
+			continue
+		}
+		if fields.params == nil {
+			fields.params = make(map[string]json.RawMessage)
+		}
+		fields.params[k] = v
+	}
+
+	*cs = CredentialStatus(fields)
+	return nil
+}
@@ -0,0 +1,94 @@
+package verifiable
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type paramsStatusResolver struct {
+	params map[string]any
+}
+
+func (r *paramsStatusResolver) Resolve(_ context.Context,
+	credentialStatus CredentialStatus) (RevocationStatus, error) {
+
+	r.params = credentialStatus.Params()
+	return RevocationStatus{}, nil
+}
+
+func TestCredentialStatus_Params(t *testing.T) {
+	in := `{
+  "id": "https://status.example.com/1",
+  "type": "ExampleStatus2024",
+  "revocationNonce": 7,
+  "contractAddress": "80001:0x2fCE183c7Fbc4EbB5DB3B0F5a63e0e02AE9a85d2",
+  "chainParams": {"confirmations": 12, "finalized": true},
+  "statusIssuer": {
+    "id": "https://issuer.example.com/status/7",
+    "type": "SparseMerkleTreeProof",
+    "revocationNonce": 0,
+    "backup": ["https://mirror.example.com"]
+  }
+}`
+	var status CredentialStatus
+	require.NoError(t, json.Unmarshal([]byte(in), &status))
+	require.Equal(t, CredentialStatusType("ExampleStatus2024"), status.Type)
+	require.Equal(t, uint64(7), status.RevocationNonce)
+	require.Equal(t, map[string]any{
+		"contractAddress": "80001:0x2fCE183c7Fbc4EbB5DB3B0F5a63e0e02AE9a85d2",
+		"chainParams": map[string]any{"confirmations": float64(12),
+			"finalized": true},
+	}, status.Params())
+	require.Equal(t, map[string]any{
+		"backup": []any{"https://mirror.example.com"},
+	}, status.StatusIssuer.Params())
+
+	out, err := json.Marshal(status)
+	require.NoError(t, err)
+	require.JSONEq(t, in, string(out))
+
+	// statuses without params are encoded as before
+	var plain CredentialStatus
+	require.NoError(t, json.Unmarshal(
+		[]byte(`{"id":"https://status.example.com/1",`+
+			`"type":"SparseMerkleTreeProof","revocationNonce":1}`), &plain))
+	require.Empty(t, plain.Params())
+	require.Equal(t, CredentialStatus{ID: "https://status.example.com/1",
+		Type: SparseMerkleTreeProof, RevocationNonce: 1}, plain)
+
+	require.NoError(t, plain.SetParam("epoch", 3))
+	require.Equal(t, map[string]any{"epoch": float64(3)}, plain.Params())
+	out, err = json.Marshal(&plain)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"id":"https://status.example.com/1",
+"type":"SparseMerkleTreeProof","revocationNonce":1,"epoch":3}`, string(out))
+
+	require.EqualError(t, plain.SetParam("revocationNonce", 2),
+		"revocationNonce is a field of credential status")
+}
+
+func TestCredentialStatus_ParamsPassedToResolver(t *testing.T) {
+	var vc W3CCredential
+	require.NoError(t, json.Unmarshal([]byte(`{
+  "credentialStatus": {
+    "id": "https://status.example.com/1",
+    "type": "ExampleStatus2024",
+    "revocationNonce": 7,
+    "contractAddress": "80001:0x2fCE183c7Fbc4EbB5DB3B0F5a63e0e02AE9a85d2"
+  }
+}`), &vc))
+
+	resolver := &paramsStatusResolver{}
+	registry := &CredentialStatusResolverRegistry{}
+	registry.Register("ExampleStatus2024", resolver)
+	credStatus, err := coerceCredentialStatus(vc.CredentialStatus)
+	require.NoError(t, err)
+	_, _ = ValidateCredentialStatus(context.Background(), *credStatus,
+		WithValidationStatusResolverRegistry(registry))
+	require.Equal(t, map[string]any{
+		"contractAddress": "80001:0x2fCE183c7Fbc4EbB5DB3B0F5a63e0e02AE9a85d2",
+	}, resolver.params)
+}
@@ -0,0 +1,116 @@
+package verifiable
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// StructureViolation describes the credential property that does not
+// conform to the VC Data Model 1.1 structural rules
+type StructureViolation struct {
+	// Property is the JSON path of the property, e.g. credentialSchema.id
+	Property string
+	Message  string
+}
+
+func (v StructureViolation) String() string {
+	return fmt.Sprintf("%v: %v", v.Property, v.Message)
+}
+
+// ValidateStructure checks the credential against the structural rules of
+// the VC Data Model 1.1: required properties, the base context, the
+// VerifiableCredential type, URI formats and dates. It does not verify
+// proofs and does not load any remote documents. Returns nil if no
+// violations were found.
+func (vc *W3CCredential) ValidateStructure() []StructureViolation {
+	var violations []StructureViolation
+	add := func(property, msg string, args ...any) {
+		violations = append(violations, StructureViolation{
+			Property: property,
+			Message:  fmt.Sprintf(msg, args...),
+		})
+	}
+
+	if len(vc.Context) == 0 {
+		add("@context", "is required")
+	} else if vc.Context[0] != JSONLDSchemaW3CCredential2018 {
+		add("@context", "first entry must be %v, got %v",
+			JSONLDSchemaW3CCredential2018, vc.Context[0])
+	}
+
+	if vc.ID != "" && !isURI(vc.ID) {
+		add("id", "must be a URI: %v", vc.ID)
+	}
+
+	if len(vc.Type) == 0 {
+		add("type", "is required")
+	} else if !containsString(vc.Type, TypeW3CVerifiableCredential) {
+		add("type", "must contain %v", TypeW3CVerifiableCredential)
+	}
+
+	if vc.Issuer == "" {
+		add("issuer", "is required")
+	} else if !isURI(vc.Issuer) {
+		add("issuer", "must be a URI: %v", vc.Issuer)
+	}
+
+	if vc.IssuanceDate == nil || vc.IssuanceDate.IsZero() {
+		add("issuanceDate", "is required")
+	} else if vc.Expiration != nil &&
+		vc.Expiration.Before(*vc.IssuanceDate) {
+
+		add("expirationDate", "must not be before issuanceDate")
+	}
+
+	if len(vc.CredentialSubject) == 0 {
+		add("credentialSubject", "is required")
+	} else if subjID, ok := vc.CredentialSubject["id"]; ok {
+		subjIDStr, isStr := subjID.(string)
+		if !isStr || !isURI(subjIDStr) {
+			add("credentialSubject.id", "must be a URI: %v", subjID)
+		}
+	}
+
+	if vc.CredentialSchema != (CredentialSchema{}) {
+		if vc.CredentialSchema.ID == "" {
+			add("credentialSchema.id", "is required")
+		} else if !isURI(vc.CredentialSchema.ID) {
+			add("credentialSchema.id", "must be a URI: %v",
+				vc.CredentialSchema.ID)
+		}
+		if vc.CredentialSchema.Type == "" {
+			add("credentialSchema.type", "is required")
+		}
+	}
+
+	if vc.CredentialStatus != nil {
+		var status CredentialStatus
+		err := remarshalObj(&status, vc.CredentialStatus)
+		if err != nil {
+			add("credentialStatus", "must be an object")
+		} else {
+			if status.ID == "" {
+				add("credentialStatus.id", "is required")
+			}
+			if status.Type == "" {
+				add("credentialStatus.type", "is required")
+			}
+		}
+	}
+
+	return violations
+}
+
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package verifiable
+
+import (
+	"context"
+	"sync"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+)
+
+// TrustRegistry decides whether the issuer is trusted to issue credentials
+// of the type. Implementations may consult governance frameworks, e.g. a
+// remote registry service or a smart contract.
+type TrustRegistry interface {
+	IsTrustedIssuer(ctx context.Context, issuer *w3c.DID,
+		credentialType string) (bool, error)
+}
+
+// TrustRegistryFunc is an adapter to use ordinary functions as
+// TrustRegistry, e.g. to call remote registries
+type TrustRegistryFunc func(ctx context.Context, issuer *w3c.DID,
+	credentialType string) (bool, error)
+
+// IsTrustedIssuer calls f(ctx, issuer, credentialType)
+func (f TrustRegistryFunc) IsTrustedIssuer(ctx context.Context,
+	issuer *w3c.DID, credentialType string) (bool, error) {
+
+	return f(ctx, issuer, credentialType)
+}
+
+// TrustedIssuer is the issuer entry of StaticTrustRegistry
+type TrustedIssuer struct {
+	DID *w3c.DID
+	// CredentialTypes the issuer is trusted to issue. If empty, the issuer
+	// is trusted for all types.
+	CredentialTypes []string
+	// Name is the human-readable name of the issuer
+	Name string
+	// Metadata is the governance framework specific data of the issuer
+	Metadata map[string]any
+}
+
+// StaticTrustRegistry is the in-memory TrustRegistry. It is safe for
+// concurrent use.
+type StaticTrustRegistry struct {
+	m       sync.RWMutex
+	issuers map[string]TrustedIssuer
+}
+
+// NewStaticTrustRegistry creates the registry with the issuers
+func NewStaticTrustRegistry(issuers ...TrustedIssuer) *StaticTrustRegistry {
+	r := &StaticTrustRegistry{
+		issuers: make(map[string]TrustedIssuer, len(issuers)),
+	}
+	for _, i := range issuers {
+		r.Add(i)
+	}
+	return r
+}
+
+// Add adds the issuer or replaces the entry of the issuer with the same DID
+func (r *StaticTrustRegistry) Add(issuer TrustedIssuer) {
+	if issuer.DID == nil {
+		return
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.issuers == nil {
+		r.issuers = make(map[string]TrustedIssuer)
+	}
+	r.issuers[issuer.DID.String()] = issuer
+}
+
+// Remove removes the issuer from the registry
+func (r *StaticTrustRegistry) Remove(did *w3c.DID) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	delete(r.issuers, did.String())
+}
+
+// Issuer returns the entry of the issuer
+func (r *StaticTrustRegistry) Issuer(did *w3c.DID) (TrustedIssuer, bool) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	issuer, ok := r.issuers[did.String()]
+	return issuer, ok
+}
+
+// IsTrustedIssuer checks that the issuer is in the registry and is trusted
+// for the credential type
+func (r *StaticTrustRegistry) IsTrustedIssuer(_ context.Context,
+	issuer *w3c.DID, credentialType string) (bool, error) {
+
+	entry, ok := r.Issuer(issuer)
+	if !ok {
+		return false, nil
+	}
+	if len(entry.CredentialTypes) == 0 {
+		return true, nil
+	}
+	return containsAny(entry.CredentialTypes, []string{credentialType}), nil
+}
@@ -0,0 +1,49 @@
+package verifiable
+
+import (
+	"context"
+	"testing"
+
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticTrustRegistry(t *testing.T) {
+	ctx := context.Background()
+	did1, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qLGnFZiHrhdNh5KwdkGvbCN1sR2pUaBpBahAXC3zf")
+	require.NoError(t, err)
+	did2, err := w3c.ParseDID(
+		"did:polygonid:polygon:mumbai:2qH2mPVRN7ZDCnEofjeh8Qd2Uo3YsEhTVhKhjB8xs4")
+	require.NoError(t, err)
+
+	registry := NewStaticTrustRegistry(
+		TrustedIssuer{DID: did1, Name: "Issuer 1",
+			CredentialTypes: []string{"KYCAgeCredential"},
+			Metadata:        map[string]any{"country": "UA"}})
+
+	trusted, err := registry.IsTrustedIssuer(ctx, did1, "KYCAgeCredential")
+	require.NoError(t, err)
+	require.True(t, trusted)
+	trusted, err = registry.IsTrustedIssuer(ctx, did1, "Other")
+	require.NoError(t, err)
+	require.False(t, trusted)
+	trusted, err = registry.IsTrustedIssuer(ctx, did2, "KYCAgeCredential")
+	require.NoError(t, err)
+	require.False(t, trusted)
+
+	issuer, ok := registry.Issuer(did1)
+	require.True(t, ok)
+	require.Equal(t, "Issuer 1", issuer.Name)
+	require.Equal(t, "UA", issuer.Metadata["country"])
+
+	// issuer without credential types is trusted for all types
+	registry.Add(TrustedIssuer{DID: did2})
+	trusted, err = registry.IsTrustedIssuer(ctx, did2, "Other")
+	require.NoError(t, err)
+	require.True(t, trusted)
+
+	registry.Remove(did1)
+	_, ok = registry.Issuer(did1)
+	require.False(t, ok)
+}
@@ -0,0 +1,119 @@
+package verifiable
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/curve25519"
+)
+
+// ErrKeyAgreementKeyNotFound is returned when the DID document has no
+// suitable X25519 key agreement key
+var ErrKeyAgreementKeyNotFound = errors.New("X25519 key agreement key not found")
+
+// X25519PublicKey is the public key of the recipient of encrypted fields
+// and JWE
+type X25519PublicKey struct {
+	// KeyID is the ID of the verification method in the recipient's DID
+	// document
+	KeyID string
+	Key   [32]byte
+}
+
+// X25519KeyFromDIDDocument returns the X25519 key agreement key from the DID
+// document. If keyID is empty, the first suitable key is returned. Keys
+// defined with publicKeyJwk (kty OKP, crv X25519) and publicKeyHex are
+// supported.
+func X25519KeyFromDIDDocument(doc DIDDocument,
+	keyID string) (X25519PublicKey, error) {
+
+	for _, ka := range doc.KeyAgreement {
+		var vm CommonVerificationMethod
+		switch kat := ka.(type) {
+		case string:
+			var found bool
+			vm, found = doc.verificationMethodByRef(kat)
+			if !found {
+				continue
+			}
+		default:
+			if err := remarshalObj(&vm, kat); err != nil {
+				return X25519PublicKey{}, err
+			}
+		}
+
+		if keyID != "" && vm.ID != keyID {
+			continue
+		}
+
+		key, ok := x25519KeyFromVerificationMethod(vm)
+		if ok {
+			return X25519PublicKey{KeyID: vm.ID, Key: key}, nil
+		}
+	}
+
+	return X25519PublicKey{}, ErrKeyAgreementKeyNotFound
+}
+
+func x25519KeyFromVerificationMethod(vm CommonVerificationMethod) ([32]byte,
+	bool) {
+
+	var key [32]byte
+	var keyBytes []byte
+	var err error
+	switch {
+	case vm.PublicKeyJwk != nil:
+		if vm.PublicKeyJwk["kty"] != "OKP" ||
+			vm.PublicKeyJwk["crv"] != "X25519" {
+			return key, false
+		}
+		x, _ := vm.PublicKeyJwk["x"].(string)
+		keyBytes, err = base64.RawURLEncoding.DecodeString(x)
+	case vm.PublicKeyHex != "" &&
+		strings.HasPrefix(vm.Type, "X25519KeyAgreementKey"):
+		keyBytes, err = hex.DecodeString(vm.PublicKeyHex)
+	default:
+		return key, false
+	}
+	if err != nil || len(keyBytes) != len(key) {
+		return key, false
+	}
+	copy(key[:], keyBytes)
+	return key, true
+}
+
+// x25519EphemeralAgreement generates the ephemeral X25519 key and returns
+// its public key and the shared secret with the recipient's key. It is used
+// by EncryptJWE and EncryptSubjectFields, which derive their keys from the
+// shared secret differently.
+func x25519EphemeralAgreement(recipientKey [32]byte) (ephemeralPub,
+	sharedSecret []byte, err error) {
+
+	var ephemeralKey [32]byte
+	if _, err = io.ReadFull(rand.Reader, ephemeralKey[:]); err != nil {
+		return nil, nil, err
+	}
+	ephemeralPub, err = curve25519.X25519(ephemeralKey[:],
+		curve25519.Basepoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	sharedSecret, err = x25519Agreement(ephemeralKey, recipientKey[:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return ephemeralPub, sharedSecret, nil
+}
+
+// x25519Agreement returns the shared secret of the private key and the
+// peer's public key
+func x25519Agreement(privateKey [32]byte, peerPub []byte) ([]byte, error) {
+	if len(peerPub) != curve25519.PointSize {
+		return nil, errors.New("invalid X25519 public key")
+	}
+	return curve25519.X25519(privateKey[:], peerPub)
+}